@@ -47,6 +47,21 @@ const DashboardPage = () => {
         }
     };
 
+    // O download exige o token, então o ZIP é baixado pela API e salvo a partir de um blob.
+    const handleDownload = async (video) => {
+        try {
+            const response = await api.get(`/videos/${video.id}/download`, { responseType: 'blob' });
+            const url = URL.createObjectURL(response.data);
+            const link = document.createElement('a');
+            link.href = url;
+            link.download = `frames_${video.id}.zip`;
+            link.click();
+            URL.revokeObjectURL(url);
+        } catch (err) {
+            alert('Erro ao baixar os frames');
+        }
+    };
+
     const closeDeleteModal = () => {
         if (!deleteModal.isLoading) {
             setDeleteModal({ isOpen: false, videoId: null, isLoading: false });
@@ -101,15 +116,13 @@ const DashboardPage = () => {
 
                                 <div style={{ display: 'flex', gap: '10px' }}>
                                     {video.status === 'completed' && (
-                                        <a
-                                            href={`http://localhost:8080/api/v1/videos/${video.id}/download`}
-                                            target="_blank"
-                                            rel="noopener noreferrer"
+                                        <button
+                                            onClick={() => handleDownload(video)}
                                             className="btn btn-primary"
                                             style={{ flex: 1, fontSize: '0.9rem' }}
                                         >
                                             <FiDownload style={{ marginRight: '5px' }} /> Download
-                                        </a>
+                                        </button>
                                     )}
                                     <button
                                         onClick={() => handleDeleteClick(video.id)}
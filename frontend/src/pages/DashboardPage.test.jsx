@@ -160,4 +160,34 @@ describe('DashboardPage', () => {
     await act(async () => fireEvent.click(screen.getByText('Atualizar')))
     expect(api.get).toHaveBeenCalledTimes(2)
   })
+
+  it('downloads frames through the authenticated API', async () => {
+    const blob = new Blob(['zip'])
+    URL.createObjectURL = vi.fn(() => 'blob:frames')
+    URL.revokeObjectURL = vi.fn()
+    api.get.mockImplementation((url) =>
+      url === '/videos'
+        ? Promise.resolve({ data: [{ id: '1', original_name: 'video.mp4', status: 'completed', created_at: new Date().toISOString() }] })
+        : Promise.resolve({ data: blob })
+    )
+    renderPage()
+    await waitFor(() => expect(screen.getByText('Download')).toBeDefined())
+    await act(async () => fireEvent.click(screen.getByText('Download')))
+    expect(api.get).toHaveBeenCalledWith('/videos/1/download', { responseType: 'blob' })
+    expect(URL.createObjectURL).toHaveBeenCalledWith(blob)
+    expect(URL.revokeObjectURL).toHaveBeenCalledWith('blob:frames')
+  })
+
+  it('shows alert on download failure', async () => {
+    const alertMock = vi.spyOn(window, 'alert').mockImplementation(() => {})
+    api.get.mockImplementation((url) =>
+      url === '/videos'
+        ? Promise.resolve({ data: [{ id: '1', original_name: 'video.mp4', status: 'completed', created_at: new Date().toISOString() }] })
+        : Promise.reject(new Error('forbidden'))
+    )
+    renderPage()
+    await waitFor(() => expect(screen.getByText('Download')).toBeDefined())
+    await act(async () => fireEvent.click(screen.getByText('Download')))
+    await waitFor(() => expect(alertMock).toHaveBeenCalledWith('Erro ao baixar os frames'))
+  })
 })
@@ -35,16 +35,16 @@ if ($response -eq 'S' -or $response -eq 's') {
     minikube image build -t g57-auth-service:latest ./services/auth-service
     
     Write-Host "Buildando Video Service..."
-    minikube image build -t g57-video-service:latest ./services/video-service
+    minikube image build -t g57-video-service:latest -f video-service/Dockerfile ./services
     
     Write-Host "Buildando Processing Service..."
-    minikube image build -t g57-processing-service:latest ./services/processing-service
+    minikube image build -t g57-processing-service:latest -f processing-service/Dockerfile ./services
     
     Write-Host "Buildando Status Service..."
-    minikube image build -t g57-status-service:latest ./services/status-service
+    minikube image build -t g57-status-service:latest -f status-service/Dockerfile ./services
     
     Write-Host "Buildando Notification Service..."
-    minikube image build -t g57-notification-service:latest ./services/notification-service
+    minikube image build -t g57-notification-service:latest -f notification-service/Dockerfile ./services
     
     Write-Host "Buildando API Gateway..."
     minikube image build -t g57-api-gateway:latest ./services/api-gateway
@@ -27,7 +27,11 @@ const app = express();
 const PORT = process.env.PORT || 8080;
 
 app.use(helmet());
-app.use(cors());
+app.use(cors({
+  exposedHeaders: ['Location', 'Upload-Offset', 'Upload-Length', 'Upload-Expires', 'Tus-Resumable',
+    'Tus-Version', 'Tus-Extension', 'Tus-Max-Size', 'X-Video-Id', 'ETag', 'Last-Modified', 'Accept-Ranges',
+    'Content-Range', 'Content-Disposition']
+}));
 app.use(morgan('combined', { stream: { write: message => logger.info(message.trim()) } }));
 
 
@@ -113,6 +117,7 @@ const authMiddleware = require('./middleware/auth');
 const AUTH_SERVICE_URL = process.env.AUTH_SERVICE_URL || 'http://localhost:8081';
 const VIDEO_SERVICE_URL = process.env.VIDEO_SERVICE_URL || 'http://localhost:8082';
 const STATUS_SERVICE_URL = process.env.STATUS_SERVICE_URL || 'http://localhost:8083';
+const NOTIFICATION_SERVICE_URL = process.env.NOTIFICATION_SERVICE_URL || 'http://localhost:8084';
 
 const proxyOptions = {
   timeout: parseInt(process.env.PROXY_TIMEOUT_MS) || 300000,      // 300s default (supports large file uploads)
@@ -142,11 +147,23 @@ app.use('/api/v1/auth', createProxyMiddleware({
   ...proxyOptions
 }));
 
-app.use('/api/v1/videos/:id/download', createProxyMiddleware({
+// Share links are for people without an account; the link's token is
+// checked by the video service.
+app.use('/api/v1/shares', createProxyMiddleware({
   target: VIDEO_SERVICE_URL,
   ...proxyOptions
 }));
 
+// Status events are a long-lived Server-Sent Events stream, so it must be
+// routed before the video service and must not be cut off by the proxy
+// timeouts.
+app.use('/api/v1/videos/events', authMiddleware, createProxyMiddleware({
+  target: STATUS_SERVICE_URL,
+  ...proxyOptions,
+  timeout: 0,
+  proxyTimeout: 0
+}));
+
 app.use('/api/v1/videos', authMiddleware, createProxyMiddleware({
   target: VIDEO_SERVICE_URL,
   ...proxyOptions
@@ -162,6 +179,11 @@ app.use('/api/v1/stats', authMiddleware, createProxyMiddleware({
   ...proxyOptions
 }));
 
+app.use('/api/v1/webhooks', authMiddleware, createProxyMiddleware({
+  target: NOTIFICATION_SERVICE_URL,
+  ...proxyOptions
+}));
+
 app.use((req, res) => {
   res.status(404).json({
     error: 'Not Found',
@@ -186,6 +208,7 @@ function startServer() {
     logger.info(`Auth Service: ${AUTH_SERVICE_URL}`);
     logger.info(`Video Service: ${VIDEO_SERVICE_URL}`);
     logger.info(`Status Service: ${STATUS_SERVICE_URL}`);
+    logger.info(`Notification Service: ${NOTIFICATION_SERVICE_URL}`);
   });
   return server;
 }
@@ -257,8 +257,20 @@ describe('API Gateway - Full Coverage Tests', () => {
     });
 
     describe('Video Download Route', () => {
-        it('should proxy video download requests without auth', async () => {
+        it('should require auth for video downloads', async () => {
             const res = await request(app).get('/api/v1/videos/123/download');
+            expect(res.status).toBe(401);
+        });
+
+        it('should proxy video downloads with auth', async () => {
+            const res = await request(app)
+                .get('/api/v1/videos/123/download')
+                .set('Authorization', 'Bearer valid-token');
+            expect(res.status).toBe(200);
+        });
+
+        it('should proxy share link downloads without auth', async () => {
+            const res = await request(app).get('/api/v1/shares/abc/download');
             expect(res.status).toBe(200);
         });
     });
@@ -271,6 +283,22 @@ describe('API Gateway - Full Coverage Tests', () => {
             expect(res.status).toBe(200);
         });
 
+        it('should require auth for the status event stream', async () => {
+            const res = await request(app).get('/api/v1/videos/events');
+            expect(res.status).toBe(401);
+        });
+
+        it('should proxy the status event stream without timeouts', async () => {
+            const res = await request(app)
+                .get('/api/v1/videos/events')
+                .set('Authorization', 'Bearer valid-token');
+            expect(res.status).toBe(200);
+
+            const config = proxyConfigs.find(c => c.target === 'http://localhost:8083' && c.proxyTimeout === 0);
+            expect(config).toBeDefined();
+            expect(config.timeout).toBe(0);
+        });
+
         it('should proxy stats requests with auth', async () => {
             const res = await request(app)
                 .get('/api/v1/stats')
@@ -279,6 +307,20 @@ describe('API Gateway - Full Coverage Tests', () => {
         });
     });
 
+    describe('Webhook Routes', () => {
+        it('should require auth for webhooks', async () => {
+            const res = await request(app).get('/api/v1/webhooks');
+            expect(res.status).toBe(401);
+        });
+
+        it('should proxy webhook requests with auth', async () => {
+            const res = await request(app)
+                .post('/api/v1/webhooks')
+                .set('Authorization', 'Bearer valid-token');
+            expect(res.status).toBe(200);
+        });
+    });
+
     describe('Exported Functions', () => {
         it('should export startServer function', () => {
             const { startServer } = require('./server');
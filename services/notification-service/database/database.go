@@ -9,7 +9,7 @@ import (
 	"notification-service/domain"
 	"notification-service/infra/utils"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Database struct {
@@ -60,11 +60,12 @@ func (d *Database) Close() error {
 
 func (d *Database) CreateNotification(notification *domain.Notification) error {
 	query := `
-		INSERT INTO notifications (id, user_id, video_id, type, status, subject, message, recipient, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO notifications (id, user_id, video_id, batch_id, type, status, subject, message, recipient, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
-	_, err := d.db.Exec(query, notification.ID, notification.UserID, notification.VideoID, notification.Type,
-		notification.Status, notification.Subject, notification.Message, notification.Recipient, notification.CreatedAt)
+	_, err := d.db.Exec(query, notification.ID, notification.UserID, notification.VideoID, notification.BatchID,
+		notification.Type, notification.Status, notification.Subject, notification.Message, notification.Recipient,
+		notification.CreatedAt)
 	return err
 }
 
@@ -78,4 +79,254 @@ func (d *Database) UpdateNotification(notification *domain.Notification) error {
 	return err
 }
 
+const webhookColumns = `id, user_id, url, secret, events, active, created_at, updated_at`
 
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*domain.Webhook, error) {
+	webhook := &domain.Webhook{}
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events),
+		&webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (d *Database) CreateWebhook(webhook *domain.Webhook) error {
+	_, err := d.db.Exec(`
+		INSERT INTO webhooks (`+webhookColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, webhook.ID, webhook.UserID, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active,
+		webhook.CreatedAt, webhook.UpdatedAt)
+	return err
+}
+
+func (d *Database) GetWebhooks(userID string) ([]*domain.Webhook, error) {
+	rows, err := d.db.Query(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*domain.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetWebhook returns sql.ErrNoRows when there is no such webhook.
+func (d *Database) GetWebhook(id string) (*domain.Webhook, error) {
+	return scanWebhook(d.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+}
+
+func (d *Database) UpdateWebhook(webhook *domain.Webhook) error {
+	_, err := d.db.Exec(`
+		UPDATE webhooks SET url = $1, events = $2, active = $3, updated_at = $4
+		WHERE id = $5
+	`, webhook.URL, pq.Array(webhook.Events), webhook.Active, webhook.UpdatedAt, webhook.ID)
+	return err
+}
+
+// DeleteWebhook removes a webhook with its delivery history.
+func (d *Database) DeleteWebhook(id string) error {
+	_, err := d.db.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	return err
+}
+
+// CreateWebhookDeliveries queues the event for every active webhook of its
+// user that subscribes to it, and returns how many were queued. An event
+// already queued for a webhook is skipped, so a redelivered message is not
+// sent twice.
+func (d *Database) CreateWebhookDeliveries(event *domain.WebhookEvent, payload []byte) (int, error) {
+	result, err := d.db.Exec(`
+		INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, created_at, next_attempt_at)
+		SELECT gen_random_uuid(), id, $2, $3, $4, NOW(), NOW()
+		FROM webhooks
+		WHERE user_id = $1 AND active AND $3 = ANY(events)
+		ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING
+	`, event.UserID, event.ID, event.Type, payload)
+	if err != nil {
+		return 0, err
+	}
+	queued, err := result.RowsAffected()
+	return int(queued), err
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, redelivery_of, created_at, delivered_at`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*domain.WebhookDelivery, error) {
+	delivery := &domain.WebhookDelivery{}
+	var lastStatusCode sql.NullInt64
+	var lastError, redeliveryOf sql.NullString
+	var deliveredAt sql.NullTime
+	dest := append([]interface{}{&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType,
+		&delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &lastStatusCode,
+		&lastError, &redeliveryOf, &delivery.CreatedAt, &deliveredAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if lastStatusCode.Valid {
+		code := int(lastStatusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	if lastError.Valid {
+		delivery.LastError = &lastError.String
+	}
+	if redeliveryOf.Valid {
+		delivery.RedeliveryOf = &redeliveryOf.String
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
+
+// ClaimWebhookDeliveries picks up to limit due deliveries of active webhooks,
+// oldest first, and pushes their next attempt back by lease so no other
+// dispatcher picks them up while they are being sent. Should the holder die,
+// they become due again once the lease runs out.
+func (d *Database) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	rows, err := d.db.Query(`
+		WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries wd
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM due, webhooks w
+		WHERE wd.id = due.id AND w.id = wd.webhook_id
+		RETURNING wd.id, wd.webhook_id, wd.event_id, wd.event_type, wd.payload, wd.status, wd.attempts,
+			wd.next_attempt_at, wd.last_status_code, wd.last_error, wd.redelivery_of, wd.created_at,
+			wd.delivered_at, w.url, w.secret
+	`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		var url, secret string
+		delivery, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		delivery.URL, delivery.Secret = url, secret
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordWebhookAttempt stores an attempt and the delivery's state after it.
+func (d *Database) RecordWebhookAttempt(delivery *domain.WebhookDelivery, attempt *domain.WebhookAttempt) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO webhook_delivery_attempts (id, delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, attempt.ID, attempt.DeliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.DurationMs,
+		attempt.AttemptedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5, delivered_at = $6
+		WHERE id = $7
+	`, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError,
+		delivery.DeliveredAt, delivery.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetWebhookDeliveries returns a webhook's latest deliveries, newest first.
+func (d *Database) GetWebhookDeliveries(webhookID string, limit int) ([]*domain.WebhookDelivery, error) {
+	rows, err := d.db.Query(`
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2
+	`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// GetWebhookDelivery returns sql.ErrNoRows when the webhook has no such
+// delivery.
+func (d *Database) GetWebhookDelivery(webhookID, id string) (*domain.WebhookDelivery, error) {
+	return scanWebhookDelivery(d.db.QueryRow(`
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE id = $1 AND webhook_id = $2
+	`, id, webhookID))
+}
+
+func (d *Database) GetWebhookAttempts(deliveryID string) ([]*domain.WebhookAttempt, error) {
+	rows, err := d.db.Query(`
+		SELECT id, delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt
+	`, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*domain.WebhookAttempt{}
+	for rows.Next() {
+		attempt := &domain.WebhookAttempt{}
+		var statusCode sql.NullInt64
+		var attemptErr sql.NullString
+		if err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.Attempt, &statusCode, &attemptErr,
+			&attempt.DurationMs, &attempt.AttemptedAt); err != nil {
+			return nil, err
+		}
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			attempt.StatusCode = &code
+		}
+		if attemptErr.Valid {
+			attempt.Error = &attemptErr.String
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+// RedeliverWebhookDelivery queues the payload of a delivery again, as a new
+// delivery, and returns sql.ErrNoRows when the webhook has no such delivery.
+func (d *Database) RedeliverWebhookDelivery(webhookID, id string) (*domain.WebhookDelivery, error) {
+	return scanWebhookDelivery(d.db.QueryRow(`
+		INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, redelivery_of, created_at, next_attempt_at)
+		SELECT gen_random_uuid(), webhook_id, event_id, event_type, payload, id, NOW(), NOW()
+		FROM webhook_deliveries
+		WHERE id = $1 AND webhook_id = $2
+		RETURNING `+webhookDeliveryColumns, id, webhookID))
+}
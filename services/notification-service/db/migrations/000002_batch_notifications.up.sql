@@ -0,0 +1,4 @@
+-- Batch emails cover every video of a batch, so they reference the batch
+-- instead of a single video.
+ALTER TABLE notifications ADD COLUMN batch_id UUID; -- Logical reference to Video Service
+CREATE INDEX idx_notifications_batch_id ON notifications(batch_id) WHERE batch_id IS NOT NULL;
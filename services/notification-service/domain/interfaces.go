@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"time"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -8,6 +10,19 @@ type DatabaseInterface interface {
 
 	CreateNotification(notification *Notification) error
 	UpdateNotification(notification *Notification) error
+
+	CreateWebhook(webhook *Webhook) error
+	GetWebhooks(userID string) ([]*Webhook, error)
+	GetWebhook(id string) (*Webhook, error)
+	UpdateWebhook(webhook *Webhook) error
+	DeleteWebhook(id string) error
+	CreateWebhookDeliveries(event *WebhookEvent, payload []byte) (int, error)
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	RecordWebhookAttempt(delivery *WebhookDelivery, attempt *WebhookAttempt) error
+	GetWebhookDeliveries(webhookID string, limit int) ([]*WebhookDelivery, error)
+	GetWebhookDelivery(webhookID, id string) (*WebhookDelivery, error)
+	GetWebhookAttempts(deliveryID string) ([]*WebhookAttempt, error)
+	RedeliverWebhookDelivery(webhookID, id string) (*WebhookDelivery, error)
 	Ping() error
 	Close() error
 }
@@ -18,6 +33,7 @@ type SMTPInterface interface {
 
 type RabbitMQInterface interface {
 	SubscribeNotification() (<-chan amqp.Delivery, error)
+	SubscribeWebhookEvents() (<-chan amqp.Delivery, error)
 }
 
 type AuthServiceClient interface {
@@ -26,4 +42,5 @@ type AuthServiceClient interface {
 
 type VideoServiceClient interface {
 	GetVideoByID(videoID string) (*Video, error)
+	GetBatchByID(batchID string) (*Batch, error)
 }
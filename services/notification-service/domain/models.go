@@ -62,10 +62,23 @@ type ProcessingJob struct {
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 }
 
+// Batch is a group of videos uploaded together, with its aggregate status as
+// reported by video-service.
+type Batch struct {
+	ID     string         `json:"id"`
+	UserID string         `json:"user_id"`
+	Name   string         `json:"name"`
+	Status string         `json:"status"`
+	Total  int            `json:"total"`
+	Counts map[string]int `json:"counts"`
+	Videos []Video        `json:"videos"`
+}
+
 type Notification struct {
 	ID           string     `json:"id" db:"id"`
 	UserID       string     `json:"user_id" db:"user_id"`
 	VideoID      *string    `json:"video_id,omitempty" db:"video_id"`
+	BatchID      *string    `json:"batch_id,omitempty" db:"batch_id"`
 	Type         string     `json:"type" db:"type"`
 	Status       string     `json:"status" db:"status"`
 	Subject      string     `json:"subject" db:"subject"`
@@ -77,6 +90,93 @@ type Notification struct {
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 }
 
+// Processing lifecycle events a webhook can subscribe to.
+const (
+	WebhookEventVideoQueued    = "video.queued"
+	WebhookEventVideoCompleted = "video.completed"
+	WebhookEventVideoFailed    = "video.failed"
+)
+
+// WebhookEventTypes lists every event a webhook can subscribe to.
+var WebhookEventTypes = []string{WebhookEventVideoQueued, WebhookEventVideoCompleted, WebhookEventVideoFailed}
+
+// Statuses of a webhook delivery.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook is an endpoint a user registered to receive their videos' events.
+type Webhook struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"-" db:"secret"`
+	Events    []string  `json:"events" db:"events"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes tells whether the webhook wants events of the given type.
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the body of every webhook request.
+type WebhookEvent struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	UserID     string           `json:"-"`
+	Data       WebhookVideoData `json:"data"`
+}
+
+type WebhookVideoData struct {
+	VideoID      string `json:"video_id"`
+	RunID        string `json:"run_id,omitempty"`
+	ZipSizeBytes int64  `json:"zip_size_bytes,omitempty"`
+	FrameCount   int    `json:"frame_count,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one webhook. URL and
+// Secret are the webhook's, filled in when the delivery is claimed.
+type WebhookDelivery struct {
+	ID             string     `json:"id" db:"id"`
+	WebhookID      string     `json:"webhook_id" db:"webhook_id"`
+	EventID        string     `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Payload        []byte     `json:"-" db:"payload"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string    `json:"last_error,omitempty" db:"last_error"`
+	RedeliveryOf   *string    `json:"redelivery_of,omitempty" db:"redelivery_of"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	URL            string     `json:"-"`
+	Secret         string     `json:"-"`
+}
+
+// WebhookAttempt records one HTTP request made for a delivery.
+type WebhookAttempt struct {
+	ID          string    `json:"id" db:"id"`
+	DeliveryID  string    `json:"delivery_id" db:"delivery_id"`
+	Attempt     int       `json:"attempt" db:"attempt"`
+	StatusCode  *int      `json:"status_code,omitempty" db:"status_code"`
+	Error       *string   `json:"error,omitempty" db:"error"`
+	DurationMs  int       `json:"duration_ms" db:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
 type AuditLog struct {
 	ID         string    `json:"id" db:"id"`
 	UserID     *string   `json:"user_id,omitempty" db:"user_id"`
@@ -8,6 +8,8 @@ import (
 	"time"
 	
 	"notification-service/domain"
+
+	"shared/clienterr"
 )
 
 type AuthServiceClient struct {
@@ -29,18 +31,18 @@ func (c *AuthServiceClient) GetUserByID(userID string) (*domain.User, error) {
 	
 	resp, err := c.client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+		return nil, fmt.Errorf("failed to get user by ID: %w", clienterr.Transient(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, &clienterr.StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 	
 	var user domain.User
 	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", clienterr.Permanent(err))
 	}
 	
 	return &user, nil
@@ -8,6 +8,8 @@ import (
 
 	"notification-service/domain"
 
+	"shared/clienterr"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,6 +45,7 @@ func TestAuthServiceClient_GetUserByID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.Contains(t, err.Error(), "404")
+	assert.ErrorIs(t, err, clienterr.ErrNotFound)
 }
 
 func TestAuthServiceClient_GetUserByID_ServerError(t *testing.T) {
@@ -72,6 +75,7 @@ func TestAuthServiceClient_GetUserByID_InvalidJSON(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.Contains(t, err.Error(), "failed to decode response")
+	assert.ErrorIs(t, err, clienterr.ErrPermanent)
 }
 
 func TestAuthServiceClient_GetUserByID_ConnectionError(t *testing.T) {
@@ -82,6 +86,7 @@ func TestAuthServiceClient_GetUserByID_ConnectionError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.Contains(t, err.Error(), "failed to get user by ID")
+	assert.ErrorIs(t, err, clienterr.ErrTransient)
 }
 
 func TestNewAuthServiceClient(t *testing.T) {
@@ -8,6 +8,8 @@ import (
 	"time"
 	
 	"notification-service/domain"
+
+	"shared/clienterr"
 )
 
 type VideoServiceClient struct {
@@ -29,19 +31,41 @@ func (c *VideoServiceClient) GetVideoByID(videoID string) (*domain.Video, error)
 	
 	resp, err := c.client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get video by ID: %w", err)
+		return nil, fmt.Errorf("failed to get video by ID: %w", clienterr.Transient(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, &clienterr.StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 	
 	var video domain.Video
 	if err := json.NewDecoder(resp.Body).Decode(&video); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", clienterr.Permanent(err))
 	}
 	
 	return &video, nil
 }
+
+func (c *VideoServiceClient) GetBatchByID(batchID string) (*domain.Batch, error) {
+	url := fmt.Sprintf("%s/api/internal/batches/%s", c.baseURL, batchID)
+
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch by ID: %w", clienterr.Transient(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &clienterr.StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var batch domain.Batch
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", clienterr.Permanent(err))
+	}
+
+	return &batch, nil
+}
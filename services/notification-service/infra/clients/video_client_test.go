@@ -8,6 +8,8 @@ import (
 
 	"notification-service/domain"
 
+	"shared/clienterr"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,6 +44,7 @@ func TestVideoServiceClient_GetVideoByID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, video)
 	assert.Contains(t, err.Error(), "404")
+	assert.ErrorIs(t, err, clienterr.ErrNotFound)
 }
 
 func TestVideoServiceClient_GetVideoByID_ServerError(t *testing.T) {
@@ -71,6 +74,7 @@ func TestVideoServiceClient_GetVideoByID_InvalidJSON(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, video)
 	assert.Contains(t, err.Error(), "failed to decode response")
+	assert.ErrorIs(t, err, clienterr.ErrPermanent)
 }
 
 func TestVideoServiceClient_GetVideoByID_ConnectionError(t *testing.T) {
@@ -80,6 +84,42 @@ func TestVideoServiceClient_GetVideoByID_ConnectionError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, video)
 	assert.Contains(t, err.Error(), "failed to get video by ID")
+	assert.ErrorIs(t, err, clienterr.ErrTransient)
+}
+
+func TestVideoServiceClient_GetBatchByID_Success(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/internal/batches/b1", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"b1","name":"incident-42","status":"completed_with_errors","total":2,
+			"counts":{"completed":1,"failed":1},
+			"videos":[{"id":"v1","original_name":"a.mp4","status":"completed"},
+			          {"id":"v2","original_name":"b.mp4","status":"failed","error_message":"no video stream"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewVideoServiceClient(srv.URL)
+	batch, err := c.GetBatchByID("b1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "incident-42", batch.Name)
+	assert.Equal(t, 1, batch.Counts["failed"])
+	if assert.Len(t, batch.Videos, 2) {
+		assert.Equal(t, "no video stream", *batch.Videos[1].ErrorMessage)
+	}
+}
+
+func TestVideoServiceClient_GetBatchByID_NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := NewVideoServiceClient(srv.URL)
+	batch, err := c.GetBatchByID("missing")
+
+	assert.Nil(t, batch)
+	assert.ErrorIs(t, err, clienterr.ErrNotFound)
 }
 
 func TestNewVideoServiceClient(t *testing.T) {
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"notification-service/domain"
+
+	"shared/netguard"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Limits on the webhooks of one user and on a delivery history page.
+const (
+	maxWebhooksPerUser     = 10
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+type WebhookHandler struct {
+	db      domain.DatabaseInterface
+	allowed []*net.IPNet
+}
+
+// NewWebhookHandler refuses webhook URLs on internal and reserved addresses
+// unless allowed contains them.
+func NewWebhookHandler(db domain.DatabaseInterface, allowed []*net.IPNet) *WebhookHandler {
+	return &WebhookHandler{db: db, allowed: allowed}
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+}
+
+// UpdateWebhookRequest changes the fields that are given and leaves the rest.
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// CreateWebhookResponse is the only response the secret is ever sent in.
+type CreateWebhookResponse struct {
+	*domain.Webhook
+	Secret string `json:"secret"`
+}
+
+type DeliveryListResponse struct {
+	Deliveries []*domain.WebhookDelivery `json:"deliveries"`
+}
+
+type DeliveryResponse struct {
+	*domain.WebhookDelivery
+	Attempts []*domain.WebhookAttempt `json:"attempt_history"`
+}
+
+// Create registers a webhook for the caller and returns it with the secret
+// its payloads are signed with.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := h.validateWebhookURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := validateWebhookEvents(req.Events)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	existing, err := h.db.GetWebhooks(userID)
+	if err != nil {
+		log.Printf("Failed to list webhooks of user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("at most %d webhooks can be registered", maxWebhooksPerUser)})
+		return
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	now := time.Now()
+	webhook := &domain.Webhook{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.db.CreateWebhook(webhook); err != nil {
+		log.Printf("Failed to create webhook for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateWebhookResponse{Webhook: webhook, Secret: secret})
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	webhooks, err := h.db.GetWebhooks(c.GetString("user_id"))
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhooks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (h *WebhookHandler) Get(c *gin.Context) {
+	webhook, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Update changes a webhook's URL or events, or pauses and resumes it.
+// Deliveries of a paused webhook wait until it is resumed.
+func (h *WebhookHandler) Update(c *gin.Context) {
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	webhook, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	if req.URL != nil {
+		if err := h.validateWebhookURL(*req.URL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		events, err := validateWebhookEvents(req.Events)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		webhook.Events = events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	webhook.UpdatedAt = time.Now()
+
+	if err := h.db.UpdateWebhook(webhook); err != nil {
+		log.Printf("Failed to update webhook %s: %v", webhook.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	webhook, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+	if err := h.db.DeleteWebhook(webhook.ID); err != nil {
+		log.Printf("Failed to delete webhook %s: %v", webhook.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries returns a webhook's latest deliveries, newest first, up to
+// the limit query parameter.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	limit := defaultDeliveriesLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxDeliveriesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxDeliveriesLimit)})
+			return
+		}
+		limit = n
+	}
+
+	webhook, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.db.GetWebhookDeliveries(webhook.ID, limit)
+	if err != nil {
+		log.Printf("Failed to list deliveries of webhook %s: %v", webhook.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, DeliveryListResponse{Deliveries: deliveries})
+}
+
+// GetDelivery returns a delivery with every attempt made at it.
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	webhook, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+	delivery, ok := h.delivery(c, webhook)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.db.GetWebhookAttempts(delivery.ID)
+	if err != nil {
+		log.Printf("Failed to list attempts of delivery %s: %v", delivery.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get delivery"})
+		return
+	}
+	c.JSON(http.StatusOK, DeliveryResponse{WebhookDelivery: delivery, Attempts: attempts})
+}
+
+// Redeliver sends a delivery's event to the webhook again, as a new delivery
+// with attempts of its own.
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	webhook, ok := h.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.db.RedeliverWebhookDelivery(webhook.ID, c.Param("delivery_id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to redeliver delivery %s: %v", c.Param("delivery_id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver"})
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func (h *WebhookHandler) ownedWebhook(c *gin.Context) (*domain.Webhook, bool) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+	webhook, err := h.db.GetWebhook(c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed to get webhook %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook"})
+		return nil, false
+	}
+	if webhook.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+	return webhook, true
+}
+
+func (h *WebhookHandler) delivery(c *gin.Context, webhook *domain.Webhook) (*domain.WebhookDelivery, bool) {
+	if _, err := uuid.Parse(c.Param("delivery_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return nil, false
+	}
+	delivery, err := h.db.GetWebhookDelivery(webhook.ID, c.Param("delivery_id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed to get delivery %s: %v", c.Param("delivery_id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get delivery"})
+		return nil, false
+	}
+	return delivery, true
+}
+
+func (h *WebhookHandler) validateWebhookURL(raw string) error {
+	u, err := netguard.ValidateURL(raw)
+	if err != nil {
+		return fmt.Errorf("url must be an absolute http or https URL without credentials")
+	}
+	// Hostnames are checked again on every delivery, once resolved.
+	if err := netguard.CheckHost(u.Hostname(), h.allowed); err != nil {
+		return fmt.Errorf("url must not point to an internal address")
+	}
+	return nil
+}
+
+// validateWebhookEvents checks events are known and returns them without
+// repeats.
+func validateWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("at least one event is required")
+	}
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, event := range events {
+		if !isWebhookEventType(event) {
+			return nil, fmt.Errorf("unknown event %q", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return unique, nil
+}
+
+func isWebhookEventType(event string) bool {
+	for _, known := range domain.WebhookEventTypes {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
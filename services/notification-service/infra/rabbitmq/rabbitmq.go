@@ -25,7 +25,8 @@ type VideoProcessingMessage struct {
 
 type NotificationMessage struct {
 	UserID  string `json:"user_id"`
-	VideoID string `json:"video_id"`
+	VideoID string `json:"video_id,omitempty"`
+	BatchID string `json:"batch_id,omitempty"`
 	Type    string `json:"type"`
 	Subject string `json:"subject"`
 	Message string `json:"message"`
@@ -114,6 +115,34 @@ func InitRabbitMQ() *RabbitMQClient {
 		log.Fatalf("Failed to bind queue: %v", err)
 	}
 
+	_, err = channel.QueueDeclare(
+		"notification.webhooks.queue",
+		true,
+		false,
+		false,
+		false,
+		amqp.Table{
+			"x-dead-letter-exchange": "notification.dlx",
+			"x-dead-letter-routing-key": "notification.webhooks.dlq",
+		},
+	)
+	if err != nil {
+		log.Fatalf("Failed to declare queue: %v", err)
+	}
+
+	for _, routingKey := range []string{"video.upload", "video.processing.completed", "video.processing.failed"} {
+		err = channel.QueueBind(
+			"notification.webhooks.queue",
+			routingKey,
+			"video.exchange",
+			false,
+			nil,
+		)
+		if err != nil {
+			log.Fatalf("Failed to bind queue: %v", err)
+		}
+	}
+
 	err = channel.QueueBind(
 		"notification.queue",
 		"notification.#",
@@ -251,6 +280,20 @@ func (r *RabbitMQClient) SubscribeNotification() (<-chan amqp.Delivery, error) {
 	)
 }
 
+// SubscribeWebhookEvents consumes the video events webhooks are sent for:
+// uploads being queued and processing finishing.
+func (r *RabbitMQClient) SubscribeWebhookEvents() (<-chan amqp.Delivery, error) {
+	return r.channel.Consume(
+		"notification.webhooks.queue",
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+}
+
 func (r *RabbitMQClient) GetQueueStats(queueName string) (int, error) {
 	queue, err := r.channel.QueueInspect(queueName)
 	if err != nil {
@@ -12,12 +12,17 @@ import (
 	"time"
 
 	"notification-service/database"
+	"notification-service/domain"
 	"notification-service/infra/clients"
 	"notification-service/infra/email"
+	"notification-service/infra/handlers"
 	"notification-service/infra/rabbitmq"
 	"notification-service/infra/utils"
 	"notification-service/service"
+	"shared/jwtauth"
+	"shared/netguard"
 
+	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -42,6 +47,20 @@ func main() {
 	log.Printf("Auth Service client initialized: %s", authServiceURL)
 	log.Printf("Video Service client initialized: %s", videoServiceURL)
 
+	webhookAllowed, err := netguard.ParseCIDRs(utils.GetEnv("WEBHOOK_ALLOWED_CIDRS", ""))
+	if err != nil {
+		log.Fatalf("Invalid WEBHOOK_ALLOWED_CIDRS: %v", err)
+	}
+
+	auth, err := jwtauth.New(jwtauth.Config{
+		Secret:         utils.GetEnv("JWT_SECRET", "6f9e5ef5-ab0c-4bb4-a4f5-caefc64bc5d1"),
+		Issuer:         utils.GetEnv("JWT_ISSUER", "g57-auth-service"),
+		TrustedCallers: utils.GetEnv("TRUSTED_CALLER_CIDRS", ""),
+	})
+	if err != nil {
+		log.Fatalf("Invalid authentication config: %v", err)
+	}
+
 	workerCount := getEnvInt("WORKER_COUNT", 3)
 	log.Printf("Notification Service starting with %d workers", workerCount)
 
@@ -59,6 +78,31 @@ func main() {
 		}(i)
 	}
 
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		service.NewWebhookEventConsumer(db, rmq).Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		service.NewWebhookDispatcher(db, getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8), webhookAllowed).Run(ctx)
+	}()
+
+	srv := &http.Server{
+		Addr:         ":" + utils.GetEnv("PORT", "8084"),
+		Handler:      setupRouter(db, handlers.NewWebhookHandler(db, webhookAllowed), auth),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Notification Service API starting on port %s", utils.GetEnv("PORT", "8084"))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
 	go func() {
 		log.Println("Metrics server starting on :8091")
 		http.Handle("/metrics", promhttp.Handler())
@@ -71,6 +115,13 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	log.Println("Shutting down server...")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
 	log.Println("Shutting down workers...")
 	cancel()
 
@@ -88,6 +139,62 @@ func main() {
 	}
 }
 
+func setupRouter(db domain.DatabaseInterface, webhookHandler *handlers.WebhookHandler, auth *jwtauth.Authenticator) *gin.Engine {
+	if utils.GetEnv("GIN_MODE", "debug") == "release" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+	router.Use(handlers.CorsMiddleware())
+
+	router.GET("/health", healthCheck)
+	router.GET("/health/live", livenessProbe)
+	router.GET("/health/ready", readinessProbe(db))
+
+	api := router.Group("/api/v1")
+	{
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(auth.Middleware())
+		{
+			webhooks.POST("", webhookHandler.Create)
+			webhooks.GET("", webhookHandler.List)
+			webhooks.GET("/:id", webhookHandler.Get)
+			webhooks.PATCH("/:id", webhookHandler.Update)
+			webhooks.DELETE("/:id", webhookHandler.Delete)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			webhooks.GET("/:id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		}
+	}
+
+	return router
+}
+
+func healthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"service": "notification-service",
+		"version": "1.0.0",
+		"time":    time.Now().Unix(),
+	})
+}
+
+func livenessProbe(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+func readinessProbe(db domain.DatabaseInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := db.Ping(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": "database"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	}
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := utils.GetEnv(key, ""); value != "" {
 		var intValue int
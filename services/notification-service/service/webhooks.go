@@ -0,0 +1,348 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"notification-service/domain"
+
+	"shared/netguard"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Headers sent with every webhook request. The signature is
+// "sha256=" followed by the hex HMAC-SHA256, keyed with the webhook's secret,
+// of the timestamp, a dot and the body.
+const (
+	WebhookHeaderID        = "X-Webhook-Id"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// webhookEventTypes maps the routing keys of video.exchange to the webhook
+// events they are sent as.
+var webhookEventTypes = map[string]string{
+	"video.upload":               domain.WebhookEventVideoQueued,
+	"video.processing.completed": domain.WebhookEventVideoCompleted,
+	"video.processing.failed":    domain.WebhookEventVideoFailed,
+}
+
+// videoMessage holds the fields webhooks need from both the upload message
+// video-service queues and the lifecycle events processing-service publishes.
+type videoMessage struct {
+	EventID      string     `json:"event_id"`
+	OccurredAt   *time.Time `json:"occurred_at"`
+	VideoID      string     `json:"video_id"`
+	UserID       string     `json:"user_id"`
+	RunID        string     `json:"run_id"`
+	ZipSizeBytes int64      `json:"zip_size_bytes"`
+	FrameCount   int        `json:"frame_count"`
+	ErrorMessage string     `json:"error_message"`
+}
+
+// WebhookEventConsumer turns video events into deliveries for the webhooks
+// of the video's owner. Deliveries are stored before the message is acked,
+// so an event is never lost, and at most once per webhook, so a message
+// the broker redelivers is not sent twice.
+type WebhookEventConsumer struct {
+	db         domain.DatabaseInterface
+	rabbitmq   domain.RabbitMQInterface
+	retryDelay time.Duration
+}
+
+func NewWebhookEventConsumer(db domain.DatabaseInterface, rabbitmq domain.RabbitMQInterface) *WebhookEventConsumer {
+	return &WebhookEventConsumer{
+		db:         db,
+		rabbitmq:   rabbitmq,
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// Run consumes events until ctx is cancelled, subscribing again whenever the
+// broker drops the consumer.
+func (c *WebhookEventConsumer) Run(ctx context.Context) {
+	log.Println("Webhook event consumer started")
+
+	for {
+		msgs, err := c.rabbitmq.SubscribeWebhookEvents()
+		if err != nil {
+			log.Printf("Webhook event consumer: failed to subscribe: %v", err)
+		} else {
+			c.consume(ctx, msgs)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook event consumer stopped")
+			return
+		case <-time.After(c.retryDelay):
+		}
+	}
+}
+
+func (c *WebhookEventConsumer) consume(ctx context.Context, msgs <-chan amqp.Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				log.Println("Webhook event consumer: channel closed")
+				return
+			}
+			c.handleDelivery(msg)
+		}
+	}
+}
+
+func (c *WebhookEventConsumer) handleDelivery(msg amqp.Delivery) {
+	eventType, ok := webhookEventTypes[msg.RoutingKey]
+	if !ok {
+		log.Printf("Webhook event consumer: discarding message with routing key %q", msg.RoutingKey)
+		msg.Nack(false, false)
+		return
+	}
+
+	var message videoMessage
+	if err := json.Unmarshal(msg.Body, &message); err != nil {
+		log.Printf("Webhook event consumer: invalid %s message: %v", msg.RoutingKey, err)
+		msg.Nack(false, false)
+		return
+	}
+	if message.VideoID == "" || message.UserID == "" {
+		log.Printf("Webhook event consumer: discarding %s message without a video or user", msg.RoutingKey)
+		msg.Nack(false, false)
+		return
+	}
+
+	event := webhookEvent(eventType, &message)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Webhook event consumer: failed to encode %s for video %s: %v", eventType, message.VideoID, err)
+		msg.Nack(false, false)
+		return
+	}
+
+	queued, err := c.db.CreateWebhookDeliveries(event, payload)
+	if err != nil {
+		log.Printf("Webhook event consumer: failed to queue %s for video %s: %v", eventType, message.VideoID, err)
+		msg.Nack(false, true)
+		return
+	}
+
+	if queued > 0 {
+		log.Printf("Video %s: queued %s for %d webhook(s)", message.VideoID, eventType, queued)
+	}
+	msg.Ack(false)
+}
+
+// webhookEvent builds the event sent for a message. Upload messages have no
+// event ID of their own, and are published again when processing is deferred,
+// so a queued event takes its ID from the run it queues.
+func webhookEvent(eventType string, message *videoMessage) *domain.WebhookEvent {
+	event := &domain.WebhookEvent{
+		ID:         message.EventID,
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		UserID:     message.UserID,
+		Data: domain.WebhookVideoData{
+			VideoID:      message.VideoID,
+			RunID:        message.RunID,
+			ZipSizeBytes: message.ZipSizeBytes,
+			FrameCount:   message.FrameCount,
+			ErrorMessage: message.ErrorMessage,
+		},
+	}
+	if message.OccurredAt != nil {
+		event.OccurredAt = message.OccurredAt.UTC()
+	}
+	if eventType == domain.WebhookEventVideoQueued {
+		event.ID = message.RunID
+	}
+	if _, err := uuid.Parse(event.ID); err != nil {
+		event.ID = generateID()
+	}
+	return event
+}
+
+// WebhookDispatcher sends due webhook deliveries. A delivery that gets no 2xx
+// answer is tried again with exponential backoff until it runs out of
+// attempts, and every attempt is recorded. Receivers can be sent an event
+// more than once and should use the delivery or event ID to tell.
+type WebhookDispatcher struct {
+	db           domain.DatabaseInterface
+	client       *http.Client
+	now          func() time.Time
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+}
+
+// NewWebhookDispatcher sends through a client that refuses internal and
+// reserved addresses, redirects included, unless allowed contains them.
+func NewWebhookDispatcher(db domain.DatabaseInterface, maxAttempts int, allowed []*net.IPNet) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:           db,
+		client:       netguard.NewClient(allowed, 10*time.Second),
+		now:          time.Now,
+		batchSize:    20,
+		pollInterval: 2 * time.Second,
+		maxAttempts:  maxAttempts,
+		baseBackoff:  30 * time.Second,
+		maxBackoff:   time.Hour,
+	}
+}
+
+// Run sends due deliveries until ctx is cancelled, polling again right away
+// while there is a backlog.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	log.Println("Webhook dispatcher started")
+
+	for {
+		sent, err := d.DispatchOnce(ctx)
+		if err != nil {
+			log.Printf("Webhook dispatcher: failed to claim deliveries: %v", err)
+		}
+
+		wait := d.pollInterval
+		if sent == d.batchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook dispatcher stopped")
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// DispatchOnce claims a batch of due deliveries and sends them concurrently,
+// returning how many it claimed.
+func (d *WebhookDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	// The lease outlasts the request timeout, so a delivery is not claimed
+	// again while it is still being sent.
+	deliveries, err := d.db.ClaimWebhookDeliveries(d.batchSize, d.client.Timeout+time.Minute)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *domain.WebhookDelivery) {
+			defer wg.Done()
+			d.send(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// send makes one attempt at a delivery and records it.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *domain.WebhookDelivery) {
+	started := d.now()
+	statusCode, err := d.post(ctx, delivery, started)
+	finished := d.now()
+
+	delivery.Attempts++
+	attempt := &domain.WebhookAttempt{
+		ID:          generateID(),
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts,
+		DurationMs:  int(finished.Sub(started).Milliseconds()),
+		AttemptedAt: started,
+	}
+	delivery.LastStatusCode = nil
+	delivery.LastError = nil
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+		delivery.LastStatusCode = &statusCode
+	}
+	if err != nil {
+		attempt.Error = stringPtr(err.Error())
+		delivery.LastError = stringPtr(err.Error())
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliveryStatusSucceeded
+		delivery.DeliveredAt = timePtr(finished)
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = domain.DeliveryStatusFailed
+		log.Printf("Webhook delivery %s to %s failed after %d attempts: %v", delivery.ID, delivery.URL, delivery.Attempts, err)
+	default:
+		delivery.NextAttemptAt = finished.Add(d.backoff(delivery.Attempts))
+	}
+
+	if err := d.db.RecordWebhookAttempt(delivery, attempt); err != nil {
+		// The lease runs out and the delivery is sent again.
+		log.Printf("Failed to record attempt %d of webhook delivery %s: %v", attempt.Attempt, delivery.ID, err)
+	}
+}
+
+// post sends the delivery's payload, returning the status code of the
+// answer, if any, and an error unless it was a 2xx.
+func (d *WebhookDispatcher) post(ctx context.Context, delivery *domain.WebhookDelivery, at time.Time) (int, error) {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "video-processing-webhooks/1.0")
+	req.Header.Set(WebhookHeaderID, delivery.WebhookID)
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderDelivery, delivery.ID)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is how long to wait after the given attempt: the base backoff,
+// doubled for every attempt after the first, up to the maximum.
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	wait := d.baseBackoff
+	for i := 1; i < attempt && wait < d.maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.maxBackoff)
+}
+
+// SignPayload returns the X-Webhook-Signature of a body sent at timestamp.
+func SignPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
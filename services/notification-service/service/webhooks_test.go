@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/domain"
+
+	"shared/netguard"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testEventID = "7b0a4a8e-5a0e-4c57-9d8e-3f0d6d1f6a01"
+	testRunID   = "c1d2e3f4-0000-4000-8000-000000000001"
+)
+
+func webhookMessage(routingKey string, body interface{}, ack *MockAcknowledger) amqp.Delivery {
+	data, _ := json.Marshal(body)
+	return amqp.Delivery{RoutingKey: routingKey, Body: data, Acknowledger: ack, DeliveryTag: 1}
+}
+
+// ─── WebhookEventConsumer ─────────────────────────────────────────────────────
+
+func TestWebhookConsumer_CompletedEvent(t *testing.T) {
+	db := new(MockDatabase)
+	ack := new(MockAcknowledger)
+	occurred := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var queued *domain.WebhookEvent
+	var payload []byte
+	db.On("CreateWebhookDeliveries", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			queued = args.Get(0).(*domain.WebhookEvent)
+			payload = args.Get(1).([]byte)
+		}).
+		Return(2, nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	c := NewWebhookEventConsumer(db, nil)
+	c.handleDelivery(webhookMessage("video.processing.completed", map[string]interface{}{
+		"event_id": testEventID, "type": "video.processing.completed", "occurred_at": occurred,
+		"video_id": "v1", "user_id": "u1", "run_id": testRunID, "zip_path": "processed/frames_v1.zip",
+		"zip_size_bytes": 2048, "frame_count": 12,
+	}, ack))
+
+	ack.AssertExpectations(t)
+	assert.Equal(t, testEventID, queued.ID)
+	assert.Equal(t, domain.WebhookEventVideoCompleted, queued.Type)
+	assert.Equal(t, "u1", queued.UserID)
+
+	var sent map[string]interface{}
+	assert.NoError(t, json.Unmarshal(payload, &sent))
+	assert.Equal(t, testEventID, sent["id"])
+	assert.Equal(t, "video.completed", sent["type"])
+	assert.Equal(t, "2024-05-01T12:00:00Z", sent["occurred_at"])
+	assert.NotContains(t, sent, "user_id")
+	assert.NotContains(t, string(payload), "zip_path")
+	data := sent["data"].(map[string]interface{})
+	assert.Equal(t, "v1", data["video_id"])
+	assert.Equal(t, testRunID, data["run_id"])
+	assert.Equal(t, 2048.0, data["zip_size_bytes"])
+	assert.Equal(t, 12.0, data["frame_count"])
+}
+
+func TestWebhookConsumer_QueuedEventKeyedByRun(t *testing.T) {
+	db := new(MockDatabase)
+	ack := new(MockAcknowledger)
+
+	db.On("CreateWebhookDeliveries", mock.MatchedBy(func(e *domain.WebhookEvent) bool {
+		return e.ID == testRunID && e.Type == domain.WebhookEventVideoQueued && e.Data.VideoID == "v1"
+	}), mock.Anything).Return(1, nil).Twice()
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	// A deferred upload is published again with the same run; both copies
+	// must map to the same event.
+	c := NewWebhookEventConsumer(db, nil)
+	message := map[string]interface{}{"video_id": "v1", "user_id": "u1", "run_id": testRunID, "filename": "a.mp4"}
+	c.handleDelivery(webhookMessage("video.upload", message, ack))
+	c.handleDelivery(webhookMessage("video.upload", message, ack))
+
+	db.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
+
+func TestWebhookConsumer_FailedEventWithoutValidID(t *testing.T) {
+	db := new(MockDatabase)
+	ack := new(MockAcknowledger)
+
+	db.On("CreateWebhookDeliveries", mock.MatchedBy(func(e *domain.WebhookEvent) bool {
+		return e.ID != "" && e.ID != "not-a-uuid" && e.Data.ErrorMessage == "no video stream"
+	}), mock.Anything).Return(0, nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	c := NewWebhookEventConsumer(db, nil)
+	c.handleDelivery(webhookMessage("video.processing.failed", map[string]interface{}{
+		"event_id": "not-a-uuid", "video_id": "v1", "user_id": "u1", "error_message": "no video stream",
+	}, ack))
+
+	db.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
+
+func TestWebhookConsumer_Discarded(t *testing.T) {
+	cases := []struct {
+		name       string
+		routingKey string
+		body       []byte
+	}{
+		{"unknown routing key", "video.processing.started", []byte(`{"video_id":"v1","user_id":"u1"}`)},
+		{"invalid json", "video.processing.completed", []byte("invalid json")},
+		{"missing user", "video.processing.completed", []byte(`{"video_id":"v1"}`)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := new(MockDatabase)
+			ack := new(MockAcknowledger)
+			ack.On("Nack", uint64(1), false, false).Return(nil)
+
+			c := NewWebhookEventConsumer(db, nil)
+			c.handleDelivery(amqp.Delivery{RoutingKey: tc.routingKey, Body: tc.body, Acknowledger: ack, DeliveryTag: 1})
+
+			ack.AssertExpectations(t)
+			db.AssertNotCalled(t, "CreateWebhookDeliveries", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWebhookConsumer_DatabaseErrorRequeues(t *testing.T) {
+	db := new(MockDatabase)
+	ack := new(MockAcknowledger)
+
+	db.On("CreateWebhookDeliveries", mock.Anything, mock.Anything).Return(0, errors.New("db down"))
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	c := NewWebhookEventConsumer(db, nil)
+	c.handleDelivery(webhookMessage("video.processing.completed", map[string]interface{}{
+		"event_id": testEventID, "video_id": "v1", "user_id": "u1",
+	}, ack))
+
+	ack.AssertExpectations(t)
+}
+
+func TestWebhookConsumer_Run(t *testing.T) {
+	db := new(MockDatabase)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+
+	msgs := make(chan amqp.Delivery, 1)
+	mq.On("SubscribeWebhookEvents").Return((<-chan amqp.Delivery)(msgs), nil)
+	db.On("CreateWebhookDeliveries", mock.Anything, mock.Anything).Return(1, nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewWebhookEventConsumer(db, mq)
+
+	go func() {
+		msgs <- webhookMessage("video.processing.completed", map[string]interface{}{
+			"event_id": testEventID, "video_id": "v1", "user_id": "u1",
+		}, ack)
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	c.Run(ctx)
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
+
+// ─── WebhookDispatcher ────────────────────────────────────────────────────────
+
+// testDispatcher may reach the loopback test servers.
+func testDispatcher(db *MockDatabase, maxAttempts int) (*WebhookDispatcher, time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	allowed, _ := netguard.ParseCIDRs("127.0.0.1")
+	d := NewWebhookDispatcher(db, maxAttempts, allowed)
+	d.now = func() time.Time { return now }
+	return d, now
+}
+
+func pendingDelivery(url string, attempts int) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:        "d1",
+		WebhookID: "w1",
+		EventID:   testEventID,
+		EventType: domain.WebhookEventVideoCompleted,
+		Payload:   []byte(`{"id":"` + testEventID + `","type":"video.completed"}`),
+		Status:    domain.DeliveryStatusPending,
+		Attempts:  attempts,
+		URL:       url,
+		Secret:    "whsec_test",
+	}
+}
+
+func TestDispatchOnce_SignsAndSucceeds(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	db := new(MockDatabase)
+	d, now := testDispatcher(db, 5)
+	delivery := pendingDelivery(server.URL, 0)
+
+	db.On("ClaimWebhookDeliveries", 20, mock.Anything).Return([]*domain.WebhookDelivery{delivery}, nil)
+	db.On("RecordWebhookAttempt", delivery, mock.MatchedBy(func(a *domain.WebhookAttempt) bool {
+		return a.Attempt == 1 && *a.StatusCode == http.StatusNoContent && a.Error == nil && a.DeliveryID == "d1"
+	})).Return(nil)
+
+	claimed, err := d.DispatchOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	db.AssertExpectations(t)
+	assert.Equal(t, domain.DeliveryStatusSucceeded, delivery.Status)
+	assert.Equal(t, now, *delivery.DeliveredAt)
+	assert.Equal(t, delivery.Payload, body)
+
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.Equal(t, "w1", headers.Get(WebhookHeaderID))
+	assert.Equal(t, "d1", headers.Get(WebhookHeaderDelivery))
+	assert.Equal(t, "video.completed", headers.Get(WebhookHeaderEvent))
+	assert.Equal(t, "1714564800", headers.Get(WebhookHeaderTimestamp))
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1714564800." + string(body)))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), headers.Get(WebhookHeaderSignature))
+}
+
+func TestDispatchOnce_RetriesWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	db := new(MockDatabase)
+	d, now := testDispatcher(db, 5)
+	delivery := pendingDelivery(server.URL, 2)
+
+	db.On("ClaimWebhookDeliveries", 20, mock.Anything).Return([]*domain.WebhookDelivery{delivery}, nil)
+	db.On("RecordWebhookAttempt", delivery, mock.MatchedBy(func(a *domain.WebhookAttempt) bool {
+		return a.Attempt == 3 && *a.StatusCode == http.StatusServiceUnavailable && *a.Error == "unexpected status 503"
+	})).Return(nil)
+
+	d.DispatchOnce(context.Background())
+
+	db.AssertExpectations(t)
+	assert.Equal(t, domain.DeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, now.Add(2*time.Minute), delivery.NextAttemptAt)
+	assert.Equal(t, http.StatusServiceUnavailable, *delivery.LastStatusCode)
+	assert.Nil(t, delivery.DeliveredAt)
+}
+
+func TestDispatchOnce_FailsAfterLastAttempt(t *testing.T) {
+	db := new(MockDatabase)
+	d, _ := testDispatcher(db, 3)
+	// Nothing listens on this address, so the request fails before any answer.
+	delivery := pendingDelivery("http://127.0.0.1:1", 2)
+
+	db.On("ClaimWebhookDeliveries", 20, mock.Anything).Return([]*domain.WebhookDelivery{delivery}, nil)
+	db.On("RecordWebhookAttempt", delivery, mock.MatchedBy(func(a *domain.WebhookAttempt) bool {
+		return a.Attempt == 3 && a.StatusCode == nil && a.Error != nil
+	})).Return(nil)
+
+	d.DispatchOnce(context.Background())
+
+	db.AssertExpectations(t)
+	assert.Equal(t, domain.DeliveryStatusFailed, delivery.Status)
+	assert.Nil(t, delivery.LastStatusCode)
+	assert.NotNil(t, delivery.LastError)
+}
+
+func TestDispatchOnce_RefusesInternalAddress(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	db := new(MockDatabase)
+	d, _ := testDispatcher(db, 5)
+	d.client = netguard.NewClient(nil, time.Second)
+	delivery := pendingDelivery(server.URL, 0)
+
+	db.On("ClaimWebhookDeliveries", 20, mock.Anything).Return([]*domain.WebhookDelivery{delivery}, nil)
+	db.On("RecordWebhookAttempt", delivery, mock.MatchedBy(func(a *domain.WebhookAttempt) bool {
+		return a.StatusCode == nil && a.Error != nil
+	})).Return(nil)
+
+	d.DispatchOnce(context.Background())
+
+	db.AssertExpectations(t)
+	assert.False(t, reached)
+	assert.Contains(t, *delivery.LastError, netguard.ErrForbiddenAddress.Error())
+}
+
+func TestDispatchOnce_RefusesRedirectToInternalAddress(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect to an internal address was followed")
+	}))
+	defer internal.Close()
+	// The receiver is allowed, but where it redirects to is not.
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(internal.URL, "127.0.0.1", "[::1]", 1), http.StatusTemporaryRedirect)
+	}))
+	defer receiver.Close()
+
+	db := new(MockDatabase)
+	d, _ := testDispatcher(db, 5)
+	delivery := pendingDelivery(receiver.URL, 0)
+
+	db.On("ClaimWebhookDeliveries", 20, mock.Anything).Return([]*domain.WebhookDelivery{delivery}, nil)
+	db.On("RecordWebhookAttempt", delivery, mock.MatchedBy(func(a *domain.WebhookAttempt) bool {
+		return a.StatusCode == nil && a.Error != nil
+	})).Return(nil)
+
+	d.DispatchOnce(context.Background())
+
+	db.AssertExpectations(t)
+	assert.Contains(t, *delivery.LastError, netguard.ErrForbiddenAddress.Error())
+}
+
+func TestDispatchOnce_ClaimError(t *testing.T) {
+	db := new(MockDatabase)
+	d, _ := testDispatcher(db, 3)
+	db.On("ClaimWebhookDeliveries", 20, mock.Anything).Return(nil, errors.New("db down"))
+
+	claimed, err := d.DispatchOnce(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, claimed)
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	d := NewWebhookDispatcher(nil, 10, nil)
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, d.backoff(tc.attempt), "attempt %d", tc.attempt)
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1700000000.{"a":1}`))
+
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), SignPayload("secret", "1700000000", []byte(`{"a":1}`)))
+	assert.NotEqual(t, SignPayload("secret", "1700000000", []byte(`{"a":1}`)), SignPayload("secret", "1700000001", []byte(`{"a":1}`)))
+}
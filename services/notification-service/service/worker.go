@@ -10,6 +10,7 @@ import (
 	"time"
 	"notification-service/domain"
 	"notification-service/infra/rabbitmq"
+	"shared/clienterr"
 	"github.com/google/uuid"
 )
 
@@ -22,6 +23,24 @@ type NotificationWorker struct {
 	videoClient domain.VideoServiceClient
 }
 
+// BatchEmailData fills the batch email, which replaces the per-video emails
+// for videos uploaded as a batch.
+type BatchEmailData struct {
+	UserName    string
+	BatchName   string
+	Total       int
+	Completed   int
+	Failed      int
+	Videos      []BatchEmailVideo
+	DownloadURL string
+}
+
+type BatchEmailVideo struct {
+	Name         string
+	Status       string
+	ErrorMessage string
+}
+
 type EmailData struct {
 	UserName       string
 	VideoID        string
@@ -66,12 +85,16 @@ func (w *NotificationWorker) Start(ctx context.Context) {
 				continue
 			}
 
-			log.Printf("Worker %d: Sending notification for video %s", w.ID, message.VideoID)
+			if message.BatchID != "" {
+				log.Printf("Worker %d: Sending notification for batch %s", w.ID, message.BatchID)
+			} else {
+				log.Printf("Worker %d: Sending notification for video %s", w.ID, message.VideoID)
+			}
 			err = w.sendNotification(ctx, &message)
 
 			if err != nil {
-				if isNotFoundErr(err) {
-					log.Printf("Worker %d: Dropping message for missing data (404): %v", w.ID, err)
+				if clienterr.IsPermanent(err) {
+					log.Printf("Worker %d: Dropping message after permanent failure: %v", w.ID, err)
 					msg.Ack(false)
 				} else {
 					log.Printf("Worker %d: Error sending notification: %v", w.ID, err)
@@ -91,6 +114,10 @@ func (w *NotificationWorker) sendNotification(ctx context.Context, message *rabb
 		return fmt.Errorf("failed to get user from Auth Service: %w", err)
 	}
 
+	if message.Type == "batch_completed" {
+		return w.sendBatchNotification(message, user)
+	}
+
 	video, err := w.videoClient.GetVideoByID(message.VideoID)
 	if err != nil {
 		return fmt.Errorf("failed to get video from Video Service: %w", err)
@@ -151,14 +178,76 @@ func (w *NotificationWorker) sendNotification(ctx context.Context, message *rabb
 		htmlBody = message.Message
 	}
 
+	if err := w.deliver(notification, htmlBody, err); err != nil {
+		return err
+	}
+
+	log.Printf("Worker %d: Email sent to %s for video %s", w.ID, user.Email, video.ID)
+	return nil
+}
+
+// sendBatchNotification sends the single email for a batch whose videos have
+// all finished, listing how each of them went.
+func (w *NotificationWorker) sendBatchNotification(message *rabbitmq.NotificationMessage, user *domain.User) error {
+	batch, err := w.videoClient.GetBatchByID(message.BatchID)
 	if err != nil {
+		return fmt.Errorf("failed to get batch from Video Service: %w", err)
+	}
+
+	notification := &domain.Notification{
+		ID:        generateID(),
+		UserID:    message.UserID,
+		BatchID:   &message.BatchID,
+		Type:      "email",
+		Status:    "pending",
+		Subject:   message.Subject,
+		Message:   message.Message,
+		Recipient: user.Email,
+		CreatedAt: time.Now(),
+	}
+
+	if err := w.db.CreateNotification(notification); err != nil {
+		return fmt.Errorf("failed to create notification record: %w", err)
+	}
+
+	emailData := BatchEmailData{
+		UserName:  user.Name,
+		BatchName: batch.Name,
+		Total:     batch.Total,
+		Completed: batch.Counts["completed"],
+		Failed:    batch.Total - batch.Counts["completed"],
+	}
+	for _, video := range batch.Videos {
+		line := BatchEmailVideo{Name: video.OriginalName, Status: video.Status}
+		if video.ErrorMessage != nil {
+			line.ErrorMessage = *video.ErrorMessage
+		}
+		emailData.Videos = append(emailData.Videos, line)
+	}
+	if emailData.Completed > 0 {
+		emailData.DownloadURL = fmt.Sprintf("http://localhost:8080/api/v1/videos/batches/%s/download", batch.ID)
+	}
+
+	htmlBody, err := w.renderTemplate("batch_completed.html", emailData)
+	if err := w.deliver(notification, htmlBody, err); err != nil {
+		return err
+	}
+
+	log.Printf("Worker %d: Email sent to %s for batch %s", w.ID, user.Email, batch.ID)
+	return nil
+}
+
+// deliver emails the rendered body and records the outcome on the
+// notification. renderErr is the error from rendering the body, if any.
+func (w *NotificationWorker) deliver(notification *domain.Notification, htmlBody string, renderErr error) error {
+	if renderErr != nil {
 		notification.Status = "failed"
-		notification.ErrorMessage = stringPtr(err.Error())
+		notification.ErrorMessage = stringPtr(renderErr.Error())
 		w.db.UpdateNotification(notification)
-		return fmt.Errorf("failed to render template: %w", err)
+		return fmt.Errorf("failed to render template: %w", renderErr)
 	}
 
-	err = w.smtp.SendEmail(user.Email, message.Subject, htmlBody)
+	err := w.smtp.SendEmail(notification.Recipient, notification.Subject, htmlBody)
 	if err != nil {
 		notification.Status = "failed"
 		notification.ErrorMessage = stringPtr(err.Error())
@@ -170,12 +259,10 @@ func (w *NotificationWorker) sendNotification(ctx context.Context, message *rabb
 	notification.Status = "sent"
 	notification.SentAt = timePtr(time.Now())
 	w.db.UpdateNotification(notification)
-
-	log.Printf("Worker %d: Email sent to %s for video %s", w.ID, user.Email, video.ID)
 	return nil
 }
 
-func (w *NotificationWorker) renderTemplate(templateName string, data EmailData) (string, error) {
+func (w *NotificationWorker) renderTemplate(templateName string, data interface{}) (string, error) {
 	tmpl, err := template.ParseFiles(fmt.Sprintf("templates/%s", templateName))
 	if err != nil {
 		return "", err
@@ -228,10 +315,3 @@ func stringPtr(s string) *string {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
-
-func isNotFoundErr(err error) bool {
-	if err == nil {
-		return false
-	}
-	return bytes.Contains([]byte(err.Error()), []byte("404"))
-}
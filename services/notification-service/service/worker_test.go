@@ -11,6 +11,8 @@ import (
 	"notification-service/domain"
 	"notification-service/infra/rabbitmq"
 
+	"shared/clienterr"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -26,6 +28,69 @@ func (m *MockDatabase) CreateNotification(notification *domain.Notification) err
 func (m *MockDatabase) UpdateNotification(notification *domain.Notification) error {
 	return m.Called(notification).Error(0)
 }
+func (m *MockDatabase) CreateWebhook(webhook *domain.Webhook) error {
+	return m.Called(webhook).Error(0)
+}
+func (m *MockDatabase) GetWebhooks(userID string) ([]*domain.Webhook, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Webhook), args.Error(1)
+}
+func (m *MockDatabase) GetWebhook(id string) (*domain.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Webhook), args.Error(1)
+}
+func (m *MockDatabase) UpdateWebhook(webhook *domain.Webhook) error {
+	return m.Called(webhook).Error(0)
+}
+func (m *MockDatabase) DeleteWebhook(id string) error { return m.Called(id).Error(0) }
+func (m *MockDatabase) CreateWebhookDeliveries(event *domain.WebhookEvent, payload []byte) (int, error) {
+	args := m.Called(event, payload)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockDatabase) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+func (m *MockDatabase) RecordWebhookAttempt(delivery *domain.WebhookDelivery, attempt *domain.WebhookAttempt) error {
+	return m.Called(delivery, attempt).Error(0)
+}
+func (m *MockDatabase) GetWebhookDeliveries(webhookID string, limit int) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(webhookID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+func (m *MockDatabase) GetWebhookDelivery(webhookID, id string) (*domain.WebhookDelivery, error) {
+	args := m.Called(webhookID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
+func (m *MockDatabase) GetWebhookAttempts(deliveryID string) ([]*domain.WebhookAttempt, error) {
+	args := m.Called(deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookAttempt), args.Error(1)
+}
+func (m *MockDatabase) RedeliverWebhookDelivery(webhookID, id string) (*domain.WebhookDelivery, error) {
+	args := m.Called(webhookID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
 func (m *MockDatabase) Ping() error  { return m.Called().Error(0) }
 func (m *MockDatabase) Close() error { return m.Called().Error(0) }
 
@@ -49,6 +114,14 @@ func (m *MockVideoClient) GetVideoByID(id string) (*domain.Video, error) {
 	return args.Get(0).(*domain.Video), args.Error(1)
 }
 
+func (m *MockVideoClient) GetBatchByID(id string) (*domain.Batch, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Batch), args.Error(1)
+}
+
 type MockSMTPClient struct{ mock.Mock }
 
 func (m *MockSMTPClient) SendEmail(to, subject, body string) error {
@@ -65,6 +138,14 @@ func (m *MockRabbitMQ) SubscribeNotification() (<-chan amqp.Delivery, error) {
 	return args.Get(0).(<-chan amqp.Delivery), args.Error(1)
 }
 
+func (m *MockRabbitMQ) SubscribeWebhookEvents() (<-chan amqp.Delivery, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan amqp.Delivery), args.Error(1)
+}
+
 type MockAcknowledger struct{ mock.Mock }
 
 func (m *MockAcknowledger) Ack(tag uint64, multiple bool) error {
@@ -375,6 +456,89 @@ func TestSendNotification_VideoFailed_TemplateNotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to render template")
 }
 
+func sampleBatch() *domain.Batch {
+	reason := "no_video_stream: file has no video stream"
+	return &domain.Batch{
+		ID: "b1", UserID: "u1", Name: "incident-42", Status: "completed_with_errors", Total: 2,
+		Counts: map[string]int{"completed": 1, "failed": 1},
+		Videos: []domain.Video{
+			{ID: "v1", OriginalName: "cam1.mp4", Status: "completed"},
+			{ID: "v2", OriginalName: "cam2.mp4", Status: "failed", ErrorMessage: &reason},
+		},
+	}
+}
+
+func TestSendNotification_BatchCompleted_Success(t *testing.T) {
+	db := new(MockDatabase)
+	smtp := new(MockSMTPClient)
+	auth := new(MockAuthClient)
+	video := new(MockVideoClient)
+
+	os.MkdirAll("templates", 0755)
+	os.WriteFile("templates/batch_completed.html",
+		[]byte("{{.BatchName}}: {{.Completed}}/{{.Total}}{{range .Videos}} {{.Name}}={{.Status}}{{end}} {{.DownloadURL}}"), 0644)
+	defer os.RemoveAll("templates")
+
+	auth.On("GetUserByID", "u1").Return(&domain.User{ID: "u1", Email: "u@e.com", Name: "User"}, nil)
+	video.On("GetBatchByID", "b1").Return(sampleBatch(), nil)
+	db.On("CreateNotification", mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.VideoID == nil && n.BatchID != nil && *n.BatchID == "b1"
+	})).Return(nil)
+	db.On("UpdateNotification", mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.Status == "sent"
+	})).Return(nil)
+	smtp.On("SendEmail", "u@e.com", "Batch Processing Completed",
+		"incident-42: 1/2 cam1.mp4=completed cam2.mp4=failed http://localhost:8080/api/v1/videos/batches/b1/download").
+		Return(nil)
+
+	w := newTestWorker(1, db, nil, smtp, auth, video)
+	err := w.sendNotification(context.Background(), &rabbitmq.NotificationMessage{
+		UserID: "u1", BatchID: "b1", Type: "batch_completed", Subject: "Batch Processing Completed",
+	})
+
+	assert.NoError(t, err)
+	video.AssertNotCalled(t, "GetVideoByID", mock.Anything)
+	smtp.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestSendNotification_BatchCompleted_BatchNotFound(t *testing.T) {
+	auth := new(MockAuthClient)
+	video := new(MockVideoClient)
+
+	auth.On("GetUserByID", "u1").Return(&domain.User{ID: "u1", Email: "u@e.com"}, nil)
+	video.On("GetBatchByID", "b1").Return(nil, &clienterr.StatusError{StatusCode: 404})
+
+	w := newTestWorker(1, nil, nil, nil, auth, video)
+	err := w.sendNotification(context.Background(), &rabbitmq.NotificationMessage{
+		UserID: "u1", BatchID: "b1", Type: "batch_completed",
+	})
+
+	assert.Error(t, err)
+	assert.True(t, clienterr.IsPermanent(err))
+}
+
+func TestRenderTemplate_BatchCompleted(t *testing.T) {
+	wd, _ := os.Getwd()
+	os.Chdir("..")
+	defer os.Chdir(wd)
+
+	w := newTestWorker(1, nil, nil, nil, nil, nil)
+	body, err := w.renderTemplate("batch_completed.html", BatchEmailData{
+		UserName: "User", BatchName: "incident-42", Total: 2, Completed: 1, Failed: 1,
+		Videos: []BatchEmailVideo{
+			{Name: "cam1.mp4", Status: "completed"},
+			{Name: "cam2.mp4", Status: "failed", ErrorMessage: "no video stream"},
+		},
+		DownloadURL: "http://localhost:8080/api/v1/videos/batches/b1/download",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, body, "incident-42")
+	assert.Contains(t, body, "Falhou: no video stream")
+	assert.Contains(t, body, "/api/v1/videos/batches/b1/download")
+}
+
 // ─── Start ────────────────────────────────────────────────────────────────────
 
 func TestStart_SubscribeError(t *testing.T) {
@@ -454,12 +618,12 @@ func TestStart_UnmarshalError(t *testing.T) {
 }
 
 func TestStart_SendError_NotFound_Acked(t *testing.T) {
-	// 404-type error → Ack (drop the message, don't retry)
+	// Permanent error (404) → Ack (drop the message, don't retry)
 	mq := new(MockRabbitMQ)
 	auth := new(MockAuthClient)
 	ack := new(MockAcknowledger)
 
-	auth.On("GetUserByID", "u1").Return(nil, errors.New("unexpected status code 404: not found"))
+	auth.On("GetUserByID", "u1").Return(nil, &clienterr.StatusError{StatusCode: 404, Body: "not found"})
 
 	msg := rabbitmq.NotificationMessage{UserID: "u1", VideoID: "v1", Type: "custom"}
 	body, _ := json.Marshal(msg)
@@ -485,7 +649,7 @@ func TestStart_SendError_NotFound_Acked(t *testing.T) {
 }
 
 func TestStart_SendError_Transient_Nacked(t *testing.T) {
-	// Non-404 transient error → Nack with requeue=true
+	// Transient error → Nack with requeue=true
 	mq := new(MockRabbitMQ)
 	auth := new(MockAuthClient)
 	ack := new(MockAcknowledger)
@@ -561,14 +725,6 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
-func TestIsNotFoundErr(t *testing.T) {
-	assert.False(t, isNotFoundErr(nil))
-	assert.True(t, isNotFoundErr(errors.New("unexpected status code 404: not found")))
-	assert.True(t, isNotFoundErr(errors.New("404")))
-	assert.False(t, isNotFoundErr(errors.New("500 internal server error")))
-	assert.False(t, isNotFoundErr(errors.New("connection refused")))
-}
-
 func TestStringPtr(t *testing.T) {
 	p := stringPtr("hello")
 	assert.NotNil(t, p)
@@ -59,23 +59,31 @@ func (d *Database) Close() error {
 
 func (d *Database) CreateProcessingJob(job *domain.ProcessingJob) error {
 	query := `
-		INSERT INTO processing_jobs (id, video_id, worker_id, status, started_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO processing_jobs (id, video_id, run_id, user_id, worker_id, status, started_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
-	_, err := d.db.Exec(query, job.ID, job.VideoID, job.WorkerID, job.Status, job.StartedAt, job.CreatedAt)
+	_, err := d.db.Exec(query, job.ID, job.VideoID, job.RunID, job.UserID, job.WorkerID, job.Status, job.StartedAt,
+		job.CreatedAt)
 	return err
 }
 
 func (d *Database) UpdateProcessingJob(job *domain.ProcessingJob) error {
 	query := `
 		UPDATE processing_jobs 
-		SET status = $1, completed_at = $2, duration_seconds = $3, error_message = $4, retry_count = $5
-		WHERE id = $6
+		SET status = $1, completed_at = $2, duration_seconds = $3, error_message = $4, retry_count = $5,
+		    metadata = COALESCE($6::jsonb, metadata)
+		WHERE id = $7
 	`
-	_, err := d.db.Exec(query, job.Status, job.CompletedAt, job.DurationSeconds, job.ErrorMessage, job.RetryCount, job.ID)
+	_, err := d.db.Exec(query, job.Status, job.CompletedAt, job.DurationSeconds, job.ErrorMessage, job.RetryCount, job.Metadata, job.ID)
 	return err
 }
 
-
-
-
+// CountRunningJobs counts the user's jobs marked running since the given
+// time. Older rows are ignored so a worker that crashed mid-job cannot hold
+// the user's slot forever.
+func (d *Database) CountRunningJobs(userID string, since time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM processing_jobs WHERE user_id = $1 AND status = 'running' AND started_at > $2`
+	err := d.db.QueryRow(query, userID, since).Scan(&count)
+	return count, err
+}
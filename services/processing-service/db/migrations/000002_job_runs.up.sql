@@ -0,0 +1,5 @@
+-- A video can be processed more than once; each job belongs to the run that
+-- queued it in video-service. Jobs from before runs existed have none.
+ALTER TABLE processing_jobs ADD COLUMN run_id UUID;
+
+CREATE INDEX idx_jobs_run_id ON processing_jobs(run_id) WHERE run_id IS NOT NULL;
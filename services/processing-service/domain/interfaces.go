@@ -1,13 +1,16 @@
 package domain
 
 import (
+	"context"
 	"io"
+	"time"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type DatabaseInterface interface {
 	CreateProcessingJob(job *ProcessingJob) error
 	UpdateProcessingJob(job *ProcessingJob) error
+	CountRunningJobs(userID string, since time.Time) (int, error)
 }
 
 type MinIOInterface interface {
@@ -17,12 +20,13 @@ type MinIOInterface interface {
 
 type RabbitMQInterface interface {
 	PublishNotification(message NotificationMessage) error
-	SubscribeVideoUpload() (<-chan amqp.Delivery, error)
+	DeferVideoUpload(message VideoProcessingMessage, delay time.Duration) error
+	PublishVideoEvent(ctx context.Context, event VideoEvent) error
+	PublishVideoProgress(event VideoEvent) error
+	SubscribeVideoUpload(consumerTag string) (<-chan amqp.Delivery, error)
+	CancelConsumer(consumerTag string) error
 }
 
 type VideoServiceClient interface {
 	GetVideoByID(videoID string) (*Video, error)
-	UpdateVideoStatus(videoID, status string, errorMessage string) error
-	CompleteVideo(videoID, zipPath string, zipSize int64, frameCount int) error
-	FailVideo(videoID, errorMessage string) error
 }
@@ -35,6 +35,7 @@ type Video struct {
 	QueuedAt              *time.Time `json:"queued_at,omitempty" db:"queued_at"`
 	ProcessingStartedAt   *time.Time `json:"processing_started_at,omitempty" db:"processing_started_at"`
 	ProcessingCompletedAt *time.Time `json:"processing_completed_at,omitempty" db:"processing_completed_at"`
+	BatchID               *string    `json:"batch_id,omitempty" db:"batch_id"`
 }
 
 type Session struct {
@@ -51,6 +52,7 @@ type Session struct {
 type ProcessingJob struct {
 	ID              string     `json:"id" db:"id"`
 	VideoID         string     `json:"video_id" db:"video_id"`
+	RunID           *string    `json:"run_id,omitempty" db:"run_id"`
 	UserID          string     `json:"user_id" db:"user_id"`
 	WorkerID        string     `json:"worker_id" db:"worker_id"`
 	Status          string     `json:"status" db:"status"`
@@ -112,10 +114,74 @@ type SystemStats struct {
 }
 
 type VideoProcessingMessage struct {
-	VideoID     string `json:"video_id"`
-	UserID      string `json:"user_id"`
-	Filename    string `json:"filename"`
-	StoragePath string `json:"storage_path"`
+	VideoID     string            `json:"video_id"`
+	UserID      string            `json:"user_id"`
+	Filename    string            `json:"filename"`
+	StoragePath string            `json:"storage_path"`
+	Priority    int               `json:"priority"`
+	Options     ProcessingOptions `json:"options"`
+	RunID       string            `json:"run_id,omitempty"`
+	Reprocess   bool              `json:"reprocess,omitempty"`
+	Deferrals   int               `json:"deferrals,omitempty"`
+}
+
+type ProcessingOptions struct {
+	SnapToChapters bool      `json:"snap_to_chapters,omitempty"`
+	Start          *float64  `json:"start,omitempty"`
+	End            *float64  `json:"end,omitempty"`
+	Timestamps     []float64 `json:"timestamps,omitempty"`
+}
+
+// FrameInfo is one frame in a video's zip and the moment of the video it was
+// taken at, in seconds.
+type FrameInfo struct {
+	Index     int     `json:"index"`
+	Name      string  `json:"name"`
+	Timestamp float64 `json:"timestamp_seconds"`
+}
+
+type Chapter struct {
+	ID    int64   `json:"id"`
+	Start float64 `json:"start_seconds"`
+	End   float64 `json:"end_seconds"`
+	Title string  `json:"title,omitempty"`
+}
+
+// Routing keys for the video lifecycle events published on video.exchange.
+const (
+	EventVideoProcessingStarted   = "video.processing.started"
+	EventVideoProcessingCompleted = "video.processing.completed"
+	EventVideoProcessingFailed    = "video.processing.failed"
+)
+
+// EventVideoProgress is the routing key of the updates published as a job
+// moves through its stages. Unlike lifecycle events they are neither
+// persisted nor confirmed: a lost one is superseded by the next.
+const EventVideoProgress = "video.progress"
+
+// Stages of a job reported in progress updates.
+const (
+	StageDownloading      = "downloading"
+	StageExtractingFrames = "extracting_frames"
+	StagePackaging        = "packaging"
+	StageUploading        = "uploading"
+)
+
+// VideoEvent reports a processing state change to video-service. EventID lets
+// the consumer discard redeliveries; RunID names the run the message started.
+type VideoEvent struct {
+	EventID      string    `json:"event_id"`
+	Type         string    `json:"type"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	VideoID      string    `json:"video_id"`
+	UserID       string    `json:"user_id"`
+	RunID        string    `json:"run_id,omitempty"`
+	ZipPath      string    `json:"zip_path,omitempty"`
+	ZipSizeBytes int64     `json:"zip_size_bytes,omitempty"`
+	FrameCount   int       `json:"frame_count,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	Stage        string    `json:"stage,omitempty"`
+	Progress     int       `json:"progress,omitempty"`
 }
 
 type NotificationMessage struct {
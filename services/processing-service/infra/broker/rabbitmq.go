@@ -1,9 +1,13 @@
 package broker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"sync"
+	"time"
 	"processing-service/domain"
 	"processing-service/infra/utils"
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -13,6 +17,9 @@ type RabbitMQClient struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	url     string
+
+	confirmMu      sync.Mutex
+	confirmChannel *amqp.Channel
 }
 
 func InitRabbitMQ() *RabbitMQClient {
@@ -81,6 +88,17 @@ func (r *RabbitMQClient) declareInfrastructure() error {
 		return fmt.Errorf("failed to bind video queue: %v", err)
 	}
 
+	// Deferred uploads wait here, unconsumed, until their expiration moves
+	// them back to the upload queue.
+	_, err = r.channel.QueueDeclare("video.upload.delay.queue", true, false, false, false,
+		amqp.Table{
+			"x-dead-letter-exchange":    "video.exchange",
+			"x-dead-letter-routing-key": "video.upload",
+		})
+	if err != nil {
+		return fmt.Errorf("failed to declare video delay queue: %v", err)
+	}
+
 	err = r.channel.QueueBind("notification.queue", "notification.#", "notification.exchange", false, nil)
 	if err != nil {
 		return fmt.Errorf("failed to bind notification queue: %v", err)
@@ -123,6 +141,9 @@ func (r *RabbitMQClient) Ping() error {
 }
 
 func (r *RabbitMQClient) Close() error {
+	if r.confirmChannel != nil {
+		r.confirmChannel.Close()
+	}
 	if r.channel != nil {
 		r.channel.Close()
 	}
@@ -132,33 +153,121 @@ func (r *RabbitMQClient) Close() error {
 	return nil
 }
 
-func (r *RabbitMQClient) PublishVideoUpload(message domain.VideoProcessingMessage, priority int) error {
+// DeferVideoUpload parks an upload in the delay queue, from which the broker
+// returns it to the upload queue once delay has passed.
+func (r *RabbitMQClient) DeferVideoUpload(message domain.VideoProcessingMessage, delay time.Duration) error {
+	if err := r.ensureConnection(); err != nil {
+		return err
+	}
+
 	body, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
 	return r.channel.Publish(
+		"",
+		"video.upload.delay.queue",
+		false,
+		false,
+		amqp.Publishing{
+			DeliveryMode: amqp.Persistent,
+			ContentType:  "application/json",
+			Body:         body,
+			Priority:     uint8(message.Priority),
+			Expiration:   strconv.FormatInt(delay.Milliseconds(), 10),
+		},
+	)
+}
+
+// PublishVideoEvent publishes a lifecycle event on a channel in confirm mode
+// and waits until the broker has taken responsibility for it.
+func (r *RabbitMQClient) PublishVideoEvent(ctx context.Context, event domain.VideoEvent) error {
+	r.confirmMu.Lock()
+	defer r.confirmMu.Unlock()
+
+	if err := r.ensureConnection(); err != nil {
+		return err
+	}
+
+	if r.confirmChannel == nil || r.confirmChannel.IsClosed() {
+		ch, err := r.conn.Channel()
+		if err != nil {
+			return fmt.Errorf("failed to open confirm channel: %v", err)
+		}
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return fmt.Errorf("failed to enable publisher confirms: %v", err)
+		}
+		r.confirmChannel = ch
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := r.confirmChannel.PublishWithDeferredConfirmWithContext(ctx,
 		"video.exchange",
-		"video.upload",
+		event.Type,
 		false,
 		false,
 		amqp.Publishing{
 			DeliveryMode: amqp.Persistent,
 			ContentType:  "application/json",
+			MessageId:    event.EventID,
+			Timestamp:    event.OccurredAt,
+			Body:         body,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed waiting for publisher confirm: %v", err)
+	}
+	if !acked {
+		return fmt.Errorf("broker rejected %s event for video %s", event.Type, event.VideoID)
+	}
+	return nil
+}
+
+// PublishVideoProgress publishes a progress update without waiting for the
+// broker, as a transient message.
+func (r *RabbitMQClient) PublishVideoProgress(event domain.VideoEvent) error {
+	if err := r.ensureConnection(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return r.channel.Publish(
+		"video.exchange",
+		domain.EventVideoProgress,
+		false,
+		false,
+		amqp.Publishing{
+			DeliveryMode: amqp.Transient,
+			ContentType:  "application/json",
+			MessageId:    event.EventID,
+			Timestamp:    event.OccurredAt,
 			Body:         body,
-			Priority:     uint8(priority),
 		},
 	)
 }
 
-func (r *RabbitMQClient) SubscribeVideoUpload() (<-chan amqp.Delivery, error) {
+func (r *RabbitMQClient) SubscribeVideoUpload(consumerTag string) (<-chan amqp.Delivery, error) {
 	if err := r.ensureConnection(); err != nil {
 		return nil, err
 	}
 	return r.channel.Consume(
 		"video.upload.queue",
-		"",
+		consumerTag,
 		false,
 		false,
 		false,
@@ -167,6 +276,15 @@ func (r *RabbitMQClient) SubscribeVideoUpload() (<-chan amqp.Delivery, error) {
 	)
 }
 
+// CancelConsumer stops new deliveries for the given consumer. The broker
+// closes the deliveries channel once every in-flight delivery has been sent.
+func (r *RabbitMQClient) CancelConsumer(consumerTag string) error {
+	if r.channel == nil || r.channel.IsClosed() {
+		return fmt.Errorf("channel is closed")
+	}
+	return r.channel.Cancel(consumerTag, false)
+}
+
 func (r *RabbitMQClient) PublishNotification(message domain.NotificationMessage) error {
 	if err := r.ensureConnection(); err != nil {
 		return err
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"processing-service/database"
@@ -38,11 +39,16 @@ func main() {
 	log.Printf("Video Service client initialized: %s", videoServiceURL)
 
 	workerCount := getEnvInt("WORKER_COUNT", 5)
+	gracePeriod := time.Duration(getEnvInt("SHUTDOWN_GRACE_PERIOD_SECONDS", 300)) * time.Second
 	log.Printf("Processing Service starting with %d workers", workerCount)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	var ready atomic.Bool
 	var wg sync.WaitGroup
 
 	for i := 0; i < workerCount; i++ {
@@ -50,13 +56,27 @@ func main() {
 		go func(workerID int) {
 			defer wg.Done()
 			worker := service.NewWorker(workerID, db, minio, rabbitmq, videoClient)
-			worker.Start(ctx)
+			worker.Start(ctx, jobCtx)
 		}(i)
 	}
+	ready.Store(true)
 
 	go func() {
 		log.Println("Metrics server starting on :8090")
 		http.Handle("/metrics", promhttp.Handler())
+		http.HandleFunc("/health/live", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"alive"}`))
+		})
+		http.HandleFunc("/health/ready", func(w http.ResponseWriter, r *http.Request) {
+			if !ready.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"status":"draining"}`))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"ready"}`))
+		})
 		if err := http.ListenAndServe(":8090", nil); err != nil {
 			log.Printf("Failed to start metrics server: %v", err)
 		}
@@ -66,7 +86,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down workers...")
+	log.Printf("Draining workers (grace period %s)...", gracePeriod)
+	ready.Store(false)
 	cancel()
 
 	done := make(chan struct{})
@@ -78,7 +99,16 @@ func main() {
 	select {
 	case <-done:
 		log.Println("All workers stopped gracefully")
-	case <-time.After(30 * time.Second):
+		return
+	case <-time.After(gracePeriod):
+		log.Println("Grace period expired, interrupting in-flight jobs")
+		cancelJobs()
+	}
+
+	select {
+	case <-done:
+		log.Println("All workers stopped after interrupting jobs")
+	case <-time.After(10 * time.Second):
 		log.Println("Timeout waiting for workers to stop")
 	}
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"processing-service/domain"
+)
+
+// overUserCap reports whether the message's owner already has
+// MAX_JOBS_PER_USER jobs running on the cluster. The count is read before the
+// job row exists, so two workers can both let a user through at the same
+// instant; the cap is a fairness bound, not a hard limit.
+func (w *Worker) overUserCap(message *domain.VideoProcessingMessage) bool {
+	if message.UserID == "" {
+		return false
+	}
+
+	limit := envInt("MAX_JOBS_PER_USER", 2)
+	staleAfter := time.Duration(envInt("JOB_STALE_AFTER_SECONDS", 7200)) * time.Second
+
+	running, err := w.db.CountRunningJobs(message.UserID, time.Now().Add(-staleAfter))
+	if err != nil {
+		log.Printf("Worker %d: Could not count running jobs for user %s, processing anyway: %v", w.ID, message.UserID, err)
+		return false
+	}
+	return running >= limit
+}
+
+// shouldDefer reports whether the message has to wait for its owner's other
+// jobs. Once it has been deferred FAIRNESS_MAX_DEFERRALS times it runs even
+// over the cap, so a user who keeps the cap full still sees it processed.
+func (w *Worker) shouldDefer(message *domain.VideoProcessingMessage) bool {
+	if !w.overUserCap(message) {
+		return false
+	}
+	if message.Deferrals >= envInt("FAIRNESS_MAX_DEFERRALS", 30) {
+		log.Printf("Worker %d: Video %s was deferred %d times, processing it over user %s's cap",
+			w.ID, message.VideoID, message.Deferrals, message.UserID)
+		return false
+	}
+	return true
+}
+
+// deferMessage hands the message to the broker to be delivered again after
+// FAIRNESS_BACKOFF_MS, one priority level lower, behind other users'
+// uploads. The worker does not wait for it. The caller acks the original
+// delivery only if this succeeds.
+func (w *Worker) deferMessage(message domain.VideoProcessingMessage) error {
+	backoff := time.Duration(envInt("FAIRNESS_BACKOFF_MS", 2000)) * time.Millisecond
+
+	if message.Priority > 1 {
+		message.Priority--
+	}
+	message.Deferrals++
+
+	return w.rabbitmq.DeferVideoUpload(message, backoff)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"processing-service/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ─── overUserCap ──────────────────────────────────────────────────────────────
+
+func TestOverUserCap(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CountRunningJobs", "busy", mock.AnythingOfType("time.Time")).Return(2, nil)
+	db.On("CountRunningJobs", "idle", mock.AnythingOfType("time.Time")).Return(1, nil)
+	w := newTestWorker(1, db, nil, nil, nil)
+
+	assert.True(t, w.overUserCap(&domain.VideoProcessingMessage{UserID: "busy"}))
+	assert.False(t, w.overUserCap(&domain.VideoProcessingMessage{UserID: "idle"}))
+}
+
+func TestOverUserCap_IgnoresStaleJobs(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CountRunningJobs", "u1", mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since) > 119*time.Minute && time.Since(since) < 121*time.Minute
+	})).Return(0, nil)
+
+	assert.False(t, newTestWorker(1, db, nil, nil, nil).overUserCap(&domain.VideoProcessingMessage{UserID: "u1"}))
+	db.AssertExpectations(t)
+}
+
+func TestOverUserCap_ConfiguredLimit(t *testing.T) {
+	os.Setenv("MAX_JOBS_PER_USER", "5")
+	defer os.Unsetenv("MAX_JOBS_PER_USER")
+
+	db := new(MockDatabase)
+	db.On("CountRunningJobs", "u1", mock.Anything).Return(4, nil)
+
+	assert.False(t, newTestWorker(1, db, nil, nil, nil).overUserCap(&domain.VideoProcessingMessage{UserID: "u1"}))
+}
+
+func TestOverUserCap_CountErrorLetsJobThrough(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CountRunningJobs", "u1", mock.Anything).Return(0, errors.New("db down"))
+
+	assert.False(t, newTestWorker(1, db, nil, nil, nil).overUserCap(&domain.VideoProcessingMessage{UserID: "u1"}))
+}
+
+func TestOverUserCap_NoUser(t *testing.T) {
+	db := new(MockDatabase)
+
+	assert.False(t, newTestWorker(1, db, nil, nil, nil).overUserCap(&domain.VideoProcessingMessage{}))
+	db.AssertNotCalled(t, "CountRunningJobs", mock.Anything, mock.Anything)
+}
+
+// ─── shouldDefer ──────────────────────────────────────────────────────────────
+
+func TestShouldDefer(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CountRunningJobs", "busy", mock.Anything).Return(2, nil)
+	db.On("CountRunningJobs", "idle", mock.Anything).Return(0, nil)
+	w := newTestWorker(1, db, nil, nil, nil)
+
+	assert.True(t, w.shouldDefer(&domain.VideoProcessingMessage{UserID: "busy", Deferrals: 29}))
+	assert.False(t, w.shouldDefer(&domain.VideoProcessingMessage{UserID: "idle"}))
+}
+
+func TestShouldDefer_GivesUpAfterMaxDeferrals(t *testing.T) {
+	os.Setenv("FAIRNESS_MAX_DEFERRALS", "3")
+	defer os.Unsetenv("FAIRNESS_MAX_DEFERRALS")
+
+	db := new(MockDatabase)
+	db.On("CountRunningJobs", "busy", mock.Anything).Return(2, nil)
+	w := newTestWorker(1, db, nil, nil, nil)
+
+	assert.True(t, w.shouldDefer(&domain.VideoProcessingMessage{UserID: "busy", Deferrals: 2}))
+	assert.False(t, w.shouldDefer(&domain.VideoProcessingMessage{UserID: "busy", Deferrals: 3}))
+}
+
+// ─── deferMessage ─────────────────────────────────────────────────────────────
+
+func TestDeferMessage_LowersPriority(t *testing.T) {
+	mq := new(MockRabbitMQ)
+	mq.On("DeferVideoUpload", domain.VideoProcessingMessage{VideoID: "v1", UserID: "u1", Priority: 4, Deferrals: 1}, 2*time.Second).Return(nil)
+
+	err := newTestWorker(1, nil, nil, mq, nil).deferMessage(domain.VideoProcessingMessage{VideoID: "v1", UserID: "u1", Priority: 5})
+
+	assert.NoError(t, err)
+	mq.AssertExpectations(t)
+}
+
+func TestDeferMessage_PriorityFloor(t *testing.T) {
+	mq := new(MockRabbitMQ)
+	mq.On("DeferVideoUpload", mock.MatchedBy(func(m domain.VideoProcessingMessage) bool {
+		return m.Priority == 1 && m.Deferrals == 4
+	}), mock.Anything).Return(nil)
+
+	err := newTestWorker(1, nil, nil, mq, nil).deferMessage(domain.VideoProcessingMessage{Priority: 1, Deferrals: 3})
+
+	assert.NoError(t, err)
+	mq.AssertExpectations(t)
+}
+
+func TestDeferMessage_ConfiguredBackoff(t *testing.T) {
+	os.Setenv("FAIRNESS_BACKOFF_MS", "500")
+	defer os.Unsetenv("FAIRNESS_BACKOFF_MS")
+
+	mq := new(MockRabbitMQ)
+	mq.On("DeferVideoUpload", mock.Anything, 500*time.Millisecond).Return(nil)
+
+	err := newTestWorker(1, nil, nil, mq, nil).deferMessage(domain.VideoProcessingMessage{Priority: 5})
+
+	assert.NoError(t, err)
+	mq.AssertExpectations(t)
+}
+
+// ─── Start ────────────────────────────────────────────────────────────────────
+
+func TestStart_UserOverCap_DeferredAndAcked(t *testing.T) {
+	db := new(MockDatabase)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+	vc := new(MockVideoClient)
+
+	db.On("CountRunningJobs", "u1", mock.Anything).Return(2, nil)
+	mq.On("DeferVideoUpload", mock.MatchedBy(func(m domain.VideoProcessingMessage) bool {
+		return m.VideoID == "v1" && m.Priority == 4 && m.Deferrals == 1
+	}), mock.Anything).Return(nil)
+
+	data, _ := json.Marshal(domain.VideoProcessingMessage{VideoID: "v1", UserID: "u1", Priority: 5})
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
+	close(msgs)
+
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	newTestWorker(1, db, nil, mq, vc).Start(context.Background(), context.Background())
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+	vc.AssertNotCalled(t, "GetVideoByID", mock.Anything)
+}
+
+func TestStart_DeferPublishError_Requeued(t *testing.T) {
+	db := new(MockDatabase)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+
+	db.On("CountRunningJobs", "u1", mock.Anything).Return(3, nil)
+	mq.On("DeferVideoUpload", mock.Anything, mock.Anything).Return(errors.New("channel closed"))
+
+	data, _ := json.Marshal(domain.VideoProcessingMessage{VideoID: "v1", UserID: "u1", Priority: 5})
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
+	close(msgs)
+
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	newTestWorker(1, db, nil, mq, nil).Start(context.Background(), context.Background())
+
+	ack.AssertExpectations(t)
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"processing-service/domain"
+	"processing-service/infra/utils"
+)
+
+// Subtitle codecs ffmpeg can convert to WebVTT/SRT. Bitmap tracks such as
+// PGS or DVB cannot be turned into text and are skipped.
+var textSubtitleCodecs = map[string]bool{
+	"subrip":   true,
+	"srt":      true,
+	"ass":      true,
+	"ssa":      true,
+	"webvtt":   true,
+	"mov_text": true,
+	"text":     true,
+}
+
+type mediaProbe struct {
+	Streams  []probeStream  `json:"streams"`
+	Chapters []probeChapter `json:"chapters"`
+	Format   probeFormat    `json:"format"`
+}
+
+type probeStream struct {
+	Index       int               `json:"index"`
+	CodecType   string            `json:"codec_type"`
+	CodecName   string            `json:"codec_name"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Duration    string            `json:"duration"`
+	Disposition map[string]int    `json:"disposition"`
+	Tags        map[string]string `json:"tags"`
+}
+
+type probeChapter struct {
+	ID        int64             `json:"id"`
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags"`
+}
+
+type probeFormat struct {
+	FormatName string `json:"format_name"`
+	Duration   string `json:"duration"`
+	Size       string `json:"size"`
+}
+
+func probeMedia(ctx context.Context, videoPath string) (*mediaProbe, error) {
+	cmd := execCommand(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_streams",
+		"-show_chapters",
+		"-show_format",
+		videoPath,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var probe mediaProbe
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	return &probe, nil
+}
+
+func (p *mediaProbe) subtitleStreams() []probeStream {
+	streams := []probeStream{}
+	for _, s := range p.Streams {
+		if s.CodecType == "subtitle" && textSubtitleCodecs[s.CodecName] {
+			streams = append(streams, s)
+		}
+	}
+	return streams
+}
+
+func (p *mediaProbe) chapters() []domain.Chapter {
+	chapters := make([]domain.Chapter, 0, len(p.Chapters))
+	for _, c := range p.Chapters {
+		start, err := strconv.ParseFloat(c.StartTime, 64)
+		if err != nil {
+			continue
+		}
+		end, _ := strconv.ParseFloat(c.EndTime, 64)
+		chapters = append(chapters, domain.Chapter{
+			ID:    c.ID,
+			Start: start,
+			End:   end,
+			Title: c.Tags["title"],
+		})
+	}
+	return chapters
+}
+
+// extractSubtitles writes every text subtitle track as both WebVTT and SRT
+// and returns the generated files.
+func (w *Worker) extractSubtitles(ctx context.Context, videoPath string, probe *mediaProbe, outDir string) ([]string, error) {
+	streams := probe.subtitleStreams()
+	if len(streams) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, s := range streams {
+		base := filepath.Join(outDir, subtitleBaseName(s))
+		vttPath := base + ".vtt"
+		srtPath := base + ".srt"
+
+		cmd := execCommand(ctx, "ffmpeg",
+			"-i", videoPath,
+			"-map", fmt.Sprintf("0:%d", s.Index), "-c:s", "webvtt", "-y", vttPath,
+			"-map", fmt.Sprintf("0:%d", s.Index), "-c:s", "srt", "-y", srtPath,
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return files, fmt.Errorf("failed to extract subtitle stream %d: %w, output: %s", s.Index, err, string(output))
+		}
+
+		files = append(files, vttPath, srtPath)
+	}
+
+	return files, nil
+}
+
+func subtitleBaseName(s probeStream) string {
+	name := fmt.Sprintf("subtitle_%d", s.Index)
+	if lang := sanitizeTag(s.Tags["language"]); lang != "" {
+		name += "_" + lang
+	}
+	return name
+}
+
+func sanitizeTag(value string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+func writeChapters(chapters []domain.Chapter, path string) error {
+	data, err := json.MarshalIndent(chapters, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// frameManifestName is the zip entry listing every frame with the moment of
+// the video it was taken at, so frames can be browsed without guessing.
+const frameManifestName = "frames.json"
+
+// frameTimestamps returns the moment each of count frames was taken at: the
+// seeked timestamps when there are any, otherwise FFMPEG_FPS samples from
+// the start of the requested range.
+func frameTimestamps(count int, seeked []float64, options domain.ProcessingOptions) []float64 {
+	if seeked != nil {
+		return seeked
+	}
+	start := 0.0
+	if options.Start != nil {
+		start = *options.Start
+	}
+	fps := parseFPS(utils.GetEnv("FFMPEG_FPS", "1"))
+	timestamps := make([]float64, count)
+	for i := range timestamps {
+		timestamps[i] = start + float64(i)/fps
+	}
+	return timestamps
+}
+
+// parseFPS reads an ffmpeg frame rate, either a number or a ratio such as
+// 30000/1001. Anything unreadable counts as one frame per second.
+func parseFPS(value string) float64 {
+	num, den, isRatio := strings.Cut(value, "/")
+	fps, err := strconv.ParseFloat(num, 64)
+	if err != nil || fps <= 0 {
+		return 1
+	}
+	if isRatio {
+		d, err := strconv.ParseFloat(den, 64)
+		if err != nil || d <= 0 {
+			return 1
+		}
+		fps /= d
+	}
+	return fps
+}
+
+func writeFrameManifest(frames []string, timestamps []float64, path string) error {
+	manifest := make([]domain.FrameInfo, len(frames))
+	for i, frame := range frames {
+		manifest[i] = domain.FrameInfo{Index: i + 1, Name: filepath.Base(frame)}
+		if i < len(timestamps) {
+			manifest[i].Timestamp = math.Round(timestamps[i]*1000) / 1000
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// extractFramesAt grabs a single frame at each timestamp, seeking on the
+// input so ffmpeg does not decode everything before it.
+func (w *Worker) extractFramesAt(ctx context.Context, videoPath string, timestamps []float64, framesDir string) error {
+	for i, ts := range timestamps {
+		framePath := filepath.Join(framesDir, fmt.Sprintf("frame_%04d.png", i+1))
+		cmd := execCommand(ctx, "ffmpeg",
+			"-ss", formatSeconds(ts),
+			"-i", videoPath,
+			"-frames:v", "1",
+			"-y",
+			framePath,
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("ffmpeg error at %ss: %w, output: %s", formatSeconds(ts), err, string(output))
+		}
+	}
+	return nil
+}
+
+// rangeArgs turns the start/end options into input options, so ffmpeg seeks
+// straight to the start and stops reading at the end instead of decoding
+// the whole file.
+func rangeArgs(options domain.ProcessingOptions) []string {
+	args := []string{}
+	start := 0.0
+	if options.Start != nil {
+		start = *options.Start
+		args = append(args, "-ss", formatSeconds(start))
+	}
+	if options.End != nil {
+		args = append(args, "-t", formatSeconds(*options.End-start))
+	}
+	return args
+}
+
+func inRange(ts float64, options domain.ProcessingOptions) bool {
+	if options.Start != nil && ts < *options.Start {
+		return false
+	}
+	if options.End != nil && ts >= *options.End {
+		return false
+	}
+	return true
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
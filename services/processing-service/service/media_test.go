@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"processing-service/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ─── probeMedia ───────────────────────────────────────────────────────────────
+
+func TestProbeMedia_Success(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	probe, err := probeMedia(context.Background(), "WITH_EXTRAS.mkv")
+
+	assert.NoError(t, err)
+	assert.Len(t, probe.Streams, 3)
+	assert.Len(t, probe.Chapters, 2)
+	assert.Equal(t, "30.000000", probe.Format.Duration)
+}
+
+func TestProbeMedia_CommandError(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	probe, err := probeMedia(context.Background(), "FAIL_FFPROBE.mkv")
+
+	assert.Error(t, err)
+	assert.Nil(t, probe)
+	assert.Contains(t, err.Error(), "ffprobe failed")
+}
+
+// ─── mediaProbe helpers ───────────────────────────────────────────────────────
+
+func TestMediaProbe_SubtitleStreams_SkipsBitmapTracks(t *testing.T) {
+	var probe mediaProbe
+	assert.NoError(t, json.Unmarshal([]byte(probeWithExtras), &probe))
+
+	streams := probe.subtitleStreams()
+
+	assert.Len(t, streams, 1)
+	assert.Equal(t, 1, streams[0].Index)
+}
+
+func TestMediaProbe_Chapters(t *testing.T) {
+	var probe mediaProbe
+	assert.NoError(t, json.Unmarshal([]byte(probeWithExtras), &probe))
+
+	chapters := probe.chapters()
+
+	assert.Equal(t, []domain.Chapter{
+		{ID: 0, Start: 0, End: 12.5, Title: "Intro"},
+		{ID: 1, Start: 12.5, End: 30, Title: "Incident"},
+	}, chapters)
+}
+
+func TestMediaProbe_Chapters_SkipsInvalidStart(t *testing.T) {
+	probe := mediaProbe{Chapters: []probeChapter{{ID: 0, StartTime: "N/A"}}}
+	assert.Empty(t, probe.chapters())
+}
+
+func TestSubtitleBaseName(t *testing.T) {
+	assert.Equal(t, "subtitle_2", subtitleBaseName(probeStream{Index: 2}))
+	assert.Equal(t, "subtitle_3_eng", subtitleBaseName(probeStream{Index: 3, Tags: map[string]string{"language": "eng"}}))
+	assert.Equal(t, "subtitle_4_pt-BR", subtitleBaseName(probeStream{Index: 4, Tags: map[string]string{"language": "pt-BR/../"}}))
+}
+
+// ─── extractSubtitles ─────────────────────────────────────────────────────────
+
+func TestExtractSubtitles_WritesVTTAndSRT(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	var probe mediaProbe
+	assert.NoError(t, json.Unmarshal([]byte(probeWithExtras), &probe))
+	outDir := t.TempDir()
+
+	files, err := newTestWorker(1, nil, nil, nil, nil).extractSubtitles(context.Background(), "video.mkv", &probe, outDir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(outDir, "subtitle_1_por.vtt"),
+		filepath.Join(outDir, "subtitle_1_por.srt"),
+	}, files)
+}
+
+func TestExtractSubtitles_NoTracks(t *testing.T) {
+	files, err := newTestWorker(1, nil, nil, nil, nil).extractSubtitles(context.Background(), "video.mp4", &mediaProbe{}, t.TempDir())
+
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestExtractSubtitles_FFmpegError(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	var probe mediaProbe
+	assert.NoError(t, json.Unmarshal([]byte(probeWithExtras), &probe))
+
+	_, err := newTestWorker(1, nil, nil, nil, nil).extractSubtitles(context.Background(), "FAIL_FFMPEG.mkv", &probe, t.TempDir())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "subtitle stream 1")
+}
+
+// ─── writeChapters ────────────────────────────────────────────────────────────
+
+func TestWriteChapters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chapters.json")
+	chapters := []domain.Chapter{{ID: 1, Start: 0, End: 5, Title: "Start"}}
+
+	assert.NoError(t, writeChapters(chapters, path))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var decoded []domain.Chapter
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, chapters, decoded)
+}
+
+// ─── extractFramesAt ──────────────────────────────────────────────────────────
+
+func TestExtractFramesAt_OneFramePerTimestamp(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	framesDir := t.TempDir()
+	err := newTestWorker(1, nil, nil, nil, nil).extractFramesAt(context.Background(), "video.mp4", []float64{0, 12.5, 20}, framesDir)
+
+	assert.NoError(t, err)
+	frames, _ := filepath.Glob(filepath.Join(framesDir, "*.png"))
+	assert.Len(t, frames, 3)
+}
+
+func TestExtractFramesAt_Error(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	err := newTestWorker(1, nil, nil, nil, nil).extractFramesAt(context.Background(), "FAIL_FFMPEG.mp4", []float64{1}, t.TempDir())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1.000s")
+}
+
+// ─── frame manifest ───────────────────────────────────────────────────────────
+
+func TestFrameTimestamps_Seeked(t *testing.T) {
+	assert.Equal(t, []float64{3, 7.5}, frameTimestamps(2, []float64{3, 7.5}, domain.ProcessingOptions{}))
+}
+
+func TestFrameTimestamps_SampledFromStart(t *testing.T) {
+	t.Setenv("FFMPEG_FPS", "2")
+	start := 10.0
+
+	assert.Equal(t, []float64{10, 10.5, 11}, frameTimestamps(3, nil, domain.ProcessingOptions{Start: &start}))
+}
+
+func TestParseFPS(t *testing.T) {
+	assert.Equal(t, 25.0, parseFPS("25"))
+	assert.InDelta(t, 29.97, parseFPS("30000/1001"), 0.001)
+	assert.Equal(t, 0.5, parseFPS("1/2"))
+	assert.Equal(t, 1.0, parseFPS("fast"))
+	assert.Equal(t, 1.0, parseFPS("0"))
+	assert.Equal(t, 1.0, parseFPS("1/0"))
+}
+
+func TestWriteFrameManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), frameManifestName)
+
+	err := writeFrameManifest([]string{"/tmp/frames/frame_0001.png", "/tmp/frames/frame_0002.png"}, []float64{0, 1.0001}, path)
+
+	assert.NoError(t, err)
+	data, _ := os.ReadFile(path)
+	var decoded []domain.FrameInfo
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, []domain.FrameInfo{
+		{Index: 1, Name: "frame_0001.png", Timestamp: 0},
+		{Index: 2, Name: "frame_0002.png", Timestamp: 1},
+	}, decoded)
+}
+
+// ─── ranges ───────────────────────────────────────────────────────────────────
+
+func TestRangeArgs(t *testing.T) {
+	start, end := 720.0, 900.0
+
+	assert.Empty(t, rangeArgs(domain.ProcessingOptions{}))
+	assert.Equal(t, []string{"-ss", "720.000", "-t", "180.000"}, rangeArgs(domain.ProcessingOptions{Start: &start, End: &end}))
+	assert.Equal(t, []string{"-ss", "720.000"}, rangeArgs(domain.ProcessingOptions{Start: &start}))
+	assert.Equal(t, []string{"-t", "900.000"}, rangeArgs(domain.ProcessingOptions{End: &end}))
+}
+
+func TestInRange(t *testing.T) {
+	start, end := 10.0, 20.0
+	options := domain.ProcessingOptions{Start: &start, End: &end}
+
+	assert.True(t, inRange(10, options))
+	assert.True(t, inRange(15, options))
+	assert.False(t, inRange(20, options))
+	assert.False(t, inRange(5, options))
+	assert.True(t, inRange(5, domain.ProcessingOptions{}))
+}
+
+func TestFormatSeconds(t *testing.T) {
+	assert.Equal(t, "12.500", formatSeconds(12.5))
+	assert.Equal(t, "0.000", formatSeconds(0))
+}
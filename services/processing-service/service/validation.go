@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+
+	"processing-service/domain"
+	"processing-service/infra/utils"
+)
+
+// Error codes reported to the user when an input is rejected before
+// processing. They end up in the video's error message as "code: detail".
+const (
+	ErrCodeUnreadableMedia    = "unreadable_media"
+	ErrCodeNoVideoStream      = "no_video_stream"
+	ErrCodeUnsupportedCodec   = "unsupported_codec"
+	ErrCodeEmptyStream        = "empty_stream"
+	ErrCodeResolutionTooLarge = "resolution_too_large"
+	ErrCodeDurationTooLong    = "duration_too_long"
+	ErrCodeInvalidRange       = "invalid_range"
+	ErrCodeInvalidTimestamps  = "invalid_timestamps"
+)
+
+// Video codecs ffmpeg can decode into frames in our image.
+var supportedVideoCodecs = map[string]bool{
+	"h264":       true,
+	"hevc":       true,
+	"vp8":        true,
+	"vp9":        true,
+	"av1":        true,
+	"mpeg4":      true,
+	"mpeg2video": true,
+	"mpeg1video": true,
+	"msmpeg4v2":  true,
+	"msmpeg4v3":  true,
+	"h263":       true,
+	"wmv1":       true,
+	"wmv2":       true,
+	"wmv3":       true,
+	"vc1":        true,
+	"flv1":       true,
+	"mjpeg":      true,
+	"prores":     true,
+	"theora":     true,
+}
+
+// ValidationError marks an input that can never be processed. Retrying the
+// message would fail the same way, so the worker drops it.
+type ValidationError struct {
+	Code    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+type mediaLimits struct {
+	maxWidth    int
+	maxHeight   int
+	maxDuration float64
+}
+
+func loadMediaLimits() mediaLimits {
+	return mediaLimits{
+		maxWidth:    envInt("MAX_VIDEO_WIDTH", 3840),
+		maxHeight:   envInt("MAX_VIDEO_HEIGHT", 2160),
+		maxDuration: float64(envInt("MAX_VIDEO_DURATION_SECONDS", 7200)),
+	}
+}
+
+// validateMedia checks the probe of a downloaded file before any frame is
+// extracted.
+func validateMedia(probe *mediaProbe, limits mediaLimits) error {
+	var video *probeStream
+	for i := range probe.Streams {
+		s := &probe.Streams[i]
+		if s.CodecType == "video" && s.Disposition["attached_pic"] == 0 {
+			video = s
+			break
+		}
+	}
+	if video == nil {
+		return &ValidationError{Code: ErrCodeNoVideoStream, Message: "file has no video stream"}
+	}
+
+	if !supportedVideoCodecs[video.CodecName] {
+		return &ValidationError{Code: ErrCodeUnsupportedCodec, Message: fmt.Sprintf("video codec %q is not supported", video.CodecName)}
+	}
+
+	if video.Width <= 0 || video.Height <= 0 {
+		return &ValidationError{Code: ErrCodeEmptyStream, Message: "video stream has no picture"}
+	}
+	if video.Width > limits.maxWidth || video.Height > limits.maxHeight {
+		return &ValidationError{
+			Code:    ErrCodeResolutionTooLarge,
+			Message: fmt.Sprintf("resolution %dx%d exceeds the %dx%d limit", video.Width, video.Height, limits.maxWidth, limits.maxHeight),
+		}
+	}
+
+	// Some containers (e.g. browser-recorded WebM) carry no duration at all;
+	// only reject when one is present and clearly wrong.
+	duration, ok := parseDuration(video.Duration)
+	if !ok {
+		duration, ok = parseDuration(probe.Format.Duration)
+	}
+	if ok && duration <= 0 {
+		return &ValidationError{Code: ErrCodeEmptyStream, Message: "video stream has zero length"}
+	}
+	if ok && duration > limits.maxDuration {
+		return &ValidationError{
+			Code:    ErrCodeDurationTooLong,
+			Message: fmt.Sprintf("duration %.0fs exceeds the %.0fs limit", duration, limits.maxDuration),
+		}
+	}
+
+	return nil
+}
+
+// validateOptions checks the requested range and timestamps against the
+// media duration, when the container reports one.
+func validateOptions(options domain.ProcessingOptions, probe *mediaProbe) error {
+	duration, ok := parseDuration(probe.Format.Duration)
+	if !ok {
+		return nil
+	}
+
+	if options.Start != nil && *options.Start >= duration {
+		return &ValidationError{
+			Code:    ErrCodeInvalidRange,
+			Message: fmt.Sprintf("start %ss is past the end of the video (%ss)", formatSeconds(*options.Start), formatSeconds(duration)),
+		}
+	}
+	for _, ts := range options.Timestamps {
+		if ts >= duration {
+			return &ValidationError{
+				Code:    ErrCodeInvalidTimestamps,
+				Message: fmt.Sprintf("timestamp %ss is past the end of the video (%ss)", formatSeconds(ts), formatSeconds(duration)),
+			}
+		}
+	}
+	return nil
+}
+
+func parseDuration(value string) (float64, bool) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seconds, true
+}
+
+func envInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(utils.GetEnv(key, ""))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"processing-service/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLimits = mediaLimits{maxWidth: 1920, maxHeight: 1080, maxDuration: 600}
+
+func assertRejected(t *testing.T, err error, code string) {
+	t.Helper()
+	validationErr, ok := err.(*ValidationError)
+	if assert.True(t, ok, "expected *ValidationError, got %v", err) {
+		assert.Equal(t, code, validationErr.Code)
+	}
+}
+
+func TestValidateMedia_Accepts(t *testing.T) {
+	var probe mediaProbe
+	assert.NoError(t, json.Unmarshal([]byte(probeWithExtras), &probe))
+
+	assert.NoError(t, validateMedia(&probe, testLimits))
+}
+
+func TestValidateMedia_MissingDurationAllowed(t *testing.T) {
+	probe := &mediaProbe{Streams: []probeStream{{CodecType: "video", CodecName: "vp8", Width: 640, Height: 480}}}
+
+	assert.NoError(t, validateMedia(probe, testLimits))
+}
+
+func TestValidateMedia_NoVideoStream(t *testing.T) {
+	var probe mediaProbe
+	assert.NoError(t, json.Unmarshal([]byte(probeAudioOnly), &probe))
+
+	assertRejected(t, validateMedia(&probe, testLimits), ErrCodeNoVideoStream)
+}
+
+func TestValidateMedia_UnsupportedCodec(t *testing.T) {
+	probe := &mediaProbe{Streams: []probeStream{{CodecType: "video", CodecName: "cinepak", Width: 320, Height: 240}}}
+
+	err := validateMedia(probe, testLimits)
+
+	assertRejected(t, err, ErrCodeUnsupportedCodec)
+	assert.Contains(t, err.Error(), `unsupported_codec: video codec "cinepak"`)
+}
+
+func TestValidateMedia_ZeroLength(t *testing.T) {
+	probe := &mediaProbe{
+		Streams: []probeStream{{CodecType: "video", CodecName: "h264", Width: 640, Height: 480, Duration: "0.000000"}},
+		Format:  probeFormat{Duration: "10.0"},
+	}
+
+	assertRejected(t, validateMedia(probe, testLimits), ErrCodeEmptyStream)
+}
+
+func TestValidateMedia_NoPicture(t *testing.T) {
+	probe := &mediaProbe{Streams: []probeStream{{CodecType: "video", CodecName: "h264"}}}
+
+	assertRejected(t, validateMedia(probe, testLimits), ErrCodeEmptyStream)
+}
+
+func TestValidateMedia_ResolutionTooLarge(t *testing.T) {
+	probe := &mediaProbe{Streams: []probeStream{{CodecType: "video", CodecName: "hevc", Width: 3840, Height: 2160}}}
+
+	assertRejected(t, validateMedia(probe, testLimits), ErrCodeResolutionTooLarge)
+}
+
+func TestValidateMedia_DurationTooLong(t *testing.T) {
+	probe := &mediaProbe{
+		Streams: []probeStream{{CodecType: "video", CodecName: "h264", Width: 640, Height: 480}},
+		Format:  probeFormat{Duration: "3600.000000"},
+	}
+
+	err := validateMedia(probe, testLimits)
+
+	assertRejected(t, err, ErrCodeDurationTooLong)
+	assert.Contains(t, err.Error(), "3600s exceeds the 600s limit")
+}
+
+func TestLoadMediaLimits(t *testing.T) {
+	os.Setenv("MAX_VIDEO_WIDTH", "1280")
+	os.Setenv("MAX_VIDEO_DURATION_SECONDS", "not-a-number")
+	defer os.Unsetenv("MAX_VIDEO_WIDTH")
+	defer os.Unsetenv("MAX_VIDEO_DURATION_SECONDS")
+
+	limits := loadMediaLimits()
+
+	assert.Equal(t, 1280, limits.maxWidth)
+	assert.Equal(t, 2160, limits.maxHeight)
+	assert.Equal(t, float64(7200), limits.maxDuration)
+}
+
+func TestValidateOptions(t *testing.T) {
+	probe := &mediaProbe{Format: probeFormat{Duration: "30.000000"}}
+	start, late := 10.0, 30.0
+
+	assert.NoError(t, validateOptions(domain.ProcessingOptions{Start: &start, Timestamps: []float64{0, 29.9}}, probe))
+	assertRejected(t, validateOptions(domain.ProcessingOptions{Start: &late}, probe), ErrCodeInvalidRange)
+	assertRejected(t, validateOptions(domain.ProcessingOptions{Timestamps: []float64{5, 31}}, probe), ErrCodeInvalidTimestamps)
+	assert.NoError(t, validateOptions(domain.ProcessingOptions{Start: &late}, &mediaProbe{}))
+}
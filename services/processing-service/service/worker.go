@@ -4,21 +4,25 @@ import (
 	"archive/zip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 	"time"
 	"processing-service/domain"
 	"processing-service/infra/utils"
+	"shared/clienterr"
 	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 var execCommand = exec.CommandContext
 
+const eventPublishTimeout = 5 * time.Second
+
 type Worker struct {
 	ID          int
 	db          domain.DatabaseInterface
@@ -37,10 +41,14 @@ func NewWorker(id int, db domain.DatabaseInterface, minio domain.MinIOInterface,
 	}
 }
 
-func (w *Worker) Start(ctx context.Context) {
+// Start consumes uploads until ctx is cancelled, then drains: the consumer is
+// cancelled so no new work arrives, while the job in progress keeps running
+// under jobCtx until it finishes or the caller cancels jobCtx as well.
+func (w *Worker) Start(ctx, jobCtx context.Context) {
 	log.Printf("Worker %d started", w.ID)
 
-	msgs, err := w.rabbitmq.SubscribeVideoUpload()
+	consumerTag := fmt.Sprintf("processing-worker-%d-%s", w.ID, generateID())
+	msgs, err := w.rabbitmq.SubscribeVideoUpload(consumerTag)
 	if err != nil {
 		log.Fatalf("Worker %d: Failed to subscribe to queue: %v", w.ID, err)
 	}
@@ -48,7 +56,8 @@ func (w *Worker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("Worker %d stopping...", w.ID)
+			log.Printf("Worker %d draining...", w.ID)
+			w.drain(consumerTag, msgs)
 			return
 		case msg, ok := <-msgs:
 			if !ok {
@@ -56,42 +65,86 @@ func (w *Worker) Start(ctx context.Context) {
 				return
 			}
 
-			var message domain.VideoProcessingMessage
-			if err := json.Unmarshal(msg.Body, &message); err != nil {
-				log.Printf("Worker %d: Error unmarshaling message: %v", w.ID, err)
-				msg.Nack(false, false)
-				continue
+			if ctx.Err() != nil {
+				msg.Nack(false, true)
+				w.drain(consumerTag, msgs)
+				return
 			}
 
-			log.Printf("Worker %d: Processing video %s", w.ID, message.VideoID)
-			err := w.processVideo(ctx, &message)
-
-			if err != nil {
-				log.Printf("Worker %d: Error processing video %s: %v", w.ID, message.VideoID, err)
-				// If the video record doesn't exist in the Video Service (404), discard the
-				// message instead of requeuing to avoid an infinite retry loop.
-				if strings.Contains(err.Error(), "status code 404") || strings.Contains(err.Error(), "Video not found") {
-					log.Printf("Worker %d: Video %s not found in Video Service, discarding message", w.ID, message.VideoID)
-					msg.Nack(false, false)
-				} else {
-					msg.Nack(false, true)
-				}
-			} else {
-				log.Printf("Worker %d: Successfully processed video %s", w.ID, message.VideoID)
-				msg.Ack(false)
-			}
+			w.handleDelivery(jobCtx, msg)
 		}
 	}
 }
 
+func (w *Worker) handleDelivery(ctx context.Context, msg amqp.Delivery) {
+	var message domain.VideoProcessingMessage
+	if err := json.Unmarshal(msg.Body, &message); err != nil {
+		log.Printf("Worker %d: Error unmarshaling message: %v", w.ID, err)
+		msg.Nack(false, false)
+		return
+	}
+
+	if w.shouldDefer(&message) {
+		if err := w.deferMessage(message); err != nil {
+			log.Printf("Worker %d: Failed to defer video %s: %v", w.ID, message.VideoID, err)
+			msg.Nack(false, true)
+			return
+		}
+		log.Printf("Worker %d: User %s is at the in-flight limit, deferred video %s", w.ID, message.UserID, message.VideoID)
+		msg.Ack(false)
+		return
+	}
+
+	log.Printf("Worker %d: Processing video %s", w.ID, message.VideoID)
+	err := w.processVideo(ctx, &message)
+
+	if err != nil {
+		log.Printf("Worker %d: Error processing video %s: %v", w.ID, message.VideoID, err)
+		// Rejected inputs were already failed and reported to the user.
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			log.Printf("Worker %d: Video %s rejected: %v", w.ID, message.VideoID, validationErr)
+			msg.Ack(false)
+			return
+		}
+		// Failures a retry cannot fix (missing video, rejected request, bad
+		// response) are discarded instead of requeued to avoid an infinite loop.
+		if clienterr.IsPermanent(err) {
+			log.Printf("Worker %d: Discarding message for video %s after permanent failure", w.ID, message.VideoID)
+			msg.Nack(false, false)
+		} else {
+			msg.Nack(false, true)
+		}
+	} else {
+		log.Printf("Worker %d: Successfully processed video %s", w.ID, message.VideoID)
+		msg.Ack(false)
+	}
+}
+
+// drain cancels the consumer and hands back anything the broker had already
+// prefetched for it, so another replica can pick it up.
+func (w *Worker) drain(consumerTag string, msgs <-chan amqp.Delivery) {
+	if err := w.rabbitmq.CancelConsumer(consumerTag); err != nil {
+		log.Printf("Worker %d: Failed to cancel consumer %s: %v", w.ID, consumerTag, err)
+		return
+	}
+
+	for msg := range msgs {
+		msg.Nack(false, true)
+	}
+	log.Printf("Worker %d stopped consuming", w.ID)
+}
+
 func (w *Worker) processVideo(ctx context.Context, message *domain.VideoProcessingMessage) error {
 	video, err := w.videoClient.GetVideoByID(message.VideoID)
 	if err != nil {
 		return fmt.Errorf("failed to get video from Video Service: %w", err)
 	}
 
-	if err := w.videoClient.UpdateVideoStatus(message.VideoID, "processing", ""); err != nil {
-		log.Printf("Warning: Failed to update video status to processing: %v", err)
+	// The started event is informational: a later completed or failed event
+	// supersedes it, so a publish failure does not hold up the job.
+	if err := w.publishEvent(video, message, domain.VideoEvent{Type: domain.EventVideoProcessingStarted}); err != nil {
+		log.Printf("Warning: Failed to publish started event for video %s: %v", message.VideoID, err)
 	}
 
 	job := &domain.ProcessingJob{
@@ -103,91 +156,140 @@ func (w *Worker) processVideo(ctx context.Context, message *domain.VideoProcessi
 		StartedAt: timePtr(time.Now()),
 		CreatedAt: time.Now(),
 	}
+	if message.RunID != "" {
+		job.RunID = stringPtr(message.RunID)
+	}
 	w.db.CreateProcessingJob(job)
 
 	tempDir := filepath.Join("temp", message.VideoID)
 	os.MkdirAll(tempDir, 0755)
 	defer os.RemoveAll(tempDir)
 
+	w.reportProgress(video, message, domain.StageDownloading, 0)
 	videoPath := filepath.Join(tempDir, message.Filename)
 	if err := w.minio.DownloadFile(message.StoragePath, videoPath); err != nil {
 		w.updateJobFailed(job, err)
-		w.updateVideoFailed(video, err)
-		return fmt.Errorf("failed to download video: %w", err)
+		return w.updateVideoFailed(video, message, err, fmt.Errorf("failed to download video: %w", err))
 	}
 
 	framesDir := filepath.Join(tempDir, "frames")
 	os.MkdirAll(framesDir, 0755)
 
-	framePattern := filepath.Join(framesDir, "frame_%04d.png")
-	fps := utils.GetEnv("FFMPEG_FPS", "1")
+	probe, err := probeMedia(ctx, videoPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return w.interrupted(ctx, job, err)
+		}
+		log.Printf("Worker %d: Could not probe video %s: %v", w.ID, message.VideoID, err)
+		err = &ValidationError{Code: ErrCodeUnreadableMedia, Message: "file could not be read as a video"}
+	} else {
+		err = validateMedia(probe, loadMediaLimits())
+	}
+	if err != nil {
+		w.updateJobFailed(job, err)
+		return w.updateVideoFailed(video, message, err, err)
+	}
 
-	cmd := execCommand(ctx, "ffmpeg",
-		"-i", videoPath,
-		"-vf", fmt.Sprintf("fps=%s", fps),
-		"-y",
-		framePattern,
-	)
+	artifacts, chapters := w.extractArtifacts(ctx, videoPath, probe, tempDir)
 
-	output, err := cmd.CombinedOutput()
+	if err := validateOptions(message.Options, probe); err != nil {
+		w.updateJobFailed(job, err)
+		return w.updateVideoFailed(video, message, err, err)
+	}
+
+	w.reportProgress(video, message, domain.StageExtractingFrames, 20)
+	timestamps, err := w.extractFrames(ctx, videoPath, message.Options, chapters, framesDir)
 	if err != nil {
-		w.updateJobFailed(job, fmt.Errorf("ffmpeg error: %w, output: %s", err, string(output)))
-		w.updateVideoFailed(video, fmt.Errorf("failed to extract frames"))
-		return fmt.Errorf("ffmpeg failed: %w", err)
+		if ctx.Err() != nil {
+			return w.interrupted(ctx, job, err)
+		}
+		w.updateJobFailed(job, err)
+		return w.updateVideoFailed(video, message, fmt.Errorf("failed to extract frames"), fmt.Errorf("ffmpeg failed: %w", err))
 	}
 
 	frames, err := filepath.Glob(filepath.Join(framesDir, "*.png"))
 	if err != nil || len(frames) == 0 {
 		w.updateJobFailed(job, fmt.Errorf("no frames extracted"))
-		w.updateVideoFailed(video, fmt.Errorf("no frames extracted"))
-		return fmt.Errorf("no frames extracted")
+		return w.updateVideoFailed(video, message, fmt.Errorf("no frames extracted"), fmt.Errorf("no frames extracted"))
 	}
 
 	log.Printf("Worker %d: Extracted %d frames from video %s", w.ID, len(frames), message.VideoID)
 
+	w.reportProgress(video, message, domain.StagePackaging, 70)
+
+	// Each run keeps its own zip, under a name a redelivery of the run reuses.
 	zipFilename := fmt.Sprintf("frames_%s_%s.zip", message.VideoID, time.Now().Format("20060102_150405"))
+	if message.RunID != "" {
+		zipFilename = fmt.Sprintf("frames_%s_%s.zip", message.VideoID, message.RunID)
+	}
 	zipPath := filepath.Join(tempDir, zipFilename)
 
-	if err := w.createZipFile(frames, zipPath); err != nil {
+	entries := make([]zipEntry, 0, len(frames)+len(artifacts)+1)
+	for _, frame := range frames {
+		entries = append(entries, zipEntry{path: frame, name: filepath.Base(frame)})
+	}
+	entries = append(entries, artifacts...)
+
+	manifestPath := filepath.Join(tempDir, frameManifestName)
+	if err := writeFrameManifest(frames, frameTimestamps(len(frames), timestamps, message.Options), manifestPath); err != nil {
+		log.Printf("Worker %d: Failed to write frame manifest: %v", w.ID, err)
+	} else {
+		entries = append(entries, zipEntry{path: manifestPath, name: frameManifestName})
+	}
+
+	if err := w.writeZip(entries, zipPath); err != nil {
 		w.updateJobFailed(job, err)
-		w.updateVideoFailed(video, fmt.Errorf("failed to create zip"))
-		return fmt.Errorf("failed to create zip: %w", err)
+		return w.updateVideoFailed(video, message, fmt.Errorf("failed to create zip"), fmt.Errorf("failed to create zip: %w", err))
 	}
 
 	zipFile, err := os.Open(zipPath)
 	if err != nil {
 		w.updateJobFailed(job, err)
-		w.updateVideoFailed(video, fmt.Errorf("failed to open zip"))
-		return fmt.Errorf("failed to open zip: %w", err)
+		return w.updateVideoFailed(video, message, fmt.Errorf("failed to open zip"), fmt.Errorf("failed to open zip: %w", err))
 	}
 	defer zipFile.Close()
 
+	w.reportProgress(video, message, domain.StageUploading, 90)
 	zipInfo, _ := zipFile.Stat()
 	zipStoragePath, err := w.minio.UploadProcessedFile(zipFile, zipFilename, zipInfo.Size())
 	if err != nil {
 		w.updateJobFailed(job, err)
-		w.updateVideoFailed(video, fmt.Errorf("failed to upload zip"))
-		return fmt.Errorf("failed to upload zip: %w", err)
+		return w.updateVideoFailed(video, message, fmt.Errorf("failed to upload zip"), fmt.Errorf("failed to upload zip: %w", err))
 	}
 
 	frameCount := len(frames)
-	if err := w.videoClient.CompleteVideo(message.VideoID, zipStoragePath, zipInfo.Size(), frameCount); err != nil {
-		log.Printf("Warning: Failed to mark video as completed via HTTP: %v", err)
+	if err := w.publishEvent(video, message, domain.VideoEvent{
+		Type:         domain.EventVideoProcessingCompleted,
+		ZipPath:      zipStoragePath,
+		ZipSizeBytes: zipInfo.Size(),
+		FrameCount:   frameCount,
+	}); err != nil {
+		w.updateJobFailed(job, err)
+		return fmt.Errorf("failed to publish completed event: %w", err)
 	}
 
 	job.Status = "completed"
 	job.CompletedAt = timePtr(time.Now())
 	duration := int(time.Since(*job.StartedAt).Seconds())
 	job.DurationSeconds = &duration
+	if metadata, err := json.Marshal(map[string]int{
+		"frames":          frameCount,
+		"subtitle_tracks": len(probe.subtitleStreams()),
+		"chapters":        len(chapters),
+	}); err == nil {
+		job.Metadata = stringPtr(string(metadata))
+	}
 	w.db.UpdateProcessingJob(job)
 
-	w.rabbitmq.PublishNotification(domain.NotificationMessage{
-		UserID:  message.UserID,
-		VideoID: message.VideoID,
-		Type:    "video_completed",
-		Subject: "Video Processing Completed",
-		Message: fmt.Sprintf("Your video has been processed successfully. %d frames extracted.", frameCount),
-	})
+	if notifiesUser(video, message) {
+		w.rabbitmq.PublishNotification(domain.NotificationMessage{
+			UserID:  message.UserID,
+			VideoID: message.VideoID,
+			Type:    "video_completed",
+			Subject: "Video Processing Completed",
+			Message: fmt.Sprintf("Your video has been processed successfully. %d frames extracted.", frameCount),
+		})
+	}
 
 	log.Printf("Worker %d: Video %s processed successfully (%d frames, %.2fMB zip)",
 		w.ID, message.VideoID, frameCount, float64(zipInfo.Size())/1024/1024)
@@ -195,7 +297,80 @@ func (w *Worker) processVideo(ctx context.Context, message *domain.VideoProcessi
 	return nil
 }
 
-func (w *Worker) createZipFile(files []string, zipPath string) error {
+// extractFrames picks the extraction mode from the message options: explicit
+// timestamps win, then chapter starts (limited to the requested range), and
+// otherwise frames are sampled at FFMPEG_FPS across the range. It returns the
+// timestamps it seeked to, or nil when frames were sampled.
+func (w *Worker) extractFrames(ctx context.Context, videoPath string, options domain.ProcessingOptions, chapters []domain.Chapter, framesDir string) ([]float64, error) {
+	if len(options.Timestamps) > 0 {
+		return options.Timestamps, w.extractFramesAt(ctx, videoPath, options.Timestamps, framesDir)
+	}
+
+	if options.SnapToChapters {
+		starts := []float64{}
+		for _, chapter := range chapters {
+			if inRange(chapter.Start, options) {
+				starts = append(starts, chapter.Start)
+			}
+		}
+		if len(starts) > 0 {
+			return starts, w.extractFramesAt(ctx, videoPath, starts, framesDir)
+		}
+	}
+
+	return nil, w.extractFramesAtFPS(ctx, videoPath, options, framesDir)
+}
+
+func (w *Worker) extractFramesAtFPS(ctx context.Context, videoPath string, options domain.ProcessingOptions, framesDir string) error {
+	framePattern := filepath.Join(framesDir, "frame_%04d.png")
+	fps := utils.GetEnv("FFMPEG_FPS", "1")
+
+	args := append(rangeArgs(options), "-i", videoPath,
+		"-vf", fmt.Sprintf("fps=%s", fps),
+		"-y",
+		framePattern,
+	)
+	cmd := execCommand(ctx, "ffmpeg", args...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg error: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// extractArtifacts pulls subtitle tracks and chapter markers out of the
+// container. They are extras, so failures are logged and never fail the job.
+func (w *Worker) extractArtifacts(ctx context.Context, videoPath string, probe *mediaProbe, tempDir string) ([]zipEntry, []domain.Chapter) {
+	entries := []zipEntry{}
+
+	subtitles, err := w.extractSubtitles(ctx, videoPath, probe, filepath.Join(tempDir, "subtitles"))
+	if err != nil {
+		log.Printf("Worker %d: Subtitle extraction failed: %v", w.ID, err)
+	}
+	for _, file := range subtitles {
+		entries = append(entries, zipEntry{path: file, name: "subtitles/" + filepath.Base(file)})
+	}
+
+	chapters := probe.chapters()
+	if len(chapters) > 0 {
+		chaptersPath := filepath.Join(tempDir, "chapters.json")
+		if err := writeChapters(chapters, chaptersPath); err != nil {
+			log.Printf("Worker %d: Failed to write chapters: %v", w.ID, err)
+		} else {
+			entries = append(entries, zipEntry{path: chaptersPath, name: "chapters.json"})
+		}
+	}
+
+	return entries, chapters
+}
+
+type zipEntry struct {
+	path string
+	name string
+}
+
+func (w *Worker) writeZip(entries []zipEntry, zipPath string) error {
 	zipFile, err := os.Create(zipPath)
 	if err != nil {
 		return err
@@ -205,8 +380,8 @@ func (w *Worker) createZipFile(files []string, zipPath string) error {
 	zipWriter := zip.NewWriter(zipFile)
 	defer zipWriter.Close()
 
-	for _, file := range files {
-		if err := w.addFileToZip(zipWriter, file); err != nil {
+	for _, entry := range entries {
+		if err := w.addZipEntry(zipWriter, entry.path, entry.name); err != nil {
 			return err
 		}
 	}
@@ -214,7 +389,7 @@ func (w *Worker) createZipFile(files []string, zipPath string) error {
 	return nil
 }
 
-func (w *Worker) addFileToZip(zipWriter *zip.Writer, filename string) error {
+func (w *Worker) addZipEntry(zipWriter *zip.Writer, filename, name string) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
@@ -231,7 +406,7 @@ func (w *Worker) addFileToZip(zipWriter *zip.Writer, filename string) error {
 		return err
 	}
 
-	header.Name = filepath.Base(filename)
+	header.Name = name
 	header.Method = zip.Deflate
 
 	writer, err := zipWriter.CreateHeader(header)
@@ -243,6 +418,13 @@ func (w *Worker) addFileToZip(zipWriter *zip.Writer, filename string) error {
 	return err
 }
 
+// interrupted records a job cut short by shutdown. The video is left alone so
+// the requeued message can be picked up again by another worker.
+func (w *Worker) interrupted(ctx context.Context, job *domain.ProcessingJob, err error) error {
+	w.updateJobFailed(job, fmt.Errorf("processing interrupted by shutdown: %w", err))
+	return fmt.Errorf("processing interrupted: %w", ctx.Err())
+}
+
 func (w *Worker) updateJobFailed(job *domain.ProcessingJob, err error) {
 	job.Status = "failed"
 	job.CompletedAt = timePtr(time.Now())
@@ -250,18 +432,67 @@ func (w *Worker) updateJobFailed(job *domain.ProcessingJob, err error) {
 	w.db.UpdateProcessingJob(job)
 }
 
-func (w *Worker) updateVideoFailed(video *domain.Video, err error) {
-	if httpErr := w.videoClient.FailVideo(video.ID, err.Error()); httpErr != nil {
-		log.Printf("Warning: Failed to mark video as failed via HTTP: %v", httpErr)
+// updateVideoFailed reports the failure to video-service and, unless the
+// video belongs to a batch, to the user, then returns result. If the failed
+// event cannot be published the publish error is returned instead, so the
+// message is requeued rather than leaving the video stuck in processing.
+func (w *Worker) updateVideoFailed(video *domain.Video, message *domain.VideoProcessingMessage, err error, result error) error {
+	if pubErr := w.publishEvent(video, message, domain.VideoEvent{
+		Type:         domain.EventVideoProcessingFailed,
+		ErrorMessage: err.Error(),
+	}); pubErr != nil {
+		return fmt.Errorf("failed to publish failed event: %w", pubErr)
 	}
 
-	w.rabbitmq.PublishNotification(domain.NotificationMessage{
-		UserID:  video.UserID,
-		VideoID: video.ID,
-		Type:    "video_failed",
-		Subject: "Video Processing Failed",
-		Message: fmt.Sprintf("Failed to process your video: %s", err.Error()),
-	})
+	if notifiesUser(video, message) {
+		w.rabbitmq.PublishNotification(domain.NotificationMessage{
+			UserID:  video.UserID,
+			VideoID: video.ID,
+			Type:    "video_failed",
+			Subject: "Video Processing Failed",
+			Message: fmt.Sprintf("Failed to process your video: %s", err.Error()),
+		})
+	}
+
+	return result
+}
+
+// notifiesUser tells whether the worker reports the outcome to the user.
+// Videos in a batch are reported once for the whole batch, by video-service,
+// but a later reprocess of one is reported on its own.
+func notifiesUser(video *domain.Video, message *domain.VideoProcessingMessage) bool {
+	return video.BatchID == nil || message.Reprocess
+}
+
+// publishEvent stamps event with a fresh ID and the message's run and
+// publishes it for video-service to apply. It runs detached from the job
+// context so a failure can still be reported while the worker is shutting
+// down.
+func (w *Worker) publishEvent(video *domain.Video, message *domain.VideoProcessingMessage, event domain.VideoEvent) error {
+	stampEvent(&event, video, message)
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventPublishTimeout)
+	defer cancel()
+	return w.rabbitmq.PublishVideoEvent(ctx, event)
+}
+
+// reportProgress publishes that the job has reached a stage, roughly
+// progress percent of the way through. Progress is only shown to the user, so
+// a failure to publish it is logged and otherwise ignored.
+func (w *Worker) reportProgress(video *domain.Video, message *domain.VideoProcessingMessage, stage string, progress int) {
+	event := domain.VideoEvent{Type: domain.EventVideoProgress, Stage: stage, Progress: progress}
+	stampEvent(&event, video, message)
+	if err := w.rabbitmq.PublishVideoProgress(event); err != nil {
+		log.Printf("Warning: Failed to publish progress of video %s: %v", video.ID, err)
+	}
+}
+
+func stampEvent(event *domain.VideoEvent, video *domain.Video, message *domain.VideoProcessingMessage) {
+	event.EventID = generateID()
+	event.OccurredAt = time.Now().UTC()
+	event.VideoID = video.ID
+	event.UserID = video.UserID
+	event.RunID = message.RunID
 }
 
 func timePtr(t time.Time) *time.Time {
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,11 +11,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"processing-service/domain"
 
+	"shared/clienterr"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -27,6 +32,10 @@ func (m *MockDatabase) CreateProcessingJob(job *domain.ProcessingJob) error {
 func (m *MockDatabase) UpdateProcessingJob(job *domain.ProcessingJob) error {
 	return m.Called(job).Error(0)
 }
+func (m *MockDatabase) CountRunningJobs(userID string, since time.Time) (int, error) {
+	args := m.Called(userID, since)
+	return args.Int(0), args.Error(1)
+}
 
 type MockVideoClient struct{ mock.Mock }
 
@@ -37,15 +46,6 @@ func (m *MockVideoClient) GetVideoByID(videoID string) (*domain.Video, error) {
 	}
 	return args.Get(0).(*domain.Video), args.Error(1)
 }
-func (m *MockVideoClient) UpdateVideoStatus(videoID, status, errorMessage string) error {
-	return m.Called(videoID, status, errorMessage).Error(0)
-}
-func (m *MockVideoClient) CompleteVideo(videoID, zipPath string, zipSize int64, frameCount int) error {
-	return m.Called(videoID, zipPath, zipSize, frameCount).Error(0)
-}
-func (m *MockVideoClient) FailVideo(videoID, errorMessage string) error {
-	return m.Called(videoID, errorMessage).Error(0)
-}
 
 type MockMinIO struct{ mock.Mock }
 
@@ -57,18 +57,39 @@ func (m *MockMinIO) UploadProcessedFile(reader io.Reader, filename string, size
 	return args.String(0), args.Error(1)
 }
 
-type MockRabbitMQ struct{ mock.Mock }
+// MockRabbitMQ records progress updates instead of expecting them, since
+// every job publishes several.
+type MockRabbitMQ struct {
+	mock.Mock
+	progressMu sync.Mutex
+	progress   []domain.VideoEvent
+}
 
 func (m *MockRabbitMQ) PublishNotification(message domain.NotificationMessage) error {
 	return m.Called(message).Error(0)
 }
-func (m *MockRabbitMQ) SubscribeVideoUpload() (<-chan amqp.Delivery, error) {
-	args := m.Called()
+func (m *MockRabbitMQ) DeferVideoUpload(message domain.VideoProcessingMessage, delay time.Duration) error {
+	return m.Called(message, delay).Error(0)
+}
+func (m *MockRabbitMQ) PublishVideoEvent(ctx context.Context, event domain.VideoEvent) error {
+	return m.Called(event).Error(0)
+}
+func (m *MockRabbitMQ) PublishVideoProgress(event domain.VideoEvent) error {
+	m.progressMu.Lock()
+	defer m.progressMu.Unlock()
+	m.progress = append(m.progress, event)
+	return nil
+}
+func (m *MockRabbitMQ) SubscribeVideoUpload(consumerTag string) (<-chan amqp.Delivery, error) {
+	args := m.Called(consumerTag)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(<-chan amqp.Delivery), args.Error(1)
 }
+func (m *MockRabbitMQ) CancelConsumer(consumerTag string) error {
+	return m.Called(consumerTag).Error(0)
+}
 
 type MockAcknowledger struct{ mock.Mock }
 
@@ -103,6 +124,19 @@ func newTestWorker(id int, db *MockDatabase, minio *MockMinIO, mq *MockRabbitMQ,
 	return NewWorker(id, dbI, minioI, mqI, vcI)
 }
 
+// videoEvent matches a published lifecycle event of the given type.
+func videoEvent(eventType string) interface{} {
+	return mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == eventType && e.EventID != "" && e.VideoID == "v1" && e.UserID == "u1"
+	})
+}
+
+func completedEvent(zipPath string, frameCount int) interface{} {
+	return mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == domain.EventVideoProcessingCompleted && e.ZipPath == zipPath && e.FrameCount == frameCount && e.ZipSizeBytes > 0
+	})
+}
+
 // ─── ffmpeg helper process ────────────────────────────────────────────────────
 
 func MockExecCommand(ctx context.Context, command string, args ...string) *exec.Cmd {
@@ -117,33 +151,87 @@ func MockExecCommand(ctx context.Context, command string, args ...string) *exec.
 		if strings.Contains(arg, "EMPTY_FRAMES") {
 			cmd.Env = append(cmd.Env, "EMPTY_FRAMES=1")
 		}
+		if strings.Contains(arg, "WITH_EXTRAS") {
+			cmd.Env = append(cmd.Env, "WITH_EXTRAS=1")
+		}
+		if strings.Contains(arg, "FAIL_FFPROBE") {
+			cmd.Env = append(cmd.Env, "FAIL_FFPROBE=1")
+		}
+		if strings.Contains(arg, "AUDIO_ONLY") {
+			cmd.Env = append(cmd.Env, "AUDIO_ONLY=1")
+		}
 	}
 	return cmd
 }
 
+const probeWithExtras = `{
+	"streams": [
+		{"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1280, "height": 720},
+		{"index": 1, "codec_type": "subtitle", "codec_name": "subrip", "tags": {"language": "por"}},
+		{"index": 2, "codec_type": "subtitle", "codec_name": "hdmv_pgs_subtitle"}
+	],
+	"chapters": [
+		{"id": 0, "start_time": "0.000000", "end_time": "12.500000", "tags": {"title": "Intro"}},
+		{"id": 1, "start_time": "12.500000", "end_time": "30.000000", "tags": {"title": "Incident"}}
+	],
+	"format": {"format_name": "matroska,webm", "duration": "30.000000"}
+}`
+
+const probePlain = `{
+	"streams": [{"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1280, "height": 720}],
+	"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "10.000000"}
+}`
+
+const probeAudioOnly = `{
+	"streams": [
+		{"index": 0, "codec_type": "audio", "codec_name": "mp3"},
+		{"index": 1, "codec_type": "video", "codec_name": "mjpeg", "width": 500, "height": 500, "disposition": {"attached_pic": 1}}
+	],
+	"format": {"format_name": "mp3", "duration": "180.000000"}
+}`
+
 func TestHelperProcess(t *testing.T) {
 	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
 		return
 	}
-	if os.Getenv("FAIL_FFMPEG") == "1" {
-		os.Stderr.WriteString("ffmpeg error simulation")
-		os.Exit(1)
-	}
-	if os.Getenv("EMPTY_FRAMES") == "1" {
-		os.Exit(0)
-	}
 	args := os.Args
 	for i, arg := range args {
-		if arg == "--" && i+1 < len(args) && args[i+1] == "ffmpeg" {
+		if arg != "--" || i+1 >= len(args) {
+			continue
+		}
+		if args[i+1] == "ffprobe" {
+			if os.Getenv("FAIL_FFPROBE") == "1" {
+				os.Stderr.WriteString("Invalid data found when processing input")
+				os.Exit(1)
+			}
+			if os.Getenv("AUDIO_ONLY") == "1" {
+				os.Stdout.WriteString(probeAudioOnly)
+			} else if os.Getenv("WITH_EXTRAS") == "1" {
+				os.Stdout.WriteString(probeWithExtras)
+			} else {
+				os.Stdout.WriteString(probePlain)
+			}
+			os.Exit(0)
+		}
+		if os.Getenv("FAIL_FFMPEG") == "1" {
+			os.Stderr.WriteString("ffmpeg error simulation")
+			os.Exit(1)
+		}
+		if os.Getenv("EMPTY_FRAMES") == "1" {
+			os.Exit(0)
+		}
+		if args[i+1] == "ffmpeg" {
 			for j := i + 2; j < len(args); j++ {
-				if filepath.Ext(args[j]) == ".png" || strings.Contains(args[j], "frames") {
-					framesDir := args[j]
-					if filepath.Ext(args[j]) == ".png" {
-						framesDir = filepath.Dir(args[j])
+				switch filepath.Ext(args[j]) {
+				case ".png":
+					if strings.Contains(args[j], "%") {
+						os.MkdirAll(filepath.Dir(args[j]), 0755)
+						os.WriteFile(filepath.Join(filepath.Dir(args[j]), "frame_0001.png"), []byte("dummy"), 0644)
+					} else {
+						os.WriteFile(args[j], []byte("dummy"), 0644)
 					}
-					os.MkdirAll(framesDir, 0755)
-					os.WriteFile(filepath.Join(framesDir, "frame_0001.png"), []byte("dummy"), 0644)
-					break
+				case ".vtt", ".srt":
+					os.WriteFile(args[j], []byte("1\n00:00:01,000 --> 00:00:02,000\nhello\n"), 0644)
 				}
 			}
 		}
@@ -151,6 +239,18 @@ func TestHelperProcess(t *testing.T) {
 	os.Exit(0)
 }
 
+func zipEntryNames(t *testing.T, reader io.Reader) []string {
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
 // ─── NewWorker ────────────────────────────────────────────────────────────────
 
 func TestNewWorker(t *testing.T) {
@@ -188,11 +288,11 @@ func TestProcessVideo_DownloadError(t *testing.T) {
 
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
 	vc.On("GetVideoByID", "v1").Return(video, nil)
-	vc.On("UpdateVideoStatus", "v1", "processing", "").Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
 	db.On("CreateProcessingJob", mock.Anything).Return(nil)
 	minio.On("DownloadFile", "s3/path", mock.Anything).Return(errors.New("download failed"))
 	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
-	vc.On("FailVideo", "v1", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingFailed)).Return(nil)
 	mq.On("PublishNotification", mock.Anything).Return(nil)
 
 	w := newTestWorker(1, db, minio, mq, vc)
@@ -204,6 +304,31 @@ func TestProcessVideo_DownloadError(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to download video")
 }
 
+func TestProcessVideo_BatchVideoFailsWithoutNotification(t *testing.T) {
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	batchID := "b1"
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued", BatchID: &batchID}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s3/path", mock.Anything).Return(errors.New("download failed"))
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingFailed)).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "v.mp4", StoragePath: "s3/path",
+	})
+
+	assert.Error(t, err)
+	mq.AssertExpectations(t)
+	mq.AssertNotCalled(t, "PublishNotification", mock.Anything)
+}
+
 func TestProcessVideo_FFmpegError(t *testing.T) {
 	origExec := execCommand
 	execCommand = MockExecCommand
@@ -216,11 +341,11 @@ func TestProcessVideo_FFmpegError(t *testing.T) {
 
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
 	vc.On("GetVideoByID", "v1").Return(video, nil)
-	vc.On("UpdateVideoStatus", "v1", "processing", "").Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
 	db.On("CreateProcessingJob", mock.Anything).Return(nil)
 	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
 	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
-	vc.On("FailVideo", "v1", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingFailed)).Return(nil)
 	mq.On("PublishNotification", mock.Anything).Return(nil)
 
 	w := newTestWorker(1, db, minio, mq, vc)
@@ -233,6 +358,69 @@ func TestProcessVideo_FFmpegError(t *testing.T) {
 	db.AssertExpectations(t)
 }
 
+func TestProcessVideo_UnreadableMediaRejected(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == domain.EventVideoProcessingFailed && e.ErrorMessage == "unreadable_media: file could not be read as a video"
+	})).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "FAIL_FFPROBE.mp4", StoragePath: "s",
+	})
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, ErrCodeUnreadableMedia, validationErr.Code)
+	vc.AssertExpectations(t)
+}
+
+func TestProcessVideo_NoVideoStreamRejected(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == domain.EventVideoProcessingFailed && strings.HasPrefix(e.ErrorMessage, ErrCodeNoVideoStream+":")
+	})).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "AUDIO_ONLY.mp3", StoragePath: "s",
+	})
+
+	assert.Error(t, err)
+	vc.AssertExpectations(t)
+	mq.AssertExpectations(t)
+}
+
 func TestProcessVideo_NoFramesError(t *testing.T) {
 	origExec := execCommand
 	execCommand = MockExecCommand
@@ -245,11 +433,11 @@ func TestProcessVideo_NoFramesError(t *testing.T) {
 
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
 	vc.On("GetVideoByID", "v1").Return(video, nil)
-	vc.On("UpdateVideoStatus", "v1", "processing", "").Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
 	db.On("CreateProcessingJob", mock.Anything).Return(nil)
 	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
 	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
-	vc.On("FailVideo", "v1", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingFailed)).Return(nil)
 	mq.On("PublishNotification", mock.Anything).Return(nil)
 
 	w := newTestWorker(1, db, minio, mq, vc)
@@ -273,12 +461,12 @@ func TestProcessVideo_UploadError(t *testing.T) {
 
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
 	vc.On("GetVideoByID", "v1").Return(video, nil)
-	vc.On("UpdateVideoStatus", "v1", "processing", "").Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
 	db.On("CreateProcessingJob", mock.Anything).Return(nil)
 	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
 	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("upload failed"))
 	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
-	vc.On("FailVideo", "v1", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingFailed)).Return(nil)
 	mq.On("PublishNotification", mock.Anything).Return(nil)
 
 	w := newTestWorker(1, db, minio, mq, vc)
@@ -302,23 +490,302 @@ func TestProcessVideo_Success(t *testing.T) {
 
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
 	vc.On("GetVideoByID", "v1").Return(video, nil)
-	vc.On("UpdateVideoStatus", "v1", "processing", "").Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
 	db.On("CreateProcessingJob", mock.Anything).Return(nil)
 	minio.On("DownloadFile", "s3/path", mock.Anything).Return(nil)
 	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("processed/frames.zip", nil)
-	vc.On("CompleteVideo", "v1", "processed/frames.zip", mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingCompleted)).Return(nil)
 	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
 	mq.On("PublishNotification", mock.Anything).Return(nil)
 
 	w := newTestWorker(1, db, minio, mq, vc)
 	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
-		VideoID: "v1", UserID: "u1", Filename: "test.mp4", StoragePath: "s3/path",
+		VideoID: "v1", UserID: "u1", Filename: "test.mp4", StoragePath: "s3/path", RunID: "r1",
 	})
 
 	assert.NoError(t, err)
 	db.AssertExpectations(t)
 	vc.AssertExpectations(t)
 	mq.AssertExpectations(t)
+
+	stages := []string{}
+	for _, event := range mq.progress {
+		stages = append(stages, event.Stage)
+		assert.Equal(t, domain.EventVideoProgress, event.Type)
+		assert.Equal(t, "v1", event.VideoID)
+		assert.Equal(t, "u1", event.UserID)
+		assert.Equal(t, "r1", event.RunID)
+	}
+	assert.Equal(t, []string{domain.StageDownloading, domain.StageExtractingFrames, domain.StagePackaging, domain.StageUploading}, stages)
+}
+
+func TestProcessVideo_ReprocessRun(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	batchID := "b1"
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "completed", BatchID: &batchID}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == domain.EventVideoProcessingStarted && e.RunID == "r2"
+	})).Return(nil)
+	db.On("CreateProcessingJob", mock.MatchedBy(func(j *domain.ProcessingJob) bool {
+		return j.RunID != nil && *j.RunID == "r2"
+	})).Return(nil)
+	minio.On("DownloadFile", "s3/path", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, "frames_v1_r2.zip", mock.Anything).Return("processed/frames_v1_r2.zip", nil)
+	mq.On("PublishVideoEvent", mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == domain.EventVideoProcessingCompleted && e.RunID == "r2" && e.ZipPath == "processed/frames_v1_r2.zip"
+	})).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "test.mp4", StoragePath: "s3/path", RunID: "r2", Reprocess: true,
+	})
+
+	// A reprocess is reported to the user even for a video in a batch.
+	assert.NoError(t, err)
+	db.AssertExpectations(t)
+	minio.AssertExpectations(t)
+	mq.AssertExpectations(t)
+}
+
+func TestProcessVideo_CompletedEventPublishError(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("zip/path", nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingCompleted)).Return(errors.New("broker down"))
+	db.On("UpdateProcessingJob", mock.MatchedBy(func(j *domain.ProcessingJob) bool {
+		return j.Status == "failed"
+	})).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "test.mp4", StoragePath: "s",
+	})
+
+	// Not permanent, so the message is requeued and the video never stays
+	// processing while the job looks completed.
+	assert.ErrorContains(t, err, "failed to publish completed event")
+	assert.False(t, clienterr.IsPermanent(err))
+	db.AssertExpectations(t)
+	mq.AssertNotCalled(t, "PublishNotification", mock.Anything)
+}
+
+func TestProcessVideo_ZipIncludesSubtitlesAndChapters(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	var names []string
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { names = zipEntryNames(t, args.Get(0).(io.Reader)) }).
+		Return("zip/path", nil)
+	mq.On("PublishVideoEvent", completedEvent("zip/path", 1)).Return(nil)
+	db.On("UpdateProcessingJob", mock.MatchedBy(func(j *domain.ProcessingJob) bool {
+		return j.Status == "completed" && j.Metadata != nil &&
+			strings.Contains(*j.Metadata, `"chapters":2`) && strings.Contains(*j.Metadata, `"subtitle_tracks":1`)
+	})).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "WITH_EXTRAS.mkv", StoragePath: "s",
+	})
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"frame_0001.png",
+		"subtitles/subtitle_1_por.vtt",
+		"subtitles/subtitle_1_por.srt",
+		"chapters.json",
+		"frames.json",
+	}, names)
+	db.AssertExpectations(t)
+}
+
+func TestProcessVideo_SnapToChapters(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("zip/path", nil)
+	mq.On("PublishVideoEvent", completedEvent("zip/path", 2)).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "WITH_EXTRAS.mkv", StoragePath: "s",
+		Options: domain.ProcessingOptions{SnapToChapters: true},
+	})
+
+	assert.NoError(t, err)
+	vc.AssertExpectations(t)
+}
+
+func TestProcessVideo_SnapToChapters_WithinRange(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("zip/path", nil)
+	mq.On("PublishVideoEvent", completedEvent("zip/path", 1)).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	start := 10.0
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "WITH_EXTRAS.mkv", StoragePath: "s",
+		Options: domain.ProcessingOptions{SnapToChapters: true, Start: &start},
+	})
+
+	assert.NoError(t, err)
+	vc.AssertExpectations(t)
+}
+
+func TestProcessVideo_Timestamps(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("zip/path", nil)
+	mq.On("PublishVideoEvent", completedEvent("zip/path", 3)).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "clip.mp4", StoragePath: "s",
+		Options: domain.ProcessingOptions{Timestamps: []float64{1, 4.5, 9}},
+	})
+
+	assert.NoError(t, err)
+	vc.AssertExpectations(t)
+}
+
+func TestProcessVideo_TimestampPastEndRejected(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == domain.EventVideoProcessingFailed && strings.HasPrefix(e.ErrorMessage, ErrCodeInvalidTimestamps+":")
+	})).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "clip.mp4", StoragePath: "s",
+		Options: domain.ProcessingOptions{Timestamps: []float64{1, 42}},
+	})
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	vc.AssertExpectations(t)
+	mq.AssertNotCalled(t, "PublishVideoEvent", videoEvent(domain.EventVideoProcessingCompleted))
+}
+
+func TestProcessVideo_SnapToChapters_NoChaptersFallsBackToFPS(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("zip/path", nil)
+	mq.On("PublishVideoEvent", completedEvent("zip/path", 1)).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(context.Background(), &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "test.mp4", StoragePath: "s",
+		Options: domain.ProcessingOptions{SnapToChapters: true},
+	})
+
+	assert.NoError(t, err)
+	vc.AssertExpectations(t)
 }
 
 // ─── Start ────────────────────────────────────────────────────────────────────
@@ -331,10 +798,10 @@ func TestStart_UnmarshalError(t *testing.T) {
 	msgs <- amqp.Delivery{Body: []byte("invalid json"), Acknowledger: ack, DeliveryTag: 1}
 	close(msgs)
 
-	mq.On("SubscribeVideoUpload").Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
 	ack.On("Nack", uint64(1), false, false).Return(nil)
 
-	newTestWorker(1, nil, nil, mq, nil).Start(context.Background())
+	newTestWorker(1, nil, nil, mq, nil).Start(context.Background(), context.Background())
 
 	mq.AssertExpectations(t)
 	ack.AssertExpectations(t)
@@ -346,6 +813,7 @@ func TestStart_ProcessError_Nacked(t *testing.T) {
 	ack := new(MockAcknowledger)
 	vc := new(MockVideoClient)
 
+	db.On("CountRunningJobs", mock.Anything, mock.Anything).Return(0, nil)
 	vc.On("GetVideoByID", "v1").Return(nil, errors.New("not found"))
 
 	data, _ := json.Marshal(domain.VideoProcessingMessage{VideoID: "v1"})
@@ -353,25 +821,107 @@ func TestStart_ProcessError_Nacked(t *testing.T) {
 	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
 	close(msgs)
 
-	mq.On("SubscribeVideoUpload").Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	newTestWorker(1, db, nil, mq, vc).Start(context.Background(), context.Background())
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
+
+func TestStart_PermanentError_Discarded(t *testing.T) {
+	db := new(MockDatabase)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+	vc := new(MockVideoClient)
+
+	db.On("CountRunningJobs", mock.Anything, mock.Anything).Return(0, nil)
+	vc.On("GetVideoByID", "v1").Return(nil, &clienterr.StatusError{StatusCode: 404, Body: `{"error":"Video not found"}`})
+
+	data, _ := json.Marshal(domain.VideoProcessingMessage{VideoID: "v1"})
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
+	close(msgs)
+
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	ack.On("Nack", uint64(1), false, false).Return(nil)
+
+	newTestWorker(1, db, nil, mq, vc).Start(context.Background(), context.Background())
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
+
+func TestStart_TransientStatusError_Requeued(t *testing.T) {
+	db := new(MockDatabase)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+	vc := new(MockVideoClient)
+
+	db.On("CountRunningJobs", mock.Anything, mock.Anything).Return(0, nil)
+	vc.On("GetVideoByID", "v1").Return(nil, &clienterr.StatusError{StatusCode: 503, Body: "unavailable"})
+
+	data, _ := json.Marshal(domain.VideoProcessingMessage{VideoID: "v1"})
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
+	close(msgs)
+
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
 	ack.On("Nack", uint64(1), false, true).Return(nil)
 
-	newTestWorker(1, db, nil, mq, vc).Start(context.Background())
+	newTestWorker(1, db, nil, mq, vc).Start(context.Background(), context.Background())
 
 	mq.AssertExpectations(t)
 	ack.AssertExpectations(t)
 }
 
+func TestStart_ValidationError_AckedWithoutRetry(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+	vc := new(MockVideoClient)
+
+	db.On("CountRunningJobs", mock.Anything, mock.Anything).Return(0, nil)
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingFailed)).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+
+	data, _ := json.Marshal(domain.VideoProcessingMessage{VideoID: "v1", UserID: "u1", Filename: "FAIL_FFPROBE.txt", StoragePath: "s"})
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
+	close(msgs)
+
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	newTestWorker(1, db, minio, mq, vc).Start(context.Background(), context.Background())
+
+	ack.AssertExpectations(t)
+	ack.AssertNotCalled(t, "Nack", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestStart_ContextCancelled(t *testing.T) {
 	mq := new(MockRabbitMQ)
 
 	msgs := make(chan amqp.Delivery) // never receives
-	mq.On("SubscribeVideoUpload").Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("CancelConsumer", mock.Anything).Run(func(mock.Arguments) { close(msgs) }).Return(nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // cancel immediately
 
-	newTestWorker(1, nil, nil, mq, nil).Start(ctx)
+	newTestWorker(1, nil, nil, mq, nil).Start(ctx, context.Background())
 
 	mq.AssertExpectations(t)
 }
@@ -387,13 +937,14 @@ func TestStart_Success(t *testing.T) {
 	ack := new(MockAcknowledger)
 	vc := new(MockVideoClient)
 
+	db.On("CountRunningJobs", mock.Anything, mock.Anything).Return(0, nil)
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
 	vc.On("GetVideoByID", "v1").Return(video, nil)
-	vc.On("UpdateVideoStatus", "v1", "processing", "").Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
 	db.On("CreateProcessingJob", mock.Anything).Return(nil)
 	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
 	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("zip/path", nil)
-	vc.On("CompleteVideo", "v1", "zip/path", mock.AnythingOfType("int64"), mock.AnythingOfType("int")).Return(nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingCompleted)).Return(nil)
 	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
 	mq.On("PublishNotification", mock.Anything).Return(nil)
 	ack.On("Ack", uint64(1), false).Return(nil)
@@ -405,7 +956,8 @@ func TestStart_Success(t *testing.T) {
 	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
 	close(msgs)
 
-	mq.On("SubscribeVideoUpload").Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("CancelConsumer", mock.Anything).Return(nil).Maybe()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
@@ -413,13 +965,124 @@ func TestStart_Success(t *testing.T) {
 		cancel()
 	}()
 
-	newTestWorker(1, db, minio, mq, vc).Start(ctx)
+	newTestWorker(1, db, minio, mq, vc).Start(ctx, context.Background())
 
 	mq.AssertExpectations(t)
 	ack.AssertExpectations(t)
 	vc.AssertExpectations(t)
 }
 
+func TestStart_DrainRequeuesPrefetchedDelivery(t *testing.T) {
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+
+	data, _ := json.Marshal(domain.VideoProcessingMessage{VideoID: "v1"})
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
+
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("CancelConsumer", mock.Anything).Run(func(mock.Arguments) { close(msgs) }).Return(nil)
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	newTestWorker(1, nil, nil, mq, nil).Start(ctx, context.Background())
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
+
+func TestStart_DrainCancelConsumerError(t *testing.T) {
+	mq := new(MockRabbitMQ)
+
+	msgs := make(chan amqp.Delivery)
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("CancelConsumer", mock.Anything).Return(errors.New("channel is closed"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	newTestWorker(1, nil, nil, mq, nil).Start(ctx, context.Background())
+
+	mq.AssertExpectations(t)
+}
+
+func TestStart_InFlightJobUsesJobContext(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+	vc := new(MockVideoClient)
+
+	db.On("CountRunningJobs", mock.Anything, mock.Anything).Return(0, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	// Shutdown starts while the job is running; the job must still complete.
+	vc.On("GetVideoByID", "v1").Run(func(mock.Arguments) { cancel() }).Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	minio.On("UploadProcessedFile", mock.Anything, mock.Anything, mock.Anything).Return("zip/path", nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingCompleted)).Return(nil)
+	db.On("UpdateProcessingJob", mock.Anything).Return(nil)
+	mq.On("PublishNotification", mock.Anything).Return(nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	data, _ := json.Marshal(domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "test.mp4", StoragePath: "s",
+	})
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- amqp.Delivery{Body: data, Acknowledger: ack, DeliveryTag: 1}
+
+	mq.On("SubscribeVideoUpload", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	mq.On("CancelConsumer", mock.Anything).Run(func(mock.Arguments) { close(msgs) }).Return(nil)
+
+	newTestWorker(1, db, minio, mq, vc).Start(ctx, context.Background())
+
+	ack.AssertExpectations(t)
+	vc.AssertExpectations(t)
+	mq.AssertExpectations(t)
+}
+
+func TestProcessVideo_InterruptedByShutdown(t *testing.T) {
+	origExec := execCommand
+	execCommand = MockExecCommand
+	defer func() { execCommand = origExec }()
+
+	db := new(MockDatabase)
+	minio := new(MockMinIO)
+	mq := new(MockRabbitMQ)
+	vc := new(MockVideoClient)
+
+	video := &domain.Video{ID: "v1", UserID: "u1", Status: "queued"}
+	vc.On("GetVideoByID", "v1").Return(video, nil)
+	mq.On("PublishVideoEvent", videoEvent(domain.EventVideoProcessingStarted)).Return(nil)
+	db.On("CreateProcessingJob", mock.Anything).Return(nil)
+	minio.On("DownloadFile", "s", mock.Anything).Return(nil)
+	db.On("UpdateProcessingJob", mock.MatchedBy(func(j *domain.ProcessingJob) bool {
+		return j.Status == "failed" && strings.Contains(*j.ErrorMessage, "interrupted by shutdown")
+	})).Return(nil)
+
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	cancelJobs()
+
+	w := newTestWorker(1, db, minio, mq, vc)
+	err := w.processVideo(jobCtx, &domain.VideoProcessingMessage{
+		VideoID: "v1", UserID: "u1", Filename: "FAIL_FFMPEG", StoragePath: "s",
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "processing interrupted")
+	mq.AssertNotCalled(t, "PublishVideoEvent", videoEvent(domain.EventVideoProcessingFailed))
+	db.AssertExpectations(t)
+}
+
 // ─── updateJobFailed ──────────────────────────────────────────────────────────
 
 func TestUpdateJobFailed(t *testing.T) {
@@ -438,35 +1101,39 @@ func TestUpdateJobFailed(t *testing.T) {
 
 func TestUpdateVideoFailed(t *testing.T) {
 	mq := new(MockRabbitMQ)
-	vc := new(MockVideoClient)
 
-	vc.On("FailVideo", "v1", "test error").Return(nil)
+	mq.On("PublishVideoEvent", mock.MatchedBy(func(e domain.VideoEvent) bool {
+		return e.Type == domain.EventVideoProcessingFailed && e.VideoID == "v1" && e.ErrorMessage == "test error"
+	})).Return(nil)
 	mq.On("PublishNotification", mock.Anything).Return(nil)
 
-	w := newTestWorker(1, nil, nil, mq, vc)
-	w.updateVideoFailed(&domain.Video{ID: "v1", UserID: "u1"}, errors.New("test error"))
+	w := newTestWorker(1, nil, nil, mq, nil)
+	result := errors.New("wrapped")
+	err := w.updateVideoFailed(&domain.Video{ID: "v1", UserID: "u1"}, &domain.VideoProcessingMessage{}, errors.New("test error"), result)
 
-	vc.AssertExpectations(t)
+	assert.Equal(t, result, err)
 	mq.AssertExpectations(t)
 }
 
-func TestUpdateVideoFailed_FailVideoError(t *testing.T) {
+func TestUpdateVideoFailed_PublishError(t *testing.T) {
 	mq := new(MockRabbitMQ)
-	vc := new(MockVideoClient)
 
-	vc.On("FailVideo", "v1", "boom").Return(errors.New("http error"))
-	mq.On("PublishNotification", mock.Anything).Return(nil)
+	mq.On("PublishVideoEvent", mock.Anything).Return(errors.New("broker down"))
 
-	w := newTestWorker(1, nil, nil, mq, vc)
-	w.updateVideoFailed(&domain.Video{ID: "v1", UserID: "u1"}, errors.New("boom"))
+	w := newTestWorker(1, nil, nil, mq, nil)
+	err := w.updateVideoFailed(&domain.Video{ID: "v1", UserID: "u1"}, &domain.VideoProcessingMessage{}, errors.New("boom"),
+		&ValidationError{Code: ErrCodeEmptyStream})
 
-	vc.AssertExpectations(t)
-	mq.AssertExpectations(t)
+	// The publish error replaces the result so the message is requeued.
+	assert.ErrorContains(t, err, "failed to publish failed event")
+	var validationErr *ValidationError
+	assert.False(t, errors.As(err, &validationErr))
+	mq.AssertNotCalled(t, "PublishNotification", mock.Anything)
 }
 
-// ─── createZipFile / addFileToZip ─────────────────────────────────────────────
+// ─── writeZip ─────────────────────────────────────────────────────────────────
 
-func TestCreateZipFile_Success(t *testing.T) {
+func TestWriteZip_Success(t *testing.T) {
 	w := newTestWorker(1, nil, nil, nil, nil)
 	tempDir, _ := os.MkdirTemp("", "zip-test")
 	defer os.RemoveAll(tempDir)
@@ -475,32 +1142,29 @@ func TestCreateZipFile_Success(t *testing.T) {
 	os.WriteFile(f1, []byte("test content"), 0644)
 
 	zipPath := filepath.Join(tempDir, "out.zip")
-	err := w.createZipFile([]string{f1}, zipPath)
+	err := w.writeZip([]zipEntry{{path: f1, name: "frames/1.txt"}}, zipPath)
 
 	assert.NoError(t, err)
-	_, statErr := os.Stat(zipPath)
-	assert.NoError(t, statErr)
+	reader, openErr := zip.OpenReader(zipPath)
+	assert.NoError(t, openErr)
+	defer reader.Close()
+	assert.Len(t, reader.File, 1)
+	assert.Equal(t, "frames/1.txt", reader.File[0].Name)
 }
 
-func TestCreateZipFile_InvalidDestination(t *testing.T) {
+func TestWriteZip_InvalidDestination(t *testing.T) {
 	w := newTestWorker(1, nil, nil, nil, nil)
-	err := w.createZipFile([]string{"notexist.txt"}, "/no/such/dir/out.zip")
+	err := w.writeZip([]zipEntry{{path: "notexist.txt", name: "notexist.txt"}}, "/no/such/dir/out.zip")
 	assert.Error(t, err)
 }
 
-func TestCreateZipFile_MissingSourceFile(t *testing.T) {
+func TestWriteZip_MissingSourceFile(t *testing.T) {
 	w := newTestWorker(1, nil, nil, nil, nil)
 	tempDir, _ := os.MkdirTemp("", "zip-test")
 	defer os.RemoveAll(tempDir)
 
 	zipPath := filepath.Join(tempDir, "out.zip")
-	err := w.createZipFile([]string{"/no/such/file.txt"}, zipPath)
-	assert.Error(t, err)
-}
-
-func TestAddFileToZip_Error(t *testing.T) {
-	w := newTestWorker(1, nil, nil, nil, nil)
-	err := w.addFileToZip(nil, "nonexistent.png")
+	err := w.writeZip([]zipEntry{{path: "/no/such/file.txt", name: "file.txt"}}, zipPath)
 	assert.Error(t, err)
 }
 
@@ -0,0 +1,92 @@
+// Package clienterr classifies the errors of the services' HTTP clients so
+// callers can decide whether a failed call is worth retrying.
+package clienterr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error classes returned by the service clients. Callers should test for
+// them with errors.Is instead of inspecting error messages.
+var (
+	ErrNotFound     = errors.New("resource not found")
+	ErrConflict     = errors.New("resource conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrTransient    = errors.New("transient failure")
+	ErrPermanent    = errors.New("permanent failure")
+)
+
+// StatusError is returned when a service answers with an unexpected HTTP
+// status. It unwraps to the error class derived from the status code.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Unwrap() error {
+	return classifyStatus(e.StatusCode)
+}
+
+func classifyStatus(code int) error {
+	switch {
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusConflict:
+		return ErrConflict
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrUnauthorized
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500:
+		return ErrTransient
+	default:
+		return ErrPermanent
+	}
+}
+
+// classifiedError tags an underlying error with a class while keeping the
+// original message and chain intact.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
+
+// Transient marks network failures and timeouts, which may succeed on retry.
+func Transient(err error) error {
+	return &classifiedError{class: ErrTransient, err: err}
+}
+
+// Permanent marks failures a retry cannot fix, such as an undecodable body.
+func Permanent(err error) error {
+	return &classifiedError{class: ErrPermanent, err: err}
+}
+
+// IsRetryable reports whether err is worth retrying. Errors that were not
+// produced by a client are treated as retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, ErrTransient) || !IsPermanent(err)
+}
+
+// IsPermanent reports whether err belongs to a class that will fail the same
+// way however many times the call is repeated.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrConflict) ||
+		errors.Is(err, ErrUnauthorized) ||
+		errors.Is(err, ErrPermanent)
+}
@@ -0,0 +1,69 @@
+package clienterr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusError_Classification(t *testing.T) {
+	tests := []struct {
+		code  int
+		class error
+	}{
+		{404, ErrNotFound},
+		{409, ErrConflict},
+		{401, ErrUnauthorized},
+		{403, ErrUnauthorized},
+		{408, ErrTransient},
+		{429, ErrTransient},
+		{500, ErrTransient},
+		{503, ErrTransient},
+		{400, ErrPermanent},
+		{422, ErrPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			err := fmt.Errorf("call failed: %w", &StatusError{StatusCode: tt.code, Body: "body"})
+			assert.ErrorIs(t, err, tt.class)
+
+			var statusErr *StatusError
+			assert.True(t, errors.As(err, &statusErr))
+			assert.Equal(t, tt.code, statusErr.StatusCode)
+		})
+	}
+}
+
+func TestStatusError_Message(t *testing.T) {
+	err := &StatusError{StatusCode: 404, Body: "not found"}
+	assert.Equal(t, "unexpected status code 404: not found", err.Error())
+}
+
+func TestClassifiedError_KeepsChain(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Transient(cause)
+
+	assert.Equal(t, "connection refused", err.Error())
+	assert.ErrorIs(t, err, ErrTransient)
+	assert.ErrorIs(t, err, cause)
+	assert.ErrorIs(t, Permanent(cause), ErrPermanent)
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.True(t, IsPermanent(&StatusError{StatusCode: 404}))
+	assert.True(t, IsPermanent(Permanent(errors.New("bad json"))))
+	assert.False(t, IsPermanent(&StatusError{StatusCode: 502}))
+	assert.False(t, IsPermanent(Transient(errors.New("timeout"))))
+	assert.False(t, IsPermanent(errors.New("disk full")))
+	assert.False(t, IsPermanent(nil))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&StatusError{StatusCode: 503}))
+	assert.True(t, IsRetryable(Transient(errors.New("timeout"))))
+	assert.True(t, IsRetryable(errors.New("disk full")))
+	assert.False(t, IsRetryable(&StatusError{StatusCode: 409}))
+	assert.False(t, IsRetryable(nil))
+}
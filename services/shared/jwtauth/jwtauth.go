@@ -0,0 +1,176 @@
+// Package jwtauth authenticates requests to the services with the access
+// tokens auth-service issues.
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"shared/netguard"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Headers the API gateway sets after checking the caller's token itself.
+const (
+	UserIDHeader   = "X-User-Id"
+	UserRoleHeader = "X-User-Role"
+)
+
+// DefaultRole is the role of a user whose token or headers name none.
+const DefaultRole = "user"
+
+// Claims are the claims of an auth-service access token.
+type Claims struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Config is what an Authenticator accepts.
+type Config struct {
+	// Secret is the key auth-service signs tokens with (JWT_SECRET).
+	Secret string
+	// Issuer, when set, must match the tokens' iss claim.
+	Issuer string
+	// TrustedCallers lists, comma-separated, the CIDRs or addresses whose
+	// requests may name the user in X-User-Id instead of carrying a token.
+	// Empty trusts nobody.
+	TrustedCallers string
+}
+
+// Authenticator checks access tokens and, from trusted callers, the
+// gateway's user headers.
+type Authenticator struct {
+	secret  []byte
+	issuer  string
+	trusted []*net.IPNet
+	now     func() time.Time
+}
+
+func New(config Config) (*Authenticator, error) {
+	if config.Secret == "" {
+		return nil, fmt.Errorf("a signing secret is required")
+	}
+	trusted, err := netguard.ParseCIDRs(config.TrustedCallers)
+	if err != nil {
+		return nil, err
+	}
+	return &Authenticator{
+		secret:  []byte(config.Secret),
+		issuer:  config.Issuer,
+		trusted: trusted,
+		now:     time.Now,
+	}, nil
+}
+
+// ErrExpired is returned for a token whose signature is good but whose
+// expiry has passed.
+var ErrExpired = errors.New("token has expired")
+
+// Validate checks a token's signature, expiry and issuer, accepting it with
+// or without the "Bearer " prefix, and returns its claims. UserID is filled
+// from the subject when the token has no user_id claim.
+func (a *Authenticator) Validate(token string) (*Claims, error) {
+	token = strings.TrimSpace(token)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	if token == "" {
+		return nil, fmt.Errorf("empty token")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(a.now),
+	}
+	if a.issuer != "" {
+		options = append(options, jwt.WithIssuer(a.issuer))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, options...)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.UserID == "" {
+		claims.UserID = claims.Subject
+	}
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("token names no user")
+	}
+	if claims.Role == "" {
+		claims.Role = DefaultRole
+	}
+	return claims, nil
+}
+
+// Middleware authenticates each request and sets "user_id" and "user_role"
+// on the context. A trusted caller may name the user in the gateway's
+// headers; anyone else's headers are ignored and their token is checked.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.GetHeader(UserIDHeader); userID != "" && a.trustedCaller(c.Request) {
+			role := c.GetHeader(UserRoleHeader)
+			if role == "" {
+				role = DefaultRole
+			}
+			c.Set("user_id", userID)
+			c.Set("user_role", role)
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader("Authorization")
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+			c.Abort()
+			return
+		}
+		claims, err := a.Validate(token)
+		if err != nil {
+			message := "Invalid token"
+			if errors.Is(err, ErrExpired) {
+				message = "Token has expired"
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+			c.Abort()
+			return
+		}
+		c.Set("user_id", claims.UserID)
+		c.Set("user_role", claims.Role)
+		c.Next()
+	}
+}
+
+// trustedCaller looks at the address the connection came from, never at
+// forwarding headers, which the caller controls.
+func (a *Authenticator) trustedCaller(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range a.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecret = "test-secret"
+	testIssuer = "g57-auth-service"
+	testUserID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newAuthenticator(t *testing.T, trusted string) *Authenticator {
+	t.Helper()
+	a, err := New(Config{Secret: testSecret, Issuer: testIssuer, TrustedCallers: trusted})
+	require.NoError(t, err)
+	return a
+}
+
+func validClaims() Claims {
+	now := time.Now()
+	return Claims{
+		UserID: testUserID,
+		Email:  "user@example.com",
+		Role:   "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    testIssuer,
+			Subject:   testUserID,
+		},
+	}
+}
+
+func sign(t *testing.T, claims Claims, secret string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+// ---------- New ----------
+
+func TestNew_RequiresSecret(t *testing.T) {
+	_, err := New(Config{})
+	assert.Error(t, err)
+}
+
+func TestNew_InvalidTrustedCallers(t *testing.T) {
+	_, err := New(Config{Secret: testSecret, TrustedCallers: "10.0.0.0/8, not-an-ip"})
+	assert.Error(t, err)
+}
+
+// ---------- Validate ----------
+
+func TestValidate_ValidToken(t *testing.T) {
+	a := newAuthenticator(t, "")
+
+	claims, err := a.Validate("Bearer " + sign(t, validClaims(), testSecret))
+
+	require.NoError(t, err)
+	assert.Equal(t, testUserID, claims.UserID)
+	assert.Equal(t, "admin", claims.Role)
+	assert.Equal(t, "user@example.com", claims.Email)
+}
+
+func TestValidate_WithoutBearerPrefix(t *testing.T) {
+	a := newAuthenticator(t, "")
+
+	claims, err := a.Validate(sign(t, validClaims(), testSecret))
+
+	require.NoError(t, err)
+	assert.Equal(t, testUserID, claims.UserID)
+}
+
+func TestValidate_UserIDFallsBackToSubject(t *testing.T) {
+	a := newAuthenticator(t, "")
+	c := validClaims()
+	c.UserID = ""
+	c.Role = ""
+
+	claims, err := a.Validate(sign(t, c, testSecret))
+
+	require.NoError(t, err)
+	assert.Equal(t, testUserID, claims.UserID)
+	assert.Equal(t, DefaultRole, claims.Role)
+}
+
+func TestValidate_Rejects(t *testing.T) {
+	a := newAuthenticator(t, "")
+
+	noUser := validClaims()
+	noUser.UserID = ""
+	noUser.Subject = ""
+
+	noExpiry := validClaims()
+	noExpiry.ExpiresAt = nil
+
+	wrongIssuer := validClaims()
+	wrongIssuer.Issuer = "someone-else"
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"empty":        "Bearer ",
+		"garbage":      "Bearer not.a.token",
+		"wrong secret": sign(t, validClaims(), "other-secret"),
+		"alg none":     unsigned,
+		"no user":      sign(t, noUser, testSecret),
+		"no expiry":    sign(t, noExpiry, testSecret),
+		"wrong issuer": sign(t, wrongIssuer, testSecret),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := a.Validate(token)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrExpired)
+		})
+	}
+}
+
+func TestValidate_Expired(t *testing.T) {
+	a := newAuthenticator(t, "")
+	c := validClaims()
+	c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	_, err := a.Validate(sign(t, c, testSecret))
+
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestValidate_AnyIssuerWhenUnset(t *testing.T) {
+	a, err := New(Config{Secret: testSecret})
+	require.NoError(t, err)
+	c := validClaims()
+	c.Issuer = "someone-else"
+
+	_, err = a.Validate(sign(t, c, testSecret))
+
+	assert.NoError(t, err)
+}
+
+// ---------- Middleware ----------
+
+func serve(a *Authenticator, remoteAddr string, headers map[string]string) (*httptest.ResponseRecorder, gin.H) {
+	seen := gin.H{}
+	router := gin.New()
+	router.Use(a.Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		seen["user_id"] = c.GetString("user_id")
+		seen["user_role"] = c.GetString("user_role")
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	router.ServeHTTP(w, req)
+	return w, seen
+}
+
+func TestMiddleware_ValidToken(t *testing.T) {
+	a := newAuthenticator(t, "")
+
+	w, seen := serve(a, "203.0.113.7:5000", map[string]string{
+		"Authorization": "Bearer " + sign(t, validClaims(), testSecret),
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, testUserID, seen["user_id"])
+	assert.Equal(t, "admin", seen["user_role"])
+}
+
+func TestMiddleware_MissingToken(t *testing.T) {
+	a := newAuthenticator(t, "")
+
+	w, _ := serve(a, "203.0.113.7:5000", nil)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Authorization required")
+}
+
+func TestMiddleware_InvalidToken(t *testing.T) {
+	a := newAuthenticator(t, "")
+
+	w, _ := serve(a, "203.0.113.7:5000", map[string]string{
+		"Authorization": "Bearer " + sign(t, validClaims(), "other-secret"),
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid token")
+}
+
+func TestMiddleware_ExpiredToken(t *testing.T) {
+	a := newAuthenticator(t, "")
+	c := validClaims()
+	c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	w, _ := serve(a, "203.0.113.7:5000", map[string]string{
+		"Authorization": "Bearer " + sign(t, c, testSecret),
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Token has expired")
+}
+
+func TestMiddleware_TrustedCallerHeaders(t *testing.T) {
+	a := newAuthenticator(t, "10.0.0.0/8")
+
+	w, seen := serve(a, "10.1.2.3:5000", map[string]string{
+		UserIDHeader:   testUserID,
+		UserRoleHeader: "admin",
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, testUserID, seen["user_id"])
+	assert.Equal(t, "admin", seen["user_role"])
+}
+
+func TestMiddleware_TrustedCallerDefaultRole(t *testing.T) {
+	a := newAuthenticator(t, "10.1.2.3")
+
+	w, seen := serve(a, "10.1.2.3:5000", map[string]string{UserIDHeader: testUserID})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, DefaultRole, seen["user_role"])
+}
+
+func TestMiddleware_UntrustedCallerHeadersIgnored(t *testing.T) {
+	a := newAuthenticator(t, "10.0.0.0/8")
+
+	w, _ := serve(a, "203.0.113.7:5000", map[string]string{
+		UserIDHeader:      testUserID,
+		UserRoleHeader:    "admin",
+		"X-Forwarded-For": "10.1.2.3",
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMiddleware_UntrustedCallerUsesToken(t *testing.T) {
+	a := newAuthenticator(t, "10.0.0.0/8")
+	c := validClaims()
+	c.Role = "user"
+
+	w, seen := serve(a, "203.0.113.7:5000", map[string]string{
+		UserIDHeader:    "someone-else",
+		UserRoleHeader:  "admin",
+		"Authorization": "Bearer " + sign(t, c, testSecret),
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, testUserID, seen["user_id"])
+	assert.Equal(t, "user", seen["user_role"])
+}
+
+func TestMiddleware_NoTrustedCallersByDefault(t *testing.T) {
+	a := newAuthenticator(t, "")
+
+	w, _ := serve(a, "127.0.0.1:5000", map[string]string{UserIDHeader: testUserID})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
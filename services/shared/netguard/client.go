@@ -0,0 +1,138 @@
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRedirects bounds how many redirects a fetch follows.
+const maxRedirects = 5
+
+// ErrForbiddenAddress is returned when a URL resolves to an address that may
+// not be fetched.
+var ErrForbiddenAddress = errors.New("address is not allowed")
+
+// deniedRanges are blocked on top of what net.IP classifies as loopback,
+// private, link-local, multicast or unspecified.
+var deniedRanges = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, which can reach any IPv4 address
+	"2001:db8::/32", // documentation
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets, err := ParseCIDRs(strings.Join(cidrs, ","))
+	if err != nil {
+		panic(err)
+	}
+	return nets
+}
+
+// CheckAddress refuses internal and reserved addresses unless one of allowed
+// contains them.
+func CheckAddress(ip net.IP, allowed []*net.IPNet) error {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, ipNet := range allowed {
+		if ipNet.Contains(ip) {
+			return nil
+		}
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	for _, ipNet := range deniedRanges {
+		if ipNet.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+		}
+	}
+	return nil
+}
+
+// CheckHost refuses a URL host that names the local machine or is a literal
+// address CheckAddress refuses, so such a URL can be rejected up front.
+// Other hostnames are checked when they are dialled.
+func CheckHost(host string, allowed []*net.IPNet) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckAddress(ip, allowed)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// ValidateURL accepts absolute http and https URLs without credentials.
+func ValidateURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("URL scheme must be http or https")
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("URL must have a host")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("URL must not contain credentials")
+	}
+	return u, nil
+}
+
+// NewClient returns an HTTP client for user-supplied URLs. The address check
+// runs on every connection after DNS resolution, redirects included, so a
+// hostname that resolves (or is rebound) to an internal address is refused.
+// Proxy settings from the environment are ignored for the same reason.
+func NewClient(allowed []*net.IPNet, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return CheckAddress(ip, allowed)
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if _, err := ValidateURL(req.URL.String()); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,30 @@
+package netguard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- ParseCIDRs ----------
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs(" 10.0.0.0/8, 192.168.1.5 ,,::1")
+	require.NoError(t, err)
+	require.Len(t, nets, 3)
+	assert.Equal(t, "10.0.0.0/8", nets[0].String())
+	assert.Equal(t, "192.168.1.5/32", nets[1].String())
+	assert.Equal(t, "::1/128", nets[2].String())
+}
+
+func TestParseCIDRs_Invalid(t *testing.T) {
+	_, err := ParseCIDRs("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParseCIDRs("not-an-ip")
+	assert.Error(t, err)
+
+	nets, err := ParseCIDRs("")
+	assert.NoError(t, err)
+	assert.Empty(t, nets)
+}
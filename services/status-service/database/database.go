@@ -4,11 +4,14 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"status-service/domain"
 	"status-service/infra/utils"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Database struct {
@@ -54,3 +57,239 @@ func (d *Database) Ping() error {
 func (d *Database) Close() error {
 	return d.db.Close()
 }
+
+const videoColumns = `id, user_id, filename, original_name, size_bytes, duration_seconds, status, storage_path,
+	zip_path, zip_size_bytes, frame_count, error_message, retry_count, priority, created_at, updated_at,
+	queued_at, processing_started_at, processing_completed_at, deleted_at`
+
+// ApplyVideoChange brings the video's row up to the change, unless the row
+// already shows a newer one. A purged video keeps its row, marked purged
+// and deleted, so later snapshots of it are ignored too.
+func (d *Database) ApplyVideoChange(change *domain.VideoChange) error {
+	video := change.Video
+	deletedAt := utc(video.DeletedAt)
+	if change.Deleted && deletedAt == nil {
+		changedAt := change.ChangedAt.UTC()
+		deletedAt = &changedAt
+	}
+
+	query := `
+		INSERT INTO videos (` + videoColumns + `, purged, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		ON CONFLICT (id) DO UPDATE SET
+			filename = EXCLUDED.filename,
+			original_name = EXCLUDED.original_name,
+			size_bytes = EXCLUDED.size_bytes,
+			duration_seconds = EXCLUDED.duration_seconds,
+			status = EXCLUDED.status,
+			storage_path = EXCLUDED.storage_path,
+			zip_path = EXCLUDED.zip_path,
+			zip_size_bytes = EXCLUDED.zip_size_bytes,
+			frame_count = EXCLUDED.frame_count,
+			error_message = EXCLUDED.error_message,
+			retry_count = EXCLUDED.retry_count,
+			priority = EXCLUDED.priority,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at,
+			queued_at = EXCLUDED.queued_at,
+			processing_started_at = EXCLUDED.processing_started_at,
+			processing_completed_at = EXCLUDED.processing_completed_at,
+			deleted_at = EXCLUDED.deleted_at,
+			purged = EXCLUDED.purged,
+			changed_at = EXCLUDED.changed_at
+		WHERE videos.changed_at < EXCLUDED.changed_at
+	`
+	_, err := d.db.Exec(query,
+		video.ID, video.UserID, video.Filename, video.OriginalName, video.SizeBytes,
+		video.DurationSeconds, video.Status, video.StoragePath, video.ZipPath, video.ZipSizeBytes,
+		video.FrameCount, video.ErrorMessage, video.RetryCount, video.Priority,
+		video.CreatedAt.UTC(), video.UpdatedAt.UTC(), utc(video.QueuedAt), utc(video.ProcessingStartedAt),
+		utc(video.ProcessingCompletedAt), deletedAt, change.Deleted, change.ChangedAt,
+	)
+	return err
+}
+
+// utc converts a time to UTC, which the TIMESTAMP columns are kept in.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVideo(row rowScanner) (*domain.Video, error) {
+	video := &domain.Video{}
+	err := row.Scan(
+		&video.ID, &video.UserID, &video.Filename, &video.OriginalName, &video.SizeBytes,
+		&video.DurationSeconds, &video.Status, &video.StoragePath, &video.ZipPath, &video.ZipSizeBytes,
+		&video.FrameCount, &video.ErrorMessage, &video.RetryCount, &video.Priority,
+		&video.CreatedAt, &video.UpdatedAt, &video.QueuedAt, &video.ProcessingStartedAt, &video.ProcessingCompletedAt,
+		&video.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return video, nil
+}
+
+// GetVideo returns the video as the read model has it, trashed and purged
+// videos included, or nil when it has not heard of the video.
+func (d *Database) GetVideo(id string) (*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE id = $1`
+	video, err := scanVideo(d.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return video, err
+}
+
+// videoSorts maps each listing order to the expression it sorts on and the
+// type a cursor value is cast to. Videos without a known duration sort as
+// the shortest.
+var videoSorts = map[string]struct{ column, cast string }{
+	domain.VideoSortCreated:  {"created_at", "timestamp"},
+	domain.VideoSortSize:     {"size_bytes", "bigint"},
+	domain.VideoSortDuration: {"COALESCE(duration_seconds, -1)", "numeric"},
+}
+
+// likeEscaper makes a search term match literally in LIKE.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListVideos returns a page of the user's videos in the listing's order,
+// starting after its cursor. It reads one row more than the limit to tell
+// whether there is a next page.
+func (d *Database) ListVideos(userID string, listing *domain.VideoListing) (*domain.VideoPage, error) {
+	sort, ok := videoSorts[listing.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", listing.Sort)
+	}
+
+	args := []interface{}{userID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	if len(listing.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+arg(pq.Array(listing.Statuses))+")")
+	}
+	if listing.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(*listing.CreatedFrom))
+	}
+	if listing.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(*listing.CreatedTo))
+	}
+	if listing.Search != "" {
+		conditions = append(conditions, "original_name ILIKE "+arg("%"+likeEscaper.Replace(listing.Search)+"%"))
+	}
+
+	direction, compare := "ASC", ">"
+	if listing.Descending {
+		direction, compare = "DESC", "<"
+	}
+	if listing.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)",
+			sort.column, compare, arg(listing.After.Value), sort.cast, arg(listing.After.ID)))
+	}
+
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE ` + strings.Join(conditions, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", sort.column, direction, direction, arg(listing.Limit+1))
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := []domain.Video{}
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, *video)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.VideoPage{Videos: videos}
+	if len(videos) > listing.Limit {
+		page.Videos = videos[:listing.Limit]
+		last := page.Videos[listing.Limit-1]
+		cursor := &domain.VideoCursor{
+			Sort:       listing.Sort,
+			Descending: listing.Descending,
+			Value:      videoSortValue(&last, listing.Sort),
+			ID:         last.ID,
+		}
+		page.NextCursor = cursor.Encode()
+	}
+	return page, nil
+}
+
+// videoSortValue is the video's value in the sort column, as a cursor holds it.
+func videoSortValue(video *domain.Video, sort string) string {
+	switch sort {
+	case domain.VideoSortSize:
+		return strconv.FormatInt(video.SizeBytes, 10)
+	case domain.VideoSortDuration:
+		if video.DurationSeconds == nil {
+			return "-1"
+		}
+		return strconv.FormatFloat(*video.DurationSeconds, 'f', -1, 64)
+	default:
+		return video.CreatedAt.Format(domain.CursorTimeFormat)
+	}
+}
+
+func (d *Database) GetUserStats(userID string) (*domain.UserStats, error) {
+	stats := &domain.UserStats{}
+	query := `
+		SELECT
+			COUNT(*) as total_videos,
+			COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed_videos,
+			COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed_videos,
+			COUNT(CASE WHEN status = 'processing' THEN 1 END) as processing_videos,
+			COUNT(CASE WHEN status = 'pending' THEN 1 END) as pending_videos,
+			COALESCE(SUM(size_bytes) / 1024.0 / 1024.0, 0) as total_storage_mb,
+			COALESCE(AVG(EXTRACT(EPOCH FROM (processing_completed_at - processing_started_at))), 0) as avg_processing_time
+		FROM videos
+		WHERE user_id = $1 AND deleted_at IS NULL
+	`
+	err := d.db.QueryRow(query, userID).Scan(
+		&stats.TotalVideos, &stats.CompletedVideos, &stats.FailedVideos,
+		&stats.ProcessingVideos, &stats.PendingVideos, &stats.TotalStorageMB, &stats.AvgProcessingTime,
+	)
+	return stats, err
+}
+
+// GetSystemStats counts the videos of the last 24 hours, trashed ones
+// included, as video-service does.
+func (d *Database) GetSystemStats() (*domain.SystemStats, error) {
+	stats := &domain.SystemStats{}
+	query := `
+		SELECT
+			COUNT(*) as total_videos,
+			COUNT(CASE WHEN status = 'pending' THEN 1 END) as pending_videos,
+			COUNT(CASE WHEN status = 'queued' THEN 1 END) as queued_videos,
+			COUNT(CASE WHEN status = 'processing' THEN 1 END) as processing_videos,
+			COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed_videos,
+			COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed_videos,
+			COALESCE(AVG(EXTRACT(EPOCH FROM (processing_completed_at - processing_started_at))), 0) as avg_processing_time
+		FROM videos
+		WHERE created_at > NOW() - INTERVAL '24 hours' AND NOT purged
+	`
+	err := d.db.QueryRow(query).Scan(
+		&stats.TotalVideos, &stats.PendingVideos, &stats.QueuedVideos,
+		&stats.ProcessingVideos, &stats.CompletedVideos, &stats.FailedVideos, &stats.AvgProcessingTime,
+	)
+	return stats, err
+}
@@ -0,0 +1,50 @@
+-- Read model of video-service's videos, kept up to date from the
+-- video.changed snapshots it publishes. Listings, stats and downloads are
+-- served from here, so they keep working while video-service is down.
+CREATE TABLE videos (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    original_name VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    duration_seconds DECIMAL(10, 2),
+    status VARCHAR(50) NOT NULL,
+    storage_path VARCHAR(500) NOT NULL DEFAULT '',
+    zip_path VARCHAR(500),
+    zip_size_bytes BIGINT,
+    frame_count INT,
+    error_message TEXT,
+    retry_count INT NOT NULL DEFAULT 0,
+    priority INT NOT NULL DEFAULT 5,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    queued_at TIMESTAMP,
+    processing_started_at TIMESTAMP,
+    processing_completed_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    -- A purged video is kept as a tombstone, so a late snapshot of it does
+    -- not bring it back.
+    purged BOOLEAN NOT NULL DEFAULT false,
+    -- When video-service made the change this row shows; older snapshots
+    -- are ignored.
+    changed_at TIMESTAMPTZ NOT NULL
+);
+
+-- Keyset pagination of a user's videos, one index per sort order, as in
+-- video-service.
+CREATE INDEX idx_videos_user_created ON videos(user_id, created_at, id);
+CREATE INDEX idx_videos_user_size ON videos(user_id, size_bytes, id);
+CREATE INDEX idx_videos_user_duration ON videos(user_id, (COALESCE(duration_seconds, -1)), id);
+CREATE INDEX idx_videos_created_at ON videos(created_at DESC);
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_videos_original_name_trgm ON videos USING gin (original_name gin_trgm_ops);
+
+-- The read model replaces the cache tables, which were never used.
+DROP VIEW IF EXISTS cache_stats;
+DROP FUNCTION IF EXISTS cleanup_expired_cache();
+DROP TABLE IF EXISTS status_cache;
+DROP TABLE IF EXISTS query_logs;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+
+COMMENT ON TABLE videos IS 'Read model of the videos in video-service';
@@ -1,10 +1,20 @@
 package domain
 
 import (
+	"context"
 	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// DatabaseInterface holds the read model of the videos, projected from the
+// changes video-service publishes.
 type DatabaseInterface interface {
+	ApplyVideoChange(change *VideoChange) error
+	GetVideo(id string) (*Video, error)
+	ListVideos(userID string, listing *VideoListing) (*VideoPage, error)
+	GetUserStats(userID string) (*UserStats, error)
+	GetSystemStats() (*SystemStats, error)
 	Ping() error
 	Close() error
 }
@@ -12,6 +22,21 @@ type DatabaseInterface interface {
 type RedisInterface interface {
 	Get(key string) (string, error)
 	Set(key string, value interface{}, expiration time.Duration) error
+	Delete(key string) error
+	InvalidatePattern(pattern string) error
+	Ping() error
+	Close() error
+}
+
+// PubSubInterface carries messages to every replica of the service.
+type PubSubInterface interface {
+	Publish(channel string, message string) error
+	Listen(ctx context.Context, channel string) (<-chan string, error)
+}
+
+type RabbitMQInterface interface {
+	SubscribeStatusEvents(consumerTag string) (<-chan amqp.Delivery, error)
+	SubscribeVideoChanges(consumerTag string) (<-chan amqp.Delivery, error)
 	Ping() error
 	Close() error
 }
@@ -21,9 +46,8 @@ type MinIOInterface interface {
 	Ping() error
 }
 
+// VideoServiceClient answers for the videos the read model has not caught up
+// with yet.
 type VideoServiceClient interface {
-	GetVideosByUserID(userID, status string) ([]*Video, error)
 	GetVideoByID(id string) (*Video, error)
-	GetUserStats(userID string) (*UserStats, error)
-	GetSystemStats() (*SystemStats, error)
 }
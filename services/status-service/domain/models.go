@@ -1,6 +1,17 @@
 package domain
 
-import "time"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
 	ID              string     `json:"id" db:"id"`
@@ -35,6 +46,252 @@ type Video struct {
 	QueuedAt              *time.Time `json:"queued_at,omitempty" db:"queued_at"`
 	ProcessingStartedAt   *time.Time `json:"processing_started_at,omitempty" db:"processing_started_at"`
 	ProcessingCompletedAt *time.Time `json:"processing_completed_at,omitempty" db:"processing_completed_at"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// VideoChange is a video as video-service published it after changing it.
+// Deleted marks a video purged for good. ChangedAt orders the changes of one
+// video, so a late one does not undo a newer one.
+type VideoChange struct {
+	Video
+	Deleted   bool      `json:"deleted"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Sort orders of video listings.
+const (
+	VideoSortCreated  = "created_at"
+	VideoSortSize     = "size"
+	VideoSortDuration = "duration"
+)
+
+// Page sizes of video listings.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var videoStatuses = map[string]bool{
+	"pending": true, "importing": true, "queued": true, "processing": true,
+	"completed": true, "failed": true, "cancelled": true, "expired": true,
+}
+
+// VideoListQuery is a listing request as the client sent it:
+//
+//	status        one or more statuses, repeated or comma-separated
+//	created_from  RFC 3339 time or date, inclusive
+//	created_to    RFC 3339 time, exclusive, or date, inclusive
+//	q             text searched for in original_name
+//	sort          created_at (default), size or duration
+//	order         desc (default) or asc
+//	limit         page size, 1 to 100, default 20
+//	cursor        next_cursor of the previous page
+type VideoListQuery struct {
+	Statuses    []string
+	CreatedFrom string
+	CreatedTo   string
+	Search      string
+	Sort        string
+	Order       string
+	Limit       int
+	Cursor      string
+}
+
+// Values encodes the query as video-service expects it. Encoding is
+// canonical, statuses included, so it can also key the cache.
+func (q VideoListQuery) Values() url.Values {
+	values := url.Values{}
+	if len(q.Statuses) > 0 {
+		statuses := append([]string(nil), q.Statuses...)
+		sort.Strings(statuses)
+		values.Set("status", strings.Join(statuses, ","))
+	}
+	for key, value := range map[string]string{
+		"created_from": q.CreatedFrom,
+		"created_to":   q.CreatedTo,
+		"q":            q.Search,
+		"sort":         q.Sort,
+		"order":        q.Order,
+		"cursor":       q.Cursor,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+// VideoListing is a VideoListQuery checked and read into the page it selects.
+type VideoListing struct {
+	Statuses    []string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Search      string
+	Sort        string
+	Descending  bool
+	After       *VideoCursor
+	Limit       int
+}
+
+// Listing checks the query and reads it into the listing it asks for. Its
+// errors are fit to show the client.
+func (q VideoListQuery) Listing() (*VideoListing, error) {
+	listing := &VideoListing{
+		Statuses:   q.Statuses,
+		Search:     strings.TrimSpace(q.Search),
+		Sort:       q.Sort,
+		Descending: true,
+		Limit:      DefaultPageSize,
+	}
+
+	for _, status := range q.Statuses {
+		if !videoStatuses[status] {
+			return nil, fmt.Errorf("unknown status %q", status)
+		}
+	}
+
+	var err error
+	if listing.CreatedFrom, err = parseListTime(q.CreatedFrom, false); err != nil {
+		return nil, fmt.Errorf("invalid created_from: %w", err)
+	}
+	if listing.CreatedTo, err = parseListTime(q.CreatedTo, true); err != nil {
+		return nil, fmt.Errorf("invalid created_to: %w", err)
+	}
+
+	switch listing.Sort {
+	case "":
+		listing.Sort = VideoSortCreated
+	case VideoSortCreated, VideoSortSize, VideoSortDuration:
+	default:
+		return nil, fmt.Errorf("sort must be created_at, size or duration")
+	}
+
+	switch q.Order {
+	case "", "desc":
+	case "asc":
+		listing.Descending = false
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if q.Limit != 0 {
+		if q.Limit < 1 || q.Limit > MaxPageSize {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+		}
+		listing.Limit = q.Limit
+	}
+
+	if q.Cursor != "" {
+		cursor, err := DecodeVideoCursor(q.Cursor)
+		if err != nil || cursor.Sort != listing.Sort || cursor.Descending != listing.Descending {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		listing.After = cursor
+	}
+
+	return listing, nil
+}
+
+// parseListTime reads an RFC 3339 time or a date. As the end of a range, a
+// date includes the whole day.
+func parseListTime(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("expected RFC 3339 time or YYYY-MM-DD date")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// VideoCursor is the position a listing continues from: the last video's
+// value in the sort column, and its id to break ties. It is the same cursor
+// video-service hands out.
+type VideoCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d"`
+	Value      string `json:"v"`
+	ID         string `json:"id"`
+}
+
+// CursorTimeFormat is how a cursor holds a created_at value, keeping the
+// microseconds the column stores.
+const CursorTimeFormat = "2006-01-02T15:04:05.999999"
+
+// Encode turns the cursor into the opaque next_cursor of a page.
+func (c *VideoCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeVideoCursor reads a next_cursor, making sure its values parse as
+// their columns' types before they reach the database.
+func DecodeVideoCursor(value string) (*VideoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor VideoCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return nil, err
+	}
+	switch cursor.Sort {
+	case VideoSortCreated:
+		_, err = time.Parse(CursorTimeFormat, cursor.Value)
+	case VideoSortSize:
+		_, err = strconv.ParseInt(cursor.Value, 10, 64)
+	case VideoSortDuration:
+		_, err = strconv.ParseFloat(cursor.Value, 64)
+	default:
+		err = fmt.Errorf("unknown sort %q", cursor.Sort)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// VideoPage is one page of a listing. NextCursor is empty on the last page.
+type VideoPage struct {
+	Videos     []Video `json:"videos"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Kinds of StatusEvent, which are also the SSE event names they are sent as.
+const (
+	StatusEventStatus   = "status"
+	StatusEventProgress = "progress"
+)
+
+// StatusEvent is a change to one of a user's videos, pushed to that user's
+// open streams. A status event reports a new status; a progress event
+// reports how far processing has got, as a stage and a rough percentage.
+type StatusEvent struct {
+	Type         string    `json:"type"`
+	VideoID      string    `json:"video_id"`
+	UserID       string    `json:"user_id"`
+	RunID        string    `json:"run_id,omitempty"`
+	Status       string    `json:"status"`
+	Stage        string    `json:"stage,omitempty"`
+	Progress     int       `json:"progress"`
+	FrameCount   int       `json:"frame_count,omitempty"`
+	ZipSizeBytes int64     `json:"zip_size_bytes,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
 }
 
 type Session struct {
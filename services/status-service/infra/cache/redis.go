@@ -177,3 +177,31 @@ func (r *RedisClient) Publish(channel string, message string) error {
 func (r *RedisClient) Subscribe(channel string) *redis.PubSub {
 	return r.client.Subscribe(r.ctx, channel)
 }
+
+// Listen subscribes to channel and returns its messages until ctx is
+// cancelled or the subscription breaks, when the returned channel is closed.
+func (r *RedisClient) Listen(ctx context.Context, channel string) (<-chan string, error) {
+	pubsub := r.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	messages := make(chan string)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+		for {
+			msg, err := pubsub.ReceiveMessage(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case messages <- msg.Payload:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return messages, nil
+}
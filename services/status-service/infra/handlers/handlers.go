@@ -3,6 +3,8 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 	"status-service/domain"
 	"status-service/service"
@@ -35,9 +37,12 @@ type VideoStatusResponse struct {
 	ProcessingDuration    *int       `json:"processing_duration_seconds,omitempty"`
 }
 
+// ListVideosResponse is one page of videos. Total counts the videos on this
+// page; NextCursor fetches the next one.
 type ListVideosResponse struct {
-	Videos []VideoStatusResponse `json:"videos"`
-	Total  int                   `json:"total"`
+	Videos     []VideoStatusResponse `json:"videos"`
+	Total      int                   `json:"total"`
+	NextCursor string                `json:"next_cursor,omitempty"`
 }
 
 type UserStatsResponse struct {
@@ -63,20 +68,49 @@ type SystemStatsResponse struct {
 
 func (h *StatusHandler) ListVideos(c *gin.Context) {
 	userID := c.GetString("user_id")
-	status := c.Query("status")
 
-	videos, err := h.statusService.ListVideos(userID, status)
+	query := domain.VideoListQuery{
+		CreatedFrom: c.Query("created_from"),
+		CreatedTo:   c.Query("created_to"),
+		Search:      c.Query("q"),
+		Sort:        c.Query("sort"),
+		Order:       c.Query("order"),
+		Cursor:      c.Query("cursor"),
+	}
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				query.Statuses = append(query.Statuses, status)
+			}
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		query.Limit = limit
+	}
+
+	if _, err := query.Listing(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.statusService.ListVideos(userID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch videos"})
 		return
 	}
 
 	response := ListVideosResponse{
-		Videos: make([]VideoStatusResponse, 0, len(videos)),
-		Total:  len(videos),
+		Videos:     make([]VideoStatusResponse, 0, len(page.Videos)),
+		Total:      len(page.Videos),
+		NextCursor: page.NextCursor,
 	}
 
-	for _, video := range videos {
+	for _, video := range page.Videos {
 		response.Videos = append(response.Videos, h.videoToResponse(&video))
 	}
 
@@ -17,41 +17,32 @@ import (
 "github.com/stretchr/testify/mock"
 )
 
-// MockDatabase implements domain.DatabaseInterface (only Ping/Close).
+// MockDatabase implements domain.DatabaseInterface.
 type MockDatabase struct {
 	mock.Mock
 }
 
-func (m *MockDatabase) Ping() error {
-	return m.Called().Error(0)
+func (m *MockDatabase) ApplyVideoChange(change *domain.VideoChange) error {
+	return m.Called(change).Error(0)
 }
 
-func (m *MockDatabase) Close() error {
-	return m.Called().Error(0)
-}
-
-// MockVideoClient implements domain.VideoServiceClient.
-type MockVideoClient struct {
-	mock.Mock
-}
-
-func (m *MockVideoClient) GetVideosByUserID(userID, status string) ([]*domain.Video, error) {
-	args := m.Called(userID, status)
+func (m *MockDatabase) GetVideo(id string) (*domain.Video, error) {
+	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*domain.Video), args.Error(1)
+	return args.Get(0).(*domain.Video), args.Error(1)
 }
 
-func (m *MockVideoClient) GetVideoByID(id string) (*domain.Video, error) {
-	args := m.Called(id)
+func (m *MockDatabase) ListVideos(userID string, listing *domain.VideoListing) (*domain.VideoPage, error) {
+	args := m.Called(userID, listing)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.Video), args.Error(1)
+	return args.Get(0).(*domain.VideoPage), args.Error(1)
 }
 
-func (m *MockVideoClient) GetUserStats(userID string) (*domain.UserStats, error) {
+func (m *MockDatabase) GetUserStats(userID string) (*domain.UserStats, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -59,7 +50,7 @@ func (m *MockVideoClient) GetUserStats(userID string) (*domain.UserStats, error)
 	return args.Get(0).(*domain.UserStats), args.Error(1)
 }
 
-func (m *MockVideoClient) GetSystemStats() (*domain.SystemStats, error) {
+func (m *MockDatabase) GetSystemStats() (*domain.SystemStats, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -67,6 +58,14 @@ func (m *MockVideoClient) GetSystemStats() (*domain.SystemStats, error) {
 	return args.Get(0).(*domain.SystemStats), args.Error(1)
 }
 
+func (m *MockDatabase) Ping() error {
+	return m.Called().Error(0)
+}
+
+func (m *MockDatabase) Close() error {
+	return m.Called().Error(0)
+}
+
 // MockRedis implements domain.RedisInterface.
 type MockRedis struct {
 	mock.Mock
@@ -82,6 +81,14 @@ func (m *MockRedis) Set(key string, value interface{}, expiration time.Duration)
 	return args.Error(0)
 }
 
+func (m *MockRedis) Delete(key string) error {
+	return m.Called(key).Error(0)
+}
+
+func (m *MockRedis) InvalidatePattern(pattern string) error {
+	return m.Called(pattern).Error(0)
+}
+
 func (m *MockRedis) Ping() error {
 	return m.Called().Error(0)
 }
@@ -132,11 +139,11 @@ handler.GetUserStats(context)
 
 func TestListVideos_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
-	videos := []*domain.Video{
+	videos := []domain.Video{
 		{
 			ID: "v1", Filename: "f1.mp4", Status: "completed", ZipPath: utils.StringPtr("z1.zip"),
 			FrameCount: utils.IntPtr(100), ZipSizeBytes: utils.Int64Ptr(1024), ErrorMessage: utils.StringPtr(""),
@@ -144,15 +151,73 @@ func TestListVideos_Success(t *testing.T) {
 		},
 	}
 
-	mockRedis.On("Get", "videos:user:user123:status:").Return("", errors.New("cache miss"))
-	mockVC.On("GetVideosByUserID", "user123", "").Return(videos, nil)
-	mockRedis.On("Set", "videos:user:user123:status:", mock.Anything, mock.Anything).Return(nil)
+	mockRedis.On("Get", "videos:user:user123:").Return("", errors.New("cache miss"))
+	mockDB.On("ListVideos", "user123", mock.Anything).Return(&domain.VideoPage{Videos: videos, NextCursor: "next"}, nil)
+	mockRedis.On("Set", "videos:user:user123:", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("GET", "/videos", nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	var resp ListVideosResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, 1, resp.Total)
+	assert.Equal(t, "next", resp.NextCursor)
+}
+
+func TestListVideos_PassesQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	mockRedis := new(MockRedis)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
+
+	cursor := &domain.VideoCursor{Sort: "size", Value: "2048", ID: "7f1b2c3d-0000-4000-8000-000000000001"}
+	query := domain.VideoListQuery{
+		Statuses: []string{"completed", "failed", "queued"}, CreatedFrom: "2024-03-01", CreatedTo: "2024-03-31",
+		Search: "keynote", Sort: "size", Order: "asc", Limit: 10, Cursor: cursor.Encode(),
+	}
+	key := "videos:user:user123:" + query.Values().Encode()
+	mockRedis.On("Get", key).Return("", errors.New("cache miss"))
+	mockDB.On("ListVideos", "user123", mock.MatchedBy(func(listing *domain.VideoListing) bool {
+		return len(listing.Statuses) == 3 && listing.CreatedFrom != nil && listing.CreatedTo != nil &&
+			listing.Search == "keynote" && listing.Sort == "size" && !listing.Descending &&
+			listing.Limit == 10 && listing.After.Value == "2048"
+	})).Return(&domain.VideoPage{}, nil)
+	mockRedis.On("Set", key, mock.Anything, mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("GET", "/videos?status=completed,failed&status=queued&created_from=2024-03-01"+
+		"&created_to=2024-03-31&q=keynote&sort=size&order=asc&limit=10&cursor="+cursor.Encode(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertExpectations(t)
+}
+
+func TestListVideos_InvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	r, _ := setupTestRouter(mockDB, new(MockRedis), nil, nil)
+
+	req, _ := http.NewRequest("GET", "/videos?sort=name", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"sort must be created_at, size or duration"}`, w.Body.String())
+	mockDB.AssertNotCalled(t, "ListVideos", mock.Anything, mock.Anything)
+}
+
+func TestListVideos_InvalidLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, _ := setupTestRouter(nil, new(MockRedis), nil, nil)
+
+	req, _ := http.NewRequest("GET", "/videos?limit=none", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
 func TestListVideos_CacheHit(t *testing.T) {
@@ -160,10 +225,10 @@ func TestListVideos_CacheHit(t *testing.T) {
 	mockRedis := new(MockRedis)
 	r, _ := setupTestRouter(nil, mockRedis, nil, nil)
 
-	videos := []domain.Video{{ID: "v-cached"}}
-	cachedData, _ := json.Marshal(videos)
+	page := domain.VideoPage{Videos: []domain.Video{{ID: "v-cached"}}}
+	cachedData, _ := json.Marshal(page)
 
-	mockRedis.On("Get", "videos:user:user123:status:").Return(string(cachedData), nil)
+	mockRedis.On("Get", "videos:user:user123:").Return(string(cachedData), nil)
 
 	req, _ := http.NewRequest("GET", "/videos", nil)
 	w := httptest.NewRecorder()
@@ -174,12 +239,12 @@ func TestListVideos_CacheHit(t *testing.T) {
 
 func TestListVideos_Error(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
-	mockRedis.On("Get", "videos:user:user123:status:").Return("", errors.New("cache miss"))
-	mockVC.On("GetVideosByUserID", "user123", "").Return(nil, errors.New("service error"))
+	mockRedis.On("Get", "videos:user:user123:").Return("", errors.New("cache miss"))
+	mockDB.On("ListVideos", "user123", mock.Anything).Return(nil, errors.New("db error"))
 
 	req, _ := http.NewRequest("GET", "/videos", nil)
 	w := httptest.NewRecorder()
@@ -190,9 +255,9 @@ func TestListVideos_Error(t *testing.T) {
 
 func TestGetVideo_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	video := &domain.Video{
 		ID: "v1", UserID: "user123", Status: "completed", StoragePath: "path/v1.mp4",
@@ -201,7 +266,7 @@ func TestGetVideo_Success(t *testing.T) {
 	}
 
 	mockRedis.On("Get", "video:v1").Return("", errors.New("miss"))
-	mockVC.On("GetVideoByID", "v1").Return(video, nil)
+	mockDB.On("GetVideo", "v1").Return(video, nil)
 	mockRedis.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("GET", "/videos/v1", nil)
@@ -230,14 +295,14 @@ func TestGetVideo_CacheHit(t *testing.T) {
 
 func TestGetVideo_AccessDenied(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	video := &domain.Video{ID: "v1", UserID: "other_user"}
 
 	mockRedis.On("Get", "video:v1").Return("", errors.New("miss"))
-	mockVC.On("GetVideoByID", "v1").Return(video, nil)
+	mockDB.On("GetVideo", "v1").Return(video, nil)
 
 	req, _ := http.NewRequest("GET", "/videos/v1", nil)
 	w := httptest.NewRecorder()
@@ -248,12 +313,12 @@ func TestGetVideo_AccessDenied(t *testing.T) {
 
 func TestGetVideo_NotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	mockRedis.On("Get", "video:v1").Return("", errors.New("miss"))
-	mockVC.On("GetVideoByID", "v1").Return(nil, errors.New("video not found"))
+	mockDB.On("GetVideo", "v1").Return(nil, errors.New("video not found"))
 
 	req, _ := http.NewRequest("GET", "/videos/v1", nil)
 	w := httptest.NewRecorder()
@@ -264,17 +329,17 @@ func TestGetVideo_NotFound(t *testing.T) {
 
 func TestDownloadZip_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockMinio := new(MockMinIO)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, mockMinio, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, mockMinio, nil)
 
 	video := &domain.Video{
 		ID: "v1", UserID: "user123", Status: "completed", ZipPath: utils.StringPtr("path/v1.zip"),
 	}
 
 	mockRedis.On("Get", "video:v1").Return("", errors.New("miss"))
-	mockVC.On("GetVideoByID", "v1").Return(video, nil)
+	mockDB.On("GetVideo", "v1").Return(video, nil)
 	mockRedis.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 	mockMinio.On("GetPresignedURL", "path/v1.zip", mock.Anything).Return("http://download", nil)
 
@@ -287,14 +352,14 @@ func TestDownloadZip_Success(t *testing.T) {
 
 func TestDownloadZip_NotCompleted(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	video := &domain.Video{ID: "v1", UserID: "user123", Status: "processing"}
 
 	mockRedis.On("Get", "video:v1").Return("", errors.New("miss"))
-	mockVC.On("GetVideoByID", "v1").Return(video, nil)
+	mockDB.On("GetVideo", "v1").Return(video, nil)
 	mockRedis.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("GET", "/videos/v1/download", nil)
@@ -306,17 +371,17 @@ func TestDownloadZip_NotCompleted(t *testing.T) {
 
 func TestDownloadZip_MinIOError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockMinio := new(MockMinIO)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, mockMinio, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, mockMinio, nil)
 
 	video := &domain.Video{
 		ID: "v1", UserID: "user123", Status: "completed", ZipPath: utils.StringPtr("path/v1.zip"),
 	}
 
 	mockRedis.On("Get", "video:v1").Return("", errors.New("miss"))
-	mockVC.On("GetVideoByID", "v1").Return(video, nil)
+	mockDB.On("GetVideo", "v1").Return(video, nil)
 	mockRedis.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 	mockMinio.On("GetPresignedURL", "path/v1.zip", mock.Anything).Return("", errors.New("minio error"))
 
@@ -329,14 +394,14 @@ func TestDownloadZip_MinIOError(t *testing.T) {
 
 func TestGetUserStats_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	stats := &domain.UserStats{TotalVideos: 10, CompletedVideos: 8}
 
 	mockRedis.On("Get", "stats:user:user123").Return("", errors.New("miss"))
-	mockVC.On("GetUserStats", "user123").Return(stats, nil)
+	mockDB.On("GetUserStats", "user123").Return(stats, nil)
 	mockRedis.On("Set", "stats:user:user123", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("GET", "/stats", nil)
@@ -365,12 +430,12 @@ func TestGetUserStats_CacheHit(t *testing.T) {
 
 func TestGetUserStats_Error(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	mockRedis.On("Get", "stats:user:user123").Return("", errors.New("miss"))
-	mockVC.On("GetUserStats", "user123").Return(nil, errors.New("stats error"))
+	mockDB.On("GetUserStats", "user123").Return(nil, errors.New("stats error"))
 
 	req, _ := http.NewRequest("GET", "/stats", nil)
 	w := httptest.NewRecorder()
@@ -381,14 +446,14 @@ func TestGetUserStats_Error(t *testing.T) {
 
 func TestGetSystemStats_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	stats := &domain.SystemStats{TotalUsers: 5, TotalVideos: 50}
 
 	mockRedis.On("Get", "stats:system").Return("", errors.New("miss"))
-	mockVC.On("GetSystemStats").Return(stats, nil)
+	mockDB.On("GetSystemStats").Return(stats, nil)
 	mockRedis.On("Set", "stats:system", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("GET", "/system/stats", nil)
@@ -417,12 +482,12 @@ func TestGetSystemStats_CacheHit(t *testing.T) {
 
 func TestGetSystemStats_Error(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockVC := new(MockVideoClient)
+	mockDB := new(MockDatabase)
 	mockRedis := new(MockRedis)
-	r, _ := setupTestRouter(nil, mockRedis, nil, mockVC)
+	r, _ := setupTestRouter(mockDB, mockRedis, nil, nil)
 
 	mockRedis.On("Get", "stats:system").Return("", errors.New("miss"))
-	mockVC.On("GetSystemStats").Return(nil, errors.New("system stats error"))
+	mockDB.On("GetSystemStats").Return(nil, errors.New("system stats error"))
 
 	req, _ := http.NewRequest("GET", "/system/stats", nil)
 	w := httptest.NewRecorder()
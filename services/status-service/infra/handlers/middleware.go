@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,29 +17,3 @@ func CorsMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
-
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
-			c.Abort()
-			return
-		}
-		userID, role, err := validateToken(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-		c.Set("user_id", userID)
-		c.Set("role", role)
-		c.Next()
-	}
-}
-
-func validateToken(token string) (string, string, error) {
-	// dummy implementation to be updated with real JWT logic if needed, 
-	// but maintaining current main.go logic for now.
-	return "user-123", "user", nil
-}
@@ -37,37 +37,3 @@ func TestCorsMiddleware_OptionsRequest(t *testing.T) {
 
 	assert.Equal(t, http.StatusNoContent, w.Code)
 }
-
-func TestAuthMiddleware_MissingToken(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	r := gin.New()
-	r.Use(AuthMiddleware())
-	r.GET("/test", func(c *gin.Context) {
-		c.Status(http.StatusOK)
-	})
-
-	req, _ := http.NewRequest("GET", "/test", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
-
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-}
-
-func TestAuthMiddleware_ValidToken(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	r := gin.New()
-	r.Use(AuthMiddleware())
-	r.GET("/test", func(c *gin.Context) {
-		userID := c.GetString("user_id")
-		role := c.GetString("role")
-		c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": role})
-	})
-
-	req, _ := http.NewRequest("GET", "/test", nil)
-	req.Header.Set("Authorization", "Bearer sometoken")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
-
-	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Contains(t, w.Body.String(), "user-123")
-}
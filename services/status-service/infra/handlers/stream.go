@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"status-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A stream with nothing to send still writes a comment this often, so
+// proxies and the client can tell an idle stream from a dead one.
+const streamHeartbeatInterval = 15 * time.Second
+
+type StreamHandler struct {
+	hub       *service.StreamHub
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(hub *service.StreamHub) *StreamHandler {
+	return &StreamHandler{
+		hub:       hub,
+		heartbeat: streamHeartbeatInterval,
+	}
+}
+
+// StreamEvents pushes the caller's status and progress events as
+// Server-Sent Events, named "status" and "progress", until the client goes
+// away. When the stream ends without the client leaving, it should reconnect
+// and reload its listing, as events may have been missed.
+func (h *StreamHandler) StreamEvents(c *gin.Context) {
+	stream := h.hub.Open(c.GetString("user_id"))
+	defer h.hub.Close(stream)
+
+	// The stream outlives the server's write timeout.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n: connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-stream.Events:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, event)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"status-service/domain"
+	"status-service/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePubSub hands the hub the messages written to its channel until the
+// hub stops listening.
+type fakePubSub struct {
+	messages chan string
+}
+
+func (f *fakePubSub) Publish(channel string, message string) error {
+	f.messages <- message
+	return nil
+}
+
+func (f *fakePubSub) Listen(ctx context.Context, channel string) (<-chan string, error) {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message := <-f.messages:
+				out <- message
+			}
+		}
+	}()
+	return out, nil
+}
+
+func streamServer(t *testing.T, heartbeat time.Duration) (*httptest.Server, *fakePubSub, context.CancelFunc) {
+	gin.SetMode(gin.TestMode)
+	pubsub := &fakePubSub{messages: make(chan string, 1)}
+	hub := service.NewStreamHub(pubsub)
+	ctx, cancel := context.WithCancel(context.Background())
+	go hub.Run(ctx)
+
+	handler := NewStreamHandler(hub)
+	handler.heartbeat = heartbeat
+	r := gin.New()
+	r.GET("/videos/events", func(c *gin.Context) {
+		c.Set("user_id", c.Query("user"))
+		handler.StreamEvents(c)
+	})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+	return server, pubsub, cancel
+}
+
+// readEvent reads lines up to the end of the next SSE message.
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func publish(pubsub *fakePubSub, event domain.StatusEvent) {
+	data, _ := json.Marshal(event)
+	pubsub.Publish(service.StatusEventsChannel, string(data))
+}
+
+func TestStreamEvents_PushesUserEvents(t *testing.T) {
+	server, pubsub, _ := streamServer(t, time.Hour)
+
+	resp, err := http.Get(server.URL + "/videos/events?user=u1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(t, []string{"retry: 3000", ": connected"}, readEvent(t, reader))
+
+	publish(pubsub, domain.StatusEvent{Type: "status", UserID: "u2", VideoID: "other", Status: "queued"})
+	publish(pubsub, domain.StatusEvent{Type: "progress", UserID: "u1", VideoID: "v1", Status: "processing", Stage: "packaging", Progress: 70})
+
+	lines := readEvent(t, reader)
+	assert.Equal(t, "event:progress", lines[0])
+	var event domain.StatusEvent
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data:")), &event))
+	assert.Equal(t, "v1", event.VideoID)
+	assert.Equal(t, "packaging", event.Stage)
+	assert.Equal(t, 70, event.Progress)
+}
+
+func TestStreamEvents_Heartbeat(t *testing.T) {
+	server, _, _ := streamServer(t, 10*time.Millisecond)
+
+	resp, err := http.Get(server.URL + "/videos/events?user=u1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent(t, reader)
+	assert.Equal(t, []string{": keepalive"}, readEvent(t, reader))
+}
+
+func TestStreamEvents_EndsWhenHubStops(t *testing.T) {
+	server, _, stop := streamServer(t, time.Hour)
+
+	resp, err := http.Get(server.URL + "/videos/events?user=u1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent(t, reader)
+	stop()
+
+	_, err = reader.ReadString('\n')
+	assert.Error(t, err)
+}
@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 	"status-service/database"
+	"status-service/infra/broker"
 	"status-service/domain"
 	"status-service/infra/cache"
 	"status-service/infra/clients"
@@ -16,6 +17,7 @@ import (
 	"status-service/infra/storage"
 	"status-service/infra/utils"
 	"status-service/service"
+	"shared/jwtauth"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -31,12 +33,32 @@ func main() {
 
 	minio := storage.InitMinIO()
 
+	rabbitmq := broker.InitRabbitMQ()
+	defer rabbitmq.Close()
+
 	videoClient := clients.NewVideoServiceClient(utils.GetEnv("VIDEO_SERVICE_URL", "http://video-service:8082"))
 
 	statusService := service.NewStatusService(db, redis, minio, videoClient)
 	statusHandler := handlers.NewStatusHandler(statusService)
 
-	router := setupRouter(db, redis, statusHandler)
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	hub := service.NewStreamHub(redis)
+	go hub.Run(workersCtx)
+	go service.NewStatusEventConsumer(rabbitmq, redis).Run(workersCtx)
+	go service.NewVideoChangeConsumer(rabbitmq, db, redis).Run(workersCtx)
+
+	auth, err := jwtauth.New(jwtauth.Config{
+		Secret:         utils.GetEnv("JWT_SECRET", "6f9e5ef5-ab0c-4bb4-a4f5-caefc64bc5d1"),
+		Issuer:         utils.GetEnv("JWT_ISSUER", "g57-auth-service"),
+		TrustedCallers: utils.GetEnv("TRUSTED_CALLER_CIDRS", ""),
+	})
+	if err != nil {
+		log.Fatalf("Invalid authentication config: %v", err)
+	}
+
+	router := setupRouter(db, redis, statusHandler, handlers.NewStreamHandler(hub), auth)
 
 	srv := &http.Server{
 		Addr:         ":" + utils.GetEnv("PORT", "8083"),
@@ -58,6 +80,9 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	// Ends the open event streams, which would otherwise hold up shutdown.
+	stopWorkers()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -68,7 +93,7 @@ func main() {
 	log.Println("Server exited")
 }
 
-func setupRouter(db domain.DatabaseInterface, redis domain.RedisInterface, statusHandler *handlers.StatusHandler) *gin.Engine {
+func setupRouter(db domain.DatabaseInterface, redis domain.RedisInterface, statusHandler *handlers.StatusHandler, streamHandler *handlers.StreamHandler, auth *jwtauth.Authenticator) *gin.Engine {
 	if utils.GetEnv("GIN_MODE", "debug") == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -86,15 +111,16 @@ func setupRouter(db domain.DatabaseInterface, redis domain.RedisInterface, statu
 	api := router.Group("/api/v1")
 	{
 		videos := api.Group("/videos")
-		videos.Use(handlers.AuthMiddleware())
+		videos.Use(auth.Middleware())
 		{
 			videos.GET("/status", statusHandler.ListVideos)
+			videos.GET("/events", streamHandler.StreamEvents)
 			videos.GET("/:id", statusHandler.GetVideo)
 			videos.GET("/:id/download", statusHandler.DownloadZip)
 		}
 
 		stats := api.Group("/stats")
-		stats.Use(handlers.AuthMiddleware())
+		stats.Use(auth.Middleware())
 		{
 			stats.GET("/user", statusHandler.GetUserStats)
 			stats.GET("/system", statusHandler.GetSystemStats)
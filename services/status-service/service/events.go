@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"status-service/domain"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// StatusEventsChannel is the Redis channel status events are fanned out on to
+// every replica.
+const StatusEventsChannel = "status:events"
+
+// brokerEvent holds the fields of both the upload message video-service
+// queues and the events processing-service publishes.
+type brokerEvent struct {
+	Type         string     `json:"type"`
+	OccurredAt   *time.Time `json:"occurred_at"`
+	VideoID      string     `json:"video_id"`
+	UserID       string     `json:"user_id"`
+	RunID        string     `json:"run_id"`
+	ZipSizeBytes int64      `json:"zip_size_bytes"`
+	FrameCount   int        `json:"frame_count"`
+	ErrorMessage string     `json:"error_message"`
+	Stage        string     `json:"stage"`
+	Progress     int        `json:"progress"`
+}
+
+// StatusEventConsumer turns the broker's video events into status events and
+// publishes them on Redis. Replicas share the queue, so each event is
+// published once and reaches the streams of every replica.
+type StatusEventConsumer struct {
+	rabbitmq   domain.RabbitMQInterface
+	pubsub     domain.PubSubInterface
+	retryDelay time.Duration
+}
+
+func NewStatusEventConsumer(rabbitmq domain.RabbitMQInterface, pubsub domain.PubSubInterface) *StatusEventConsumer {
+	return &StatusEventConsumer{
+		rabbitmq:   rabbitmq,
+		pubsub:     pubsub,
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// Run consumes events until ctx is cancelled, subscribing again whenever the
+// broker drops the consumer.
+func (c *StatusEventConsumer) Run(ctx context.Context) {
+	log.Println("Status event consumer started")
+
+	consumerTag := fmt.Sprintf("status-events-%s", uuid.New().String())
+	for {
+		msgs, err := c.rabbitmq.SubscribeStatusEvents(consumerTag)
+		if err != nil {
+			log.Printf("Status event consumer: failed to subscribe: %v", err)
+		} else {
+			c.consume(ctx, msgs)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Status event consumer stopped")
+			return
+		case <-time.After(c.retryDelay):
+		}
+	}
+}
+
+func (c *StatusEventConsumer) consume(ctx context.Context, msgs <-chan amqp.Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				log.Println("Status event consumer: channel closed")
+				return
+			}
+			c.handleDelivery(msg)
+		}
+	}
+}
+
+func (c *StatusEventConsumer) handleDelivery(msg amqp.Delivery) {
+	var message brokerEvent
+	if err := json.Unmarshal(msg.Body, &message); err != nil {
+		log.Printf("Status event consumer: invalid %s message: %v", msg.RoutingKey, err)
+		msg.Nack(false, false)
+		return
+	}
+
+	event, ok := statusEvent(msg.RoutingKey, &message)
+	if !ok {
+		log.Printf("Status event consumer: discarding %s message for video %q", msg.RoutingKey, message.VideoID)
+		msg.Nack(false, false)
+		return
+	}
+
+	data, _ := json.Marshal(event)
+	if err := c.pubsub.Publish(StatusEventsChannel, string(data)); err != nil {
+		log.Printf("Status event consumer: failed to publish %s for video %s: %v", event.Status, event.VideoID, err)
+		msg.Nack(false, true)
+		return
+	}
+	msg.Ack(false)
+}
+
+// statusEvent maps a broker message to the status event it is streamed as.
+// It reports false for messages that are not about a user's video.
+func statusEvent(routingKey string, message *brokerEvent) (*domain.StatusEvent, bool) {
+	if message.VideoID == "" || message.UserID == "" {
+		return nil, false
+	}
+
+	event := &domain.StatusEvent{
+		Type:       domain.StatusEventStatus,
+		VideoID:    message.VideoID,
+		UserID:     message.UserID,
+		RunID:      message.RunID,
+		OccurredAt: time.Now().UTC(),
+	}
+	if message.OccurredAt != nil {
+		event.OccurredAt = message.OccurredAt.UTC()
+	}
+
+	switch routingKey {
+	case "video.upload":
+		event.Status = "queued"
+	case "video.processing.started":
+		event.Status = "processing"
+	case "video.progress":
+		event.Type = domain.StatusEventProgress
+		event.Status = "processing"
+		event.Stage = message.Stage
+		event.Progress = message.Progress
+	case "video.processing.completed":
+		event.Status = "completed"
+		event.Progress = 100
+		event.FrameCount = message.FrameCount
+		event.ZipSizeBytes = message.ZipSizeBytes
+	case "video.processing.failed":
+		event.Status = "failed"
+		event.ErrorMessage = message.ErrorMessage
+	default:
+		return nil, false
+	}
+	return event, true
+}
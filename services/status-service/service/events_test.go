@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"status-service/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ---------- mocks ----------
+
+type mockPubSub struct{ mock.Mock }
+
+func (m *mockPubSub) Publish(channel string, message string) error {
+	return m.Called(channel, message).Error(0)
+}
+func (m *mockPubSub) Listen(ctx context.Context, channel string) (<-chan string, error) {
+	args := m.Called(channel)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan string), args.Error(1)
+}
+
+type mockRabbitMQ struct{ mock.Mock }
+
+func (m *mockRabbitMQ) SubscribeStatusEvents(consumerTag string) (<-chan amqp.Delivery, error) {
+	args := m.Called(consumerTag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan amqp.Delivery), args.Error(1)
+}
+func (m *mockRabbitMQ) SubscribeVideoChanges(consumerTag string) (<-chan amqp.Delivery, error) {
+	args := m.Called(consumerTag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan amqp.Delivery), args.Error(1)
+}
+func (m *mockRabbitMQ) Ping() error  { return m.Called().Error(0) }
+func (m *mockRabbitMQ) Close() error { return m.Called().Error(0) }
+
+type mockAcknowledger struct{ mock.Mock }
+
+func (m *mockAcknowledger) Ack(tag uint64, multiple bool) error {
+	return m.Called(tag, multiple).Error(0)
+}
+func (m *mockAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	return m.Called(tag, multiple, requeue).Error(0)
+}
+func (m *mockAcknowledger) Reject(tag uint64, requeue bool) error {
+	return m.Called(tag, requeue).Error(0)
+}
+
+func delivery(routingKey, body string, ack *mockAcknowledger) amqp.Delivery {
+	return amqp.Delivery{RoutingKey: routingKey, Body: []byte(body), Acknowledger: ack, DeliveryTag: 1}
+}
+
+// published captures the status event the consumer publishes.
+func published(ps *mockPubSub) *domain.StatusEvent {
+	event := &domain.StatusEvent{}
+	ps.On("Publish", StatusEventsChannel, mock.Anything).
+		Run(func(args mock.Arguments) { json.Unmarshal([]byte(args.String(1)), event) }).
+		Return(nil)
+	return event
+}
+
+// ---------- StatusEventConsumer ----------
+
+func TestStatusEvent_Mapping(t *testing.T) {
+	cases := []struct {
+		name       string
+		routingKey string
+		body       string
+		want       domain.StatusEvent
+	}{
+		{"queued", "video.upload", `{"video_id":"v1","user_id":"u1","run_id":"r1","filename":"a.mp4"}`,
+			domain.StatusEvent{Type: "status", Status: "queued", RunID: "r1"}},
+		{"started", "video.processing.started", `{"type":"video.processing.started","video_id":"v1","user_id":"u1"}`,
+			domain.StatusEvent{Type: "status", Status: "processing"}},
+		{"progress", "video.progress", `{"type":"video.progress","video_id":"v1","user_id":"u1","stage":"packaging","progress":70}`,
+			domain.StatusEvent{Type: "progress", Status: "processing", Stage: "packaging", Progress: 70}},
+		{"completed", "video.processing.completed", `{"video_id":"v1","user_id":"u1","frame_count":12,"zip_size_bytes":2048}`,
+			domain.StatusEvent{Type: "status", Status: "completed", Progress: 100, FrameCount: 12, ZipSizeBytes: 2048}},
+		{"failed", "video.processing.failed", `{"video_id":"v1","user_id":"u1","error_message":"no video stream"}`,
+			domain.StatusEvent{Type: "status", Status: "failed", ErrorMessage: "no video stream"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ps := new(mockPubSub)
+			ack := new(mockAcknowledger)
+			event := published(ps)
+			ack.On("Ack", uint64(1), false).Return(nil)
+
+			NewStatusEventConsumer(nil, ps).handleDelivery(delivery(tc.routingKey, tc.body, ack))
+
+			ack.AssertExpectations(t)
+			assert.False(t, event.OccurredAt.IsZero())
+			event.OccurredAt = time.Time{}
+			tc.want.VideoID, tc.want.UserID = "v1", "u1"
+			assert.Equal(t, tc.want, *event)
+		})
+	}
+}
+
+func TestStatusEvent_KeepsOccurredAt(t *testing.T) {
+	ps := new(mockPubSub)
+	ack := new(mockAcknowledger)
+	event := published(ps)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	NewStatusEventConsumer(nil, ps).handleDelivery(delivery("video.processing.started",
+		`{"video_id":"v1","user_id":"u1","occurred_at":"2024-05-01T12:00:00Z"}`, ack))
+
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), event.OccurredAt)
+}
+
+func TestStatusEventConsumer_Discarded(t *testing.T) {
+	cases := []struct {
+		name       string
+		routingKey string
+		body       string
+	}{
+		{"invalid json", "video.processing.started", "invalid json"},
+		{"no user", "video.processing.started", `{"video_id":"v1"}`},
+		{"unknown routing key", "video.deleted", `{"video_id":"v1","user_id":"u1"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ps := new(mockPubSub)
+			ack := new(mockAcknowledger)
+			ack.On("Nack", uint64(1), false, false).Return(nil)
+
+			NewStatusEventConsumer(nil, ps).handleDelivery(delivery(tc.routingKey, tc.body, ack))
+
+			ack.AssertExpectations(t)
+			ps.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestStatusEventConsumer_PublishErrorRequeues(t *testing.T) {
+	ps := new(mockPubSub)
+	ack := new(mockAcknowledger)
+	ps.On("Publish", StatusEventsChannel, mock.Anything).Return(errors.New("redis down"))
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	NewStatusEventConsumer(nil, ps).handleDelivery(delivery("video.upload", `{"video_id":"v1","user_id":"u1"}`, ack))
+
+	ack.AssertExpectations(t)
+}
+
+func TestStatusEventConsumer_Run(t *testing.T) {
+	mq := new(mockRabbitMQ)
+	ps := new(mockPubSub)
+	ack := new(mockAcknowledger)
+
+	msgs := make(chan amqp.Delivery, 1)
+	mq.On("SubscribeStatusEvents", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	ps.On("Publish", StatusEventsChannel, mock.Anything).Return(nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		msgs <- delivery("video.upload", `{"video_id":"v1","user_id":"u1"}`, ack)
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	NewStatusEventConsumer(mq, ps).Run(ctx)
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"status-service/domain"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// VideoChangeConsumer keeps the read model up to date from the video.changed
+// snapshots video-service publishes, and drops the cached reads each change
+// makes stale.
+type VideoChangeConsumer struct {
+	rabbitmq   domain.RabbitMQInterface
+	db         domain.DatabaseInterface
+	redis      domain.RedisInterface
+	retryDelay time.Duration
+}
+
+func NewVideoChangeConsumer(rabbitmq domain.RabbitMQInterface, db domain.DatabaseInterface, redis domain.RedisInterface) *VideoChangeConsumer {
+	return &VideoChangeConsumer{
+		rabbitmq:   rabbitmq,
+		db:         db,
+		redis:      redis,
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// Run consumes changes until ctx is cancelled, subscribing again whenever the
+// broker drops the consumer.
+func (c *VideoChangeConsumer) Run(ctx context.Context) {
+	log.Println("Video change consumer started")
+
+	consumerTag := fmt.Sprintf("status-projection-%s", uuid.New().String())
+	for {
+		msgs, err := c.rabbitmq.SubscribeVideoChanges(consumerTag)
+		if err != nil {
+			log.Printf("Video change consumer: failed to subscribe: %v", err)
+		} else {
+			c.consume(ctx, msgs)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Video change consumer stopped")
+			return
+		case <-time.After(c.retryDelay):
+		}
+	}
+}
+
+func (c *VideoChangeConsumer) consume(ctx context.Context, msgs <-chan amqp.Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				log.Println("Video change consumer: channel closed")
+				return
+			}
+			c.handleDelivery(msg)
+		}
+	}
+}
+
+// handleDelivery applies a change and then invalidates the cache. Applying is
+// idempotent, so a change whose invalidation failed is simply redelivered.
+func (c *VideoChangeConsumer) handleDelivery(msg amqp.Delivery) {
+	var change domain.VideoChange
+	if err := json.Unmarshal(msg.Body, &change); err != nil {
+		log.Printf("Video change consumer: invalid message: %v", err)
+		msg.Nack(false, false)
+		return
+	}
+	if change.ID == "" || change.UserID == "" || change.ChangedAt.IsZero() {
+		log.Printf("Video change consumer: discarding incomplete change of video %q", change.ID)
+		msg.Nack(false, false)
+		return
+	}
+
+	if err := c.db.ApplyVideoChange(&change); err != nil {
+		log.Printf("Video change consumer: failed to apply change of video %s: %v", change.ID, err)
+		msg.Nack(false, true)
+		return
+	}
+
+	if err := c.invalidate(&change.Video); err != nil {
+		log.Printf("Video change consumer: failed to invalidate cache of video %s: %v", change.ID, err)
+		msg.Nack(false, true)
+		return
+	}
+	msg.Ack(false)
+}
+
+// invalidate drops every cached read the video shows up in.
+func (c *VideoChangeConsumer) invalidate(video *domain.Video) error {
+	for _, key := range []string{
+		fmt.Sprintf("video:%s", video.ID),
+		fmt.Sprintf("stats:user:%s", video.UserID),
+		"stats:system",
+	} {
+		if err := c.redis.Delete(key); err != nil {
+			return err
+		}
+	}
+	return c.redis.InvalidatePattern(fmt.Sprintf("videos:user:%s:*", video.UserID))
+}
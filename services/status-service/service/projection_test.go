@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"status-service/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const videoChange = `{"id":"v1","user_id":"u1","filename":"v1.mp4","original_name":"talk.mp4","status":"completed",` +
+	`"zip_path":"zips/v1.zip","created_at":"2024-05-01T12:00:00+00:00","updated_at":"2024-05-01T12:05:00+00:00",` +
+	`"deleted_at":null,"deleted":false,"changed_at":"2024-05-01T12:05:00.123456+00:00"}`
+
+func expectInvalidation(r *mockRedis) {
+	r.On("Delete", "video:v1").Return(nil)
+	r.On("Delete", "stats:user:u1").Return(nil)
+	r.On("Delete", "stats:system").Return(nil)
+	r.On("InvalidatePattern", "videos:user:u1:*").Return(nil)
+}
+
+// ---------- handleDelivery ----------
+
+func TestVideoChangeConsumer_AppliesChange(t *testing.T) {
+	d := new(mockDB)
+	r := new(mockRedis)
+	ack := new(mockAcknowledger)
+	var applied *domain.VideoChange
+	d.On("ApplyVideoChange", mock.Anything).
+		Run(func(args mock.Arguments) { applied = args.Get(0).(*domain.VideoChange) }).
+		Return(nil)
+	expectInvalidation(r)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	NewVideoChangeConsumer(nil, d, r).handleDelivery(delivery("video.changed", videoChange, ack))
+
+	ack.AssertExpectations(t)
+	r.AssertExpectations(t)
+	assert.Equal(t, "v1", applied.ID)
+	assert.Equal(t, "u1", applied.UserID)
+	assert.Equal(t, "completed", applied.Status)
+	assert.Equal(t, "zips/v1.zip", *applied.ZipPath)
+	assert.Nil(t, applied.DeletedAt)
+	assert.False(t, applied.Deleted)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 5, 0, 123456000, time.UTC), applied.ChangedAt.UTC())
+}
+
+func TestVideoChangeConsumer_AppliesPurge(t *testing.T) {
+	d := new(mockDB)
+	r := new(mockRedis)
+	ack := new(mockAcknowledger)
+	d.On("ApplyVideoChange", mock.MatchedBy(func(change *domain.VideoChange) bool { return change.Deleted })).Return(nil)
+	expectInvalidation(r)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	body := `{"id":"v1","user_id":"u1","status":"completed","deleted":true,"changed_at":"2024-05-02T00:00:00Z"}`
+	NewVideoChangeConsumer(nil, d, r).handleDelivery(delivery("video.changed", body, ack))
+
+	ack.AssertExpectations(t)
+	d.AssertExpectations(t)
+}
+
+func TestVideoChangeConsumer_DiscardsInvalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"not json":      `not-json`,
+		"no video":      `{"user_id":"u1","changed_at":"2024-05-02T00:00:00Z"}`,
+		"no user":       `{"id":"v1","changed_at":"2024-05-02T00:00:00Z"}`,
+		"no changed_at": `{"id":"v1","user_id":"u1"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := new(mockDB)
+			ack := new(mockAcknowledger)
+			ack.On("Nack", uint64(1), false, false).Return(nil)
+
+			NewVideoChangeConsumer(nil, d, new(mockRedis)).handleDelivery(delivery("video.changed", body, ack))
+
+			ack.AssertExpectations(t)
+			d.AssertNotCalled(t, "ApplyVideoChange", mock.Anything)
+		})
+	}
+}
+
+func TestVideoChangeConsumer_ApplyErrorRequeues(t *testing.T) {
+	d := new(mockDB)
+	r := new(mockRedis)
+	ack := new(mockAcknowledger)
+	d.On("ApplyVideoChange", mock.Anything).Return(errors.New("db down"))
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	NewVideoChangeConsumer(nil, d, r).handleDelivery(delivery("video.changed", videoChange, ack))
+
+	ack.AssertExpectations(t)
+	r.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestVideoChangeConsumer_InvalidationErrorRequeues(t *testing.T) {
+	d := new(mockDB)
+	r := new(mockRedis)
+	ack := new(mockAcknowledger)
+	d.On("ApplyVideoChange", mock.Anything).Return(nil)
+	r.On("Delete", "video:v1").Return(errors.New("redis down"))
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	NewVideoChangeConsumer(nil, d, r).handleDelivery(delivery("video.changed", videoChange, ack))
+
+	ack.AssertExpectations(t)
+}
+
+// ---------- Run ----------
+
+func TestVideoChangeConsumer_Run(t *testing.T) {
+	mq := new(mockRabbitMQ)
+	d := new(mockDB)
+	r := new(mockRedis)
+	ack := new(mockAcknowledger)
+
+	msgs := make(chan amqp.Delivery, 1)
+	mq.On("SubscribeVideoChanges", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil)
+	d.On("ApplyVideoChange", mock.Anything).Return(nil)
+	expectInvalidation(r)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		msgs <- delivery("video.changed", videoChange, ack)
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	NewVideoChangeConsumer(mq, d, r).Run(ctx)
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
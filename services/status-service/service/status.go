@@ -23,32 +23,37 @@ func NewStatusService(db domain.DatabaseInterface, redis domain.RedisInterface,
 	}
 }
 
-func (s *StatusService) ListVideos(userID, status string) ([]domain.Video, error) {
-	cacheKey := fmt.Sprintf("videos:user:%s:status:%s", userID, status)
+// ListVideos returns a page of the user's videos from the read model, cached
+// under the query that selected it.
+func (s *StatusService) ListVideos(userID string, query domain.VideoListQuery) (*domain.VideoPage, error) {
+	listing, err := query.Listing()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("videos:user:%s:%s", userID, query.Values().Encode())
 	cached, err := s.redis.Get(cacheKey)
 	if err == nil && cached != "" {
-		var videos []domain.Video
-		if json.Unmarshal([]byte(cached), &videos) == nil {
-			return videos, nil
+		var page domain.VideoPage
+		if json.Unmarshal([]byte(cached), &page) == nil {
+			return &page, nil
 		}
 	}
 
-	videosPtr, err := s.videoClient.GetVideosByUserID(userID, status)
+	page, err := s.db.ListVideos(userID, listing)
 	if err != nil {
 		return nil, err
 	}
 
-	videos := make([]domain.Video, len(videosPtr))
-	for i, v := range videosPtr {
-		videos[i] = *v
-	}
-
-	jsonData, _ := json.Marshal(videos)
+	jsonData, _ := json.Marshal(page)
 	s.redis.Set(cacheKey, string(jsonData), 5*time.Minute)
 
-	return videos, nil
+	return page, nil
 }
 
+// GetVideo returns the user's video from the read model. A video the read
+// model has not heard of yet, as right after its upload, is asked of
+// video-service.
 func (s *StatusService) GetVideo(videoID, userID string) (*domain.Video, error) {
 	cacheKey := fmt.Sprintf("video:%s", videoID)
 	cached, err := s.redis.Get(cacheKey)
@@ -62,11 +67,20 @@ func (s *StatusService) GetVideo(videoID, userID string) (*domain.Video, error)
 		}
 	}
 
-	video, err := s.videoClient.GetVideoByID(videoID)
+	video, err := s.db.GetVideo(videoID)
 	if err != nil {
 		return nil, err
 	}
+	if video == nil {
+		video, err = s.videoClient.GetVideoByID(videoID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	if video.DeletedAt != nil {
+		return nil, fmt.Errorf("video not found")
+	}
 	if video.UserID != userID {
 		return nil, fmt.Errorf("access denied")
 	}
@@ -104,7 +118,7 @@ func (s *StatusService) GetUserStats(userID string) (*domain.UserStats, error) {
 		}
 	}
 
-	stats, err := s.videoClient.GetUserStats(userID)
+	stats, err := s.db.GetUserStats(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +139,7 @@ func (s *StatusService) GetSystemStats() (*domain.SystemStats, error) {
 		}
 	}
 
-	stats, err := s.videoClient.GetSystemStats()
+	stats, err := s.db.GetSystemStats()
 	if err != nil {
 		return nil, err
 	}
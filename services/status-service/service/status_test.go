@@ -16,6 +16,37 @@ import (
 
 type mockDB struct{ mock.Mock }
 
+func (m *mockDB) ApplyVideoChange(change *domain.VideoChange) error {
+	return m.Called(change).Error(0)
+}
+func (m *mockDB) GetVideo(id string) (*domain.Video, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Video), args.Error(1)
+}
+func (m *mockDB) ListVideos(userID string, listing *domain.VideoListing) (*domain.VideoPage, error) {
+	args := m.Called(userID, listing)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VideoPage), args.Error(1)
+}
+func (m *mockDB) GetUserStats(userID string) (*domain.UserStats, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserStats), args.Error(1)
+}
+func (m *mockDB) GetSystemStats() (*domain.SystemStats, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SystemStats), args.Error(1)
+}
 func (m *mockDB) Ping() error  { return m.Called().Error(0) }
 func (m *mockDB) Close() error { return m.Called().Error(0) }
 
@@ -28,6 +59,10 @@ func (m *mockRedis) Get(key string) (string, error) {
 func (m *mockRedis) Set(key string, value interface{}, exp time.Duration) error {
 	return m.Called(key, value, exp).Error(0)
 }
+func (m *mockRedis) Delete(key string) error { return m.Called(key).Error(0) }
+func (m *mockRedis) InvalidatePattern(pattern string) error {
+	return m.Called(pattern).Error(0)
+}
 func (m *mockRedis) Ping() error  { return m.Called().Error(0) }
 func (m *mockRedis) Close() error { return m.Called().Error(0) }
 
@@ -41,13 +76,6 @@ func (m *mockMinIO) Ping() error { return m.Called().Error(0) }
 
 type mockVideoClient struct{ mock.Mock }
 
-func (m *mockVideoClient) GetVideosByUserID(userID, status string) ([]*domain.Video, error) {
-	args := m.Called(userID, status)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*domain.Video), args.Error(1)
-}
 func (m *mockVideoClient) GetVideoByID(id string) (*domain.Video, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -55,63 +83,125 @@ func (m *mockVideoClient) GetVideoByID(id string) (*domain.Video, error) {
 	}
 	return args.Get(0).(*domain.Video), args.Error(1)
 }
-func (m *mockVideoClient) GetUserStats(userID string) (*domain.UserStats, error) {
-	args := m.Called(userID)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*domain.UserStats), args.Error(1)
-}
-func (m *mockVideoClient) GetSystemStats() (*domain.SystemStats, error) {
-	args := m.Called()
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*domain.SystemStats), args.Error(1)
-}
 
-func newSvc(r *mockRedis, vc *mockVideoClient, mn *mockMinIO) *StatusService {
-	return NewStatusService(nil, r, mn, vc)
+func newSvc(d *mockDB, r *mockRedis, vc *mockVideoClient, mn *mockMinIO) *StatusService {
+	var videoClient domain.VideoServiceClient
+	if vc != nil {
+		videoClient = vc
+	}
+	return NewStatusService(d, r, mn, videoClient)
 }
 
 // ---------- ListVideos ----------
 
 func TestListVideos_CacheHit(t *testing.T) {
 	r := new(mockRedis)
-	videos := []domain.Video{{ID: "v1"}}
-	data, _ := json.Marshal(videos)
-	r.On("Get", "videos:user:u1:status:").Return(string(data), nil)
+	page := domain.VideoPage{Videos: []domain.Video{{ID: "v1"}}, NextCursor: "next"}
+	data, _ := json.Marshal(page)
+	r.On("Get", "videos:user:u1:").Return(string(data), nil)
 
-	svc := newSvc(r, nil, nil)
-	result, err := svc.ListVideos("u1", "")
+	svc := newSvc(nil, r, nil, nil)
+	result, err := svc.ListVideos("u1", domain.VideoListQuery{})
 	assert.NoError(t, err)
-	assert.Len(t, result, 1)
+	assert.Len(t, result.Videos, 1)
+	assert.Equal(t, "next", result.NextCursor)
 }
 
 func TestListVideos_CacheMiss_Success(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
-	r.On("Get", "videos:user:u1:status:completed").Return("", errors.New("miss"))
-	vc.On("GetVideosByUserID", "u1", "completed").Return([]*domain.Video{{ID: "v1", Status: "completed"}}, nil)
-	r.On("Set", "videos:user:u1:status:completed", mock.Anything, mock.Anything).Return(nil)
-
-	svc := newSvc(r, vc, nil)
-	result, err := svc.ListVideos("u1", "completed")
+	query := domain.VideoListQuery{Statuses: []string{"completed"}, Limit: 10}
+	listing := &domain.VideoListing{Statuses: []string{"completed"}, Sort: "created_at", Descending: true, Limit: 10}
+	r.On("Get", "videos:user:u1:limit=10&status=completed").Return("", errors.New("miss"))
+	d.On("ListVideos", "u1", listing).Return(&domain.VideoPage{Videos: []domain.Video{{ID: "v1", Status: "completed"}}}, nil)
+	r.On("Set", "videos:user:u1:limit=10&status=completed", mock.Anything, mock.Anything).Return(nil)
+
+	svc := newSvc(d, r, nil, nil)
+	result, err := svc.ListVideos("u1", query)
 	assert.NoError(t, err)
-	assert.Len(t, result, 1)
+	assert.Len(t, result.Videos, 1)
+}
+
+func TestListVideos_CacheKeyIgnoresStatusOrder(t *testing.T) {
+	a := domain.VideoListQuery{Statuses: []string{"failed", "completed"}, Cursor: "c1"}
+	b := domain.VideoListQuery{Statuses: []string{"completed", "failed"}, Cursor: "c1"}
+	assert.Equal(t, a.Values().Encode(), b.Values().Encode())
+	assert.Equal(t, []string{"failed", "completed"}, a.Statuses)
+
+	c := domain.VideoListQuery{Statuses: []string{"completed", "failed"}, Cursor: "c2"}
+	assert.NotEqual(t, a.Values().Encode(), c.Values().Encode())
+}
+
+func TestListVideos_InvalidQuery(t *testing.T) {
+	svc := newSvc(nil, new(mockRedis), nil, nil)
+	_, err := svc.ListVideos("u1", domain.VideoListQuery{Order: "up"})
+	assert.EqualError(t, err, "order must be asc or desc")
 }
 
 func TestListVideos_Error(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
-	r.On("Get", "videos:user:u1:status:").Return("", errors.New("miss"))
-	vc.On("GetVideosByUserID", "u1", "").Return(nil, errors.New("service down"))
+	r.On("Get", "videos:user:u1:").Return("", errors.New("miss"))
+	d.On("ListVideos", "u1", mock.Anything).Return(nil, errors.New("db down"))
 
-	svc := newSvc(r, vc, nil)
-	_, err := svc.ListVideos("u1", "")
+	svc := newSvc(d, r, nil, nil)
+	_, err := svc.ListVideos("u1", domain.VideoListQuery{})
 	assert.Error(t, err)
 }
 
+// ---------- VideoListQuery ----------
+
+func TestListing_Defaults(t *testing.T) {
+	listing, err := domain.VideoListQuery{}.Listing()
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.VideoListing{Sort: "created_at", Descending: true, Limit: 20}, listing)
+}
+
+func TestListing_ReadsQuery(t *testing.T) {
+	cursor := &domain.VideoCursor{Sort: "size", Value: "1024", ID: "7f1b2c3d-0000-4000-8000-000000000001"}
+	listing, err := domain.VideoListQuery{
+		Statuses: []string{"completed", "failed"}, CreatedFrom: "2024-03-01", CreatedTo: "2024-03-31T12:00:00-03:00",
+		Search: " keynote ", Sort: "size", Order: "asc", Limit: 10, Cursor: cursor.Encode(),
+	}.Listing()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"completed", "failed"}, listing.Statuses)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *listing.CreatedFrom)
+	assert.Equal(t, time.Date(2024, 3, 31, 15, 0, 0, 0, time.UTC), *listing.CreatedTo)
+	assert.Equal(t, "keynote", listing.Search)
+	assert.False(t, listing.Descending)
+	assert.Equal(t, 10, listing.Limit)
+	assert.Equal(t, cursor, listing.After)
+}
+
+func TestListing_EndDateIncludesTheDay(t *testing.T) {
+	listing, err := domain.VideoListQuery{CreatedTo: "2024-03-31"}.Listing()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), *listing.CreatedTo)
+}
+
+func TestListing_Invalid(t *testing.T) {
+	otherSort := (&domain.VideoCursor{Sort: "size", Descending: true, Value: "1", ID: "7f1b2c3d-0000-4000-8000-000000000001"}).Encode()
+	badValue := (&domain.VideoCursor{Sort: "created_at", Descending: true, Value: "yesterday", ID: "7f1b2c3d-0000-4000-8000-000000000001"}).Encode()
+
+	cases := map[string]domain.VideoListQuery{
+		`unknown status "done"`: {Statuses: []string{"done"}},
+		"invalid created_from: expected RFC 3339 time or YYYY-MM-DD date": {CreatedFrom: "March"},
+		"sort must be created_at, size or duration":                       {Sort: "name"},
+		"order must be asc or desc":                                       {Order: "up"},
+		"limit must be between 1 and 100":                                 {Limit: 101},
+		"invalid cursor":                                                  {Cursor: "not-a-cursor"},
+	}
+	for message, query := range cases {
+		_, err := query.Listing()
+		assert.EqualError(t, err, message)
+	}
+
+	for _, cursor := range []string{otherSort, badValue} {
+		_, err := domain.VideoListQuery{Cursor: cursor}.Listing()
+		assert.EqualError(t, err, "invalid cursor")
+	}
+}
+
 // ---------- GetVideo ----------
 
 func TestGetVideo_CacheHit_Owner(t *testing.T) {
@@ -120,7 +210,7 @@ func TestGetVideo_CacheHit_Owner(t *testing.T) {
 	data, _ := json.Marshal(video)
 	r.On("Get", "video:v1").Return(string(data), nil)
 
-	svc := newSvc(r, nil, nil)
+	svc := newSvc(nil, r, nil, nil)
 	result, err := svc.GetVideo("v1", "u1")
 	assert.NoError(t, err)
 	assert.Equal(t, "v1", result.ID)
@@ -132,118 +222,157 @@ func TestGetVideo_CacheHit_Forbidden(t *testing.T) {
 	data, _ := json.Marshal(video)
 	r.On("Get", "video:v1").Return(string(data), nil)
 
-	svc := newSvc(r, nil, nil)
+	svc := newSvc(nil, r, nil, nil)
 	_, err := svc.GetVideo("v1", "u1")
 	assert.EqualError(t, err, "access denied")
 }
 
 func TestGetVideo_CacheMiss_Success(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	video := &domain.Video{ID: "v1", UserID: "u1"}
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
-	vc.On("GetVideoByID", "v1").Return(video, nil)
+	d.On("GetVideo", "v1").Return(video, nil)
 	r.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 
-	svc := newSvc(r, vc, nil)
+	svc := newSvc(d, r, nil, nil)
 	result, err := svc.GetVideo("v1", "u1")
 	assert.NoError(t, err)
 	assert.Equal(t, "v1", result.ID)
 }
 
 func TestGetVideo_CacheMiss_Forbidden(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	video := &domain.Video{ID: "v1", UserID: "other"}
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
-	vc.On("GetVideoByID", "v1").Return(video, nil)
+	d.On("GetVideo", "v1").Return(video, nil)
 
-	svc := newSvc(r, vc, nil)
+	svc := newSvc(d, r, nil, nil)
 	_, err := svc.GetVideo("v1", "u1")
 	assert.EqualError(t, err, "access denied")
 }
 
+func TestGetVideo_Deleted(t *testing.T) {
+	d := new(mockDB)
+	r := new(mockRedis)
+	deletedAt := time.Now()
+	r.On("Get", "video:v1").Return("", errors.New("miss"))
+	d.On("GetVideo", "v1").Return(&domain.Video{ID: "v1", UserID: "u1", DeletedAt: &deletedAt}, nil)
+
+	svc := newSvc(d, r, nil, nil)
+	_, err := svc.GetVideo("v1", "u1")
+	assert.EqualError(t, err, "video not found")
+	r.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetVideo_NotProjectedYet(t *testing.T) {
+	d := new(mockDB)
+	r := new(mockRedis)
+	vc := new(mockVideoClient)
+	r.On("Get", "video:v1").Return("", errors.New("miss"))
+	d.On("GetVideo", "v1").Return(nil, nil)
+	vc.On("GetVideoByID", "v1").Return(&domain.Video{ID: "v1", UserID: "u1", Status: "queued"}, nil)
+	r.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
+
+	svc := newSvc(d, r, vc, nil)
+	result, err := svc.GetVideo("v1", "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "queued", result.Status)
+}
+
 func TestGetVideo_Error(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
 	vc := new(mockVideoClient)
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
+	d.On("GetVideo", "v1").Return(nil, nil)
 	vc.On("GetVideoByID", "v1").Return(nil, errors.New("not found"))
 
-	svc := newSvc(r, vc, nil)
+	svc := newSvc(d, r, vc, nil)
 	_, err := svc.GetVideo("v1", "u1")
 	assert.Error(t, err)
 }
 
-// ---------- GetDownloadURL ----------
+func TestGetVideo_DBError(t *testing.T) {
+	d := new(mockDB)
+	r := new(mockRedis)
+	r.On("Get", "video:v1").Return("", errors.New("miss"))
+	d.On("GetVideo", "v1").Return(nil, errors.New("db down"))
 
-func zipPathPtr(s string) *string { return &s }
+	svc := newSvc(d, r, new(mockVideoClient), nil)
+	_, err := svc.GetVideo("v1", "u1")
+	assert.Error(t, err)
+}
+
+// ---------- GetDownloadURL ----------
 
 func TestGetDownloadURL_Success(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	mn := new(mockMinIO)
 	zipPath := "path/v1.zip"
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "completed", ZipPath: &zipPath}
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
-	vc.On("GetVideoByID", "v1").Return(video, nil)
+	d.On("GetVideo", "v1").Return(video, nil)
 	r.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 	mn.On("GetPresignedURL", "path/v1.zip", mock.Anything).Return("http://dl", nil)
 
-	svc := NewStatusService(nil, r, mn, vc)
+	svc := newSvc(d, r, nil, mn)
 	url, err := svc.GetDownloadURL("v1", "u1")
 	assert.NoError(t, err)
 	assert.Equal(t, "http://dl", url)
 }
 
 func TestGetDownloadURL_NotCompleted(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "processing"}
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
-	vc.On("GetVideoByID", "v1").Return(video, nil)
+	d.On("GetVideo", "v1").Return(video, nil)
 	r.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 
-	svc := NewStatusService(nil, r, nil, vc)
+	svc := newSvc(d, r, nil, nil)
 	_, err := svc.GetDownloadURL("v1", "u1")
 	assert.EqualError(t, err, "video processing not completed")
 }
 
 func TestGetDownloadURL_NoZipPath(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "completed", ZipPath: nil}
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
-	vc.On("GetVideoByID", "v1").Return(video, nil)
+	d.On("GetVideo", "v1").Return(video, nil)
 	r.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 
-	svc := NewStatusService(nil, r, nil, vc)
+	svc := newSvc(d, r, nil, nil)
 	_, err := svc.GetDownloadURL("v1", "u1")
 	assert.EqualError(t, err, "ZIP file not found")
 }
 
 func TestGetDownloadURL_MinIOError(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	mn := new(mockMinIO)
 	zipPath := "path/v1.zip"
 	video := &domain.Video{ID: "v1", UserID: "u1", Status: "completed", ZipPath: &zipPath}
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
-	vc.On("GetVideoByID", "v1").Return(video, nil)
+	d.On("GetVideo", "v1").Return(video, nil)
 	r.On("Set", "video:v1", mock.Anything, mock.Anything).Return(nil)
 	mn.On("GetPresignedURL", "path/v1.zip", mock.Anything).Return("", errors.New("minio down"))
 
-	svc := NewStatusService(nil, r, mn, vc)
+	svc := newSvc(d, r, nil, mn)
 	_, err := svc.GetDownloadURL("v1", "u1")
 	assert.Error(t, err)
 }
 
 func TestGetDownloadURL_VideoError(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	r.On("Get", "video:v1").Return("", errors.New("miss"))
-	vc.On("GetVideoByID", "v1").Return(nil, errors.New("not found"))
+	d.On("GetVideo", "v1").Return(nil, errors.New("db down"))
 
-	svc := NewStatusService(nil, r, nil, vc)
+	svc := newSvc(d, r, nil, nil)
 	_, err := svc.GetDownloadURL("v1", "u1")
 	assert.Error(t, err)
 }
@@ -256,33 +385,33 @@ func TestGetUserStats_CacheHit(t *testing.T) {
 	data, _ := json.Marshal(stats)
 	r.On("Get", "stats:user:u1").Return(string(data), nil)
 
-	svc := newSvc(r, nil, nil)
+	svc := newSvc(nil, r, nil, nil)
 	result, err := svc.GetUserStats("u1")
 	assert.NoError(t, err)
 	assert.Equal(t, 5, result.TotalVideos)
 }
 
 func TestGetUserStats_CacheMiss_Success(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	stats := &domain.UserStats{TotalVideos: 10}
 	r.On("Get", "stats:user:u1").Return("", errors.New("miss"))
-	vc.On("GetUserStats", "u1").Return(stats, nil)
+	d.On("GetUserStats", "u1").Return(stats, nil)
 	r.On("Set", "stats:user:u1", mock.Anything, mock.Anything).Return(nil)
 
-	svc := newSvc(r, vc, nil)
+	svc := newSvc(d, r, nil, nil)
 	result, err := svc.GetUserStats("u1")
 	assert.NoError(t, err)
 	assert.Equal(t, 10, result.TotalVideos)
 }
 
 func TestGetUserStats_Error(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	r.On("Get", "stats:user:u1").Return("", errors.New("miss"))
-	vc.On("GetUserStats", "u1").Return(nil, errors.New("service error"))
+	d.On("GetUserStats", "u1").Return(nil, errors.New("db error"))
 
-	svc := newSvc(r, vc, nil)
+	svc := newSvc(d, r, nil, nil)
 	_, err := svc.GetUserStats("u1")
 	assert.Error(t, err)
 }
@@ -295,33 +424,33 @@ func TestGetSystemStats_CacheHit(t *testing.T) {
 	data, _ := json.Marshal(stats)
 	r.On("Get", "stats:system").Return(string(data), nil)
 
-	svc := newSvc(r, nil, nil)
+	svc := newSvc(nil, r, nil, nil)
 	result, err := svc.GetSystemStats()
 	assert.NoError(t, err)
 	assert.Equal(t, 3, result.TotalUsers)
 }
 
 func TestGetSystemStats_CacheMiss_Success(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
-	stats := &domain.SystemStats{TotalUsers: 7}
+	stats := &domain.SystemStats{TotalVideos: 7}
 	r.On("Get", "stats:system").Return("", errors.New("miss"))
-	vc.On("GetSystemStats").Return(stats, nil)
+	d.On("GetSystemStats").Return(stats, nil)
 	r.On("Set", "stats:system", mock.Anything, mock.Anything).Return(nil)
 
-	svc := newSvc(r, vc, nil)
+	svc := newSvc(d, r, nil, nil)
 	result, err := svc.GetSystemStats()
 	assert.NoError(t, err)
-	assert.Equal(t, 7, result.TotalUsers)
+	assert.Equal(t, 7, result.TotalVideos)
 }
 
 func TestGetSystemStats_Error(t *testing.T) {
+	d := new(mockDB)
 	r := new(mockRedis)
-	vc := new(mockVideoClient)
 	r.On("Get", "stats:system").Return("", errors.New("miss"))
-	vc.On("GetSystemStats").Return(nil, errors.New("service error"))
+	d.On("GetSystemStats").Return(nil, errors.New("db error"))
 
-	svc := newSvc(r, vc, nil)
+	svc := newSvc(d, r, nil, nil)
 	_, err := svc.GetSystemStats()
 	assert.Error(t, err)
 }
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"status-service/domain"
+)
+
+// streamBufferSize is how many events a stream can fall behind by before it
+// is dropped.
+const streamBufferSize = 32
+
+// StreamHub delivers the status events on Redis to the streams open on this
+// replica, each only getting its own user's events.
+type StreamHub struct {
+	pubsub     domain.PubSubInterface
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	streams map[string]map[*Stream]struct{}
+}
+
+// Stream is one client's feed of status events. Events is closed when the
+// stream is closed, or when the client fell too far behind: it is then
+// expected to reconnect and reload what it shows.
+type Stream struct {
+	Events <-chan domain.StatusEvent
+	events chan domain.StatusEvent
+	userID string
+	closed bool
+}
+
+func NewStreamHub(pubsub domain.PubSubInterface) *StreamHub {
+	return &StreamHub{
+		pubsub:     pubsub,
+		retryDelay: 2 * time.Second,
+		streams:    map[string]map[*Stream]struct{}{},
+	}
+}
+
+// Open starts a stream of the user's events.
+func (h *StreamHub) Open(userID string) *Stream {
+	events := make(chan domain.StatusEvent, streamBufferSize)
+	stream := &Stream{Events: events, events: events, userID: userID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[userID] == nil {
+		h.streams[userID] = map[*Stream]struct{}{}
+	}
+	h.streams[userID][stream] = struct{}{}
+	return stream
+}
+
+// Close ends a stream. Closing it again does nothing.
+func (h *StreamHub) Close(stream *Stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(stream)
+}
+
+// Run listens for events until ctx is cancelled, listening again whenever the
+// subscription breaks. Open streams are closed when it returns, so the
+// server can shut down without waiting for their clients.
+func (h *StreamHub) Run(ctx context.Context) {
+	log.Println("Status stream hub started")
+
+	for {
+		messages, err := h.pubsub.Listen(ctx, StatusEventsChannel)
+		if err != nil {
+			log.Printf("Status stream hub: failed to subscribe: %v", err)
+		} else {
+			for message := range messages {
+				h.dispatch(message)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			log.Println("Status stream hub stopped")
+			return
+		case <-time.After(h.retryDelay):
+		}
+	}
+}
+
+func (h *StreamHub) dispatch(message string) {
+	var event domain.StatusEvent
+	if err := json.Unmarshal([]byte(message), &event); err != nil {
+		log.Printf("Status stream hub: invalid event: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for stream := range h.streams[event.UserID] {
+		select {
+		case stream.events <- event:
+		default:
+			log.Printf("Status stream hub: dropping a stream of user %s that fell behind", event.UserID)
+			h.remove(stream)
+		}
+	}
+}
+
+func (h *StreamHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, streams := range h.streams {
+		for stream := range streams {
+			h.remove(stream)
+		}
+	}
+}
+
+// remove must be called with h.mu held.
+func (h *StreamHub) remove(stream *Stream) {
+	if stream.closed {
+		return
+	}
+	stream.closed = true
+	close(stream.events)
+
+	delete(h.streams[stream.userID], stream)
+	if len(h.streams[stream.userID]) == 0 {
+		delete(h.streams, stream.userID)
+	}
+}
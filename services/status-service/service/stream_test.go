@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"status-service/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventMessage(userID, videoID string) string {
+	data, _ := json.Marshal(domain.StatusEvent{Type: "status", UserID: userID, VideoID: videoID, Status: "processing"})
+	return string(data)
+}
+
+// ---------- StreamHub ----------
+
+func TestStreamHub_DeliversToUserStreams(t *testing.T) {
+	hub := NewStreamHub(nil)
+	first := hub.Open("u1")
+	second := hub.Open("u1")
+	other := hub.Open("u2")
+
+	hub.dispatch(eventMessage("u1", "v1"))
+
+	for _, stream := range []*Stream{first, second} {
+		select {
+		case event := <-stream.Events:
+			assert.Equal(t, "v1", event.VideoID)
+		default:
+			t.Fatal("event not delivered")
+		}
+	}
+	assert.Empty(t, other.Events)
+}
+
+func TestStreamHub_Close(t *testing.T) {
+	hub := NewStreamHub(nil)
+	stream := hub.Open("u1")
+
+	hub.Close(stream)
+	hub.Close(stream)
+	hub.dispatch(eventMessage("u1", "v1"))
+
+	_, ok := <-stream.Events
+	assert.False(t, ok)
+	assert.Empty(t, hub.streams)
+}
+
+func TestStreamHub_DropsStreamThatFellBehind(t *testing.T) {
+	hub := NewStreamHub(nil)
+	slow := hub.Open("u1")
+
+	for i := 0; i <= streamBufferSize; i++ {
+		hub.dispatch(eventMessage("u1", "v1"))
+	}
+
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Equal(t, streamBufferSize, received)
+	assert.Empty(t, hub.streams)
+}
+
+func TestStreamHub_IgnoresInvalidMessages(t *testing.T) {
+	hub := NewStreamHub(nil)
+	stream := hub.Open("u1")
+
+	hub.dispatch("invalid json")
+
+	assert.Empty(t, stream.Events)
+}
+
+func TestStreamHub_Run(t *testing.T) {
+	ps := new(mockPubSub)
+	messages := make(chan string, 1)
+	ps.On("Listen", StatusEventsChannel).Return((<-chan string)(messages), nil).Once()
+
+	hub := NewStreamHub(ps)
+	stream := hub.Open("u1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hub.Run(ctx)
+		close(done)
+	}()
+
+	messages <- eventMessage("u1", "v1")
+	select {
+	case event := <-stream.Events:
+		assert.Equal(t, "v1", event.VideoID)
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+
+	// Stopping the hub ends the streams still open.
+	cancel()
+	close(messages)
+	<-done
+	_, ok := <-stream.Events
+	assert.False(t, ok)
+}
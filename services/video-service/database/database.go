@@ -2,12 +2,16 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 	"video-service/domain"
 	"video-service/infra/utils"
-	_ "github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Database struct {
@@ -54,25 +58,68 @@ func (d *Database) Close() error {
 	return d.db.Close()
 }
 
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 func (d *Database) CreateVideo(video *domain.Video) error {
+	return insertVideo(d.db, video)
+}
+
+// CreateVideoWithOutbox stores the video, the run its message starts and the
+// message itself in one transaction, so the message is published if and only
+// if the rows exist.
+func (d *Database) CreateVideoWithOutbox(video *domain.Video, message *domain.OutboxMessage) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertVideo(tx, video); err != nil {
+		return err
+	}
+	if _, err := insertQueuedRun(tx, message); err != nil {
+		return err
+	}
+	if err := insertOutbox(tx, message); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertVideo(ex execer, video *domain.Video) error {
 	query := `
 		INSERT INTO videos (id, user_id, filename, original_name, size_bytes, status, 
-		                    storage_path, priority, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		                    storage_path, priority, created_at, updated_at, queued_at, batch_id, current_run_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
-	_, err := d.db.Exec(query, video.ID, video.UserID, video.Filename, video.OriginalName,
-		video.SizeBytes, video.Status, video.StoragePath, video.Priority, video.CreatedAt, video.UpdatedAt)
+	_, err := ex.Exec(query, video.ID, video.UserID, video.Filename, video.OriginalName,
+		video.SizeBytes, video.Status, video.StoragePath, video.Priority, video.CreatedAt, video.UpdatedAt, video.QueuedAt,
+		video.BatchID, video.CurrentRunID)
 	return err
 }
 
-func (d *Database) GetVideoByID(id string) (*domain.Video, error) {
+const videoColumns = `id, user_id, filename, original_name, size_bytes, duration_seconds, status, storage_path,
+	zip_path, zip_size_bytes, frame_count, error_message, retry_count, priority, created_at, updated_at,
+	queued_at, processing_started_at, processing_completed_at, batch_id, current_run_id, raw_deleted_at, expired_at,
+	retention_warned_at, deleted_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVideo(row rowScanner) (*domain.Video, error) {
 	video := &domain.Video{}
-	query := `SELECT * FROM videos WHERE id = $1`
-	err := d.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&video.ID, &video.UserID, &video.Filename, &video.OriginalName, &video.SizeBytes,
 		&video.DurationSeconds, &video.Status, &video.StoragePath, &video.ZipPath, &video.ZipSizeBytes,
 		&video.FrameCount, &video.ErrorMessage, &video.RetryCount, &video.Priority,
 		&video.CreatedAt, &video.UpdatedAt, &video.QueuedAt, &video.ProcessingStartedAt, &video.ProcessingCompletedAt,
+		&video.BatchID, &video.CurrentRunID, &video.RawDeletedAt, &video.ExpiredAt, &video.RetentionWarnedAt,
+		&video.DeletedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -80,43 +127,700 @@ func (d *Database) GetVideoByID(id string) (*domain.Video, error) {
 	return video, nil
 }
 
-func (d *Database) GetVideosByUserID(userID, status string) ([]*domain.Video, error) {
-	var query string
-	var rows *sql.Rows
-	var err error
+func insertOutbox(ex execer, message *domain.OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (id, aggregate_id, exchange, routing_key, payload, priority, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := ex.Exec(query, message.ID, message.AggregateID, message.Exchange, message.RoutingKey,
+		message.Payload, message.Priority, message.CreatedAt)
+	return err
+}
+
+// videoEventUpdate builds the transition for an event. A completed video is
+// final; otherwise an event only applies if it is newer than the last
+// processing timestamp, so a stale event delivered out of order cannot undo a
+// later one.
+func videoEventUpdate(event *domain.VideoEvent) (string, []interface{}, error) {
+	switch event.Type {
+	case domain.EventVideoProcessingStarted:
+		return `
+			UPDATE videos
+			SET status = 'processing', processing_started_at = $2, error_message = NULL, updated_at = NOW()
+			WHERE id = $1 AND status IN ('pending', 'queued', 'failed')
+			  AND (processing_completed_at IS NULL OR processing_completed_at <= $2)
+		`, []interface{}{event.VideoID, event.OccurredAt}, nil
+	case domain.EventVideoProcessingCompleted:
+		return `
+			UPDATE videos
+			SET status = 'completed', zip_path = $3, zip_size_bytes = $4, frame_count = $5,
+			    error_message = NULL, processing_completed_at = $2, updated_at = NOW()
+			WHERE id = $1 AND status IN ('pending', 'queued', 'processing', 'failed')
+		`, []interface{}{event.VideoID, event.OccurredAt, event.ZipPath, event.ZipSizeBytes, event.FrameCount}, nil
+	case domain.EventVideoProcessingFailed:
+		return `
+			UPDATE videos
+			SET status = 'failed', error_message = $3, processing_completed_at = $2, updated_at = NOW()
+			WHERE id = $1 AND status IN ('pending', 'queued', 'processing', 'failed')
+			  AND (processing_started_at IS NULL OR processing_started_at <= $2)
+		`, []interface{}{event.VideoID, event.OccurredAt, event.ErrorMessage}, nil
+	}
+	return "", nil, fmt.Errorf("unknown video event type %q", event.Type)
+}
+
+// runEventUpdate builds the transition of the event's run, under the same
+// rules as videoEventUpdate.
+func runEventUpdate(event *domain.VideoEvent) (string, []interface{}, error) {
+	switch event.Type {
+	case domain.EventVideoProcessingStarted:
+		return `
+			UPDATE video_runs
+			SET status = 'processing', processing_started_at = $3, error_message = NULL, updated_at = NOW()
+			WHERE id = $1 AND video_id = $2 AND status IN ('queued', 'failed')
+			  AND (processing_completed_at IS NULL OR processing_completed_at <= $3)
+		`, []interface{}{event.RunID, event.VideoID, event.OccurredAt}, nil
+	case domain.EventVideoProcessingCompleted:
+		return `
+			UPDATE video_runs
+			SET status = 'completed', zip_path = $4, zip_size_bytes = $5, frame_count = $6,
+			    error_message = NULL, processing_completed_at = $3, updated_at = NOW()
+			WHERE id = $1 AND video_id = $2 AND status IN ('queued', 'processing', 'failed')
+		`, []interface{}{event.RunID, event.VideoID, event.OccurredAt, event.ZipPath, event.ZipSizeBytes, event.FrameCount}, nil
+	case domain.EventVideoProcessingFailed:
+		return `
+			UPDATE video_runs
+			SET status = 'failed', error_message = $4, processing_completed_at = $3, updated_at = NOW()
+			WHERE id = $1 AND video_id = $2 AND status IN ('queued', 'processing', 'failed')
+			  AND (processing_started_at IS NULL OR processing_started_at <= $3)
+		`, []interface{}{event.RunID, event.VideoID, event.OccurredAt, event.ErrorMessage}, nil
+	}
+	return "", nil, fmt.Errorf("unknown video event type %q", event.Type)
+}
+
+// showRun copies the state of run r onto its video v and makes it current.
+const showRun = `
+	UPDATE videos v
+	SET current_run_id = r.id, status = r.status, zip_path = r.zip_path, zip_size_bytes = r.zip_size_bytes,
+	    frame_count = r.frame_count, error_message = r.error_message, queued_at = r.queued_at,
+	    processing_started_at = r.processing_started_at, processing_completed_at = r.processing_completed_at,
+	    updated_at = NOW()
+	FROM video_runs r
+`
+
+// ApplyVideoEvent records the event and applies its transition in one
+// transaction. It reports whether the event changed anything: a redelivered
+// event, or one the transition rules reject, leaves the video untouched. An
+// event of a run moves the run, and the video only if it shows that run.
+func (d *Database) ApplyVideoEvent(event *domain.VideoEvent) (bool, error) {
+	update := videoEventUpdate
+	if event.RunID != "" {
+		update = runEventUpdate
+	}
+	query, args, err := update(event)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO processed_events (event_id, event_type, video_id, occurred_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id) DO NOTHING
+	`, event.EventID, event.Type, event.VideoID, event.OccurredAt)
+	if err != nil {
+		return false, err
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if inserted == 0 {
+		return false, nil
+	}
+
+	result, err = tx.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	videoUpdated := updated
+	// The video follows its current run, and any run that completes becomes
+	// current: a reprocess replaces the video's results only once it succeeds.
+	if updated > 0 && event.RunID != "" {
+		result, err := tx.Exec(showRun+`
+			WHERE r.id = $1 AND v.id = r.video_id AND (v.current_run_id = r.id OR r.status = 'completed')
+		`, event.RunID)
+		if err != nil {
+			return false, err
+		}
+		if videoUpdated, err = result.RowsAffected(); err != nil {
+			return false, err
+		}
+	}
+	if videoUpdated > 0 && event.Type != domain.EventVideoProcessingStarted {
+		if err := settleVideoBatch(tx, event.VideoID); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+// outboxMaxBackoff caps the delay before a failed outbox row is retried.
+const outboxMaxBackoff = 5 * time.Minute
+
+// RelayOutbox locks up to limit unsent messages that are due, oldest first,
+// and hands each one to publish. Rows locked by another replica are skipped.
+// A failed publish is recorded on its row, which is retried after a delay
+// that doubles with each attempt, and the batch carries on with the next row.
+// The first publish error is returned.
+func (d *Database) RelayOutbox(limit int, publish func(*domain.OutboxMessage) error) (int, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, aggregate_id, exchange, routing_key, payload, priority, attempts, created_at
+		FROM outbox
+		WHERE sent_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY created_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(query, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	messages := []*domain.OutboxMessage{}
+	for rows.Next() {
+		message := &domain.OutboxMessage{}
+		if err := rows.Scan(&message.ID, &message.AggregateID, &message.Exchange, &message.RoutingKey,
+			&message.Payload, &message.Priority, &message.Attempts, &message.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var publishErr error
+	for _, message := range messages {
+		if err := publish(message); err != nil {
+			if publishErr == nil {
+				publishErr = err
+			}
+			if _, err := tx.Exec(`
+				UPDATE outbox
+				SET attempts = attempts + 1, last_error = $1,
+				    next_attempt_at = NOW() + LEAST(POWER(2, attempts), $2) * INTERVAL '1 second'
+				WHERE id = $3`,
+				err.Error(), outboxMaxBackoff.Seconds(), message.ID); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE outbox SET attempts = attempts + 1, last_error = NULL, sent_at = NOW() WHERE id = $1`,
+			message.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return sent, publishErr
+}
+
+// PruneOutbox deletes up to limit messages sent before the given time and
+// returns how many it deleted.
+func (d *Database) PruneOutbox(sentBefore time.Time, limit int) (int, error) {
+	result, err := d.db.Exec(`
+		DELETE FROM outbox WHERE id IN (
+			SELECT id FROM outbox WHERE sent_at < $1 ORDER BY sent_at LIMIT $2
+		)
+	`, sentBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
+
+func (d *Database) GetVideoByID(id string) (*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE id = $1`
+	return scanVideo(d.db.QueryRow(query, id))
+}
+
+const uploadSessionColumns = `id, user_id, video_id, filename, original_name, storage_path, upload_method,
+	multipart_upload_id, content_type, COALESCE(checksum_sha256, ''), size_bytes, offset_bytes, parts_count,
+	tail_bytes, options, batch_id, status, locked_until, expires_at, created_at, updated_at`
+
+func scanUploadSession(row rowScanner) (*domain.UploadSession, error) {
+	session := &domain.UploadSession{}
+	var options []byte
+	err := row.Scan(&session.ID, &session.UserID, &session.VideoID, &session.Filename, &session.OriginalName,
+		&session.StoragePath, &session.Method, &session.MultipartUploadID, &session.ContentType,
+		&session.ChecksumSHA256, &session.SizeBytes, &session.OffsetBytes,
+		&session.PartsCount, &session.TailBytes, &options, &session.BatchID, &session.Status, &session.LockedUntil,
+		&session.ExpiresAt, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &session.Options); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (d *Database) CreateUploadSession(session *domain.UploadSession) error {
+	options, err := json.Marshal(session.Options)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO upload_sessions (id, user_id, video_id, filename, original_name, storage_path, upload_method,
+		    multipart_upload_id, content_type, checksum_sha256, size_bytes, options, batch_id, status, expires_at,
+		    created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, $13, $14, $15, $16, $17)
+	`
+	_, err = d.db.Exec(query, session.ID, session.UserID, session.VideoID, session.Filename, session.OriginalName,
+		session.StoragePath, session.Method, session.MultipartUploadID, session.ContentType, session.ChecksumSHA256,
+		session.SizeBytes, options, session.BatchID, session.Status, session.ExpiresAt, session.CreatedAt,
+		session.UpdatedAt)
+	return err
+}
+
+func (d *Database) GetUploadSession(id string) (*domain.UploadSession, error) {
+	query := `SELECT ` + uploadSessionColumns + ` FROM upload_sessions WHERE id = $1`
+	return scanUploadSession(d.db.QueryRow(query, id))
+}
 
-	if status != "" {
-		query = `SELECT * FROM videos WHERE user_id = $1 AND status = $2 ORDER BY created_at DESC`
-		rows, err = d.db.Query(query, userID, status)
-	} else {
-		query = `SELECT * FROM videos WHERE user_id = $1 ORDER BY created_at DESC`
-		rows, err = d.db.Query(query, userID)
+// LockUploadSession takes a lease on the session so a single request writes
+// to it at a time. The lease expires on its own if the holder dies, and is
+// released by UpdateUploadSession once LockedUntil is cleared.
+func (d *Database) LockUploadSession(id string, lease time.Duration) (*domain.UploadSession, error) {
+	query := `
+		UPDATE upload_sessions
+		SET locked_until = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id = $1 AND (locked_until IS NULL OR locked_until < NOW())
+		RETURNING ` + uploadSessionColumns
+	session, err := scanUploadSession(d.db.QueryRow(query, id, lease.Milliseconds()))
+	if err == sql.ErrNoRows {
+		if _, getErr := d.GetUploadSession(id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, domain.ErrUploadLocked
 	}
+	return session, err
+}
 
+func (d *Database) UpdateUploadSession(session *domain.UploadSession) error {
+	query := `
+		UPDATE upload_sessions
+		SET offset_bytes = $1, parts_count = $2, tail_bytes = $3, status = $4, locked_until = $5, updated_at = NOW()
+		WHERE id = $6
+	`
+	_, err := d.db.Exec(query, session.OffsetBytes, session.PartsCount, session.TailBytes, session.Status,
+		session.LockedUntil, session.ID)
+	return err
+}
+
+func (d *Database) DeleteUploadSession(id string) error {
+	_, err := d.db.Exec(`DELETE FROM upload_sessions WHERE id = $1`, id)
+	return err
+}
+
+// ListExpiredUploadSessions pages, by id, through the unfinished sessions
+// that expired before the given time.
+func (d *Database) ListExpiredUploadSessions(before time.Time, afterID string, limit int) ([]*domain.UploadSession, error) {
+	query := `SELECT ` + uploadSessionColumns + ` FROM upload_sessions
+		WHERE status <> 'completed' AND expires_at < $1
+		  AND id > COALESCE(NULLIF($2, '')::uuid, '00000000-0000-0000-0000-000000000000')
+		ORDER BY id
+		LIMIT $3`
+	rows, err := d.db.Query(query, before, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	videos := []*domain.Video{}
+	sessions := []*domain.UploadSession{}
 	for rows.Next() {
-		video := &domain.Video{}
-		err := rows.Scan(
-			&video.ID, &video.UserID, &video.Filename, &video.OriginalName, &video.SizeBytes,
-			&video.DurationSeconds, &video.Status, &video.StoragePath, &video.ZipPath, &video.ZipSizeBytes,
-			&video.FrameCount, &video.ErrorMessage, &video.RetryCount, &video.Priority,
-			&video.CreatedAt, &video.UpdatedAt, &video.QueuedAt, &video.ProcessingStartedAt, &video.ProcessingCompletedAt,
+		session, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// CreateVideoImport stores the video as importing together with the import
+// the fetcher will pick up.
+func (d *Database) CreateVideoImport(video *domain.Video, videoImport *domain.VideoImport) error {
+	options, err := json.Marshal(videoImport.Options)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertVideo(tx, video); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO video_imports (video_id, source_url, options, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, video.ID, videoImport.SourceURL, options, videoImport.NextAttemptAt, videoImport.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClaimVideoImport leases the oldest import that is due, counting the attempt.
+// It returns nil when there is nothing to do. A fetcher that dies loses its
+// lease, and the import is claimed again once it runs out.
+func (d *Database) ClaimVideoImport(lease time.Duration) (*domain.VideoImport, error) {
+	query := `
+		UPDATE video_imports vi
+		SET locked_until = NOW() + $1 * INTERVAL '1 millisecond', attempts = vi.attempts + 1
+		FROM videos v
+		WHERE v.id = vi.video_id AND vi.video_id = (
+			SELECT video_id FROM video_imports
+			WHERE completed_at IS NULL AND next_attempt_at <= NOW()
+			  AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
 		)
+		RETURNING vi.video_id, v.user_id, v.filename, v.priority, vi.source_url, vi.options, vi.attempts,
+		          vi.next_attempt_at, vi.created_at
+	`
+	videoImport := &domain.VideoImport{}
+	var options []byte
+	err := d.db.QueryRow(query, lease.Milliseconds()).Scan(&videoImport.VideoID, &videoImport.UserID,
+		&videoImport.Filename, &videoImport.Priority, &videoImport.SourceURL, &options, &videoImport.Attempts,
+		&videoImport.NextAttemptAt, &videoImport.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &videoImport.Options); err != nil {
+		return nil, err
+	}
+	return videoImport, nil
+}
+
+// UpdateVideoImportProgress reports how much has been fetched in the video's
+// size_bytes while it is importing.
+func (d *Database) UpdateVideoImportProgress(videoID string, bytesFetched int64) error {
+	query := `UPDATE videos SET size_bytes = $2, updated_at = NOW() WHERE id = $1 AND status = 'importing'`
+	_, err := d.db.Exec(query, videoID, bytesFetched)
+	return err
+}
+
+// CompleteVideoImport queues the imported video and closes the import in one
+// transaction. It reports false when the video is no longer importing, for
+// instance because it was deleted meanwhile.
+func (d *Database) CompleteVideoImport(videoID, storagePath string, size int64, message *domain.OutboxMessage) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	run, err := queuedRun(message)
+	if err != nil {
+		return false, err
+	}
+	var runID *string
+	if run != nil {
+		runID = &run.ID
+	}
+
+	result, err := tx.Exec(`
+		UPDATE videos
+		SET status = 'queued', storage_path = $2, size_bytes = $3, current_run_id = $4, error_message = NULL,
+		    queued_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'importing'
+	`, videoID, storagePath, size, runID)
+	if err != nil {
+		return false, err
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if updated == 0 {
+		return false, nil
+	}
+
+	if _, err := insertQueuedRun(tx, message); err != nil {
+		return false, err
+	}
+	if err := insertOutbox(tx, message); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE video_imports SET completed_at = NOW(), locked_until = NULL WHERE video_id = $1`,
+		videoID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RetryVideoImport releases the import until delay has passed and shows why
+// the last attempt failed on the video.
+func (d *Database) RetryVideoImport(videoID string, delay time.Duration, reason string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE video_imports
+		SET locked_until = NULL, next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE video_id = $1
+	`, videoID, delay.Milliseconds()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE videos SET error_message = $2, updated_at = NOW() WHERE id = $1 AND status = 'importing'`,
+		videoID, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FailVideoImport gives up on the import and marks the video failed.
+func (d *Database) FailVideoImport(videoID, reason string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE video_imports SET completed_at = NOW(), locked_until = NULL WHERE video_id = $1`,
+		videoID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE videos
+		SET status = 'failed', error_message = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'importing'
+	`, videoID, reason); err != nil {
+		return err
+	}
+	if err := settleVideoBatch(tx, videoID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// videoSorts maps each listing order to the expression it sorts on and the
+// type a cursor value is cast to. Videos without a known duration sort as
+// the shortest.
+var videoSorts = map[string]struct{ column, cast string }{
+	domain.VideoSortCreated:  {"created_at", "timestamp"},
+	domain.VideoSortSize:     {"size_bytes", "bigint"},
+	domain.VideoSortDuration: {"COALESCE(duration_seconds, -1)", "numeric"},
+}
+
+// likeEscaper makes a search term match literally in LIKE.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListVideos returns a page of the user's videos in the query's order,
+// starting after its cursor. It reads one row more than the limit to tell
+// whether there is a next page.
+func (d *Database) ListVideos(q domain.VideoListQuery) (*domain.VideoPage, error) {
+	sort, ok := videoSorts[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", q.Sort)
+	}
+
+	args := []interface{}{q.UserID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	if q.Trashed {
+		conditions[1] = "deleted_at IS NOT NULL"
+	}
+	if len(q.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+arg(pq.Array(q.Statuses))+")")
+	}
+	if q.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(*q.CreatedFrom))
+	}
+	if q.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(*q.CreatedTo))
+	}
+	if q.Search != "" {
+		conditions = append(conditions, "original_name ILIKE "+arg("%"+likeEscaper.Replace(q.Search)+"%"))
+	}
+
+	direction, compare := "ASC", ">"
+	if q.Descending {
+		direction, compare = "DESC", "<"
+	}
+	if q.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)",
+			sort.column, compare, arg(q.After.Value), sort.cast, arg(q.After.ID)))
+	}
+
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE ` + strings.Join(conditions, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", sort.column, direction, direction, arg(q.Limit+1))
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos, err := scanVideos(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.VideoPage{Videos: videos}
+	if len(videos) > q.Limit {
+		page.Videos = videos[:q.Limit]
+		last := page.Videos[q.Limit-1]
+		page.Next = &domain.VideoCursor{
+			Sort:       q.Sort,
+			Descending: q.Descending,
+			Value:      videoSortValue(last, q.Sort),
+			ID:         last.ID,
+		}
+	}
+	return page, nil
+}
+
+// videoSortValue is the video's value in the sort column, as a cursor holds it.
+func videoSortValue(video *domain.Video, sort string) string {
+	switch sort {
+	case domain.VideoSortSize:
+		return strconv.FormatInt(video.SizeBytes, 10)
+	case domain.VideoSortDuration:
+		if video.DurationSeconds == nil {
+			return "-1"
+		}
+		return strconv.FormatFloat(*video.DurationSeconds, 'f', -1, 64)
+	default:
+		return video.CreatedAt.Format(domain.CursorTimeFormat)
+	}
+}
+
+func scanVideos(rows *sql.Rows) ([]*domain.Video, error) {
+	videos := []*domain.Video{}
+	for rows.Next() {
+		video, err := scanVideo(rows)
 		if err != nil {
 			return nil, err
 		}
 		videos = append(videos, video)
 	}
+	return videos, rows.Err()
+}
+
+// CountActiveVideos returns how many of the user's videos are still waiting
+// for or going through processing.
+func (d *Database) CountActiveVideos(userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM videos WHERE user_id = $1 AND status IN ('pending', 'importing', 'queued', 'processing')`
+	err := d.db.QueryRow(query, userID).Scan(&count)
+	return count, err
+}
+
+// GetUserQuota returns the user's quota override, or nil when they have
+// none and their plan applies as is.
+func (d *Database) GetUserQuota(userID string) (*domain.UserQuota, error) {
+	quota := &domain.UserQuota{}
+	query := `
+		SELECT user_id, max_storage_bytes, max_videos_per_day, max_file_size_bytes, updated_at
+		FROM user_quotas WHERE user_id = $1
+	`
+	err := d.db.QueryRow(query, userID).Scan(&quota.UserID, &quota.MaxStorageBytes, &quota.MaxVideosPerDay,
+		&quota.MaxFileSizeBytes, &quota.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+func (d *Database) SetUserQuota(quota *domain.UserQuota) error {
+	query := `
+		INSERT INTO user_quotas (user_id, max_storage_bytes, max_videos_per_day, max_file_size_bytes, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET max_storage_bytes = EXCLUDED.max_storage_bytes, max_videos_per_day = EXCLUDED.max_videos_per_day,
+			max_file_size_bytes = EXCLUDED.max_file_size_bytes, updated_at = EXCLUDED.updated_at
+	`
+	_, err := d.db.Exec(query, quota.UserID, quota.MaxStorageBytes, quota.MaxVideosPerDay, quota.MaxFileSizeBytes,
+		quota.UpdatedAt)
+	return err
+}
 
-	return videos, nil
+// GetQuotaUsage adds up the user's stored uploads and the zips of all their
+// runs, and the videos created since the given time. Live upload sessions are
+// counted on their own, as they hold space and a video that do not exist yet.
+func (d *Database) GetQuotaUsage(userID string, since time.Time) (*domain.QuotaUsage, error) {
+	usage := &domain.QuotaUsage{}
+	query := `
+		SELECT
+			(SELECT COALESCE(SUM(CASE WHEN raw_deleted_at IS NULL THEN size_bytes ELSE 0 END +
+			                     CASE WHEN current_run_id IS NULL THEN COALESCE(zip_size_bytes, 0) ELSE 0 END), 0)
+			 FROM videos WHERE user_id = $1) +
+			(SELECT COALESCE(SUM(r.zip_size_bytes), 0) FROM video_runs r JOIN videos v ON v.id = r.video_id
+			 WHERE v.user_id = $1),
+			(SELECT COUNT(*) FROM videos WHERE user_id = $1 AND created_at >= $2),
+			COALESCE(SUM(size_bytes), 0),
+			COUNT(*)
+		FROM upload_sessions
+		WHERE user_id = $1 AND status <> 'completed' AND expires_at > NOW()
+	`
+	err := d.db.QueryRow(query, userID, since).Scan(&usage.StoredBytes, &usage.VideosToday,
+		&usage.ReservedBytes, &usage.ReservedVideos)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
 }
 
 func (d *Database) UpdateVideo(video *domain.Video) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE videos 
 		SET status = $1, zip_path = $2, zip_size_bytes = $3, frame_count = $4, 
@@ -124,18 +828,610 @@ func (d *Database) UpdateVideo(video *domain.Video) error {
 		    processing_started_at = $9, processing_completed_at = $10
 		WHERE id = $11
 	`
-	_, err := d.db.Exec(query, video.Status, video.ZipPath, video.ZipSizeBytes, video.FrameCount,
+	_, err = tx.Exec(query, video.Status, video.ZipPath, video.ZipSizeBytes, video.FrameCount,
 		video.ErrorMessage, video.RetryCount, video.UpdatedAt, video.QueuedAt,
 		video.ProcessingStartedAt, video.ProcessingCompletedAt, video.ID)
+	if err != nil {
+		return err
+	}
+	if err := settleVideoBatch(tx, video.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TrashVideo moves the video to the trash, where listings no longer show it.
+// A trashed video no longer holds up the email of its batch. It reports false
+// if the video was already in the trash.
+func (d *Database) TrashVideo(id string) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var batchID sql.NullString
+	err = tx.QueryRow(`
+		UPDATE videos SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING batch_id
+	`, id).Scan(&batchID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if batchID.Valid {
+		if err := settleBatch(tx, batchID.String); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// RestoreVideo takes the video back out of the trash. It reports false if the
+// video was not in the trash.
+func (d *Database) RestoreVideo(id string) (bool, error) {
+	result, err := d.db.Exec(`
+		UPDATE videos SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// ListTrashedVideos returns, in id order after afterID, videos that were moved
+// to the trash before the given time.
+func (d *Database) ListTrashedVideos(before time.Time, afterID string, limit int) ([]*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos
+		WHERE deleted_at < $1
+		  AND id > COALESCE(NULLIF($2, '')::uuid, '00000000-0000-0000-0000-000000000000')
+		ORDER BY id
+		LIMIT $3`
+	rows, err := d.db.Query(query, before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanVideos(rows)
+}
+
+// PurgeVideo deletes a video that has been in the trash since before
+// trashedBefore, along with its runs. It returns the storage objects the
+// video held, for the caller to delete; nothing is deleted if the video was
+// restored in the meantime.
+func (d *Database) PurgeVideo(id string, trashedBefore time.Time) ([]string, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var storagePath string
+	err = tx.QueryRow(`
+		SELECT COALESCE(storage_path, '') FROM videos WHERE id = $1 AND deleted_at < $2 FOR UPDATE
+	`, id, trashedBefore).Scan(&storagePath)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := videoZips(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if storagePath != "" {
+		removed = append(removed, storagePath)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM videos WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return removed, tx.Commit()
+}
+
+// videoZips returns the zips of the video and of all its runs.
+func videoZips(tx *sql.Tx, videoID string) ([]string, error) {
+	rows, err := tx.Query(`
+		SELECT zip_path FROM videos WHERE id = $1 AND zip_path IS NOT NULL
+		UNION
+		SELECT zip_path FROM video_runs WHERE video_id = $1 AND zip_path IS NOT NULL
+	`, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zips := []string{}
+	for rows.Next() {
+		var zipPath string
+		if err := rows.Scan(&zipPath); err != nil {
+			return nil, err
+		}
+		if zipPath != "" {
+			zips = append(zips, zipPath)
+		}
+	}
+	return zips, rows.Err()
+}
+
+// queuedRun reads the run a processing message starts, or nil if the message
+// carries no run.
+func queuedRun(message *domain.OutboxMessage) (*domain.VideoRun, error) {
+	var payload domain.VideoProcessingMessage
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		return nil, err
+	}
+	if payload.RunID == "" {
+		return nil, nil
+	}
+	return &domain.VideoRun{
+		ID:        payload.RunID,
+		VideoID:   payload.VideoID,
+		Options:   payload.Options,
+		Status:    "queued",
+		QueuedAt:  message.CreatedAt,
+		CreatedAt: message.CreatedAt,
+		UpdatedAt: message.CreatedAt,
+	}, nil
+}
+
+// insertQueuedRun stores the run a processing message starts, so the message
+// and its run are written together.
+func insertQueuedRun(ex execer, message *domain.OutboxMessage) (*domain.VideoRun, error) {
+	run, err := queuedRun(message)
+	if err != nil || run == nil {
+		return run, err
+	}
+	options, err := json.Marshal(run.Options)
+	if err != nil {
+		return nil, err
+	}
+	_, err = ex.Exec(`
+		INSERT INTO video_runs (id, video_id, options, status, queued_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, run.ID, run.VideoID, options, run.Status, run.QueuedAt, run.CreatedAt, run.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// QueueVideoRun starts another run of a video with the run and message in
+// the outbox message. A video that has no completed results to show switches
+// to the new run straight away; otherwise it keeps its current run until the
+// new one completes. It returns domain.ErrVideoBusy while the video has a run
+// queued or in progress, and sql.ErrNoRows if the video does not exist.
+func (d *Database) QueueVideoRun(videoID string, message *domain.OutboxMessage) (*domain.VideoRun, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM videos WHERE id = $1 FOR UPDATE`, videoID).Scan(&status); err != nil {
+		return nil, err
+	}
+	var active bool
+	if err := tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM video_runs WHERE video_id = $1 AND status IN ('queued', 'processing'))
+	`, videoID).Scan(&active); err != nil {
+		return nil, err
+	}
+	switch {
+	case active, status == "pending", status == "importing", status == "queued", status == "processing":
+		return nil, domain.ErrVideoBusy
+	}
+
+	run, err := insertQueuedRun(tx, message)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("processing message for video %s has no run", videoID)
+	}
+	if err := insertOutbox(tx, message); err != nil {
+		return nil, err
+	}
+	if status != "completed" {
+		if _, err := tx.Exec(`
+			UPDATE videos
+			SET current_run_id = $2, status = 'queued', queued_at = $3, error_message = NULL,
+			    processing_started_at = NULL, processing_completed_at = NULL, updated_at = NOW()
+			WHERE id = $1
+		`, videoID, run.ID, run.QueuedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// GetVideoRuns lists a video's runs, oldest first.
+func (d *Database) GetVideoRuns(videoID string) ([]*domain.VideoRun, error) {
+	rows, err := d.db.Query(`
+		SELECT id, video_id, options, status, zip_path, zip_size_bytes, frame_count, error_message, queued_at,
+		       processing_started_at, processing_completed_at, created_at, updated_at
+		FROM video_runs
+		WHERE video_id = $1
+		ORDER BY created_at, id
+	`, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*domain.VideoRun{}
+	for rows.Next() {
+		run := &domain.VideoRun{}
+		var options []byte
+		if err := rows.Scan(&run.ID, &run.VideoID, &options, &run.Status, &run.ZipPath, &run.ZipSizeBytes,
+			&run.FrameCount, &run.ErrorMessage, &run.QueuedAt, &run.ProcessingStartedAt,
+			&run.ProcessingCompletedAt, &run.CreatedAt, &run.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(options, &run.Options); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// SetCurrentVideoRun makes a completed run of the video its current one. It
+// reports false if the video has no such completed run.
+func (d *Database) SetCurrentVideoRun(videoID, runID string) (bool, error) {
+	result, err := d.db.Exec(showRun+`
+		WHERE r.id = $2 AND r.video_id = $1 AND v.id = $1 AND r.status = 'completed'
+	`, videoID, runID)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
+
+// ListRetentionCandidates returns up to limit videos, in id order after
+// afterID, that still hold files the retention janitor may remove and that
+// finished before the given time. A video finishes when it completes or fails,
+// or when it was created if it never reached processing. Videos in the trash
+// are left to the purger.
+func (d *Database) ListRetentionCandidates(before time.Time, afterID string, limit int) ([]*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos
+		WHERE (status IN ('completed', 'failed') OR (status = 'expired' AND storage_path <> ''))
+		  AND deleted_at IS NULL
+		  AND COALESCE(processing_completed_at, created_at) < $1
+		  AND id > COALESCE(NULLIF($2, '')::uuid, '00000000-0000-0000-0000-000000000000')
+		ORDER BY id
+		LIMIT $3`
+	rows, err := d.db.Query(query, before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanVideos(rows)
+}
+
+// ApplyRetention removes the video's upload and, with expire, its zips from
+// its rows, leaving it expired. It returns the storage objects it let go of,
+// for the caller to delete. Nothing is removed while the video has a run
+// queued or in progress.
+func (d *Database) ApplyRetention(videoID string, deleteRaw, expire bool) ([]string, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status, storagePath string
+	err = tx.QueryRow(`
+		SELECT status, COALESCE(storage_path, '') FROM videos
+		WHERE id = $1 AND NOT EXISTS (
+			SELECT 1 FROM video_runs WHERE video_id = $1 AND status IN ('queued', 'processing')
+		)
+		FOR UPDATE
+	`, videoID).Scan(&status, &storagePath)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	if deleteRaw && storagePath != "" && (status == "completed" || status == "failed" || status == domain.VideoStatusExpired) {
+		if _, err := tx.Exec(`
+			UPDATE videos SET storage_path = '', raw_deleted_at = NOW(), retention_warned_at = NULL, updated_at = NOW()
+			WHERE id = $1
+		`, videoID); err != nil {
+			return nil, err
+		}
+		removed = append(removed, storagePath)
+	}
+
+	if expire && (status == "completed" || status == "failed") {
+		zips, err := videoZips(tx, videoID)
+		if err != nil {
+			return nil, err
+		}
+		removed = append(removed, zips...)
+
+		if _, err := tx.Exec(`
+			UPDATE video_runs SET status = 'expired', zip_path = NULL, zip_size_bytes = NULL, updated_at = NOW()
+			WHERE video_id = $1 AND status = 'completed'
+		`, videoID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`
+			UPDATE videos
+			SET status = 'expired', zip_path = NULL, zip_size_bytes = NULL, expired_at = NOW(),
+			    retention_warned_at = NULL, updated_at = NOW()
+			WHERE id = $1
+		`, videoID); err != nil {
+			return nil, err
+		}
+	}
+
+	return removed, tx.Commit()
+}
+
+// MarkRetentionWarned records that the video's owner was warned about its
+// files being removed, together with the warning in the outbox. It reports
+// false if they had been warned already.
+func (d *Database) MarkRetentionWarned(videoID string, message *domain.OutboxMessage) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE videos SET retention_warned_at = NOW() WHERE id = $1 AND retention_warned_at IS NULL
+	`, videoID)
+	if err != nil {
+		return false, err
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if updated == 0 {
+		return false, nil
+	}
+
+	if err := insertOutbox(tx, message); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+const shareLinkColumns = `id, video_id, user_id, token_hash, password_hash, expires_at, max_downloads,
+	download_count, revoked_at, last_used_at, created_at`
+
+func scanShareLink(row rowScanner) (*domain.ShareLink, error) {
+	link := &domain.ShareLink{}
+	err := row.Scan(&link.ID, &link.VideoID, &link.UserID, &link.TokenHash, &link.PasswordHash, &link.ExpiresAt,
+		&link.MaxDownloads, &link.DownloadCount, &link.RevokedAt, &link.LastUsedAt, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (d *Database) CreateShareLink(link *domain.ShareLink) error {
+	query := `
+		INSERT INTO share_links (id, video_id, user_id, token_hash, password_hash, expires_at, max_downloads, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := d.db.Exec(query, link.ID, link.VideoID, link.UserID, link.TokenHash, link.PasswordHash,
+		link.ExpiresAt, link.MaxDownloads, link.CreatedAt)
 	return err
 }
 
-func (d *Database) DeleteVideo(id string) error {
-	query := `DELETE FROM videos WHERE id = $1`
-	_, err := d.db.Exec(query, id)
+// GetShareLinks lists a video's share links, newest first, revoked and
+// expired ones included.
+func (d *Database) GetShareLinks(videoID string) ([]*domain.ShareLink, error) {
+	rows, err := d.db.Query(`SELECT `+shareLinkColumns+` FROM share_links
+		WHERE video_id = $1 ORDER BY created_at DESC, id`, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*domain.ShareLink{}
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (d *Database) GetShareLinkByToken(tokenHash string) (*domain.ShareLink, error) {
+	row := d.db.QueryRow(`SELECT `+shareLinkColumns+` FROM share_links WHERE token_hash = $1`, tokenHash)
+	return scanShareLink(row)
+}
+
+// UseShareLink counts a download against the link. It reports false if the
+// link was revoked, expired or ran out of downloads in the meantime, so two
+// downloads racing for the last one cannot both get it.
+func (d *Database) UseShareLink(id string) (bool, error) {
+	result, err := d.db.Exec(`
+		UPDATE share_links SET download_count = download_count + 1, last_used_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		  AND (max_downloads IS NULL OR download_count < max_downloads)
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RevokeShareLink revokes one of the video's share links. It reports false if
+// the video has no such link or it was already revoked.
+func (d *Database) RevokeShareLink(videoID, id string) (bool, error) {
+	result, err := d.db.Exec(`
+		UPDATE share_links SET revoked_at = NOW()
+		WHERE id = $1 AND video_id = $2 AND revoked_at IS NULL
+	`, id, videoID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (d *Database) CreateBatch(batch *domain.Batch) error {
+	query := `
+		INSERT INTO batches (id, user_id, name, sealed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := d.db.Exec(query, batch.ID, batch.UserID, batch.Name, batch.SealedAt, batch.CreatedAt, batch.UpdatedAt)
 	return err
 }
 
+func (d *Database) GetBatch(id string) (*domain.Batch, error) {
+	batch := &domain.Batch{}
+	query := `SELECT id, user_id, name, sealed_at, notified_at, created_at, updated_at FROM batches WHERE id = $1`
+	err := d.db.QueryRow(query, id).Scan(&batch.ID, &batch.UserID, &batch.Name, &batch.SealedAt, &batch.NotifiedAt,
+		&batch.CreatedAt, &batch.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (d *Database) GetBatchVideos(batchID string) ([]*domain.Video, error) {
+	query := `SELECT ` + videoColumns + ` FROM videos WHERE batch_id = $1 AND deleted_at IS NULL ORDER BY created_at, id`
+	rows, err := d.db.Query(query, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanVideos(rows)
+}
+
+// SealBatch closes the batch to new videos. It refuses while a resumable or
+// direct upload for the batch is still live, since its video would otherwise
+// join after the batch may already have been reported. Sealing twice is a
+// no-op.
+func (d *Database) SealBatch(id string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var sealed bool
+	err = tx.QueryRow(`SELECT sealed_at IS NOT NULL FROM batches WHERE id = $1 FOR UPDATE`, id).Scan(&sealed)
+	if err != nil {
+		return err
+	}
+	if sealed {
+		return nil
+	}
+
+	var pending int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM upload_sessions
+		WHERE batch_id = $1 AND status <> 'completed' AND expires_at > NOW()
+	`, id).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return domain.ErrBatchUploadsPending
+	}
+
+	if _, err := tx.Exec(`UPDATE batches SET sealed_at = NOW(), updated_at = NOW() WHERE id = $1`, id); err != nil {
+		return err
+	}
+	if err := settleBatch(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// settleVideoBatch settles the batch the video belongs to, if any.
+func settleVideoBatch(tx *sql.Tx, videoID string) error {
+	var batchID sql.NullString
+	err := tx.QueryRow(`SELECT batch_id FROM videos WHERE id = $1`, videoID).Scan(&batchID)
+	if err == sql.ErrNoRows || (err == nil && !batchID.Valid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return settleBatch(tx, batchID.String)
+}
+
+// settleBatch queues the batch email once a sealed batch has no video left to
+// process, and marks the batch notified so it is sent once. The batch row is
+// locked first: when its last two videos finish at the same time, whichever
+// transaction gets the lock second sees the other one's video as finished.
+func settleBatch(tx *sql.Tx, batchID string) error {
+	var userID string
+	err := tx.QueryRow(`
+		SELECT user_id FROM batches
+		WHERE id = $1 AND sealed_at IS NOT NULL AND notified_at IS NULL
+		FOR UPDATE
+	`, batchID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var total, completed, unfinished int
+	err = tx.QueryRow(`
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'completed'),
+		       COUNT(*) FILTER (WHERE status NOT IN ('completed', 'failed', 'cancelled', 'expired'))
+		FROM videos WHERE batch_id = $1 AND deleted_at IS NULL
+	`, batchID).Scan(&total, &completed, &unfinished)
+	if err != nil {
+		return err
+	}
+	if total == 0 || unfinished > 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(domain.NotificationMessage{
+		UserID:  userID,
+		BatchID: batchID,
+		Type:    domain.NotificationBatchCompleted,
+		Subject: "Batch Processing Completed",
+		Message: fmt.Sprintf("%d of %d videos in your batch were processed successfully.", completed, total),
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE batches SET notified_at = NOW(), updated_at = NOW() WHERE id = $1`, batchID); err != nil {
+		return err
+	}
+	return insertOutbox(tx, &domain.OutboxMessage{
+		ID:          uuid.New().String(),
+		AggregateID: batchID,
+		Exchange:    "notification.exchange",
+		RoutingKey:  "notification.email",
+		Payload:     payload,
+		CreatedAt:   time.Now(),
+	})
+}
+
 func (d *Database) GetUserStats(userID string) (*domain.UserStats, error) {
 	stats := &domain.UserStats{}
 	query := `
@@ -148,7 +1444,7 @@ func (d *Database) GetUserStats(userID string) (*domain.UserStats, error) {
 			COALESCE(SUM(size_bytes) / 1024.0 / 1024.0, 0) as total_storage_mb,
 			COALESCE(AVG(EXTRACT(EPOCH FROM (processing_completed_at - processing_started_at))), 0) as avg_processing_time
 		FROM videos
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 	`
 	err := d.db.QueryRow(query, userID).Scan(
 		&stats.TotalVideos, &stats.CompletedVideos, &stats.FailedVideos,
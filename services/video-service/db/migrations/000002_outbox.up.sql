@@ -0,0 +1,17 @@
+-- Transactional outbox: events are written in the same transaction as the
+-- state change that produced them and relayed to RabbitMQ afterwards.
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    aggregate_id UUID NOT NULL,
+    exchange VARCHAR(100) NOT NULL,
+    routing_key VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_pending ON outbox(created_at) WHERE sent_at IS NULL;
+CREATE INDEX idx_outbox_aggregate_id ON outbox(aggregate_id);
@@ -0,0 +1,13 @@
+-- Lifecycle events from processing-service that have already been applied.
+-- Recording the event ID in the same transaction as the status change makes
+-- redelivered events a no-op.
+CREATE TABLE processed_events (
+    event_id UUID PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    video_id UUID NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    processed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_processed_events_video_id ON processed_events(video_id);
+CREATE INDEX idx_processed_events_processed_at ON processed_events(processed_at);
@@ -0,0 +1,26 @@
+-- Resumable (tus) uploads in progress. Bytes live in a MinIO multipart upload
+-- until the session is finished and the video record is created.
+CREATE TABLE upload_sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    video_id UUID NOT NULL UNIQUE,
+    filename VARCHAR(255) NOT NULL,
+    original_name VARCHAR(255) NOT NULL,
+    storage_path VARCHAR(500) NOT NULL,
+    multipart_upload_id VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    offset_bytes BIGINT NOT NULL DEFAULT 0,
+    parts_count INT NOT NULL DEFAULT 0,
+    tail_bytes BIGINT NOT NULL DEFAULT 0,
+    options JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'uploading'
+        CHECK (status IN ('uploading', 'assembled', 'completed')),
+    locked_until TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (offset_bytes BETWEEN 0 AND size_bytes)
+);
+
+CREATE INDEX idx_upload_sessions_user_id ON upload_sessions(user_id);
+CREATE INDEX idx_upload_sessions_expires_at ON upload_sessions(expires_at) WHERE status <> 'completed';
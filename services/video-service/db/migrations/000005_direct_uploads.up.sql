@@ -0,0 +1,8 @@
+-- Direct uploads: the client PUTs the file straight to MinIO with a presigned
+-- URL, and the session keeps what it declared so the object can be checked
+-- before the video is created.
+ALTER TABLE upload_sessions
+    ADD COLUMN upload_method VARCHAR(20) NOT NULL DEFAULT 'tus'
+        CHECK (upload_method IN ('tus', 'presigned')),
+    ADD COLUMN content_type VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN checksum_sha256 CHAR(64);
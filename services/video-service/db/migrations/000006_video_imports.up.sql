@@ -0,0 +1,18 @@
+-- URL imports: the video exists as 'importing' while a background fetcher
+-- streams the file into storage, then moves on to 'queued' like any upload.
+ALTER TABLE videos DROP CONSTRAINT IF EXISTS videos_status_check;
+ALTER TABLE videos ADD CONSTRAINT videos_status_check
+    CHECK (status IN ('pending', 'importing', 'queued', 'processing', 'completed', 'failed', 'cancelled'));
+
+CREATE TABLE video_imports (
+    video_id UUID PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    source_url TEXT NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_video_imports_pending ON video_imports(next_attempt_at) WHERE completed_at IS NULL;
@@ -0,0 +1,20 @@
+-- Batches group videos uploaded together. A batch is open while videos are
+-- being added and sealed once the client says it is complete; the owner gets
+-- one email when every video of a sealed batch has finished processing.
+CREATE TABLE batches (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    sealed_at TIMESTAMP,
+    notified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_batches_user_id ON batches(user_id);
+
+ALTER TABLE videos ADD COLUMN batch_id UUID REFERENCES batches(id) ON DELETE SET NULL;
+CREATE INDEX idx_videos_batch_id ON videos(batch_id) WHERE batch_id IS NOT NULL;
+
+-- Uploads that create their video later carry the batch until then.
+ALTER TABLE upload_sessions ADD COLUMN batch_id UUID REFERENCES batches(id) ON DELETE SET NULL;
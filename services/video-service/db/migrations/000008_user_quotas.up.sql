@@ -0,0 +1,11 @@
+-- Per-user overrides of the plan quotas configured in QUOTA_* variables.
+-- NULL keeps the plan's limit, 0 lifts it.
+CREATE TABLE user_quotas (
+    user_id UUID PRIMARY KEY,
+    max_storage_bytes BIGINT CHECK (max_storage_bytes >= 0),
+    max_videos_per_day INT CHECK (max_videos_per_day >= 0),
+    max_file_size_bytes BIGINT CHECK (max_file_size_bytes >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_upload_sessions_user_live ON upload_sessions(user_id, expires_at) WHERE status <> 'completed';
@@ -0,0 +1,10 @@
+-- Keyset pagination of a user's videos, one index per sort order. The id
+-- breaks ties so every row has a stable position.
+DROP INDEX IF EXISTS idx_videos_user_created;
+CREATE INDEX idx_videos_user_created ON videos(user_id, created_at, id);
+CREATE INDEX idx_videos_user_size ON videos(user_id, size_bytes, id);
+CREATE INDEX idx_videos_user_duration ON videos(user_id, (COALESCE(duration_seconds, -1)), id);
+
+-- Substring search on the uploaded file name.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_videos_original_name_trgm ON videos USING gin (original_name gin_trgm_ops);
@@ -0,0 +1,39 @@
+-- A run is one processing of a video's stored file. Reprocessing with new
+-- options adds a run and keeps the earlier ones with their zips; the video
+-- shows the results of its current run.
+CREATE TABLE video_runs (
+    id UUID PRIMARY KEY,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    options JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(50) NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'processing', 'completed', 'failed')),
+    zip_path VARCHAR(500),
+    zip_size_bytes BIGINT,
+    frame_count INT,
+    error_message TEXT,
+    queued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    processing_started_at TIMESTAMP,
+    processing_completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_video_runs_video_id ON video_runs(video_id, created_at);
+
+ALTER TABLE videos ADD COLUMN current_run_id UUID;
+
+-- Videos that already finished get their processing as a first run. Videos
+-- still in flight keep none: their events carry no run and update the video
+-- directly.
+WITH backfilled AS (
+    INSERT INTO video_runs (id, video_id, status, zip_path, zip_size_bytes, frame_count, error_message,
+                            queued_at, processing_started_at, processing_completed_at, created_at, updated_at)
+    SELECT gen_random_uuid(), id, status, zip_path, zip_size_bytes, frame_count, error_message,
+           COALESCE(queued_at, created_at), processing_started_at, processing_completed_at, created_at, updated_at
+    FROM videos
+    WHERE status IN ('completed', 'failed')
+    RETURNING id, video_id
+)
+UPDATE videos SET current_run_id = backfilled.id
+FROM backfilled
+WHERE videos.id = backfilled.video_id;
@@ -0,0 +1,18 @@
+-- The retention janitor deletes uploads and zips once they are older than
+-- the owner's plan allows. A video whose zips are gone, or a failed one whose
+-- upload is gone, is left as expired.
+ALTER TABLE videos DROP CONSTRAINT IF EXISTS videos_status_check;
+ALTER TABLE videos ADD CONSTRAINT videos_status_check
+    CHECK (status IN ('pending', 'importing', 'queued', 'processing', 'completed', 'failed', 'cancelled', 'expired'));
+
+ALTER TABLE video_runs DROP CONSTRAINT IF EXISTS video_runs_status_check;
+ALTER TABLE video_runs ADD CONSTRAINT video_runs_status_check
+    CHECK (status IN ('queued', 'processing', 'completed', 'failed', 'expired'));
+
+ALTER TABLE videos ADD COLUMN raw_deleted_at TIMESTAMP;
+ALTER TABLE videos ADD COLUMN expired_at TIMESTAMP;
+ALTER TABLE videos ADD COLUMN retention_warned_at TIMESTAMP;
+
+-- Videos that still hold files the janitor may remove.
+CREATE INDEX idx_videos_retention ON videos(id)
+    WHERE status IN ('completed', 'failed') OR (status = 'expired' AND storage_path <> '');
@@ -0,0 +1,5 @@
+-- Deleting a video moves it to the trash, from which it can be restored until
+-- the purger removes it for good.
+ALTER TABLE videos ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_videos_trash ON videos(deleted_at) WHERE deleted_at IS NOT NULL;
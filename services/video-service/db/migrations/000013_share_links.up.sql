@@ -0,0 +1,18 @@
+-- A share link lets someone without an account download a video's frames
+-- until it expires, runs out of downloads or is revoked. Only a hash of the
+-- link's token is stored.
+CREATE TABLE share_links (
+    id UUID PRIMARY KEY,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    password_hash VARCHAR(100),
+    expires_at TIMESTAMP NOT NULL,
+    max_downloads INT CHECK (max_downloads > 0),
+    download_count INT NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_share_links_video_id ON share_links(video_id, created_at);
@@ -0,0 +1,65 @@
+-- Every change to a video is published as a video.changed snapshot, which
+-- status-service projects into its own read model. The trigger writes it to
+-- the outbox in the transaction that made the change, whichever code path
+-- made it. A purged video is published once more with "deleted" set.
+CREATE OR REPLACE FUNCTION video_change_payload(v videos, deleted BOOLEAN)
+RETURNS JSONB AS $$
+BEGIN
+    RETURN jsonb_build_object(
+        'id', v.id,
+        'user_id', v.user_id,
+        'filename', v.filename,
+        'original_name', v.original_name,
+        'size_bytes', v.size_bytes,
+        'duration_seconds', v.duration_seconds,
+        'status', v.status,
+        'storage_path', v.storage_path,
+        'zip_path', v.zip_path,
+        'zip_size_bytes', v.zip_size_bytes,
+        'frame_count', v.frame_count,
+        'error_message', v.error_message,
+        'retry_count', v.retry_count,
+        'priority', v.priority,
+        'created_at', v.created_at AT TIME ZONE 'UTC',
+        'updated_at', v.updated_at AT TIME ZONE 'UTC',
+        'queued_at', v.queued_at AT TIME ZONE 'UTC',
+        'processing_started_at', v.processing_started_at AT TIME ZONE 'UTC',
+        'processing_completed_at', v.processing_completed_at AT TIME ZONE 'UTC',
+        'deleted_at', v.deleted_at AT TIME ZONE 'UTC',
+        'deleted', deleted,
+        -- Changes to a row are serialized by its lock, so this orders the
+        -- snapshots of one video however they are delivered.
+        'changed_at', clock_timestamp()
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION publish_video_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO outbox (aggregate_id, exchange, routing_key, payload)
+        VALUES (OLD.id, 'video.exchange', 'video.changed', video_change_payload(OLD, true));
+        RETURN OLD;
+    END IF;
+
+    -- An import reports its progress in size_bytes as it goes; readers get
+    -- the size once the import completes.
+    IF TG_OP = 'UPDATE' AND OLD.status = 'importing' AND NEW.status = 'importing'
+        AND OLD.deleted_at IS NOT DISTINCT FROM NEW.deleted_at THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO outbox (aggregate_id, exchange, routing_key, payload)
+    VALUES (NEW.id, 'video.exchange', 'video.changed', video_change_payload(NEW, false));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER publish_video_change AFTER INSERT OR UPDATE OR DELETE ON videos
+    FOR EACH ROW EXECUTE FUNCTION publish_video_change();
+
+-- Seed the read model with the videos that already exist.
+INSERT INTO outbox (aggregate_id, exchange, routing_key, payload)
+SELECT id, 'video.exchange', 'video.changed', video_change_payload(videos, false)
+FROM videos;
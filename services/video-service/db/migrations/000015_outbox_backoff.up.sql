@@ -0,0 +1,3 @@
+-- A row whose publish fails is retried after a growing delay, so the relay
+-- moves on to the rows behind it instead of picking the same one first.
+ALTER TABLE outbox ADD COLUMN next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW();
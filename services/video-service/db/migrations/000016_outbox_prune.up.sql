@@ -0,0 +1,2 @@
+-- The relay deletes rows some time after they were sent, oldest first.
+CREATE INDEX idx_outbox_sent_at ON outbox(sent_at) WHERE sent_at IS NOT NULL;
@@ -1,16 +1,57 @@
 package domain
 
 import (
+	"context"
 	"io"
+	"time"
 	"github.com/minio/minio-go/v7"
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type DatabaseInterface interface {
 	CreateVideo(video *Video) error
+	CreateVideoWithOutbox(video *Video, message *OutboxMessage) error
+	RelayOutbox(limit int, publish func(*OutboxMessage) error) (int, error)
+	PruneOutbox(sentBefore time.Time, limit int) (int, error)
+	ApplyVideoEvent(event *VideoEvent) (bool, error)
 	GetVideoByID(id string) (*Video, error)
-	GetVideosByUserID(userID, status string) ([]*Video, error)
+	CreateUploadSession(session *UploadSession) error
+	GetUploadSession(id string) (*UploadSession, error)
+	LockUploadSession(id string, lease time.Duration) (*UploadSession, error)
+	UpdateUploadSession(session *UploadSession) error
+	DeleteUploadSession(id string) error
+	ListExpiredUploadSessions(before time.Time, afterID string, limit int) ([]*UploadSession, error)
+	CreateVideoImport(video *Video, videoImport *VideoImport) error
+	ClaimVideoImport(lease time.Duration) (*VideoImport, error)
+	UpdateVideoImportProgress(videoID string, bytesFetched int64) error
+	CompleteVideoImport(videoID, storagePath string, size int64, message *OutboxMessage) (bool, error)
+	RetryVideoImport(videoID string, delay time.Duration, reason string) error
+	FailVideoImport(videoID, reason string) error
+	QueueVideoRun(videoID string, message *OutboxMessage) (*VideoRun, error)
+	GetVideoRuns(videoID string) ([]*VideoRun, error)
+	SetCurrentVideoRun(videoID, runID string) (bool, error)
+	ListRetentionCandidates(before time.Time, afterID string, limit int) ([]*Video, error)
+	ApplyRetention(videoID string, deleteRaw, expire bool) ([]string, error)
+	MarkRetentionWarned(videoID string, message *OutboxMessage) (bool, error)
+	CreateBatch(batch *Batch) error
+	GetBatch(id string) (*Batch, error)
+	GetBatchVideos(batchID string) ([]*Video, error)
+	SealBatch(id string) error
+	ListVideos(query VideoListQuery) (*VideoPage, error)
+	CountActiveVideos(userID string) (int, error)
+	GetUserQuota(userID string) (*UserQuota, error)
+	SetUserQuota(quota *UserQuota) error
+	GetQuotaUsage(userID string, since time.Time) (*QuotaUsage, error)
 	UpdateVideo(video *Video) error
-	DeleteVideo(id string) error
+	TrashVideo(id string) (bool, error)
+	RestoreVideo(id string) (bool, error)
+	ListTrashedVideos(before time.Time, afterID string, limit int) ([]*Video, error)
+	PurgeVideo(id string, trashedBefore time.Time) ([]string, error)
+	CreateShareLink(link *ShareLink) error
+	GetShareLinks(videoID string) ([]*ShareLink, error)
+	GetShareLinkByToken(tokenHash string) (*ShareLink, error)
+	UseShareLink(id string) (bool, error)
+	RevokeShareLink(videoID, id string) (bool, error)
 	GetUserStats(userID string) (*UserStats, error)
 	GetSystemStats() (*SystemStats, error)
 
@@ -21,17 +62,30 @@ type DatabaseInterface interface {
 
 type MinIOInterface interface {
 	UploadFile(reader io.Reader, filename string, size int64) (string, error)
+	NewMultipartUpload(filename string) (objectName, uploadID string, err error)
+	UploadPart(objectName, uploadID string, partNumber int, reader io.Reader, size int64) error
+	CompleteMultipartUpload(objectName, uploadID string) error
+	AbortMultipartUpload(objectName, uploadID string) error
+	PutRawObject(objectName string, reader io.Reader, size int64) error
+	GetRawObject(objectName string) (io.ReadCloser, error)
+	GetRawObjectRange(objectName string, start, end int64) (io.ReadCloser, error)
+	StatRawObject(objectName string) (minio.ObjectInfo, error)
+	PresignPutObject(objectName, contentType string, size int64, checksumSHA256 string, expires time.Duration) (string, error)
 	DeleteFile(objectName string) error
-	GetFileStream(objectName string) (*minio.Object, error)
+	GetProcessedObject(objectName string) (io.ReadCloser, error)
+	StatProcessedObject(objectName string) (minio.ObjectInfo, error)
+	GetProcessedObjectRange(objectName string, start, end int64) (io.ReadCloser, error)
 }
 
 type RabbitMQInterface interface {
-	PublishVideoUpload(message VideoProcessingMessage) error
+	PublishConfirmed(ctx context.Context, exchange, routingKey string, body []byte, priority int) error
+	SubscribeVideoEvents(consumerTag string) (<-chan amqp.Delivery, error)
     Ping() error
     Close() error
 }
 
 type AuthServiceClient interface {
 	CreateAuditLog(req AuditLogRequest) error
+	GetUserRole(userID string) (string, error)
 }
 
@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 type User struct {
 	ID              string     `json:"id" db:"id"`
@@ -35,6 +39,12 @@ type Video struct {
 	QueuedAt              *time.Time `json:"queued_at,omitempty" db:"queued_at"`
 	ProcessingStartedAt   *time.Time `json:"processing_started_at,omitempty" db:"processing_started_at"`
 	ProcessingCompletedAt *time.Time `json:"processing_completed_at,omitempty" db:"processing_completed_at"`
+	BatchID               *string    `json:"batch_id,omitempty" db:"batch_id"`
+	CurrentRunID          *string    `json:"current_run_id,omitempty" db:"current_run_id"`
+	RawDeletedAt          *time.Time `json:"raw_deleted_at,omitempty" db:"raw_deleted_at"`
+	ExpiredAt             *time.Time `json:"expired_at,omitempty" db:"expired_at"`
+	RetentionWarnedAt     *time.Time `json:"retention_warned_at,omitempty" db:"retention_warned_at"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type Session struct {
@@ -89,6 +99,48 @@ type AuditLog struct {
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
+// Orders a video listing can be sorted in.
+const (
+	VideoSortCreated  = "created_at"
+	VideoSortSize     = "size"
+	VideoSortDuration = "duration"
+)
+
+// VideoListQuery selects one page of a user's videos. Zero values leave a
+// filter out. Trashed lists the videos in the trash instead of the others.
+type VideoListQuery struct {
+	UserID      string
+	Statuses    []string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Search      string
+	Sort        string
+	Descending  bool
+	After       *VideoCursor
+	Limit       int
+	Trashed     bool
+}
+
+// VideoCursor is the position of a video in a listing: its value in the sort
+// column and its id, which breaks ties. Sort and Descending record the order
+// it was taken in.
+type VideoCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d"`
+	Value      string `json:"v"`
+	ID         string `json:"id"`
+}
+
+// CursorTimeFormat is how a cursor holds a created_at value, keeping the
+// microseconds the column stores.
+const CursorTimeFormat = "2006-01-02T15:04:05.999999"
+
+// VideoPage is one page of a listing. Next is nil on the last page.
+type VideoPage struct {
+	Videos []*Video
+	Next   *VideoCursor
+}
+
 type UserStats struct {
 	TotalVideos       int     `json:"total_videos"`
 	CompletedVideos   int     `json:"completed_videos"`
@@ -111,16 +163,238 @@ type SystemStats struct {
 }
 
 type VideoProcessingMessage struct {
-	VideoID     string `json:"video_id"`
-	UserID      string `json:"user_id"`
-	Filename    string `json:"filename"`
-	StoragePath string `json:"storage_path"`
-	Priority    int    `json:"priority"`
+	VideoID     string            `json:"video_id"`
+	UserID      string            `json:"user_id"`
+	Filename    string            `json:"filename"`
+	StoragePath string            `json:"storage_path"`
+	Priority    int               `json:"priority"`
+	Options     ProcessingOptions `json:"options"`
+	RunID       string            `json:"run_id,omitempty"`
+	Reprocess   bool              `json:"reprocess,omitempty"`
+}
+
+type ProcessingOptions struct {
+	SnapToChapters bool      `json:"snap_to_chapters,omitempty"`
+	Start          *float64  `json:"start,omitempty"`
+	End            *float64  `json:"end,omitempty"`
+	Timestamps     []float64 `json:"timestamps,omitempty"`
+}
+
+// VideoRun is one processing of a video's stored file with a set of options.
+// The video shows the results of its current run; a reprocess starts a new
+// run and keeps the earlier ones, each with its own zip.
+type VideoRun struct {
+	ID                    string            `json:"id" db:"id"`
+	VideoID               string            `json:"video_id" db:"video_id"`
+	Options               ProcessingOptions `json:"options" db:"options"`
+	Status                string            `json:"status" db:"status"`
+	ZipPath               *string           `json:"zip_path,omitempty" db:"zip_path"`
+	ZipSizeBytes          *int64            `json:"zip_size_bytes,omitempty" db:"zip_size_bytes"`
+	FrameCount            *int              `json:"frame_count,omitempty" db:"frame_count"`
+	ErrorMessage          *string           `json:"error_message,omitempty" db:"error_message"`
+	QueuedAt              time.Time         `json:"queued_at" db:"queued_at"`
+	ProcessingStartedAt   *time.Time        `json:"processing_started_at,omitempty" db:"processing_started_at"`
+	ProcessingCompletedAt *time.Time        `json:"processing_completed_at,omitempty" db:"processing_completed_at"`
+	CreatedAt             time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ShareLink lets anyone holding its token download a video's frames until it
+// expires, runs out of downloads or is revoked. Only a hash of the token is
+// kept; the token itself is shown once, when the link is created.
+type ShareLink struct {
+	ID            string     `json:"id" db:"id"`
+	VideoID       string     `json:"video_id" db:"video_id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	TokenHash     string     `json:"-" db:"token_hash"`
+	PasswordHash  *string    `json:"-" db:"password_hash"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	MaxDownloads  *int       `json:"max_downloads,omitempty" db:"max_downloads"`
+	DownloadCount int        `json:"download_count" db:"download_count"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Usable reports whether the link still allows downloads at the given time.
+func (l *ShareLink) Usable(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt) &&
+		(l.MaxDownloads == nil || l.DownloadCount < *l.MaxDownloads)
+}
+
+// ErrVideoBusy is returned when a video is reprocessed while it still has a
+// run queued or in progress.
+var ErrVideoBusy = errors.New("video is still being processed")
+
+// VideoStatusExpired marks a video whose zips, and for a failed video its
+// upload, were removed by the retention janitor.
+const VideoStatusExpired = "expired"
+
+// NotificationVideoExpiring warns a user that the retention janitor is about
+// to remove a video's files.
+const NotificationVideoExpiring = "video_expiring"
+
+// OutboxMessage is a broker message stored alongside the change that caused
+// it, waiting for the relay to publish it.
+type OutboxMessage struct {
+	ID          string    `json:"id" db:"id"`
+	AggregateID string    `json:"aggregate_id" db:"aggregate_id"`
+	Exchange    string    `json:"exchange" db:"exchange"`
+	RoutingKey  string    `json:"routing_key" db:"routing_key"`
+	Payload     []byte    `json:"payload" db:"payload"`
+	Priority    int       `json:"priority" db:"priority"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// UploadSession tracks an upload that happens over several requests. A tus
+// upload stores its bytes as parts of a MinIO multipart upload, keeping
+// anything short of a full part in a tail object until the next request brings
+// enough data to flush it. A direct upload is a single presigned PUT the
+// client makes to MinIO itself, checked against the declared size and
+// checksum when the client completes it.
+type UploadSession struct {
+	ID                string            `json:"id" db:"id"`
+	UserID            string            `json:"user_id" db:"user_id"`
+	VideoID           string            `json:"video_id" db:"video_id"`
+	Filename          string            `json:"filename" db:"filename"`
+	OriginalName      string            `json:"original_name" db:"original_name"`
+	StoragePath       string            `json:"storage_path" db:"storage_path"`
+	Method            string            `json:"method" db:"upload_method"`
+	MultipartUploadID string            `json:"-" db:"multipart_upload_id"`
+	ContentType       string            `json:"content_type,omitempty" db:"content_type"`
+	ChecksumSHA256    string            `json:"checksum_sha256,omitempty" db:"checksum_sha256"`
+	SizeBytes         int64             `json:"size_bytes" db:"size_bytes"`
+	OffsetBytes       int64             `json:"offset_bytes" db:"offset_bytes"`
+	PartsCount        int               `json:"parts_count" db:"parts_count"`
+	TailBytes         int64             `json:"tail_bytes" db:"tail_bytes"`
+	Options           ProcessingOptions `json:"options" db:"options"`
+	BatchID           *string           `json:"batch_id,omitempty" db:"batch_id"`
+	Status            string            `json:"status" db:"status"`
+	LockedUntil       *time.Time        `json:"-" db:"locked_until"`
+	ExpiresAt         time.Time         `json:"expires_at" db:"expires_at"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+
+	// Deleted is set once the session row is gone, so its lease is not
+	// written back. It is not stored.
+	Deleted bool `json:"-" db:"-"`
+}
+
+// TailObjectName is where a tus upload keeps the bytes that do not yet fill
+// a multipart part.
+func (s *UploadSession) TailObjectName() string {
+	return fmt.Sprintf("uploads/%s.tail", s.ID)
+}
+
+// Upload session states. An assembled session has all its bytes in a single
+// object but no video record yet.
+const (
+	UploadStatusUploading = "uploading"
+	UploadStatusAssembled = "assembled"
+	UploadStatusCompleted = "completed"
+)
+
+// Ways an upload session receives its bytes.
+const (
+	UploadMethodTus       = "tus"
+	UploadMethodPresigned = "presigned"
+)
+
+// ErrUploadLocked is returned when another request is writing to the same
+// upload session.
+var ErrUploadLocked = errors.New("upload session is locked by another request")
+
+// VideoImport is a pending fetch of a video from a remote URL. UserID,
+// Filename and Priority come from the video it belongs to.
+type VideoImport struct {
+	VideoID       string            `json:"video_id" db:"video_id"`
+	UserID        string            `json:"user_id" db:"user_id"`
+	Filename      string            `json:"filename" db:"filename"`
+	Priority      int               `json:"priority" db:"priority"`
+	SourceURL     string            `json:"source_url" db:"source_url"`
+	Options       ProcessingOptions `json:"options" db:"options"`
+	Attempts      int               `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time         `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+}
+
+// Batch groups videos uploaded together. Videos join it while it is open;
+// once it is sealed, its owner is notified a single time, when the last of
+// them finishes processing.
+type Batch struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	SealedAt   *time.Time `json:"sealed_at,omitempty" db:"sealed_at"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty" db:"notified_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ErrBatchUploadsPending is returned when a batch is sealed while uploads
+// meant for it are still in progress.
+var ErrBatchUploadsPending = errors.New("batch still has uploads in progress")
+
+// NotificationBatchCompleted is the notification sent once for a whole batch,
+// in place of one per video.
+const NotificationBatchCompleted = "batch_completed"
+
+// UserQuota overrides a user's plan limits. A nil field keeps the plan's
+// value; zero removes the limit.
+type UserQuota struct {
+	UserID           string    `json:"user_id" db:"user_id"`
+	MaxStorageBytes  *int64    `json:"max_storage_bytes,omitempty" db:"max_storage_bytes"`
+	MaxVideosPerDay  *int64    `json:"max_videos_per_day,omitempty" db:"max_videos_per_day"`
+	MaxFileSizeBytes *int64    `json:"max_file_size_bytes,omitempty" db:"max_file_size_bytes"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// QuotaUsage is what a user currently counts against their quota. Reserved
+// bytes and videos belong to resumable and direct uploads still in progress.
+type QuotaUsage struct {
+	StoredBytes    int64 `json:"stored_bytes"`
+	ReservedBytes  int64 `json:"reserved_bytes"`
+	VideosToday    int64 `json:"videos_today"`
+	ReservedVideos int64 `json:"reserved_videos"`
+}
+
+// Routing keys for the lifecycle events processing-service publishes on
+// video.exchange.
+const (
+	EventVideoProcessingStarted   = "video.processing.started"
+	EventVideoProcessingCompleted = "video.processing.completed"
+	EventVideoProcessingFailed    = "video.processing.failed"
+)
+
+// VideoEvent is a processing state change reported by processing-service.
+// RunID is empty for messages queued before videos had runs.
+type VideoEvent struct {
+	EventID      string    `json:"event_id"`
+	Type         string    `json:"type"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	VideoID      string    `json:"video_id"`
+	UserID       string    `json:"user_id"`
+	RunID        string    `json:"run_id,omitempty"`
+	ZipPath      string    `json:"zip_path,omitempty"`
+	ZipSizeBytes int64     `json:"zip_size_bytes,omitempty"`
+	FrameCount   int       `json:"frame_count,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+}
+
+// FrameInfo is an entry of the frames.json manifest processing-service puts
+// in each zip: a frame and the moment of the video it was taken at, in
+// seconds.
+type FrameInfo struct {
+	Index     int     `json:"index"`
+	Name      string  `json:"name"`
+	Timestamp float64 `json:"timestamp_seconds"`
 }
 
 type NotificationMessage struct {
 	UserID  string `json:"user_id"`
-	VideoID string `json:"video_id"`
+	VideoID string `json:"video_id,omitempty"`
+	BatchID string `json:"batch_id,omitempty"`
 	Type    string `json:"type"`
 	Subject string `json:"subject"`
 	Message string `json:"message"`
@@ -0,0 +1,145 @@
+// Package archive reads zips kept in object storage with ranged reads, so a
+// single file can be pulled out without downloading the whole archive.
+package archive
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tailSize is how much of the end of an archive is fetched up front. It holds
+// the central directory of archives with several thousand entries, so
+// listing one usually takes a single request.
+const tailSize = 1 << 20
+
+// RangeFunc reads bytes start to end of the archive, both inclusive.
+type RangeFunc func(start, end int64) (io.ReadCloser, error)
+
+// Reader is the central directory of an archive. It is safe for concurrent
+// use and can be kept around to open entries later.
+type Reader struct {
+	zip  *zip.Reader
+	read RangeFunc
+}
+
+// NewReader reads the central directory of an archive of the given size.
+func NewReader(size int64, read RangeFunc) (*Reader, error) {
+	ra := &rangeReaderAt{size: size, read: read}
+	if err := ra.loadTail(min(size, tailSize)); err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	// Past the directory only local headers are read, which are scattered
+	// across the archive; the tail is not worth keeping for them.
+	ra.tail = nil
+
+	return &Reader{zip: zr, read: read}, nil
+}
+
+// Files lists the entries of the archive in directory order.
+func (r *Reader) Files() []*zip.File {
+	return r.zip.File
+}
+
+// File returns the entry with the given name, or nil.
+func (r *Reader) File(name string) *zip.File {
+	for _, f := range r.zip.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Open returns the contents of an entry, read with one ranged request for
+// its compressed data.
+func (r *Reader) Open(f *zip.File) (io.ReadCloser, error) {
+	if f.Method != zip.Store && f.Method != zip.Deflate {
+		return nil, fmt.Errorf("%s: unsupported compression method %d", f.Name, f.Method)
+	}
+
+	body, err := r.OpenRaw(f)
+	if err != nil {
+		return nil, err
+	}
+	if f.Method == zip.Store {
+		return body, nil
+	}
+	return &inflater{ReadCloser: flate.NewReader(body), body: body}, nil
+}
+
+// OpenRaw returns the entry's data as stored, still compressed, so it can be
+// copied into another zip with zip.Writer.CreateRaw.
+func (r *Reader) OpenRaw(f *zip.File) (io.ReadCloser, error) {
+	offset, err := f.DataOffset()
+	if err != nil {
+		return nil, err
+	}
+	if f.CompressedSize64 == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	return r.read(offset, offset+int64(f.CompressedSize64)-1)
+}
+
+type inflater struct {
+	io.ReadCloser
+	body io.Closer
+}
+
+func (i *inflater) Close() error {
+	i.ReadCloser.Close()
+	return i.body.Close()
+}
+
+// rangeReaderAt serves zip.Reader from ranged reads, answering from the
+// prefetched tail of the archive where it can.
+type rangeReaderAt struct {
+	size      int64
+	read      RangeFunc
+	tail      []byte
+	tailStart int64
+}
+
+func (r *rangeReaderAt) loadTail(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	r.tailStart = r.size - n
+	r.tail = make([]byte, n)
+	return r.fetch(r.tail, r.tailStart)
+}
+
+func (r *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off >= r.size {
+		return 0, io.EOF
+	}
+	n := min(int64(len(p)), r.size-off)
+
+	if r.tail != nil && off >= r.tailStart {
+		copy(p, r.tail[off-r.tailStart:off-r.tailStart+n])
+	} else if err := r.fetch(p[:n], off); err != nil {
+		return 0, err
+	}
+
+	if n < int64(len(p)) {
+		return int(n), io.EOF
+	}
+	return int(n), nil
+}
+
+func (r *rangeReaderAt) fetch(p []byte, off int64) error {
+	body, err := r.read(off, off+int64(len(p))-1)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	_, err = io.ReadFull(body, p)
+	return err
+}
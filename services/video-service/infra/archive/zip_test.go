@@ -0,0 +1,174 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rangeRequest struct {
+	start, end int64
+}
+
+// testArchive builds a zip in memory and returns it with a RangeFunc over
+// it that records every request.
+func testArchive(t *testing.T, files map[string]string, stored ...string) ([]byte, RangeFunc, *[]rangeRequest) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedNames(files) {
+		method := zip.Deflate
+		for _, s := range stored {
+			if s == name {
+				method = zip.Store
+			}
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(files[name]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	data := buf.Bytes()
+	requests := &[]rangeRequest{}
+	read := func(start, end int64) (io.ReadCloser, error) {
+		*requests = append(*requests, rangeRequest{start, end})
+		if start < 0 || end >= int64(len(data)) || end < start {
+			return nil, errors.New("bad range")
+		}
+		return io.NopCloser(bytes.NewReader(data[start : end+1])), nil
+	}
+	return data, read, requests
+}
+
+func sortedNames(files map[string]string) []string {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readEntry(t *testing.T, r *Reader, name string) string {
+	f := r.File(name)
+	assert.NotNil(t, f)
+	rc, err := r.Open(f)
+	assert.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestNewReader_ListsWithOneRequest(t *testing.T) {
+	data, read, requests := testArchive(t, map[string]string{
+		"frame_0001.png": "first",
+		"frame_0002.png": "second",
+		"frames.json":    "[]",
+	})
+
+	r, err := NewReader(int64(len(data)), read)
+
+	assert.NoError(t, err)
+	names := []string{}
+	for _, f := range r.Files() {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"frame_0001.png", "frame_0002.png", "frames.json"}, names)
+	assert.Equal(t, []rangeRequest{{0, int64(len(data)) - 1}}, *requests)
+}
+
+func TestNewReader_LargeArchiveFetchesOnlyTheTail(t *testing.T) {
+	files := map[string]string{"big.bin": strings.Repeat("x", tailSize), "small.txt": "hi"}
+	data, read, requests := testArchive(t, files, "big.bin")
+
+	_, err := NewReader(int64(len(data)), read)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rangeRequest{int64(len(data)) - tailSize, int64(len(data)) - 1}, (*requests)[0])
+	assert.Len(t, *requests, 1)
+}
+
+func TestReader_Open(t *testing.T) {
+	files := map[string]string{
+		"deflated.txt": strings.Repeat("frame data ", 100),
+		"stored.txt":   "stored as is",
+		"empty.txt":    "",
+	}
+	data, read, requests := testArchive(t, files, "stored.txt")
+	r, err := NewReader(int64(len(data)), read)
+	assert.NoError(t, err)
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			*requests = nil
+			assert.Equal(t, content, readEntry(t, r, name))
+			// One read for the local header, one for the data.
+			assert.LessOrEqual(t, len(*requests), 2)
+		})
+	}
+}
+
+func TestReader_OpenRawCopiesIntoAnotherZip(t *testing.T) {
+	content := strings.Repeat("frame data ", 100)
+	data, read, _ := testArchive(t, map[string]string{"a.txt": content})
+	r, _ := NewReader(int64(len(data)), read)
+	f := r.File("a.txt")
+
+	raw, err := r.OpenRaw(f)
+	assert.NoError(t, err)
+	defer raw.Close()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	header := f.FileHeader
+	w, err := zw.CreateRaw(&header)
+	assert.NoError(t, err)
+	_, err = io.Copy(w, raw)
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	rc, err := zr.File[0].Open()
+	assert.NoError(t, err)
+	copied, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(copied))
+}
+
+func TestReader_FileMissing(t *testing.T) {
+	data, read, _ := testArchive(t, map[string]string{"a.txt": "a"})
+	r, err := NewReader(int64(len(data)), read)
+	assert.NoError(t, err)
+
+	assert.Nil(t, r.File("b.txt"))
+}
+
+func TestNewReader_NotAZip(t *testing.T) {
+	data := []byte("definitely not a zip archive")
+	read := func(start, end int64) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data[start : end+1])), nil
+	}
+
+	_, err := NewReader(int64(len(data)), read)
+
+	assert.Error(t, err)
+}
+
+func TestNewReader_ReadError(t *testing.T) {
+	read := func(start, end int64) (io.ReadCloser, error) {
+		return nil, errors.New("storage down")
+	}
+
+	_, err := NewReader(100, read)
+
+	assert.EqualError(t, err, "storage down")
+}
@@ -1,18 +1,26 @@
 package broker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"video-service/domain"
 	"video-service/infra/utils"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type RabbitMQClient struct {
+	// connMu guards conn and channel, which ensureConnection replaces when
+	// the broker drops them.
+	connMu  sync.Mutex
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	url     string
+
+	confirmMu      sync.Mutex
+	confirmChannel *amqp.Channel
 }
 
 func InitRabbitMQ() *RabbitMQClient {
@@ -81,6 +89,37 @@ func (r *RabbitMQClient) declareInfrastructure() error {
 		return fmt.Errorf("failed to bind video queue: %v", err)
 	}
 
+	_, err = r.channel.QueueDeclare("video.events.queue", true, false, false, false,
+		amqp.Table{
+			"x-dead-letter-exchange":    "video.dlx",
+			"x-dead-letter-routing-key": "video.events.dlq",
+		})
+	if err != nil {
+		return fmt.Errorf("failed to declare video events queue: %v", err)
+	}
+
+	err = r.channel.QueueBind("video.events.queue", "video.processing.*", "video.exchange", false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind video events queue: %v", err)
+	}
+
+	// status-service projects every video.changed snapshot into its read
+	// model; the queue is declared here as well so none is lost before it
+	// first starts. The arguments must match status-service's.
+	_, err = r.channel.QueueDeclare("status.projection.queue", true, false, false, false,
+		amqp.Table{
+			"x-dead-letter-exchange":    "video.dlx",
+			"x-dead-letter-routing-key": "status.projection.dlq",
+		})
+	if err != nil {
+		return fmt.Errorf("failed to declare status projection queue: %v", err)
+	}
+
+	err = r.channel.QueueBind("status.projection.queue", "video.changed", "video.exchange", false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind status projection queue: %v", err)
+	}
+
 	err = r.channel.QueueBind("notification.queue", "notification.#", "notification.exchange", false, nil)
 	if err != nil {
 		return fmt.Errorf("failed to bind notification queue: %v", err)
@@ -94,35 +133,49 @@ func (r *RabbitMQClient) declareInfrastructure() error {
 	return nil
 }
 
-func (r *RabbitMQClient) ensureConnection() error {
+// ensureConnection reconnects if the broker dropped the connection or the
+// channel, and returns the ones to use. Callers keep the returned values
+// rather than reading the fields, which another goroutine may replace.
+func (r *RabbitMQClient) ensureConnection() (*amqp.Connection, *amqp.Channel, error) {
+	r.connMu.Lock()
+	defer r.connMu.Unlock()
+
 	if r.conn == nil || r.conn.IsClosed() {
 		log.Println("RabbitMQ connection closed, reconnecting...")
 		r.connect()
 	}
 
 	if r.conn == nil || r.conn.IsClosed() {
-		return fmt.Errorf("failed to reconnect to RabbitMQ")
+		return nil, nil, fmt.Errorf("failed to reconnect to RabbitMQ")
 	}
 
 	if r.channel == nil || r.channel.IsClosed() {
 		var err error
 		r.channel, err = r.conn.Channel()
 		if err != nil {
-			return fmt.Errorf("failed to recreate channel: %v", err)
+			return nil, nil, fmt.Errorf("failed to recreate channel: %v", err)
 		}
 	}
 
-	return nil
+	return r.conn, r.channel, nil
 }
 
 func (r *RabbitMQClient) Ping() error {
-	if err := r.ensureConnection(); err != nil {
+	if _, _, err := r.ensureConnection(); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (r *RabbitMQClient) Close() error {
+	r.confirmMu.Lock()
+	defer r.confirmMu.Unlock()
+	r.connMu.Lock()
+	defer r.connMu.Unlock()
+
+	if r.confirmChannel != nil {
+		r.confirmChannel.Close()
+	}
 	if r.channel != nil {
 		r.channel.Close()
 	}
@@ -132,32 +185,74 @@ func (r *RabbitMQClient) Close() error {
 	return nil
 }
 
-func (r *RabbitMQClient) PublishVideoUpload(message domain.VideoProcessingMessage) error {
-	if err := r.ensureConnection(); err != nil {
-		return err
-	}
+// PublishConfirmed publishes on a channel in confirm mode and waits for the
+// broker to take responsibility for the message before returning.
+func (r *RabbitMQClient) PublishConfirmed(ctx context.Context, exchange, routingKey string, body []byte, priority int) error {
+	r.confirmMu.Lock()
+	defer r.confirmMu.Unlock()
 
-	body, err := json.Marshal(message)
+	conn, _, err := r.ensureConnection()
 	if err != nil {
 		return err
 	}
 
-	return r.channel.Publish(
-		"video.exchange",
-		"video.upload",
+	if r.confirmChannel == nil || r.confirmChannel.IsClosed() {
+		ch, err := conn.Channel()
+		if err != nil {
+			return fmt.Errorf("failed to open confirm channel: %v", err)
+		}
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return fmt.Errorf("failed to enable publisher confirms: %v", err)
+		}
+		r.confirmChannel = ch
+	}
+
+	confirmation, err := r.confirmChannel.PublishWithDeferredConfirmWithContext(ctx,
+		exchange,
+		routingKey,
 		false,
 		false,
 		amqp.Publishing{
 			DeliveryMode: amqp.Persistent,
 			ContentType:  "application/json",
 			Body:         body,
-			Priority:     uint8(message.Priority),
+			Priority:     uint8(priority),
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed waiting for publisher confirm: %v", err)
+	}
+	if !acked {
+		return fmt.Errorf("broker rejected message for %s/%s", exchange, routingKey)
+	}
+	return nil
+}
+
+func (r *RabbitMQClient) SubscribeVideoEvents(consumerTag string) (<-chan amqp.Delivery, error) {
+	_, channel, err := r.ensureConnection()
+	if err != nil {
+		return nil, err
+	}
+	return channel.Consume(
+		"video.events.queue",
+		consumerTag,
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
 }
 
 func (r *RabbitMQClient) PublishNotification(message domain.NotificationMessage) error {
-	if err := r.ensureConnection(); err != nil {
+	_, channel, err := r.ensureConnection()
+	if err != nil {
 		return err
 	}
 
@@ -166,7 +261,7 @@ func (r *RabbitMQClient) PublishNotification(message domain.NotificationMessage)
 		return err
 	}
 
-	return r.channel.Publish(
+	return channel.Publish(
 		"notification.exchange",
 		"notification.email",
 		false,
@@ -180,10 +275,11 @@ func (r *RabbitMQClient) PublishNotification(message domain.NotificationMessage)
 }
 
 func (r *RabbitMQClient) GetQueueStats(queueName string) (int, error) {
-	if err := r.ensureConnection(); err != nil {
+	_, channel, err := r.ensureConnection()
+	if err != nil {
 		return 0, err
 	}
-	queue, err := r.channel.QueueInspect(queueName)
+	queue, err := channel.QueueInspect(queueName)
 	if err != nil {
 		return 0, err
 	}
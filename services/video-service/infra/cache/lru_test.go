@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_GetAndAdd(t *testing.T) {
+	c := NewLRU[string, int](2)
+
+	c.Add("a", 1)
+	value, ok := c.Get("a")
+
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2)
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a")
+	c.Add("c", 3)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_AddReplaces(t *testing.T) {
+	c := NewLRU[string, int](2)
+
+	c.Add("a", 1)
+	c.Add("a", 2)
+
+	value, _ := c.Get("a")
+	assert.Equal(t, 2, value)
+	assert.Equal(t, 1, c.Len())
+}
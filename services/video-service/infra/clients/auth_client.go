@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"video-service/domain"
+
+	"shared/clienterr"
 )
 
 type AuthServiceClient struct {
@@ -41,18 +43,18 @@ func (c *AuthServiceClient) GetUserByID(userID string) (*User, error) {
 	
 	resp, err := c.client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+		return nil, fmt.Errorf("failed to get user by ID: %w", clienterr.Transient(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, &clienterr.StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 	
 	var user User
 	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", clienterr.Permanent(err))
 	}
 	
 	return &user, nil
@@ -63,23 +65,32 @@ func (c *AuthServiceClient) GetUserByEmail(email string) (*User, error) {
 	
 	resp, err := c.client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
+		return nil, fmt.Errorf("failed to get user by email: %w", clienterr.Transient(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, &clienterr.StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 	
 	var user User
 	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", clienterr.Permanent(err))
 	}
 	
 	return &user, nil
 }
 
+// GetUserRole returns the role of a user, which is also their quota plan.
+func (c *AuthServiceClient) GetUserRole(userID string) (string, error) {
+	user, err := c.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
 type ValidateTokenRequest struct {
 	Token string `json:"token"`
 }
@@ -101,13 +112,13 @@ func (c *AuthServiceClient) ValidateToken(token string) (*ValidateTokenResponse,
 	
 	resp, err := c.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate token: %w", err)
+		return nil, fmt.Errorf("failed to validate token: %w", clienterr.Transient(err))
 	}
 	defer resp.Body.Close()
 
 	var response ValidateTokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", clienterr.Permanent(err))
 	}
 	
 	return &response, nil
@@ -123,12 +134,13 @@ func (c *AuthServiceClient) CreateAuditLog(req domain.AuditLogRequest) error {
 
 	resp, err := c.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to send audit log: %w", err)
+		return fmt.Errorf("failed to send audit log: %w", clienterr.Transient(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return &clienterr.StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return nil
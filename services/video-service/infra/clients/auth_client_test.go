@@ -9,6 +9,8 @@ import (
 
 	"video-service/domain"
 
+	"shared/clienterr"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -101,6 +103,30 @@ func TestGetUserByEmail_InvalidJSON(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGetUserRole_Success(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/internal/users/u1", r.URL.Path)
+		json.NewEncoder(w).Encode(&User{ID: "u1", Role: "admin"})
+	}))
+	defer srv.Close()
+
+	c := NewAuthServiceClient(srv.URL)
+	role, err := c.GetUserRole("u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", role)
+}
+
+func TestGetUserRole_NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := NewAuthServiceClient(srv.URL)
+	_, err := c.GetUserRole("u1")
+	assert.ErrorIs(t, err, clienterr.ErrNotFound)
+}
+
 func TestValidateToken_Success(t *testing.T) {
 	resp := &ValidateTokenResponse{Valid: true, UserID: "u1"}
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxBatchFiles bounds how many videos one batch upload request may carry.
+const maxBatchFiles = 100
+
+// Overall states of a batch, derived from its videos.
+const (
+	BatchStatusOpen       = "open"
+	BatchStatusProcessing = "processing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusPartial    = "completed_with_errors"
+	BatchStatusFailed     = "failed"
+)
+
+// CreateBatchRequest opens a batch that videos are added to one by one.
+type CreateBatchRequest struct {
+	Name string `json:"name"`
+}
+
+// BatchResponse reports a batch with its videos and counts by video status.
+// Progress is the percentage of videos that are done, whatever the outcome.
+type BatchResponse struct {
+	ID          string          `json:"id"`
+	UserID      string          `json:"user_id"`
+	Name        string          `json:"name"`
+	Status      string          `json:"status"`
+	Total       int             `json:"total"`
+	Counts      map[string]int  `json:"counts"`
+	Progress    float64         `json:"progress"`
+	DownloadURL *string         `json:"download_url,omitempty"`
+	Videos      []VideoResponse `json:"videos"`
+	CreatedAt   time.Time       `json:"created_at"`
+	SealedAt    *time.Time      `json:"sealed_at,omitempty"`
+	NotifiedAt  *time.Time      `json:"notified_at,omitempty"`
+}
+
+// CreateBatch starts a batch. A JSON request opens an empty batch: videos
+// join it by passing its batch_id to any upload or import, and it is closed
+// with CloseBatch. A multipart request carries the videos themselves under
+// "videos", with processing options shared by all of them, and closes the
+// batch once they are queued.
+func (h *VideoHandler) CreateBatch(c *gin.Context) {
+	if c.ContentType() == "application/json" {
+		var req CreateBatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		batch, ok := h.createBatch(c, req.Name)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusCreated, newBatchResponse(batch, nil))
+		return
+	}
+
+	h.uploadBatch(c)
+}
+
+func (h *VideoHandler) uploadBatch(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: "Failed to read batch upload: " + err.Error(),
+		})
+		return
+	}
+
+	headers := form.File["videos"]
+	if len(headers) == 0 {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: "No videos in batch upload",
+		})
+		return
+	}
+	if len(headers) > maxBatchFiles {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: fmt.Sprintf("Too many videos in one batch upload. Max: %d", maxBatchFiles),
+		})
+		return
+	}
+
+	// Every file is checked before any is stored, so a bad file fails the
+	// request without leaving half a batch behind.
+	sizes := make([]int64, len(headers))
+	for i, header := range headers {
+		if rejection := checkUploadFile(header); rejection != nil {
+			rejection.response.Message = header.Filename + ": " + rejection.response.Message
+			c.JSON(rejection.status, rejection.response)
+			return
+		}
+		sizes[i] = header.Size
+	}
+
+	options, err := parseProcessingOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if rejection := h.checkQuota(c, sizes...); rejection != nil {
+		c.JSON(rejection.status, rejection.response)
+		return
+	}
+
+	batch, ok := h.createBatch(c, c.PostForm("name"))
+	if !ok {
+		return
+	}
+
+	for i, header := range headers {
+		if _, rejection := h.storeUpload(c, header, options, &batch.ID); rejection != nil {
+			rejection.response.Message = fmt.Sprintf("%s: %s (batch left open with %d of %d videos)",
+				header.Filename, rejection.response.Message, i, len(headers))
+			rejection.response.BatchID = batch.ID
+			c.JSON(rejection.status, rejection.response)
+			return
+		}
+	}
+
+	if err := h.db.SealBatch(batch.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, UploadResponse{
+			Success: false,
+			Message: "Videos queued but failed to close batch: " + err.Error(),
+			BatchID: batch.ID,
+		})
+		return
+	}
+
+	h.respondBatch(c, http.StatusCreated, batch.ID)
+}
+
+func (h *VideoHandler) createBatch(c *gin.Context, name string) (*domain.Batch, bool) {
+	name = strings.TrimSpace(name)
+	if len(name) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at most 255 characters"})
+		return nil, false
+	}
+
+	now := time.Now()
+	batch := &domain.Batch{
+		ID:        uuid.New().String(),
+		UserID:    c.GetString("user_id"),
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.db.CreateBatch(batch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create batch"})
+		return nil, false
+	}
+	return batch, true
+}
+
+// GetBatch reports a batch's aggregate status.
+func (h *VideoHandler) GetBatch(c *gin.Context) {
+	if _, ok := h.ownedBatch(c); !ok {
+		return
+	}
+	h.respondBatch(c, http.StatusOK, c.Param("batch_id"))
+}
+
+// CloseBatch seals the batch against new videos. Its owner is emailed once
+// every video in it has finished.
+func (h *VideoHandler) CloseBatch(c *gin.Context) {
+	batch, ok := h.ownedBatch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.SealBatch(batch.ID); err != nil {
+		if errors.Is(err, domain.ErrBatchUploadsPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Batch still has uploads in progress"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close batch"})
+		return
+	}
+
+	h.respondBatch(c, http.StatusOK, batch.ID)
+}
+
+// DownloadBatch streams one ZIP holding the ZIP of every completed video in
+// the batch. Videos that failed or are still processing are left out.
+func (h *VideoHandler) DownloadBatch(c *gin.Context) {
+	batch, ok := h.ownedBatch(c)
+	if !ok {
+		return
+	}
+
+	videos, err := h.db.GetBatchVideos(batch.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch batch videos"})
+		return
+	}
+
+	completed := []*domain.Video{}
+	for _, video := range videos {
+		if video.Status == "completed" && video.ZipPath != nil {
+			completed = append(completed, video)
+		}
+	}
+	if len(completed) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No completed videos in batch"})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"batch_%s.zip\"", batch.ID))
+	c.Status(http.StatusOK)
+
+	// Once streaming has started the status cannot change, so on failure the
+	// archive is left without its central directory and the client sees a
+	// corrupt file rather than one silently missing videos.
+	archive := zip.NewWriter(c.Writer)
+	for _, video := range completed {
+		if err := h.addVideoZip(archive, video); err != nil {
+			fmt.Printf("Failed to add video %s to batch %s download: %v\n", video.ID, batch.ID, err)
+			if !c.Writer.Written() {
+				c.Writer.Header().Del("Content-Type")
+				c.Writer.Header().Del("Content-Disposition")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get file stream: %v", err)})
+			}
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		fmt.Printf("Failed to finish batch %s download: %v\n", batch.ID, err)
+	}
+}
+
+// addVideoZip copies a video's ZIP into the archive as is; it is already
+// compressed.
+func (h *VideoHandler) addVideoZip(archive *zip.Writer, video *domain.Video) error {
+	object, err := h.minio.GetProcessedObject(*video.ZipPath)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	header := &zip.FileHeader{Name: batchEntryName(video), Method: zip.Store}
+	if video.ProcessingCompletedAt != nil {
+		header.Modified = *video.ProcessingCompletedAt
+	}
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, object)
+	return err
+}
+
+// batchEntryName names a video's ZIP after the uploaded file, reduced to its
+// base name so no entry can point outside the extraction directory, and made
+// unique with the start of the video ID.
+func batchEntryName(video *domain.Video) string {
+	name := filepath.Base(strings.ReplaceAll(video.OriginalName, "\\", "/"))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name == "" || name == "." || name == "/" {
+		name = "video"
+	}
+	return fmt.Sprintf("%s_%s.zip", name, video.ID[:min(8, len(video.ID))])
+}
+
+// ownedBatch loads the batch named in the path and checks it belongs to the
+// caller.
+func (h *VideoHandler) ownedBatch(c *gin.Context) (*domain.Batch, bool) {
+	batch, err := h.db.GetBatch(c.Param("batch_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Batch not found"})
+		return nil, false
+	}
+	if batch.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+	return batch, true
+}
+
+// batchForUpload resolves the batch_id an upload asks to join. It answers the
+// request and returns false when the batch is not the caller's or is already
+// closed.
+func (h *VideoHandler) batchForUpload(c *gin.Context, batchID string) (*string, bool) {
+	if batchID == "" {
+		return nil, true
+	}
+
+	batch, err := h.db.GetBatch(batchID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Batch not found"})
+		return nil, false
+	}
+	if batch.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+	if batch.SealedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Batch is closed"})
+		return nil, false
+	}
+	return &batch.ID, true
+}
+
+func (h *VideoHandler) respondBatch(c *gin.Context, status int, batchID string) {
+	batch, err := h.db.GetBatch(batchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch batch"})
+		return
+	}
+	videos, err := h.db.GetBatchVideos(batchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch batch videos"})
+		return
+	}
+	c.JSON(status, newBatchResponse(batch, videos))
+}
+
+func newBatchResponse(batch *domain.Batch, videos []*domain.Video) BatchResponse {
+	response := BatchResponse{
+		ID:         batch.ID,
+		UserID:     batch.UserID,
+		Name:       batch.Name,
+		Total:      len(videos),
+		Counts:     map[string]int{},
+		Videos:     make([]VideoResponse, 0, len(videos)),
+		CreatedAt:  batch.CreatedAt,
+		SealedAt:   batch.SealedAt,
+		NotifiedAt: batch.NotifiedAt,
+	}
+
+	for _, video := range videos {
+		response.Counts[video.Status]++
+		response.Videos = append(response.Videos, newVideoResponse(video))
+	}
+
+	completed := response.Counts["completed"]
+	finished := completed + response.Counts["failed"] + response.Counts["cancelled"]
+	if response.Total > 0 {
+		response.Progress = math.Round(float64(finished)*1000/float64(response.Total)) / 10
+	}
+
+	switch {
+	case batch.SealedAt == nil:
+		response.Status = BatchStatusOpen
+	case finished < response.Total:
+		response.Status = BatchStatusProcessing
+	case completed == response.Total:
+		response.Status = BatchStatusCompleted
+	case completed == 0:
+		response.Status = BatchStatusFailed
+	default:
+		response.Status = BatchStatusPartial
+	}
+
+	if completed > 0 {
+		downloadURL := fmt.Sprintf("/api/v1/videos/batches/%s/download", batch.ID)
+		response.DownloadURL = &downloadURL
+	}
+
+	return response
+}
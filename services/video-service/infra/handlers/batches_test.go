@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func batchRouter(db *MockDatabase, minio *MockMinIO, authClient *MockAuthClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(db, minio, new(MockRabbitMQ), authClient)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user123")
+	})
+	r.POST("/upload", handler.Upload)
+	r.POST("/batches", handler.CreateBatch)
+	r.GET("/batches/:batch_id", handler.GetBatch)
+	r.POST("/batches/:batch_id/close", handler.CloseBatch)
+	r.GET("/batches/:batch_id/download", handler.DownloadBatch)
+	return r
+}
+
+// multipartBatch builds a batch upload with the given files under "videos".
+func multipartBatch(files map[string][]byte, fields map[string]string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	for name, content := range files {
+		part, _ := writer.CreateFormFile("videos", name)
+		part.Write(content)
+	}
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func serve(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func sealedBatch(id string) *domain.Batch {
+	sealedAt := time.Now()
+	return &domain.Batch{ID: id, UserID: "user123", Name: "incident-42", SealedAt: &sealedAt}
+}
+
+func batchVideo(id, status string) *domain.Video {
+	video := &domain.Video{ID: id, UserID: "user123", OriginalName: id + ".mp4", Status: status}
+	if status == "completed" {
+		zipPath := "2024/01/01/" + id + ".zip"
+		video.ZipPath = &zipPath
+	}
+	return video
+}
+
+func TestCreateBatch_OpenBatch(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := batchRouter(mockDB, new(MockMinIO), new(MockAuthClient))
+
+	mockDB.On("CreateBatch", mock.MatchedBy(func(b *domain.Batch) bool {
+		return b.UserID == "user123" && b.Name == "incident-42" && b.SealedAt == nil
+	})).Return(nil)
+
+	w := postJSON(r, "/batches", gin.H{"name": " incident-42 "})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, BatchStatusOpen, resp.Status)
+	assert.NotEmpty(t, resp.ID)
+	assert.Equal(t, 0, resp.Total)
+	mockDB.AssertExpectations(t)
+}
+
+func TestCreateBatch_MultipartUploadsAndCloses(t *testing.T) {
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	mockAuth := new(MockAuthClient)
+	r := batchRouter(mockDB, mockMinio, mockAuth)
+
+	var batchID string
+	mockDB.On("CreateBatch", mock.MatchedBy(func(b *domain.Batch) bool {
+		batchID = b.ID
+		return b.Name == "incident-42"
+	})).Return(nil)
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/clip", nil).Twice()
+	mockDB.On("CountActiveVideos", "user123").Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.MatchedBy(func(v *domain.Video) bool {
+		return v.BatchID != nil && *v.BatchID == batchID
+	}), withPayload(func(m domain.VideoProcessingMessage) bool {
+		return m.Options.SnapToChapters
+	})).Return(nil).Twice()
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+	mockDB.On("SealBatch", mock.Anything).Return(nil)
+	mockDB.On("GetBatch", mock.Anything).Return(sealedBatch("b1"), nil)
+	mockDB.On("GetBatchVideos", mock.Anything).Return([]*domain.Video{
+		batchVideo("v1", "queued"), batchVideo("v2", "queued"),
+	}, nil)
+
+	body, contentType := multipartBatch(map[string][]byte{"a.mp4": sampleMP4, "b.mkv": sampleMKV},
+		map[string]string{"name": "incident-42", "snap_to_chapters": "true"})
+	req := httptest.NewRequest(http.MethodPost, "/batches", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, BatchStatusProcessing, resp.Status)
+	assert.Equal(t, 2, resp.Counts["queued"])
+	mockDB.AssertCalled(t, "SealBatch", batchID)
+	mockMinio.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestCreateBatch_MultipartRejectsBadFileBeforeStoring(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := batchRouter(mockDB, mockMinio, new(MockAuthClient))
+
+	body, contentType := multipartBatch(map[string][]byte{"a.mp4": sampleMP4, "notes.mp4": []byte("just some text")}, nil)
+	req := httptest.NewRequest(http.MethodPost, "/batches", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "notes.mp4")
+	assert.Contains(t, w.Body.String(), ErrCodeUnsupportedFormat)
+	mockDB.AssertNotCalled(t, "CreateBatch", mock.Anything)
+	mockMinio.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateBatch_MultipartStorageFailureLeavesBatchOpen(t *testing.T) {
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	r := batchRouter(mockDB, mockMinio, new(MockAuthClient))
+
+	mockDB.On("CreateBatch", mock.Anything).Return(nil)
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("minio down"))
+
+	body, contentType := multipartBatch(map[string][]byte{"a.mp4": sampleMP4}, nil)
+	req := httptest.NewRequest(http.MethodPost, "/batches", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var resp UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NotEmpty(t, resp.BatchID)
+	assert.Contains(t, resp.Message, "batch left open with 0 of 1 videos")
+	mockDB.AssertNotCalled(t, "SealBatch", mock.Anything)
+}
+
+func TestCreateBatch_MultipartWithoutVideos(t *testing.T) {
+	r := batchRouter(new(MockDatabase), new(MockMinIO), new(MockAuthClient))
+
+	body, contentType := multipartBatch(nil, map[string]string{"name": "empty"})
+	req := httptest.NewRequest(http.MethodPost, "/batches", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetBatch_AggregateStatus(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := batchRouter(mockDB, new(MockMinIO), new(MockAuthClient))
+
+	mockDB.On("GetBatch", "b1").Return(sealedBatch("b1"), nil)
+	mockDB.On("GetBatchVideos", "b1").Return([]*domain.Video{
+		batchVideo("v1", "completed"), batchVideo("v2", "completed"), batchVideo("v3", "failed"),
+		batchVideo("v4", "processing"), batchVideo("v5", "queued"), batchVideo("v6", "queued"),
+	}, nil)
+
+	w := serve(r, http.MethodGet, "/batches/b1")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, BatchStatusProcessing, resp.Status)
+	assert.Equal(t, 6, resp.Total)
+	assert.Equal(t, map[string]int{"completed": 2, "failed": 1, "processing": 1, "queued": 2}, resp.Counts)
+	assert.Equal(t, 50.0, resp.Progress)
+	assert.Len(t, resp.Videos, 6)
+	if assert.NotNil(t, resp.DownloadURL) {
+		assert.Equal(t, "/api/v1/videos/batches/b1/download", *resp.DownloadURL)
+	}
+}
+
+func TestGetBatch_NotFound(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := batchRouter(mockDB, new(MockMinIO), new(MockAuthClient))
+
+	mockDB.On("GetBatch", "missing").Return(nil, errors.New("sql: no rows in result set"))
+
+	w := serve(r, http.MethodGet, "/batches/missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetBatch_AccessDenied(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := batchRouter(mockDB, new(MockMinIO), new(MockAuthClient))
+
+	batch := sealedBatch("b1")
+	batch.UserID = "someone-else"
+	mockDB.On("GetBatch", "b1").Return(batch, nil)
+
+	w := serve(r, http.MethodGet, "/batches/b1")
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockDB.AssertNotCalled(t, "GetBatchVideos", mock.Anything)
+}
+
+func TestNewBatchResponse_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		sealed   bool
+		statuses []string
+		want     string
+	}{
+		{"open", false, []string{"completed"}, BatchStatusOpen},
+		{"processing", true, []string{"completed", "importing"}, BatchStatusProcessing},
+		{"completed", true, []string{"completed", "completed"}, BatchStatusCompleted},
+		{"with errors", true, []string{"completed", "failed"}, BatchStatusPartial},
+		{"failed", true, []string{"failed", "cancelled"}, BatchStatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := &domain.Batch{ID: "b1"}
+			if tt.sealed {
+				batch = sealedBatch("b1")
+			}
+			videos := []*domain.Video{}
+			for i, status := range tt.statuses {
+				videos = append(videos, batchVideo(string(rune('a'+i)), status))
+			}
+
+			assert.Equal(t, tt.want, newBatchResponse(batch, videos).Status)
+		})
+	}
+}
+
+func TestCloseBatch_Success(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := batchRouter(mockDB, new(MockMinIO), new(MockAuthClient))
+
+	mockDB.On("GetBatch", "b1").Return(&domain.Batch{ID: "b1", UserID: "user123"}, nil).Once()
+	mockDB.On("SealBatch", "b1").Return(nil)
+	mockDB.On("GetBatch", "b1").Return(sealedBatch("b1"), nil)
+	mockDB.On("GetBatchVideos", "b1").Return([]*domain.Video{batchVideo("v1", "queued")}, nil)
+
+	w := serve(r, http.MethodPost, "/batches/b1/close")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, BatchStatusProcessing, resp.Status)
+	assert.NotNil(t, resp.SealedAt)
+}
+
+func TestCloseBatch_UploadsPending(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := batchRouter(mockDB, new(MockMinIO), new(MockAuthClient))
+
+	mockDB.On("GetBatch", "b1").Return(&domain.Batch{ID: "b1", UserID: "user123"}, nil)
+	mockDB.On("SealBatch", "b1").Return(domain.ErrBatchUploadsPending)
+
+	w := serve(r, http.MethodPost, "/batches/b1/close")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestUpload_JoinsOpenBatch(t *testing.T) {
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	mockAuth := new(MockAuthClient)
+	r := batchRouter(mockDB, mockMinio, mockAuth)
+
+	mockDB.On("GetBatch", "b1").Return(&domain.Batch{ID: "b1", UserID: "user123"}, nil)
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/test.mp4", nil)
+	mockDB.On("CountActiveVideos", "user123").Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.MatchedBy(func(v *domain.Video) bool {
+		return v.BatchID != nil && *v.BatchID == "b1"
+	}), mock.Anything).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("batch_id", "b1")
+	part, _ := writer.CreateFormFile("video", "test.mp4")
+	part.Write(sampleMP4)
+	writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestUpload_RejectsClosedBatch(t *testing.T) {
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	r := batchRouter(mockDB, mockMinio, new(MockAuthClient))
+
+	mockDB.On("GetBatch", "b1").Return(sealedBatch("b1"), nil)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("batch_id", "b1")
+	part, _ := writer.CreateFormFile("video", "test.mp4")
+	part.Write(sampleMP4)
+	writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockMinio.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDownloadBatch_CombinesCompletedZips(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := batchRouter(mockDB, mockMinio, new(MockAuthClient))
+
+	mockDB.On("GetBatch", "b1").Return(sealedBatch("b1"), nil)
+	mockDB.On("GetBatchVideos", "b1").Return([]*domain.Video{
+		batchVideo("11111111-aaaa", "completed"), batchVideo("22222222-bbbb", "failed"),
+		batchVideo("33333333-cccc", "completed"),
+	}, nil)
+	mockMinio.On("GetProcessedObject", "2024/01/01/11111111-aaaa.zip").
+		Return(io.NopCloser(bytes.NewReader([]byte("first zip"))), nil)
+	mockMinio.On("GetProcessedObject", "2024/01/01/33333333-cccc.zip").
+		Return(io.NopCloser(bytes.NewReader([]byte("second zip"))), nil)
+
+	w := serve(r, http.MethodGet, "/batches/b1/download")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if assert.NoError(t, err) && assert.Len(t, archive.File, 2) {
+		assert.Equal(t, "11111111-aaaa_11111111.zip", archive.File[0].Name)
+		assert.Equal(t, "33333333-cccc_33333333.zip", archive.File[1].Name)
+		entry, _ := archive.File[1].Open()
+		content, _ := io.ReadAll(entry)
+		assert.Equal(t, "second zip", string(content))
+	}
+}
+
+func TestDownloadBatch_NothingCompleted(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := batchRouter(mockDB, new(MockMinIO), new(MockAuthClient))
+
+	mockDB.On("GetBatch", "b1").Return(sealedBatch("b1"), nil)
+	mockDB.On("GetBatchVideos", "b1").Return([]*domain.Video{batchVideo("v1", "processing")}, nil)
+
+	w := serve(r, http.MethodGet, "/batches/b1/download")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDownloadBatch_StorageErrorBeforeStreaming(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := batchRouter(mockDB, mockMinio, new(MockAuthClient))
+
+	mockDB.On("GetBatch", "b1").Return(sealedBatch("b1"), nil)
+	mockDB.On("GetBatchVideos", "b1").Return([]*domain.Video{batchVideo("v1", "completed")}, nil)
+	mockMinio.On("GetProcessedObject", mock.Anything).Return(nil, errors.New("stream error"))
+
+	w := serve(r, http.MethodGet, "/batches/b1/download")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestBatchEntryName(t *testing.T) {
+	tests := []struct {
+		originalName string
+		want         string
+	}{
+		{"drone footage.mp4", "drone footage_abcdef12.zip"},
+		{"../../etc/cron.d/job.mp4", "job_abcdef12.zip"},
+		{`C:\videos\cam1.mkv`, "cam1_abcdef12.zip"},
+		{"", "video_abcdef12.zip"},
+	}
+	for _, tt := range tests {
+		video := &domain.Video{ID: "abcdef12-3456", OriginalName: tt.originalName}
+		assert.Equal(t, tt.want, batchEntryName(video), tt.originalName)
+	}
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"video-service/domain"
+	"video-service/infra/media"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// directUploadTTL is how long the presigned URL, and the session behind it,
+// stay valid.
+const directUploadTTL = time.Hour
+
+// Error codes returned when a direct upload does not match what was declared.
+const (
+	ErrCodeUploadMissing    = "upload_missing"
+	ErrCodeSizeMismatch     = "size_mismatch"
+	ErrCodeChecksumMismatch = "checksum_mismatch"
+)
+
+var (
+	errUploadMissing    = errors.New("file has not been uploaded to storage yet")
+	errSizeMismatch     = errors.New("stored file does not have the declared size or content type")
+	errChecksumMismatch = errors.New("stored file does not match the declared sha256")
+
+	sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+)
+
+// DirectUploadRequest declares the file a client is about to PUT to storage.
+type DirectUploadRequest struct {
+	Filename    string         `json:"filename" binding:"required"`
+	SizeBytes   int64          `json:"size_bytes"`
+	ContentType string         `json:"content_type" binding:"required"`
+	SHA256      string         `json:"sha256" binding:"required"`
+	BatchID     string         `json:"batch_id"`
+	Options     OptionsRequest `json:"options"`
+}
+
+type DirectUploadResponse struct {
+	UploadID  string            `json:"upload_id"`
+	VideoID   string            `json:"video_id"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// createDirectUpload hands out a presigned PUT URL for videos-raw, so the
+// file goes straight to storage instead of through this service. The video is
+// created by CompleteUpload once the client reports the PUT is done.
+func (h *VideoHandler) createDirectUpload(c *gin.Context) {
+	var req DirectUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !isValidVideoFile(req.Filename) {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success:   false,
+			Message:   "Invalid video format. Supported: mp4, avi, mov, mkv, wmv, flv, webm",
+			ErrorCode: ErrCodeUnsupportedFormat,
+		})
+		return
+	}
+	if req.SizeBytes <= 0 {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success:   false,
+			Message:   "Uploaded file is empty",
+			ErrorCode: ErrCodeEmptyFile,
+		})
+		return
+	}
+
+	contentType := strings.ToLower(req.ContentType)
+	if !media.IsVideoContentType(contentType) || strings.Contains(contentType, ";") {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success:   false,
+			Message:   "Unsupported content_type: " + req.ContentType,
+			ErrorCode: ErrCodeUnsupportedFormat,
+		})
+		return
+	}
+	if !sha256Pattern.MatchString(req.SHA256) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sha256 must be 64 hex characters"})
+		return
+	}
+
+	options, err := req.Options.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if rejection := h.checkQuota(c, req.SizeBytes); rejection != nil {
+		c.JSON(rejection.status, rejection.response)
+		return
+	}
+
+	batchID, ok := h.batchForUpload(c, req.BatchID)
+	if !ok {
+		return
+	}
+
+	videoID := uuid.New().String()
+	filename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), videoID, filepath.Ext(req.Filename))
+	storagePath := fmt.Sprintf("%s/%s", time.Now().Format("2006/01/02"), filename)
+
+	checksumHeader := amzChecksum(req.SHA256)
+	uploadURL, err := h.minio.PresignPutObject(storagePath, contentType, req.SizeBytes, checksumHeader, directUploadTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to presign upload: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	session := &domain.UploadSession{
+		ID:             uuid.New().String(),
+		UserID:         c.GetString("user_id"),
+		VideoID:        videoID,
+		Filename:       filename,
+		OriginalName:   req.Filename,
+		StoragePath:    storagePath,
+		Method:         domain.UploadMethodPresigned,
+		ContentType:    contentType,
+		ChecksumSHA256: strings.ToLower(req.SHA256),
+		SizeBytes:      req.SizeBytes,
+		Options:        options,
+		BatchID:        batchID,
+		Status:         domain.UploadStatusUploading,
+		ExpiresAt:      now.Add(directUploadTTL),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := h.db.CreateUploadSession(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload session"})
+		return
+	}
+
+	c.Header("Location", tusBasePath+"/"+session.ID)
+	c.JSON(http.StatusCreated, DirectUploadResponse{
+		UploadID:  session.ID,
+		VideoID:   videoID,
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":          contentType,
+			"Content-Length":        strconv.FormatInt(req.SizeBytes, 10),
+			"x-amz-checksum-sha256": checksumHeader,
+		},
+		ExpiresAt: session.ExpiresAt,
+	})
+}
+
+// CompleteUpload checks the object a client PUT with its presigned URL and,
+// if it is what was declared, creates the video and queues it. Calling it
+// again after success returns the same video.
+func (h *VideoHandler) CompleteUpload(c *gin.Context) {
+	session, ok := h.lockUploadSession(c)
+	if !ok {
+		return
+	}
+	defer h.releaseUploadSession(session)
+
+	if session.Method != domain.UploadMethodPresigned {
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload is not a direct upload"})
+		return
+	}
+
+	if session.Status == domain.UploadStatusUploading {
+		if err := h.verifyDirectUpload(session); err != nil {
+			h.rejectDirectUpload(c, session, err)
+			return
+		}
+		session.OffsetBytes = session.SizeBytes
+		session.Status = domain.UploadStatusAssembled
+		if err := h.db.UpdateUploadSession(session); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save upload session"})
+			return
+		}
+	}
+
+	if rejection := h.finishUpload(c, session); rejection != nil {
+		c.JSON(rejection.status, rejection.response)
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadResponse{
+		Success: true,
+		Message: "Video uploaded successfully and queued for processing",
+		VideoID: session.VideoID,
+		Status:  "queued",
+	})
+}
+
+// amzChecksum turns a hex SHA-256 into the base64 form S3 uses in
+// x-amz-checksum-sha256. The value has been validated as 64 hex characters.
+func amzChecksum(hexSum string) string {
+	sum, _ := hex.DecodeString(hexSum)
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+// verifyDirectUpload stats the object for its size, content type and the
+// SHA-256 MinIO verified on the PUT, then reads its first bytes to check the
+// container signature. The file is never downloaded in full.
+func (h *VideoHandler) verifyDirectUpload(session *domain.UploadSession) error {
+	info, err := h.minio.StatRawObject(session.StoragePath)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return errUploadMissing
+		}
+		return err
+	}
+	if info.Size != session.SizeBytes || !strings.EqualFold(info.ContentType, session.ContentType) {
+		return errSizeMismatch
+	}
+	if info.ChecksumSHA256 != amzChecksum(session.ChecksumSHA256) {
+		return errChecksumMismatch
+	}
+
+	object, err := h.minio.GetRawObjectRange(session.StoragePath, 0, min(int64(media.SniffLen), info.Size)-1)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	head := make([]byte, media.SniffLen)
+	n, err := io.ReadFull(object, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	if media.MatchContainer(head[:n]) == "" {
+		return errUnsupportedContainer
+	}
+
+	return nil
+}
+
+// rejectDirectUpload answers a failed verification. A file that is not a
+// video ends the session; a missing, truncated or corrupted one is deleted so
+// the client can PUT it again while the URL is still valid.
+func (h *VideoHandler) rejectDirectUpload(c *gin.Context, session *domain.UploadSession, err error) {
+	switch {
+	case errors.Is(err, errUploadMissing):
+		c.JSON(http.StatusConflict, UploadResponse{Success: false, Message: err.Error(), ErrorCode: ErrCodeUploadMissing})
+	case errors.Is(err, errUnsupportedContainer):
+		h.discardUpload(session)
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success:   false,
+			Message:   "File content is not a supported video container",
+			ErrorCode: ErrCodeUnsupportedFormat,
+		})
+	case errors.Is(err, errSizeMismatch):
+		h.minio.DeleteFile(session.StoragePath)
+		c.JSON(http.StatusBadRequest, UploadResponse{Success: false, Message: err.Error(), ErrorCode: ErrCodeSizeMismatch})
+	case errors.Is(err, errChecksumMismatch):
+		h.minio.DeleteFile(session.StoragePath)
+		c.JSON(http.StatusBadRequest, UploadResponse{Success: false, Message: err.Error(), ErrorCode: ErrCodeChecksumMismatch})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify upload: " + err.Error()})
+	}
+}
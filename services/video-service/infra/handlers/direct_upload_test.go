@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"video-service/domain"
+	"video-service/infra/media"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ---------- In-memory fakes ----------
+
+func (m *memoryStorage) StatRawObject(objectName string) (minio.ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[objectName]
+	if !ok {
+		return minio.ObjectInfo{}, minio.ErrorResponse{Code: "NoSuchKey", StatusCode: http.StatusNotFound}
+	}
+	// MinIO keeps the digest it checked the PUT against.
+	sum := sha256.Sum256(data)
+	return minio.ObjectInfo{Key: objectName, Size: int64(len(data)), ContentType: m.types[objectName],
+		ChecksumSHA256: base64.StdEncoding.EncodeToString(sum[:])}, nil
+}
+
+func (m *memoryStorage) GetRawObjectRange(objectName string, start, end int64) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[objectName]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+	m.rangeReads += end - start + 1
+	return io.NopCloser(bytes.NewReader(data[start : end+1])), nil
+}
+
+func (m *memoryStorage) PresignPutObject(objectName, contentType string, size int64, checksumSHA256 string, expires time.Duration) (string, error) {
+	return "http://storage.test/videos-raw/" + objectName + "?X-Amz-Signature=test", nil
+}
+
+// put stores an object the way a client's presigned PUT would.
+func (m *memoryStorage) put(objectName, contentType string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[objectName] = data
+	m.types[objectName] = contentType
+}
+
+// ---------- Helpers ----------
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func postJSON(r *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createDirectUpload(t *testing.T, r *gin.Engine, file []byte) DirectUploadResponse {
+	w := postJSON(r, tusBasePath, gin.H{
+		"filename":     "clip.mp4",
+		"size_bytes":   len(file),
+		"content_type": "video/mp4",
+		"sha256":       strings.ToUpper(checksum(file)),
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp DirectUploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func completeUpload(r *gin.Engine, uploadID string) (*httptest.ResponseRecorder, UploadResponse) {
+	w := postJSON(r, tusBasePath+"/"+uploadID+"/complete", nil)
+	var resp UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// ---------- createDirectUpload ----------
+
+func TestCreateDirectUpload_Success(t *testing.T) {
+	db := newMemorySessions()
+	r := tusRouter(NewVideoHandler(db, newMemoryStorage(), nil, nil), "user-1")
+	file := videoBytes(4096)
+
+	resp := createDirectUpload(t, r, file)
+
+	assert.Equal(t, http.MethodPut, resp.Method)
+	assert.Equal(t, "video/mp4", resp.Headers["Content-Type"])
+	assert.Equal(t, "4096", resp.Headers["Content-Length"])
+	sum := sha256.Sum256(file)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), resp.Headers["x-amz-checksum-sha256"])
+	assert.Contains(t, resp.UploadURL, "X-Amz-Signature")
+
+	session, err := db.GetUploadSession(resp.UploadID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.UploadMethodPresigned, session.Method)
+	assert.Equal(t, resp.VideoID, session.VideoID)
+	assert.Equal(t, checksum(file), session.ChecksumSHA256)
+	assert.Contains(t, resp.UploadURL, session.StoragePath)
+}
+
+func TestCreateDirectUpload_Rejected(t *testing.T) {
+	valid := func() gin.H {
+		return gin.H{"filename": "clip.mp4", "size_bytes": 100, "content_type": "video/mp4", "sha256": checksum([]byte("x"))}
+	}
+	with := func(key string, value interface{}) gin.H {
+		body := valid()
+		body[key] = value
+		return body
+	}
+
+	cases := []struct {
+		name      string
+		body      gin.H
+		status    int
+		errorCode string
+	}{
+		{"missing checksum", with("sha256", ""), http.StatusBadRequest, ""},
+		{"bad checksum", with("sha256", "abc"), http.StatusBadRequest, ""},
+		{"bad content type", with("content_type", "text/html"), http.StatusBadRequest, ErrCodeUnsupportedFormat},
+		{"bad extension", with("filename", "notes.txt"), http.StatusBadRequest, ErrCodeUnsupportedFormat},
+		{"too large", with("size_bytes", maxUploadSize+1), http.StatusRequestEntityTooLarge, ErrCodeFileTooLarge},
+		{"empty", with("size_bytes", 0), http.StatusBadRequest, ErrCodeEmptyFile},
+		{"bad options", with("options", gin.H{"timestamps": []string{"5"}, "snap_to_chapters": true}), http.StatusBadRequest, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tusRouter(NewVideoHandler(newMemorySessions(), newMemoryStorage(), nil, nil), "user-1")
+
+			w := postJSON(r, tusBasePath, tc.body)
+
+			assert.Equal(t, tc.status, w.Code)
+			if tc.errorCode != "" {
+				var resp UploadResponse
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				assert.Equal(t, tc.errorCode, resp.ErrorCode)
+			}
+		})
+	}
+}
+
+// ---------- CompleteUpload ----------
+
+func TestCompleteUpload_Success(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	authClient := new(MockAuthClient)
+	authClient.On("CreateAuditLog", mock.Anything).Return(nil).Maybe()
+	r := tusRouter(NewVideoHandler(db, storage, nil, authClient), "user-1")
+
+	file := videoBytes(4096)
+	upload := createDirectUpload(t, r, file)
+	session, _ := db.GetUploadSession(upload.UploadID)
+	storage.put(session.StoragePath, "video/mp4", file)
+
+	db.On("CountActiveVideos", "user-1").Return(0, nil)
+	db.On("CreateVideoWithOutbox", mock.MatchedBy(func(v *domain.Video) bool {
+		return v.ID == upload.VideoID && v.StoragePath == session.StoragePath && v.SizeBytes == 4096
+	}), mock.Anything).Return(nil).Once()
+
+	w, resp := completeUpload(r, upload.UploadID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, upload.VideoID, resp.VideoID)
+	// Only the container signature is read back; MinIO checked the digest.
+	assert.Equal(t, int64(media.SniffLen), storage.rangeReads)
+
+	// Completing again is harmless and returns the same video.
+	w, resp = completeUpload(r, upload.UploadID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, upload.VideoID, resp.VideoID)
+
+	session, _ = db.GetUploadSession(upload.UploadID)
+	assert.Equal(t, domain.UploadStatusCompleted, session.Status)
+	assert.Nil(t, session.LockedUntil)
+	db.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestCompleteUpload_NotUploaded(t *testing.T) {
+	db := newMemorySessions()
+	r := tusRouter(NewVideoHandler(db, newMemoryStorage(), nil, nil), "user-1")
+	upload := createDirectUpload(t, r, videoBytes(4096))
+
+	w, resp := completeUpload(r, upload.UploadID)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, ErrCodeUploadMissing, resp.ErrorCode)
+	session, err := db.GetUploadSession(upload.UploadID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.UploadStatusUploading, session.Status)
+}
+
+func TestCompleteUpload_ChecksumMismatchCanBeRetried(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	authClient := new(MockAuthClient)
+	authClient.On("CreateAuditLog", mock.Anything).Return(nil).Maybe()
+	r := tusRouter(NewVideoHandler(db, storage, nil, authClient), "user-1")
+
+	file := videoBytes(4096)
+	upload := createDirectUpload(t, r, file)
+	session, _ := db.GetUploadSession(upload.UploadID)
+
+	corrupted := append([]byte{}, file...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	storage.put(session.StoragePath, "video/mp4", corrupted)
+
+	w, resp := completeUpload(r, upload.UploadID)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ErrCodeChecksumMismatch, resp.ErrorCode)
+	assert.NotContains(t, storage.objects, session.StoragePath)
+
+	storage.put(session.StoragePath, "video/mp4", file)
+	db.On("CountActiveVideos", "user-1").Return(0, nil)
+	db.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(nil)
+
+	w, _ = completeUpload(r, upload.UploadID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestCompleteUpload_SizeMismatch(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	r := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1")
+	file := videoBytes(4096)
+	upload := createDirectUpload(t, r, file)
+	session, _ := db.GetUploadSession(upload.UploadID)
+	storage.put(session.StoragePath, "video/mp4", file[:1000])
+
+	w, resp := completeUpload(r, upload.UploadID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ErrCodeSizeMismatch, resp.ErrorCode)
+	db.AssertNotCalled(t, "CreateVideoWithOutbox", mock.Anything, mock.Anything)
+}
+
+func TestCompleteUpload_RejectsNonVideo(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	r := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1")
+	file := []byte(strings.Repeat("not a video ", 100))
+	upload := createDirectUpload(t, r, file)
+	session, _ := db.GetUploadSession(upload.UploadID)
+	storage.put(session.StoragePath, "video/mp4", file)
+
+	w, resp := completeUpload(r, upload.UploadID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ErrCodeUnsupportedFormat, resp.ErrorCode)
+	assert.Empty(t, storage.objects)
+	_, err := db.GetUploadSession(upload.UploadID)
+	assert.Error(t, err)
+}
+
+func TestCompleteUpload_StorageError(t *testing.T) {
+	db := newMemorySessions()
+	r := tusRouter(NewVideoHandler(db, &statFailingStorage{memoryStorage: newMemoryStorage()}, nil, nil), "user-1")
+	upload := createDirectUpload(t, r, videoBytes(4096))
+
+	w, _ := completeUpload(r, upload.UploadID)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	session, _ := db.GetUploadSession(upload.UploadID)
+	assert.Equal(t, domain.UploadStatusUploading, session.Status)
+}
+
+type statFailingStorage struct {
+	*memoryStorage
+}
+
+func (s *statFailingStorage) StatRawObject(objectName string) (minio.ObjectInfo, error) {
+	return minio.ObjectInfo{}, errors.New("connection refused")
+}
+
+func TestCompleteUpload_OtherUser(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	upload := createDirectUpload(t, tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1"), videoBytes(4096))
+
+	w, _ := completeUpload(tusRouter(NewVideoHandler(db, storage, nil, nil), "user-2"), upload.UploadID)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDirectUpload_NotUsableThroughTus(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	r := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1")
+	upload := createDirectUpload(t, r, videoBytes(4096))
+	location := tusBasePath + "/" + upload.UploadID
+
+	assert.Equal(t, http.StatusConflict, patchUpload(r, location, 0, bytes.NewReader(sampleMP4)).Code)
+	assert.Equal(t, http.StatusNotFound, headUpload(r, location).Code)
+}
+
+func TestTerminateUpload_DirectUpload(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	r := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1")
+	file := videoBytes(4096)
+	upload := createDirectUpload(t, r, file)
+	session, _ := db.GetUploadSession(upload.UploadID)
+	storage.put(session.StoragePath, "video/mp4", file)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tusBasePath+"/"+upload.UploadID, nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, storage.objects)
+	_, err := db.GetUploadSession(upload.UploadID)
+	assert.Error(t, err)
+}
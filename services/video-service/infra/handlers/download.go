@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+)
+
+// byteRange is the part of an object a Range header asks for, both ends
+// inclusive.
+type byteRange struct {
+	start, end int64
+}
+
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// serveObject sends a stored object with the validators from its stat, and
+// honours If-None-Match, If-Modified-Since, Range and If-Range so clients can
+// revalidate a cached copy and resume an interrupted download. open reads
+// bytes start to end of the object; a negative end reads all of it. admit,
+// when set, is called once the request is known to get content, whole or
+// partial; it answers the request itself and returns false to refuse it.
+func serveObject(c *gin.Context, info minio.ObjectInfo, filename, contentType string, admit func() bool, open func(start, end int64) (io.ReadCloser, error)) {
+	etag := quoteETag(info.ETag)
+	lastModified := info.LastModified.UTC().Truncate(time.Second)
+
+	header := c.Writer.Header()
+	header.Set("Accept-Ranges", "bytes")
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	var rng *byteRange
+	if rangeHeader := c.GetHeader("Range"); rangeHeader != "" && ifRangeMatches(c.GetHeader("If-Range"), etag, lastModified) {
+		var err error
+		rng, err = parseRange(rangeHeader, info.Size)
+		if err != nil {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+			c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": "Requested range not satisfiable"})
+			return
+		}
+	}
+
+	status, start, end, length := http.StatusOK, int64(0), int64(-1), info.Size
+	extraHeaders := map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", filename),
+	}
+	if rng != nil {
+		status, start, end, length = http.StatusPartialContent, rng.start, rng.end, rng.end-rng.start+1
+		extraHeaders["Content-Range"] = fmt.Sprintf("bytes %d-%d/%d", rng.start, rng.end, info.Size)
+	}
+
+	if admit != nil && !admit() {
+		return
+	}
+
+	reader, err := open(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get file stream: %v", err)})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(status, length, contentType, reader, extraHeaders)
+}
+
+// notModified reports whether the client's copy is current. If-None-Match
+// takes precedence over If-Modified-Since, as RFC 9110 requires.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagListMatches(inm, etag, false)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(t)
+	}
+	return false
+}
+
+// ifRangeMatches reports whether a Range request may be served partially: it
+// may when there is no If-Range, or when the If-Range validator still
+// matches the object. An ETag must match strongly.
+func ifRangeMatches(ifRange, etag string, lastModified time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return etag != "" && etagListMatches(ifRange, etag, true)
+	}
+	t, err := http.ParseTime(ifRange)
+	return err == nil && !lastModified.IsZero() && lastModified.Equal(t)
+}
+
+// etagListMatches reports whether a comma-separated list of entity tags
+// holds etag. Weak comparison ignores the W/ prefix; strong comparison never
+// matches a weak tag.
+func etagListMatches(list, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" && !strong {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteETag turns MinIO's ETag into a quoted entity tag.
+func quoteETag(etag string) string {
+	etag = strings.Trim(etag, `"`)
+	if etag == "" {
+		return ""
+	}
+	return `"` + etag + `"`
+}
+
+// parseRange reads a single "bytes=" range of an object of the given size.
+// It returns nil for a header it does not serve — malformed, in another unit
+// or asking for several ranges — in which case the whole object is sent, as
+// RFC 9110 allows.
+func parseRange(header string, size int64) (*byteRange, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix < 0 {
+			return nil, nil
+		}
+		if suffix == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+		suffix = min(suffix, size)
+		return &byteRange{start: size - suffix, end: size - 1}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil, nil
+		}
+	}
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+	return &byteRange{start: start, end: min(end, size-1)}, nil
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+const downloadBody = "0123456789"
+
+var downloadModified = time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+// downloadRouter serves downloadBody through serveObject, recording the range
+// it was opened with.
+func downloadRouter(opened *[2]int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/file", func(c *gin.Context) {
+		info := minio.ObjectInfo{Size: int64(len(downloadBody)), ETag: "abc", LastModified: downloadModified}
+		serveObject(c, info, "frames.zip", "application/zip", nil, func(start, end int64) (io.ReadCloser, error) {
+			*opened = [2]int64{start, end}
+			body := downloadBody[start:]
+			if end >= 0 {
+				body = downloadBody[start : end+1]
+			}
+			return io.NopCloser(strings.NewReader(body)), nil
+		})
+	})
+	return r
+}
+
+func download(headers map[string]string) (*httptest.ResponseRecorder, [2]int64) {
+	opened := [2]int64{-2, -2}
+	r := downloadRouter(&opened)
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, opened
+}
+
+// ---------- serveObject ----------
+
+func TestServeObject_Full(t *testing.T) {
+	w, opened := download(nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, downloadBody, w.Body.String())
+	assert.Equal(t, [2]int64{0, -1}, opened)
+	assert.Equal(t, `"abc"`, w.Header().Get("ETag"))
+	assert.Equal(t, downloadModified.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+	assert.Equal(t, `attachment; filename="frames.zip"`, w.Header().Get("Content-Disposition"))
+	assert.Empty(t, w.Header().Get("Content-Range"))
+}
+
+func TestServeObject_Range(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		body   string
+		cr     string
+	}{
+		{"bounded", "bytes=2-5", "2345", "bytes 2-5/10"},
+		{"open ended", "bytes=7-", "789", "bytes 7-9/10"},
+		{"suffix", "bytes=-3", "789", "bytes 7-9/10"},
+		{"end past size", "bytes=8-100", "89", "bytes 8-9/10"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := download(map[string]string{"Range": tc.header})
+
+			assert.Equal(t, http.StatusPartialContent, w.Code)
+			assert.Equal(t, tc.body, w.Body.String())
+			assert.Equal(t, tc.cr, w.Header().Get("Content-Range"))
+		})
+	}
+}
+
+func TestServeObject_RangeNotSatisfiable(t *testing.T) {
+	w, opened := download(map[string]string{"Range": "bytes=10-"})
+
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	assert.Equal(t, "bytes */10", w.Header().Get("Content-Range"))
+	assert.Equal(t, [2]int64{-2, -2}, opened)
+}
+
+func TestServeObject_UnsupportedRangeSendsAll(t *testing.T) {
+	w, _ := download(map[string]string{"Range": "bytes=0-1,4-5"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, downloadBody, w.Body.String())
+}
+
+func TestServeObject_NotModified(t *testing.T) {
+	cases := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"etag", map[string]string{"If-None-Match": `"abc"`}, http.StatusNotModified},
+		{"weak etag", map[string]string{"If-None-Match": `"x", W/"abc"`}, http.StatusNotModified},
+		{"any", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other etag", map[string]string{"If-None-Match": `"xyz"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": downloadModified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": downloadModified.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"etag wins over date", map[string]string{
+			"If-None-Match":     `"xyz"`,
+			"If-Modified-Since": downloadModified.Format(http.TimeFormat),
+		}, http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := download(tc.headers)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, `"abc"`, w.Header().Get("ETag"))
+			if tc.status == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeObject_IfRange(t *testing.T) {
+	cases := []struct {
+		name    string
+		ifRange string
+		status  int
+	}{
+		{"etag matches", `"abc"`, http.StatusPartialContent},
+		{"etag changed", `"xyz"`, http.StatusOK},
+		{"weak etag", `W/"abc"`, http.StatusOK},
+		{"date matches", downloadModified.Format(http.TimeFormat), http.StatusPartialContent},
+		{"date changed", downloadModified.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := download(map[string]string{"Range": "bytes=0-1", "If-Range": tc.ifRange})
+
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusOK {
+				assert.Equal(t, downloadBody, w.Body.String())
+			} else {
+				assert.Equal(t, "01", w.Body.String())
+			}
+		})
+	}
+}
+
+// ---------- parseRange ----------
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		header string
+		want   *byteRange
+		err    error
+	}{
+		{"bytes=0-0", &byteRange{0, 0}, nil},
+		{"bytes=3-", &byteRange{3, 9}, nil},
+		{"bytes=-20", &byteRange{0, 9}, nil},
+		{"bytes=-0", nil, errRangeNotSatisfiable},
+		{"bytes=12-15", nil, errRangeNotSatisfiable},
+		{"bytes=5-2", nil, nil},
+		{"bytes=a-b", nil, nil},
+		{"bytes=1", nil, nil},
+		{"items=0-1", nil, nil},
+		{"bytes=0-1,3-4", nil, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.header, func(t *testing.T) {
+			got, err := parseRange(tc.header, 10)
+
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
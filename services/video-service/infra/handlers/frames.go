@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"video-service/domain"
+	"video-service/infra/archive"
+	"video-service/infra/media"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many zips keep their listing in memory, and how many thumbnails.
+const (
+	frameIndexCacheSize = 64
+	thumbnailCacheSize  = 512
+)
+
+// maxThumbnailWidth is the widest thumbnail that can be asked for.
+const maxThumbnailWidth = 1920
+
+// frameManifestName is the zip entry processing-service lists the frames'
+// timestamps in. Zips made before it existed have frames without them.
+const frameManifestName = "frames.json"
+
+// A run's zip never changes, so its frames can be cached by the browser.
+const frameCacheControl = "private, max-age=86400"
+
+var frameNamePattern = regexp.MustCompile(`^frame_(\d+)\.png$`)
+
+type FrameResponse struct {
+	Index     int      `json:"index"`
+	Name      string   `json:"name"`
+	Timestamp *float64 `json:"timestamp_seconds,omitempty"`
+	SizeBytes int64    `json:"size_bytes"`
+	URL       string   `json:"url"`
+}
+
+type FrameListResponse struct {
+	VideoID string          `json:"video_id"`
+	RunID   *string         `json:"run_id,omitempty"`
+	Total   int             `json:"total"`
+	Frames  []FrameResponse `json:"frames"`
+}
+
+// frameIndex is what is known about the frames of one zip.
+type frameIndex struct {
+	etag    string
+	archive *archive.Reader
+	frames  []FrameResponse
+	files   map[int]*zip.File
+}
+
+// ListFrames lists the frames of one of the caller's completed videos,
+// reading only the zip's directory and manifest.
+func (h *VideoHandler) ListFrames(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok || !zipReady(c, video) {
+		return
+	}
+
+	index, err := h.frameIndex(video)
+	if err != nil {
+		fmt.Printf("Failed to read frames of video %s: %v\n", video.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read frames"})
+		return
+	}
+
+	c.JSON(http.StatusOK, FrameListResponse{
+		VideoID: video.ID,
+		RunID:   video.CurrentRunID,
+		Total:   len(index.frames),
+		Frames:  index.frames,
+	})
+}
+
+// GetFrame sends one frame of one of the caller's completed videos. With a
+// width it sends a JPEG thumbnail of the frame scaled down to that width.
+func (h *VideoHandler) GetFrame(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("index"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frame index"})
+		return
+	}
+
+	width := 0
+	if raw := c.Query("width"); raw != "" {
+		width, err = strconv.Atoi(raw)
+		if err != nil || width < 1 || width > maxThumbnailWidth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("width must be between 1 and %d", maxThumbnailWidth)})
+			return
+		}
+	}
+
+	video, ok := h.ownedVideo(c)
+	if !ok || !zipReady(c, video) {
+		return
+	}
+
+	index, err := h.frameIndex(video)
+	if err != nil {
+		fmt.Printf("Failed to read frames of video %s: %v\n", video.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read frames"})
+		return
+	}
+	file, ok := index.files[number]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Frame not found"})
+		return
+	}
+
+	etag := fmt.Sprintf("%s-%d", index.etag, number)
+	if width > 0 {
+		etag = fmt.Sprintf("%s-w%d", etag, width)
+	}
+	etag = quoteETag(etag)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", frameCacheControl)
+	if notModified(c.Request, etag, time.Time{}) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if width == 0 {
+		reader, err := index.archive.Open(file)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read frame"})
+			return
+		}
+		defer reader.Close()
+		c.DataFromReader(http.StatusOK, int64(file.UncompressedSize64), "image/png", reader, nil)
+		return
+	}
+
+	thumbnail, ok := h.thumbnails.Get(etag)
+	if !ok {
+		reader, err := index.archive.Open(file)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read frame"})
+			return
+		}
+		thumbnail, err = media.Thumbnail(reader, width)
+		reader.Close()
+		if err != nil {
+			fmt.Printf("Failed to create thumbnail of frame %d of video %s: %v\n", number, video.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create thumbnail"})
+			return
+		}
+		h.thumbnails.Add(etag, thumbnail)
+	}
+
+	c.Data(http.StatusOK, "image/jpeg", thumbnail)
+}
+
+// frameIndex returns the frames of the video's zip, reading its directory
+// the first time the zip is asked about.
+func (h *VideoHandler) frameIndex(video *domain.Video) (*frameIndex, error) {
+	zipPath := *video.ZipPath
+	info, err := h.minio.StatProcessedObject(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := zipPath + "@" + info.ETag
+	if index, ok := h.frameIndexes.Get(key); ok {
+		return index, nil
+	}
+
+	reader, err := archive.NewReader(info.Size, func(start, end int64) (io.ReadCloser, error) {
+		return h.minio.GetProcessedObjectRange(zipPath, start, end)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps, err := readFrameTimestamps(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &frameIndex{
+		etag:    strings.Trim(info.ETag, `"`),
+		archive: reader,
+		frames:  []FrameResponse{},
+		files:   map[int]*zip.File{},
+	}
+	for _, file := range reader.Files() {
+		match := frameNamePattern.FindStringSubmatch(file.Name)
+		if match == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(match[1])
+		frame := FrameResponse{
+			Index:     number,
+			Name:      file.Name,
+			SizeBytes: int64(file.UncompressedSize64),
+			URL:       fmt.Sprintf("/api/v1/videos/%s/frames/%d", video.ID, number),
+		}
+		if ts, ok := timestamps[file.Name]; ok {
+			frame.Timestamp = &ts
+		}
+		index.frames = append(index.frames, frame)
+		index.files[number] = file
+	}
+	sort.Slice(index.frames, func(i, j int) bool { return index.frames[i].Index < index.frames[j].Index })
+
+	h.frameIndexes.Add(key, index)
+	return index, nil
+}
+
+// readFrameTimestamps reads the zip's frame manifest into timestamps by
+// frame name. A zip without one has no timestamps.
+func readFrameTimestamps(reader *archive.Reader) (map[string]float64, error) {
+	timestamps := map[string]float64{}
+	file := reader.File(frameManifestName)
+	if file == nil {
+		return timestamps, nil
+	}
+
+	rc, err := reader.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var manifest []domain.FrameInfo
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", frameManifestName, err)
+	}
+	for _, frame := range manifest {
+		timestamps[frame.Name] = frame.Timestamp
+	}
+	return timestamps, nil
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func framesRouter(db *MockDatabase, minio *MockMinIO) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(db, minio, nil, nil)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user123")
+	})
+	r.GET("/videos/:id/frames", handler.ListFrames)
+	r.GET("/videos/:id/frames/:index", handler.GetFrame)
+	r.POST("/videos/:id/download/subset", handler.DownloadSubset)
+	return r
+}
+
+func framePNG(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{200, 50, 50, 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// framesZip builds a zip like processing-service does: the frames, the
+// chapters and, unless manifest is nil, the frame manifest.
+func framesZip(frames [][]byte, manifest []domain.FrameInfo) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, frame := range frames {
+		w, _ := zw.CreateHeader(&zip.FileHeader{Name: frameName(i + 1), Method: zip.Deflate})
+		w.Write(frame)
+	}
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "chapters.json", Method: zip.Deflate})
+	w.Write([]byte("[]"))
+	if manifest != nil {
+		data, _ := json.Marshal(manifest)
+		w, _ := zw.CreateHeader(&zip.FileHeader{Name: frameManifestName, Method: zip.Deflate})
+		w.Write(data)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func frameName(index int) string {
+	return fmt.Sprintf("frame_%04d.png", index)
+}
+
+// serveZip makes the MinIO mock answer for the completed video's zip.
+func serveZip(minioMock *MockMinIO, data []byte) {
+	zipPath := *completedVideo().ZipPath
+	minioMock.On("StatProcessedObject", zipPath).Return(minio.ObjectInfo{Size: int64(len(data)), ETag: "zipetag"}, nil)
+	minioMock.On("GetProcessedObjectRange", zipPath, mock.Anything, mock.Anything).
+		Return(func(start, end int64) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data[start : end+1])), nil
+		})
+}
+
+func getFrame(r *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// ---------- ListFrames ----------
+
+func TestListFrames_WithTimestamps(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	frames := [][]byte{framePNG(4, 4), framePNG(8, 8)}
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip(frames, []domain.FrameInfo{
+		{Index: 1, Name: "frame_0001.png", Timestamp: 10},
+		{Index: 2, Name: "frame_0002.png", Timestamp: 10.5},
+	}))
+
+	w := serve(r, http.MethodGet, "/videos/v1/frames")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response FrameListResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, 1, response.Frames[0].Index)
+	assert.Equal(t, "frame_0001.png", response.Frames[0].Name)
+	assert.Equal(t, 10.0, *response.Frames[0].Timestamp)
+	assert.Equal(t, int64(len(frames[0])), response.Frames[0].SizeBytes)
+	assert.Equal(t, "/api/v1/videos/v1/frames/1", response.Frames[0].URL)
+	assert.Equal(t, 10.5, *response.Frames[1].Timestamp)
+}
+
+func TestListFrames_WithoutManifest(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip([][]byte{framePNG(4, 4)}, nil))
+
+	w := serve(r, http.MethodGet, "/videos/v1/frames")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response FrameListResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 1, response.Total)
+	assert.Nil(t, response.Frames[0].Timestamp)
+}
+
+func TestListFrames_ReadsDirectoryOnce(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip([][]byte{framePNG(4, 4)}, nil))
+
+	serve(r, http.MethodGet, "/videos/v1/frames")
+	serve(r, http.MethodGet, "/videos/v1/frames")
+
+	mockMinio.AssertNumberOfCalls(t, "StatProcessedObject", 2)
+	mockMinio.AssertNumberOfCalls(t, "GetProcessedObjectRange", 1)
+}
+
+func TestListFrames_Rejected(t *testing.T) {
+	processing := completedVideo()
+	processing.Status = "processing"
+	other := completedVideo()
+	other.UserID = "other"
+
+	cases := []struct {
+		name   string
+		video  *domain.Video
+		status int
+	}{
+		{"not completed", processing, http.StatusBadRequest},
+		{"other user", other, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			mockMinio := new(MockMinIO)
+			r := framesRouter(mockDB, mockMinio)
+
+			mockDB.On("GetVideoByID", "v1").Return(tc.video, nil)
+
+			w := serve(r, http.MethodGet, "/videos/v1/frames")
+
+			assert.Equal(t, tc.status, w.Code)
+			mockMinio.AssertNotCalled(t, "StatProcessedObject", mock.Anything)
+		})
+	}
+}
+
+func TestListFrames_StorageError(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{}, errors.New("storage down"))
+
+	w := serve(r, http.MethodGet, "/videos/v1/frames")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+// ---------- GetFrame ----------
+
+func TestGetFrame_Original(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	frames := [][]byte{framePNG(4, 4), framePNG(8, 8)}
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip(frames, nil))
+
+	w := serve(r, http.MethodGet, "/videos/v1/frames/2")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, frames[1], w.Body.Bytes())
+	assert.Equal(t, `"zipetag-2"`, w.Header().Get("ETag"))
+	assert.Equal(t, frameCacheControl, w.Header().Get("Cache-Control"))
+}
+
+func TestGetFrame_Thumbnail(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip([][]byte{framePNG(64, 32)}, nil))
+
+	w := serve(r, http.MethodGet, "/videos/v1/frames/1?width=16")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Equal(t, `"zipetag-1-w16"`, w.Header().Get("ETag"))
+	img, err := jpeg.Decode(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 16, 8), img.Bounds())
+
+	// The second request is answered from the caches.
+	calls := len(mockMinio.Calls)
+	again := serve(r, http.MethodGet, "/videos/v1/frames/1?width=16")
+	assert.Equal(t, w.Body.Bytes(), again.Body.Bytes())
+	mockMinio.AssertNumberOfCalls(t, "StatProcessedObject", 2)
+	assert.Equal(t, calls+1, len(mockMinio.Calls))
+}
+
+func TestGetFrame_NotModified(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip([][]byte{framePNG(4, 4)}, nil))
+
+	w := getFrame(r, "/videos/v1/frames/1", map[string]string{"If-None-Match": `"zipetag-1"`})
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+}
+
+func TestGetFrame_Rejected(t *testing.T) {
+	cases := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"bad index", "/videos/v1/frames/abc", http.StatusBadRequest},
+		{"zero index", "/videos/v1/frames/0", http.StatusBadRequest},
+		{"bad width", "/videos/v1/frames/1?width=wide", http.StatusBadRequest},
+		{"width too large", "/videos/v1/frames/1?width=5000", http.StatusBadRequest},
+		{"no such frame", "/videos/v1/frames/9", http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			mockMinio := new(MockMinIO)
+			r := framesRouter(mockDB, mockMinio)
+
+			mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+			serveZip(mockMinio, framesZip([][]byte{framePNG(4, 4)}, nil))
+
+			w := serve(r, http.MethodGet, tc.path)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -1,30 +1,51 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 	"video-service/domain"
+	"video-service/infra/cache"
+	"video-service/infra/media"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type VideoHandler struct {
-	db         domain.DatabaseInterface
-	minio      domain.MinIOInterface
-	rabbitmq   domain.RabbitMQInterface
-	authClient domain.AuthServiceClient
+	db           domain.DatabaseInterface
+	minio        domain.MinIOInterface
+	rabbitmq     domain.RabbitMQInterface
+	authClient   domain.AuthServiceClient
+	frameIndexes *cache.LRU[string, *frameIndex]
+	thumbnails   *cache.LRU[string, []byte]
 }
 
 type UploadResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	VideoID string `json:"video_id,omitempty"`
-	Status  string `json:"status,omitempty"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	VideoID   string `json:"video_id,omitempty"`
+	BatchID   string `json:"batch_id,omitempty"`
+	Status    string `json:"status,omitempty"`
 }
 
+// maxUploadSize is the file size limit of a plan that does not set one.
+const maxUploadSize = int64(500 * 1024 * 1024)
+
+// Error codes returned to clients when an upload is rejected.
+const (
+	ErrCodeUnsupportedFormat = "unsupported_format"
+	ErrCodeEmptyFile         = "empty_file"
+	ErrCodeFileTooLarge      = "file_too_large"
+)
+
 type VideoResponse struct {
 	ID                  string     `json:"id"`
 	UserID              string     `json:"user_id"`
@@ -39,21 +60,24 @@ type VideoResponse struct {
 	CreatedAt           time.Time  `json:"created_at"`
 	ProcessingStarted   *time.Time `json:"processing_started_at,omitempty"`
 	ProcessingCompleted *time.Time `json:"processing_completed_at,omitempty"`
+	BatchID             *string    `json:"batch_id,omitempty"`
+	CurrentRunID        *string    `json:"current_run_id,omitempty"`
+	DeletedAt           *time.Time `json:"deleted_at,omitempty"`
 }
 
 func NewVideoHandler(db domain.DatabaseInterface, minio domain.MinIOInterface, rabbitmq domain.RabbitMQInterface, authClient domain.AuthServiceClient) *VideoHandler {
 	return &VideoHandler{
-		db:         db,
-		minio:      minio,
-		rabbitmq:   rabbitmq,
-		authClient: authClient,
+		db:           db,
+		minio:        minio,
+		rabbitmq:     rabbitmq,
+		authClient:   authClient,
+		frameIndexes: cache.NewLRU[string, *frameIndex](frameIndexCacheSize),
+		thumbnails:   cache.NewLRU[string, []byte](thumbnailCacheSize),
 	}
 }
 
 func (h *VideoHandler) Upload(c *gin.Context) {
-	userID := c.GetString("user_id")
-
-	file, header, err := c.Request.FormFile("video")
+	header, err := c.FormFile("video")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, UploadResponse{
 			Success: false,
@@ -61,98 +85,37 @@ func (h *VideoHandler) Upload(c *gin.Context) {
 		})
 		return
 	}
-	defer file.Close()
 
-	if !isValidVideoFile(header.Filename) {
-		c.JSON(http.StatusBadRequest, UploadResponse{
-			Success: false,
-			Message: "Invalid video format. Supported: mp4, avi, mov, mkv, wmv, flv, webm",
-		})
+	if rejection := checkUploadFile(header); rejection != nil {
+		c.JSON(rejection.status, rejection.response)
 		return
 	}
 
-	maxSize := int64(500 * 1024 * 1024)
-	if header.Size > maxSize {
+	options, err := parseProcessingOptions(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, UploadResponse{
 			Success: false,
-			Message: fmt.Sprintf("File too large. Max size: 500MB, got: %.2fMB", float64(header.Size)/1024/1024),
+			Message: err.Error(),
 		})
 		return
 	}
 
-	videoID := uuid.New().String()
-	ext := filepath.Ext(header.Filename)
-	filename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), videoID, ext)
-
-	storagePath, err := h.minio.UploadFile(file, filename, header.Size)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, UploadResponse{
-			Success: false,
-			Message: "Failed to upload file: " + err.Error(),
-		})
+	if rejection := h.checkQuota(c, header.Size); rejection != nil {
+		c.JSON(rejection.status, rejection.response)
 		return
 	}
 
-	video := &domain.Video{
-		ID:           videoID,
-		UserID:       userID,
-		Filename:     filename,
-		OriginalName: header.Filename,
-		SizeBytes:    header.Size,
-		Status:       "pending",
-		StoragePath:  storagePath,
-		Priority:     1,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-	}
-
-	if err := h.db.CreateVideo(video); err != nil {
-		h.minio.DeleteFile(storagePath)
-		c.JSON(http.StatusInternalServerError, UploadResponse{
-			Success: false,
-			Message: "Failed to create video record: " + err.Error(),
-		})
+	batchID, ok := h.batchForUpload(c, c.PostForm("batch_id"))
+	if !ok {
 		return
 	}
 
-	message := domain.VideoProcessingMessage{
-		VideoID:     videoID,
-		UserID:      userID,
-		StoragePath: storagePath,
-		Filename:    filename,
-		Priority:    5,
-	}
-
-	if err := h.rabbitmq.PublishVideoUpload(message); err != nil {
-		video.Status = "failed"
-		video.ErrorMessage = StringPtr("Failed to queue for processing")
-		h.db.UpdateVideo(video)
-
-		c.JSON(http.StatusInternalServerError, UploadResponse{
-			Success: false,
-			Message: "Failed to queue video for processing: " + err.Error(),
-		})
+	videoID, rejection := h.storeUpload(c, header, options, batchID)
+	if rejection != nil {
+		c.JSON(rejection.status, rejection.response)
 		return
 	}
 
-	video.Status = "queued"
-	video.QueuedAt = TimePtr(time.Now())
-	h.db.UpdateVideo(video)
-
-	auditReq := domain.AuditLogRequest{
-		UserID:     &userID,
-		Action:     "video.upload",
-		EntityType: "video",
-		EntityID:   &videoID,
-		IPAddress:  c.ClientIP(),
-		UserAgent:  c.Request.UserAgent(),
-	}
-	go func() {
-		if err := h.authClient.CreateAuditLog(auditReq); err != nil {
-			fmt.Printf("Failed to create audit log: %v\n", err)
-		}
-	}()
-
 	c.JSON(http.StatusOK, UploadResponse{
 		Success: true,
 		Message: "Video uploaded successfully and queued for processing",
@@ -161,109 +124,146 @@ func (h *VideoHandler) Upload(c *gin.Context) {
 	})
 }
 
-func (h *VideoHandler) GetVideo(c *gin.Context) {
-	videoID := c.Param("id")
-	userID := c.GetString("user_id")
+// uploadRejection is the response for a form file that was not accepted.
+type uploadRejection struct {
+	status   int
+	response UploadResponse
+}
 
-	video, err := h.db.GetVideoByID(videoID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
-		return
-	}
+func rejectUpload(status int, message, errorCode string) *uploadRejection {
+	return &uploadRejection{status: status, response: UploadResponse{
+		Success:   false,
+		Message:   message,
+		ErrorCode: errorCode,
+	}}
+}
 
-	if video.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-		return
+// checkUploadFile validates a form file's name and container before anything
+// is stored. Its size is left to checkQuota.
+func checkUploadFile(header *multipart.FileHeader) *uploadRejection {
+	if !isValidVideoFile(header.Filename) {
+		return rejectUpload(http.StatusBadRequest,
+			"Invalid video format. Supported: mp4, avi, mov, mkv, wmv, flv, webm", ErrCodeUnsupportedFormat)
+	}
+	if header.Size == 0 {
+		return rejectUpload(http.StatusBadRequest, "Uploaded file is empty", ErrCodeEmptyFile)
 	}
 
-	response := VideoResponse{
-		ID:           video.ID,
-		UserID:       video.UserID,
-		Filename:     video.Filename,
-		OriginalName: video.OriginalName,
-		SizeBytes:    video.SizeBytes,
-		Status:       video.Status,
-		CreatedAt:    video.CreatedAt,
+	file, err := header.Open()
+	if err != nil {
+		return rejectUpload(http.StatusBadRequest, "Failed to read video file: "+err.Error(), "")
 	}
+	defer file.Close()
 
-	if video.FrameCount != nil {
-		response.FrameCount = video.FrameCount
+	container, err := media.DetectContainer(file)
+	if err != nil {
+		return rejectUpload(http.StatusBadRequest, "Failed to read video file: "+err.Error(), "")
 	}
+	if container == "" {
+		return rejectUpload(http.StatusBadRequest, "File content is not a supported video container", ErrCodeUnsupportedFormat)
+	}
+	return nil
+}
 
-	if video.ZipPath != nil && video.Status == "completed" {
-		downloadURL := fmt.Sprintf("/api/v1/videos/%s/download", videoID)
-		response.DownloadURL = &downloadURL
-		response.ZipPath = video.ZipPath
+// storeUpload writes a checked form file to storage and queues its video.
+func (h *VideoHandler) storeUpload(c *gin.Context, header *multipart.FileHeader, options domain.ProcessingOptions, batchID *string) (string, *uploadRejection) {
+	file, err := header.Open()
+	if err != nil {
+		return "", rejectUpload(http.StatusInternalServerError, "Failed to read video file: "+err.Error(), "")
 	}
+	defer file.Close()
 
-	if video.ErrorMessage != nil {
-		response.ErrorMessage = video.ErrorMessage
+	videoID := uuid.New().String()
+	ext := filepath.Ext(header.Filename)
+	filename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), videoID, ext)
+
+	storagePath, err := h.minio.UploadFile(file, filename, header.Size)
+	if err != nil {
+		return "", rejectUpload(http.StatusInternalServerError, "Failed to upload file: "+err.Error(), "")
 	}
 
-	if video.ProcessingStartedAt != nil {
-		response.ProcessingStarted = video.ProcessingStartedAt
+	video := h.newVideo(c, videoID, filename, header.Filename, storagePath, header.Size)
+	video.BatchID = batchID
+
+	if err := h.enqueueVideo(video, options); err != nil {
+		h.minio.DeleteFile(storagePath)
+		return "", rejectUpload(http.StatusInternalServerError, "Failed to create video record: "+err.Error(), "")
 	}
 
-	if video.ProcessingCompletedAt != nil {
-		response.ProcessingCompleted = video.ProcessingCompletedAt
+	h.auditVideo(c, "video.upload", videoID)
+	return videoID, nil
+}
+
+func (h *VideoHandler) GetVideo(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, newVideoResponse(video))
 }
 
 func (h *VideoHandler) List(c *gin.Context) {
-	userID := c.GetString("user_id")
-	status := c.Query("status")
+	h.listVideos(c, false)
+}
+
+// ListTrash lists the caller's videos in the trash, taking the same query
+// parameters as List.
+func (h *VideoHandler) ListTrash(c *gin.Context) {
+	h.listVideos(c, true)
+}
 
-	videos, err := h.db.GetVideosByUserID(userID, status)
+func (h *VideoHandler) listVideos(c *gin.Context, trashed bool) {
+	query, err := parseVideoListQuery(c, c.GetString("user_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch videos"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	query.Trashed = trashed
 
-	responses := make([]VideoResponse, 0)
-	for _, v := range videos {
-		resp := VideoResponse{
-			ID:           v.ID,
-			UserID:       v.UserID,
-			Filename:     v.Filename,
-			OriginalName: v.OriginalName,
-			SizeBytes:    v.SizeBytes,
-			Status:       v.Status,
-			CreatedAt:    v.CreatedAt,
-		}
-
-		if v.FrameCount != nil {
-			resp.FrameCount = v.FrameCount
-		}
-
-		if v.ZipPath != nil && v.Status == "completed" {
-			downloadURL := fmt.Sprintf("/api/v1/videos/%s/download", v.ID)
-			resp.DownloadURL = &downloadURL
-			resp.ZipPath = v.ZipPath
-		}
+	page, err := h.db.ListVideos(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch videos"})
+		return
+	}
 
-		if v.ErrorMessage != nil {
-			resp.ErrorMessage = v.ErrorMessage
-		}
+	response := VideoListResponse{
+		Videos:     make([]VideoResponse, 0, len(page.Videos)),
+		NextCursor: encodeCursor(page.Next),
+	}
+	for _, v := range page.Videos {
+		response.Videos = append(response.Videos, newVideoResponse(v))
+	}
 
-		if v.ProcessingStartedAt != nil {
-			resp.ProcessingStarted = v.ProcessingStartedAt
-		}
+	c.JSON(http.StatusOK, response)
+}
 
-		if v.ProcessingCompletedAt != nil {
-			resp.ProcessingCompleted = v.ProcessingCompletedAt
-		}
+// DeleteVideo moves the video to the trash. Its files are kept until the
+// trash is purged, and it can be restored until then.
+func (h *VideoHandler) DeleteVideo(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
 
-		responses = append(responses, resp)
+	trashed, err := h.db.TrashVideo(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete video"})
+		return
+	}
+	if !trashed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
 	}
 
-	c.JSON(http.StatusOK, responses)
+	h.auditVideo(c, "video.delete", video.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Video moved to trash"})
 }
 
-func (h *VideoHandler) DeleteVideo(c *gin.Context) {
+// RestoreVideo takes a video back out of the trash.
+func (h *VideoHandler) RestoreVideo(c *gin.Context) {
 	videoID := c.Param("id")
-	userID := c.GetString("user_id")
 
 	video, err := h.db.GetVideoByID(videoID)
 	if err != nil {
@@ -271,26 +271,131 @@ func (h *VideoHandler) DeleteVideo(c *gin.Context) {
 		return
 	}
 
-	if video.UserID != userID {
+	if video.UserID != c.GetString("user_id") {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
-	if video.StoragePath != "" {
-		h.minio.DeleteFile(video.StoragePath)
+	if video.DeletedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Video is not in the trash"})
+		return
 	}
-	if video.ZipPath != nil && *video.ZipPath != "" {
-		h.minio.DeleteFile(*video.ZipPath)
+
+	restored, err := h.db.RestoreVideo(videoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore video"})
+		return
+	}
+	if !restored {
+		c.JSON(http.StatusConflict, gin.H{"error": "Video is not in the trash"})
+		return
 	}
 
-	if err := h.db.DeleteVideo(videoID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete video"})
+	h.auditVideo(c, "video.restore", videoID)
+
+	video.DeletedAt = nil
+	c.JSON(http.StatusOK, newVideoResponse(video))
+}
+
+// DownloadZip streams the frames of one of the caller's videos.
+func (h *VideoHandler) DownloadZip(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok || !zipReady(c, video) {
+		return
+	}
+
+	h.streamZip(c, video, nil)
+}
+
+// zipReady checks the video has frames to download, and responds if not.
+func zipReady(c *gin.Context, video *domain.Video) bool {
+	if video.Status == domain.VideoStatusExpired {
+		c.JSON(http.StatusGone, gin.H{"error": "Video frames have expired"})
+		return false
+	}
+
+	if video.Status != "completed" || video.ZipPath == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Video processing not completed"})
+		return false
+	}
+	return true
+}
+
+// streamZip sends the video's ZIP, or the part of it the request asks for.
+func (h *VideoHandler) streamZip(c *gin.Context, video *domain.Video, admit func() bool) {
+	zipPath := *video.ZipPath
+	info, err := h.minio.StatProcessedObject(zipPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file info"})
 		return
 	}
 
+	serveObject(c, info, filepath.Base(zipPath), "application/zip", admit, func(start, end int64) (io.ReadCloser, error) {
+		return h.minio.GetProcessedObjectRange(zipPath, start, end)
+	})
+}
+
+func newVideoResponse(video *domain.Video) VideoResponse {
+	response := VideoResponse{
+		ID:           video.ID,
+		UserID:       video.UserID,
+		Filename:     video.Filename,
+		OriginalName: video.OriginalName,
+		SizeBytes:    video.SizeBytes,
+		Status:       video.Status,
+		CreatedAt:    video.CreatedAt,
+		FrameCount:   video.FrameCount,
+		ErrorMessage: video.ErrorMessage,
+		BatchID:      video.BatchID,
+		CurrentRunID: video.CurrentRunID,
+		DeletedAt:    video.DeletedAt,
+	}
+
+	if video.ZipPath != nil && video.Status == "completed" && video.DeletedAt == nil {
+		downloadURL := fmt.Sprintf("/api/v1/videos/%s/download", video.ID)
+		response.DownloadURL = &downloadURL
+		response.ZipPath = video.ZipPath
+	}
+
+	response.ProcessingStarted = video.ProcessingStartedAt
+	response.ProcessingCompleted = video.ProcessingCompletedAt
+
+	return response
+}
+
+// newVideo builds the record for an uploaded file, prioritised by the
+// uploader's role and how many of their videos are still waiting.
+func (h *VideoHandler) newVideo(c *gin.Context, videoID, filename, originalName, storagePath string, size int64) *domain.Video {
+	userID := c.GetString("user_id")
+
+	activeVideos, err := h.db.CountActiveVideos(userID)
+	if err != nil {
+		fmt.Printf("Failed to count active videos for %s: %v\n", userID, err)
+	}
+
+	return &domain.Video{
+		ID:           videoID,
+		UserID:       userID,
+		Filename:     filename,
+		OriginalName: originalName,
+		SizeBytes:    size,
+		StoragePath:  storagePath,
+		Priority:     uploadPriority(c.GetString("user_role"), activeVideos),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// auditVideo records a video action in the auth service without holding up
+// the response. Actions taken through a share link have no user.
+func (h *VideoHandler) auditVideo(c *gin.Context, action, videoID string) {
+	var user *string
+	if userID := c.GetString("user_id"); userID != "" {
+		user = &userID
+	}
 	auditReq := domain.AuditLogRequest{
-		UserID:     &userID,
-		Action:     "video.delete",
+		UserID:     user,
+		Action:     action,
 		EntityType: "video",
 		EntityID:   &videoID,
 		IPAddress:  c.ClientIP(),
@@ -301,42 +406,139 @@ func (h *VideoHandler) DeleteVideo(c *gin.Context) {
 			fmt.Printf("Failed to create audit log: %v\n", err)
 		}
 	}()
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Video deleted successfully"})
+// enqueueVideo stores the video as queued together with its first run and
+// its processing message in the outbox. The relay publishes the message
+// afterwards, so an upload is never lost between the database and the broker.
+func (h *VideoHandler) enqueueVideo(video *domain.Video, options domain.ProcessingOptions) error {
+	message := domain.VideoProcessingMessage{
+		VideoID:     video.ID,
+		UserID:      video.UserID,
+		StoragePath: video.StoragePath,
+		Filename:    video.Filename,
+		Priority:    video.Priority,
+		Options:     options,
+		RunID:       uuid.New().String(),
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	video.Status = "queued"
+	video.QueuedAt = TimePtr(time.Now())
+	video.CurrentRunID = &message.RunID
+
+	return h.db.CreateVideoWithOutbox(video, &domain.OutboxMessage{
+		ID:          uuid.New().String(),
+		AggregateID: video.ID,
+		Exchange:    "video.exchange",
+		RoutingKey:  "video.upload",
+		Payload:     payload,
+		Priority:    video.Priority,
+		CreatedAt:   time.Now(),
+	})
 }
 
-func (h *VideoHandler) DownloadZip(c *gin.Context) {
-	videoID := c.Param("id")
+// OptionsRequest carries processing options in a JSON body, with the same
+// fields and formats as the form upload.
+type OptionsRequest struct {
+	SnapToChapters bool     `json:"snap_to_chapters"`
+	Start          string   `json:"start"`
+	End            string   `json:"end"`
+	Timestamps     []string `json:"timestamps"`
+}
 
-	video, err := h.db.GetVideoByID(videoID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
-		return
+func (o OptionsRequest) parse() (domain.ProcessingOptions, error) {
+	fields := map[string]string{"start": o.Start, "end": o.End}
+	if o.SnapToChapters {
+		fields["snap_to_chapters"] = "true"
+	}
+	return parseOptionFields(func(key string) string { return fields[key] }, o.Timestamps)
+}
+
+func parseProcessingOptions(c *gin.Context) (domain.ProcessingOptions, error) {
+	return parseOptionFields(c.PostForm, c.PostFormArray("timestamps[]"))
+}
+
+// parseOptionFields validates processing options read through get, so form
+// uploads and tus upload metadata follow the same rules.
+func parseOptionFields(get func(string) string, timestamps []string) (domain.ProcessingOptions, error) {
+	options := domain.ProcessingOptions{}
+
+	if value := get("snap_to_chapters"); value != "" {
+		snap, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, fmt.Errorf("Invalid snap_to_chapters value: %s", value)
+		}
+		options.SnapToChapters = snap
 	}
 
-	if video.Status != "completed" || video.ZipPath == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Video processing not completed"})
-		return
+	if value := get("start"); value != "" {
+		start, err := parseTimestamp(value)
+		if err != nil {
+			return options, fmt.Errorf("Invalid start value: %s", value)
+		}
+		options.Start = &start
 	}
 
-	object, err := h.minio.GetFileStream(*video.ZipPath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get file stream: %v", err)})
-		return
+	if value := get("end"); value != "" {
+		end, err := parseTimestamp(value)
+		if err != nil {
+			return options, fmt.Errorf("Invalid end value: %s", value)
+		}
+		options.End = &end
 	}
-	defer object.Close()
 
-	info, err := object.Stat()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file info"})
-		return
+	if options.Start != nil && options.End != nil && *options.End <= *options.Start {
+		return options, fmt.Errorf("end must be after start")
+	}
+
+	if len(timestamps) > maxTimestamps {
+		return options, fmt.Errorf("Too many timestamps. Max: %d", maxTimestamps)
+	}
+	for _, value := range timestamps {
+		ts, err := parseTimestamp(value)
+		if err != nil {
+			return options, fmt.Errorf("Invalid timestamp value: %s", value)
+		}
+		options.Timestamps = append(options.Timestamps, ts)
+	}
+
+	if len(options.Timestamps) > 0 && (options.Start != nil || options.End != nil || options.SnapToChapters) {
+		return options, fmt.Errorf("timestamps cannot be combined with start, end or snap_to_chapters")
 	}
 
-	extraHeaders := map[string]string{
-		"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(*video.ZipPath)),
+	return options, nil
+}
+
+// maxTimestamps bounds how many single-frame seeks one upload may request.
+const maxTimestamps = 500
+
+// parseTimestamp accepts plain seconds ("754.5") or a clock position
+// ("12:34.5", "00:12:34").
+func parseTimestamp(value string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
 	}
 
-	c.DataFromReader(http.StatusOK, info.Size, "application/zip", object, extraHeaders)
+	var seconds float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+		if i < len(parts)-1 && n != math.Trunc(n) {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+		if i > 0 && n >= 60 {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, nil
 }
 
 func isValidVideoFile(filename string) bool {
@@ -2,6 +2,8 @@ package handlers
 
 import (
 "bytes"
+"context"
+"encoding/json"
 "errors"
 "io"
 "mime/multipart"
@@ -14,6 +16,7 @@ import (
 
 "github.com/gin-gonic/gin"
 "github.com/minio/minio-go/v7"
+amqp "github.com/rabbitmq/amqp091-go"
 "github.com/stretchr/testify/assert"
 "github.com/stretchr/testify/mock"
 )
@@ -35,20 +38,247 @@ func (m *MockDatabase) GetVideoByID(id string) (*domain.Video, error) {
 	return args.Get(0).(*domain.Video), args.Error(1)
 }
 
-func (m *MockDatabase) GetVideosByUserID(userID, status string) ([]*domain.Video, error) {
-	args := m.Called(userID, status)
+func (m *MockDatabase) CreateUploadSession(session *domain.UploadSession) error {
+	return m.Called(session).Error(0)
+}
+
+func (m *MockDatabase) GetUploadSession(id string) (*domain.UploadSession, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UploadSession), args.Error(1)
+}
+
+func (m *MockDatabase) LockUploadSession(id string, lease time.Duration) (*domain.UploadSession, error) {
+	args := m.Called(id, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UploadSession), args.Error(1)
+}
+
+func (m *MockDatabase) UpdateUploadSession(session *domain.UploadSession) error {
+	return m.Called(session).Error(0)
+}
+
+func (m *MockDatabase) DeleteUploadSession(id string) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockDatabase) ListExpiredUploadSessions(before time.Time, afterID string, limit int) ([]*domain.UploadSession, error) {
+	args := m.Called(before, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UploadSession), args.Error(1)
+}
+
+func (m *MockDatabase) CreateVideoImport(video *domain.Video, videoImport *domain.VideoImport) error {
+	return m.Called(video, videoImport).Error(0)
+}
+
+func (m *MockDatabase) ClaimVideoImport(lease time.Duration) (*domain.VideoImport, error) {
+	args := m.Called(lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VideoImport), args.Error(1)
+}
+
+func (m *MockDatabase) UpdateVideoImportProgress(videoID string, bytesFetched int64) error {
+	return m.Called(videoID, bytesFetched).Error(0)
+}
+
+func (m *MockDatabase) CompleteVideoImport(videoID, storagePath string, size int64, message *domain.OutboxMessage) (bool, error) {
+	args := m.Called(videoID, storagePath, size, message)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabase) RetryVideoImport(videoID string, delay time.Duration, reason string) error {
+	return m.Called(videoID, delay, reason).Error(0)
+}
+
+func (m *MockDatabase) FailVideoImport(videoID, reason string) error {
+	return m.Called(videoID, reason).Error(0)
+}
+
+func (m *MockDatabase) CreateBatch(batch *domain.Batch) error {
+	return m.Called(batch).Error(0)
+}
+
+func (m *MockDatabase) GetBatch(id string) (*domain.Batch, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Batch), args.Error(1)
+}
+
+func (m *MockDatabase) GetBatchVideos(batchID string) ([]*domain.Video, error) {
+	args := m.Called(batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Video), args.Error(1)
+}
+
+func (m *MockDatabase) SealBatch(id string) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockDatabase) QueueVideoRun(videoID string, message *domain.OutboxMessage) (*domain.VideoRun, error) {
+	args := m.Called(videoID, message)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VideoRun), args.Error(1)
+}
+
+func (m *MockDatabase) GetVideoRuns(videoID string) ([]*domain.VideoRun, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.VideoRun), args.Error(1)
+}
+
+func (m *MockDatabase) SetCurrentVideoRun(videoID, runID string) (bool, error) {
+	args := m.Called(videoID, runID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabase) ListVideos(query domain.VideoListQuery) (*domain.VideoPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VideoPage), args.Error(1)
+}
+
+func (m *MockDatabase) CreateVideoWithOutbox(video *domain.Video, message *domain.OutboxMessage) error {
+	return m.Called(video, message).Error(0)
+}
+
+func (m *MockDatabase) RelayOutbox(limit int, publish func(*domain.OutboxMessage) error) (int, error) {
+	args := m.Called(limit, publish)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDatabase) PruneOutbox(sentBefore time.Time, limit int) (int, error) {
+	args := m.Called(sentBefore, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDatabase) ApplyVideoEvent(event *domain.VideoEvent) (bool, error) {
+	args := m.Called(event)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabase) CountActiveVideos(userID string) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDatabase) GetUserQuota(userID string) (*domain.UserQuota, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserQuota), args.Error(1)
+}
+
+func (m *MockDatabase) SetUserQuota(quota *domain.UserQuota) error {
+	return m.Called(quota).Error(0)
+}
+
+func (m *MockDatabase) GetQuotaUsage(userID string, since time.Time) (*domain.QuotaUsage, error) {
+	args := m.Called(userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.QuotaUsage), args.Error(1)
+}
+
+func (m *MockDatabase) ListRetentionCandidates(before time.Time, afterID string, limit int) ([]*domain.Video, error) {
+	args := m.Called(before, afterID, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*domain.Video), args.Error(1)
 }
 
+func (m *MockDatabase) ApplyRetention(videoID string, deleteRaw, expire bool) ([]string, error) {
+	args := m.Called(videoID, deleteRaw, expire)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDatabase) MarkRetentionWarned(videoID string, message *domain.OutboxMessage) (bool, error) {
+	args := m.Called(videoID, message)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockDatabase) UpdateVideo(video *domain.Video) error {
 	return m.Called(video).Error(0)
 }
 
-func (m *MockDatabase) DeleteVideo(id string) error {
-	return m.Called(id).Error(0)
+func (m *MockDatabase) TrashVideo(id string) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabase) RestoreVideo(id string) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabase) ListTrashedVideos(before time.Time, afterID string, limit int) ([]*domain.Video, error) {
+	args := m.Called(before, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Video), args.Error(1)
+}
+
+func (m *MockDatabase) PurgeVideo(id string, trashedBefore time.Time) ([]string, error) {
+	args := m.Called(id, trashedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDatabase) CreateShareLink(link *domain.ShareLink) error {
+	return m.Called(link).Error(0)
+}
+
+func (m *MockDatabase) GetShareLinks(videoID string) ([]*domain.ShareLink, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ShareLink), args.Error(1)
+}
+
+func (m *MockDatabase) GetShareLinkByToken(tokenHash string) (*domain.ShareLink, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ShareLink), args.Error(1)
+}
+
+func (m *MockDatabase) UseShareLink(id string) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabase) RevokeShareLink(videoID, id string) (bool, error) {
+	args := m.Called(videoID, id)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockDatabase) GetUserStats(userID string) (*domain.UserStats, error) {
@@ -85,16 +315,79 @@ func (m *MockMinIO) UploadFile(reader io.Reader, filename string, size int64) (s
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockMinIO) NewMultipartUpload(filename string) (string, string, error) {
+	args := m.Called(filename)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockMinIO) UploadPart(objectName, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	return m.Called(objectName, uploadID, partNumber, reader, size).Error(0)
+}
+
+func (m *MockMinIO) CompleteMultipartUpload(objectName, uploadID string) error {
+	return m.Called(objectName, uploadID).Error(0)
+}
+
+func (m *MockMinIO) AbortMultipartUpload(objectName, uploadID string) error {
+	return m.Called(objectName, uploadID).Error(0)
+}
+
+func (m *MockMinIO) PutRawObject(objectName string, reader io.Reader, size int64) error {
+	return m.Called(objectName, reader, size).Error(0)
+}
+
+func (m *MockMinIO) GetRawObject(objectName string) (io.ReadCloser, error) {
+	args := m.Called(objectName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockMinIO) StatRawObject(objectName string) (minio.ObjectInfo, error) {
+	args := m.Called(objectName)
+	return args.Get(0).(minio.ObjectInfo), args.Error(1)
+}
+
+func (m *MockMinIO) GetRawObjectRange(objectName string, start, end int64) (io.ReadCloser, error) {
+	args := m.Called(objectName, start, end)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockMinIO) PresignPutObject(objectName, contentType string, size int64, checksumSHA256 string, expires time.Duration) (string, error) {
+	args := m.Called(objectName, contentType, size, checksumSHA256, expires)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockMinIO) DeleteFile(objectName string) error {
 	return m.Called(objectName).Error(0)
 }
 
-func (m *MockMinIO) GetFileStream(objectName string) (*minio.Object, error) {
+func (m *MockMinIO) GetProcessedObject(objectName string) (io.ReadCloser, error) {
 	args := m.Called(objectName)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*minio.Object), args.Error(1)
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockMinIO) StatProcessedObject(objectName string) (minio.ObjectInfo, error) {
+	args := m.Called(objectName)
+	return args.Get(0).(minio.ObjectInfo), args.Error(1)
+}
+
+func (m *MockMinIO) GetProcessedObjectRange(objectName string, start, end int64) (io.ReadCloser, error) {
+	args := m.Called(objectName, start, end)
+	if read, ok := args.Get(0).(func(start, end int64) (io.ReadCloser, error)); ok {
+		return read(start, end)
+	}
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
 }
 
 // MockRabbitMQ implements domain.RabbitMQInterface.
@@ -102,8 +395,16 @@ type MockRabbitMQ struct {
 	mock.Mock
 }
 
-func (m *MockRabbitMQ) PublishVideoUpload(message domain.VideoProcessingMessage) error {
-	return m.Called(message).Error(0)
+func (m *MockRabbitMQ) PublishConfirmed(ctx context.Context, exchange, routingKey string, body []byte, priority int) error {
+	return m.Called(ctx, exchange, routingKey, body, priority).Error(0)
+}
+
+func (m *MockRabbitMQ) SubscribeVideoEvents(consumerTag string) (<-chan amqp.Delivery, error) {
+	args := m.Called(consumerTag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan amqp.Delivery), args.Error(1)
 }
 
 func (m *MockRabbitMQ) Ping() error {
@@ -123,11 +424,38 @@ func (m *MockAuthClient) CreateAuditLog(req domain.AuditLogRequest) error {
 	return m.Called(req).Error(0)
 }
 
+func (m *MockAuthClient) GetUserRole(userID string) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
 // ---------- Upload ----------
 
+// withPayload matches an outbox message whose payload satisfies fn.
+func withPayload(fn func(m domain.VideoProcessingMessage) bool) interface{} {
+	return mock.MatchedBy(func(o *domain.OutboxMessage) bool {
+		var m domain.VideoProcessingMessage
+		return json.Unmarshal(o.Payload, &m) == nil && fn(m)
+	})
+}
+
+// allowQuota leaves the user on their plan with nothing stored yet.
+func allowQuota(db *MockDatabase, userID string) {
+	db.On("GetUserQuota", userID).Return(nil, nil)
+	db.On("GetQuotaUsage", userID, mock.Anything).Return(&domain.QuotaUsage{}, nil)
+}
+
+var (
+	sampleMP4 = append([]byte("\x00\x00\x00\x18ftypmp42"), []byte("fake video content")...)
+	sampleMKV = append([]byte{0x1A, 0x45, 0xDF, 0xA3}, []byte("fake video content")...)
+	// sampleMOV is a QuickTime file with no ftyp, starting with a wide atom.
+	sampleMOV = append([]byte("\x00\x00\x00\x08wide\x00\x00\x00\x1amdat"), []byte("fake video content")...)
+)
+
 func TestUpload_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
 	mockMinio := new(MockMinIO)
 	mockRabbit := new(MockRabbitMQ)
 	mockAuth := new(MockAuthClient)
@@ -142,13 +470,12 @@ handler.Upload(c)
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	part, _ := writer.CreateFormFile("video", "test.mp4")
-	part.Write([]byte("fake video content"))
+	part.Write(sampleMP4)
 	writer.Close()
 
 	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/test.mp4", nil)
-	mockDB.On("CreateVideo", mock.Anything).Return(nil)
-	mockRabbit.On("PublishVideoUpload", mock.Anything).Return(nil)
-	mockDB.On("UpdateVideo", mock.Anything).Return(nil)
+	mockDB.On("CountActiveVideos", mock.Anything).Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(nil)
 	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("POST", "/upload", body)
@@ -160,6 +487,45 @@ handler.Upload(c)
 	time.Sleep(20 * time.Millisecond) // let audit goroutine finish
 }
 
+func TestUpload_PriorityFromRoleAndBacklog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	mockRabbit := new(MockRabbitMQ)
+	mockAuth := new(MockAuthClient)
+	handler := NewVideoHandler(mockDB, mockMinio, mockRabbit, mockAuth)
+
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		c.Set("user_role", "admin")
+		handler.Upload(c)
+	})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("video", "test.mp4")
+	part.Write(sampleMP4)
+	writer.Close()
+
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/test.mp4", nil)
+	mockDB.On("CountActiveVideos", "user123").Return(25, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.MatchedBy(func(v *domain.Video) bool { return v.Priority == 6 }), withPayload(func(m domain.VideoProcessingMessage) bool {
+		return m.Priority == 6
+	})).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
 func TestUpload_InvalidFormat(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	handler := NewVideoHandler(nil, nil, nil, nil)
@@ -202,8 +568,10 @@ handler.Upload(c)
 
 func TestUpload_MinIOError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
 	mockMinio := new(MockMinIO)
-	handler := NewVideoHandler(nil, mockMinio, nil, nil)
+	handler := NewVideoHandler(mockDB, mockMinio, nil, nil)
 
 	r := gin.New()
 	r.POST("/upload", func(c *gin.Context) {
@@ -214,7 +582,7 @@ handler.Upload(c)
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	part, _ := writer.CreateFormFile("video", "test.mp4")
-	part.Write([]byte("fake video content"))
+	part.Write(sampleMP4)
 	writer.Close()
 
 	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("upload failed"))
@@ -230,6 +598,7 @@ handler.Upload(c)
 func TestUpload_DBCreateError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
 	mockMinio := new(MockMinIO)
 	handler := NewVideoHandler(mockDB, mockMinio, nil, nil)
 
@@ -242,11 +611,12 @@ handler.Upload(c)
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	part, _ := writer.CreateFormFile("video", "test.mp4")
-	part.Write([]byte("fake video content"))
+	part.Write(sampleMP4)
 	writer.Close()
 
 	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/test.mp4", nil)
-	mockDB.On("CreateVideo", mock.Anything).Return(errors.New("db error"))
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(errors.New("db error"))
+	mockDB.On("CountActiveVideos", mock.Anything).Return(0, nil)
 	mockMinio.On("DeleteFile", "path/test.mp4").Return(nil)
 
 	req, _ := http.NewRequest("POST", "/upload", body)
@@ -257,36 +627,328 @@ handler.Upload(c)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
-func TestUpload_RabbitMQError(t *testing.T) {
+func TestUpload_QueuedThroughOutbox(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
 	mockMinio := new(MockMinIO)
 	mockRabbit := new(MockRabbitMQ)
-	handler := NewVideoHandler(mockDB, mockMinio, mockRabbit, nil)
+	mockAuth := new(MockAuthClient)
+	handler := NewVideoHandler(mockDB, mockMinio, mockRabbit, mockAuth)
 
 	r := gin.New()
 	r.POST("/upload", func(c *gin.Context) {
-c.Set("user_id", "user123")
-handler.Upload(c)
-})
+		c.Set("user_id", "user123")
+		handler.Upload(c)
+	})
 
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	part, _ := writer.CreateFormFile("video", "test.mp4")
-	part.Write([]byte("fake video content"))
+	part.Write(sampleMP4)
 	writer.Close()
 
 	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/test.mp4", nil)
-	mockDB.On("CreateVideo", mock.Anything).Return(nil)
-	mockRabbit.On("PublishVideoUpload", mock.Anything).Return(errors.New("rabbitmq down"))
-	mockDB.On("UpdateVideo", mock.Anything).Return(nil)
+	mockDB.On("CountActiveVideos", mock.Anything).Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox",
+		mock.MatchedBy(func(v *domain.Video) bool { return v.Status == "queued" && v.QueuedAt != nil }),
+		mock.MatchedBy(func(o *domain.OutboxMessage) bool {
+			var m domain.VideoProcessingMessage
+			return o.Exchange == "video.exchange" && o.RoutingKey == "video.upload" &&
+				json.Unmarshal(o.Payload, &m) == nil && m.VideoID == o.AggregateID && m.StoragePath == "path/test.mp4"
+		}),
+	).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("POST", "/upload", body)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertExpectations(t)
+	mockRabbit.AssertNotCalled(t, "PublishConfirmed", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestUpload_EmptyFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(nil, nil, nil, nil)
+
+	r := gin.New()
+	r.POST("/upload", handler.Upload)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.CreateFormFile("video", "empty.mp4")
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error_code":"empty_file"`)
+}
+
+func TestUpload_QuickTimeWithoutFtyp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	mockAuth := new(MockAuthClient)
+	handler := NewVideoHandler(mockDB, mockMinio, new(MockRabbitMQ), mockAuth)
+
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.Upload(c)
+	})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("video", "clip.mov")
+	part.Write(sampleMOV)
+	writer.Close()
+
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/clip.mov", nil)
+	mockDB.On("CountActiveVideos", mock.Anything).Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockMinio.AssertCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+	time.Sleep(20 * time.Millisecond) // let audit goroutine finish
+}
+
+func TestUpload_RenamedNonVideo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(nil, nil, nil, nil)
+
+	r := gin.New()
+	r.POST("/upload", handler.Upload)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("video", "notes.mp4")
+	part.Write([]byte("just some text that was renamed"))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error_code":"unsupported_format"`)
+}
+
+func TestUpload_SnapToChapters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	mockRabbit := new(MockRabbitMQ)
+	mockAuth := new(MockAuthClient)
+	handler := NewVideoHandler(mockDB, mockMinio, mockRabbit, mockAuth)
+
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.Upload(c)
+	})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("snap_to_chapters", "true")
+	part, _ := writer.CreateFormFile("video", "talk.mkv")
+	part.Write(sampleMKV)
+	writer.Close()
+
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/talk.mkv", nil)
+	mockDB.On("CountActiveVideos", mock.Anything).Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, withPayload(func(m domain.VideoProcessingMessage) bool {
+		return m.Options.SnapToChapters
+	})).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestUpload_InvalidSnapToChapters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(nil, nil, nil, nil)
+
+	r := gin.New()
+	r.POST("/upload", handler.Upload)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("snap_to_chapters", "maybe")
+	part, _ := writer.CreateFormFile("video", "talk.mkv")
+	part.Write(sampleMKV)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "snap_to_chapters")
+}
+
+func TestUpload_TimeRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	mockRabbit := new(MockRabbitMQ)
+	mockAuth := new(MockAuthClient)
+	handler := NewVideoHandler(mockDB, mockMinio, mockRabbit, mockAuth)
+
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.Upload(c)
+	})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("start", "12:00")
+	writer.WriteField("end", "900")
+	part, _ := writer.CreateFormFile("video", "test.mp4")
+	part.Write(sampleMP4)
+	writer.Close()
+
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/test.mp4", nil)
+	mockDB.On("CountActiveVideos", mock.Anything).Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, withPayload(func(m domain.VideoProcessingMessage) bool {
+		return m.Options.Start != nil && *m.Options.Start == 720 &&
+			m.Options.End != nil && *m.Options.End == 900
+	})).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestUpload_Timestamps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	allowQuota(mockDB, "user123")
+	mockMinio := new(MockMinIO)
+	mockRabbit := new(MockRabbitMQ)
+	mockAuth := new(MockAuthClient)
+	handler := NewVideoHandler(mockDB, mockMinio, mockRabbit, mockAuth)
+
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.Upload(c)
+	})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("timestamps[]", "00:01:05")
+	writer.WriteField("timestamps[]", "3.5")
+	part, _ := writer.CreateFormFile("video", "test.mp4")
+	part.Write(sampleMP4)
+	writer.Close()
+
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("path/test.mp4", nil)
+	mockDB.On("CountActiveVideos", mock.Anything).Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, withPayload(func(m domain.VideoProcessingMessage) bool {
+		return assert.ObjectsAreEqual([]float64{65, 3.5}, m.Options.Timestamps)
+	})).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestUpload_InvalidExtractionOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields [][2]string
+		errMsg string
+	}{
+		{"bad start", [][2]string{{"start", "soon"}}, "Invalid start value"},
+		{"end before start", [][2]string{{"start", "60"}, {"end", "30"}}, "end must be after start"},
+		{"bad timestamp", [][2]string{{"timestamps[]", "1:75"}}, "Invalid timestamp value"},
+		{"timestamps with range", [][2]string{{"start", "1"}, {"timestamps[]", "5"}}, "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			handler := NewVideoHandler(nil, nil, nil, nil)
+
+			r := gin.New()
+			r.POST("/upload", handler.Upload)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			for _, field := range tt.fields {
+				writer.WriteField(field[0], field[1])
+			}
+			part, _ := writer.CreateFormFile("video", "test.mp4")
+			part.Write(sampleMP4)
+			writer.Close()
+
+			req, _ := http.NewRequest("POST", "/upload", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.errMsg)
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	valid := map[string]float64{
+		"0":           0,
+		"754.5":       754.5,
+		"12:34.5":     754.5,
+		"00:12:34":    754,
+		"1:00:00.250": 3600.25,
+	}
+	for input, expected := range valid {
+		got, err := parseTimestamp(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+
+	for _, input := range []string{"", "-1", "abc", "1:60", "1.5:00", "1:2:3:4", "NaN"} {
+		_, err := parseTimestamp(input)
+		assert.Error(t, err, input)
+	}
 }
 
 // ---------- GetVideo ----------
@@ -334,6 +996,27 @@ handler.GetVideo(c)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestGetVideo_InTrash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	handler := NewVideoHandler(mockDB, nil, nil, nil)
+
+	r := gin.New()
+	r.GET("/videos/:id", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.GetVideo(c)
+	})
+
+	deletedAt := time.Now()
+	mockDB.On("GetVideoByID", "v1").Return(&domain.Video{ID: "v1", UserID: "user123", DeletedAt: &deletedAt}, nil)
+
+	req, _ := http.NewRequest("GET", "/videos/v1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func TestGetVideo_AccessDenied(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDB := new(MockDatabase)
@@ -409,13 +1092,19 @@ handler.List(c)
 			ProcessingCompletedAt: &now,
 		},
 	}
-	mockDB.On("GetVideosByUserID", "user123", "").Return(videos, nil)
+	mockDB.On("ListVideos", domain.VideoListQuery{
+		UserID: "user123", Sort: domain.VideoSortCreated, Descending: true, Limit: defaultPageSize,
+	}).Return(&domain.VideoPage{Videos: videos}, nil)
 
 	req, _ := http.NewRequest("GET", "/videos", nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	var resp VideoListResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp.Videos, 1)
+	assert.Empty(t, resp.NextCursor)
 }
 
 func TestList_Error(t *testing.T) {
@@ -429,7 +1118,7 @@ c.Set("user_id", "user123")
 handler.List(c)
 })
 
-	mockDB.On("GetVideosByUserID", "user123", "").Return(nil, errors.New("db error"))
+	mockDB.On("ListVideos", mock.Anything).Return(nil, errors.New("db error"))
 
 	req, _ := http.NewRequest("GET", "/videos", nil)
 	w := httptest.NewRecorder()
@@ -456,17 +1145,19 @@ handler.DeleteVideo(c)
 	zipPath := "z.zip"
 	video := &domain.Video{ID: "v1", UserID: "user123", StoragePath: "s", ZipPath: &zipPath}
 	mockDB.On("GetVideoByID", "v1").Return(video, nil)
-	mockMinio.On("DeleteFile", "s").Return(nil)
-	mockMinio.On("DeleteFile", "z.zip").Return(nil)
-	mockDB.On("DeleteVideo", "v1").Return(nil)
-	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+	mockDB.On("TrashVideo", "v1").Return(true, nil)
+	mockAuth.On("CreateAuditLog", mock.MatchedBy(func(req domain.AuditLogRequest) bool {
+		return req.Action == "video.delete"
+	})).Return(nil)
 
 	req, _ := http.NewRequest("DELETE", "/videos/v1", nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	mockMinio.AssertNotCalled(t, "DeleteFile", mock.Anything)
 	time.Sleep(20 * time.Millisecond)
+	mockAuth.AssertExpectations(t)
 }
 
 func TestDeleteVideo_NotFound(t *testing.T) {
@@ -513,8 +1204,7 @@ handler.DeleteVideo(c)
 func TestDeleteVideo_DBError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDB := new(MockDatabase)
-	mockMinio := new(MockMinIO)
-	handler := NewVideoHandler(mockDB, mockMinio, nil, nil)
+	handler := NewVideoHandler(mockDB, nil, nil, nil)
 
 	r := gin.New()
 	r.DELETE("/videos/:id", func(c *gin.Context) {
@@ -524,7 +1214,7 @@ handler.DeleteVideo(c)
 
 	video := &domain.Video{ID: "v1", UserID: "user123", StoragePath: ""}
 	mockDB.On("GetVideoByID", "v1").Return(video, nil)
-	mockDB.On("DeleteVideo", "v1").Return(errors.New("db error"))
+	mockDB.On("TrashVideo", "v1").Return(false, errors.New("db error"))
 
 	req, _ := http.NewRequest("DELETE", "/videos/v1", nil)
 	w := httptest.NewRecorder()
@@ -533,12 +1223,10 @@ handler.DeleteVideo(c)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
-func TestDeleteVideo_EmptyZipPath(t *testing.T) {
+func TestDeleteVideo_AlreadyInTrash(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDB := new(MockDatabase)
-	mockMinio := new(MockMinIO)
-	mockAuth := new(MockAuthClient)
-	handler := NewVideoHandler(mockDB, mockMinio, nil, mockAuth)
+	handler := NewVideoHandler(mockDB, nil, nil, nil)
 
 	r := gin.New()
 	r.DELETE("/videos/:id", func(c *gin.Context) {
@@ -546,19 +1234,82 @@ func TestDeleteVideo_EmptyZipPath(t *testing.T) {
 		handler.DeleteVideo(c)
 	})
 
-	emptyZip := ""
-	video := &domain.Video{ID: "v1", UserID: "user123", StoragePath: "s", ZipPath: &emptyZip}
+	deletedAt := time.Now()
+	video := &domain.Video{ID: "v1", UserID: "user123", DeletedAt: &deletedAt}
 	mockDB.On("GetVideoByID", "v1").Return(video, nil)
-	mockMinio.On("DeleteFile", "s").Return(nil)
-	mockDB.On("DeleteVideo", "v1").Return(nil)
-	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("DELETE", "/videos/v1", nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockDB.AssertNotCalled(t, "TrashVideo", mock.Anything)
+}
+
+// ---------- RestoreVideo ----------
+
+func restoreRouter(db *MockDatabase, auth *MockAuthClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(db, nil, nil, auth)
+	r := gin.New()
+	r.POST("/videos/:id/restore", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.RestoreVideo(c)
+	})
+	return r
+}
+
+func TestRestoreVideo_Success(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	r := restoreRouter(mockDB, mockAuth)
+
+	deletedAt := time.Now()
+	zipPath := "z.zip"
+	video := &domain.Video{ID: "v1", UserID: "user123", Status: "completed", ZipPath: &zipPath, DeletedAt: &deletedAt}
+	mockDB.On("GetVideoByID", "v1").Return(video, nil)
+	mockDB.On("RestoreVideo", "v1").Return(true, nil)
+	mockAuth.On("CreateAuditLog", mock.MatchedBy(func(req domain.AuditLogRequest) bool {
+		return req.Action == "video.restore"
+	})).Return(nil)
+
+	w := serve(r, http.MethodPost, "/videos/v1/restore")
+
 	assert.Equal(t, http.StatusOK, w.Code)
+	var response VideoResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Nil(t, response.DeletedAt)
+	assert.Equal(t, "/api/v1/videos/v1/download", *response.DownloadURL)
 	time.Sleep(20 * time.Millisecond)
+	mockAuth.AssertExpectations(t)
+}
+
+func TestRestoreVideo_Rejected(t *testing.T) {
+	deletedAt := time.Now()
+	cases := []struct {
+		name     string
+		video    *domain.Video
+		restored bool
+		status   int
+	}{
+		{"not in trash", &domain.Video{ID: "v1", UserID: "user123"}, false, http.StatusConflict},
+		{"restored meanwhile", &domain.Video{ID: "v1", UserID: "user123", DeletedAt: &deletedAt}, false, http.StatusConflict},
+		{"other user", &domain.Video{ID: "v1", UserID: "other", DeletedAt: &deletedAt}, false, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			r := restoreRouter(mockDB, nil)
+
+			mockDB.On("GetVideoByID", "v1").Return(tc.video, nil)
+			mockDB.On("RestoreVideo", "v1").Return(tc.restored, nil)
+
+			w := serve(r, http.MethodPost, "/videos/v1/restore")
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
 }
 
 func TestDownloadZip_Error_NotCompleted(t *testing.T) {
@@ -567,9 +1318,12 @@ func TestDownloadZip_Error_NotCompleted(t *testing.T) {
 	handler := NewVideoHandler(mockDB, nil, nil, nil)
 
 	r := gin.New()
-	r.GET("/videos/:id/download", handler.DownloadZip)
+	r.GET("/videos/:id/download", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.DownloadZip(c)
+	})
 
-	video := &domain.Video{ID: "v1", Status: "processing"}
+	video := &domain.Video{ID: "v1", UserID: "user123", Status: "processing"}
 	mockDB.On("GetVideoByID", "v1").Return(video, nil)
 
 	req, _ := http.NewRequest("GET", "/videos/v1/download", nil)
@@ -579,6 +1333,27 @@ func TestDownloadZip_Error_NotCompleted(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestDownloadZip_Error_Expired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	handler := NewVideoHandler(mockDB, nil, nil, nil)
+
+	r := gin.New()
+	r.GET("/videos/:id/download", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.DownloadZip(c)
+	})
+
+	video := &domain.Video{ID: "v1", UserID: "user123", Status: domain.VideoStatusExpired}
+	mockDB.On("GetVideoByID", "v1").Return(video, nil)
+
+	req, _ := http.NewRequest("GET", "/videos/v1/download", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+}
+
 func TestDownloadZip_Error_GetStreamFails(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDB := new(MockDatabase)
@@ -586,12 +1361,16 @@ func TestDownloadZip_Error_GetStreamFails(t *testing.T) {
 	handler := NewVideoHandler(mockDB, mockMinio, nil, nil)
 
 	r := gin.New()
-	r.GET("/videos/:id/download", handler.DownloadZip)
+	r.GET("/videos/:id/download", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.DownloadZip(c)
+	})
 
 	zipPath := "z.zip"
-	video := &domain.Video{ID: "v1", Status: "completed", ZipPath: &zipPath}
+	video := &domain.Video{ID: "v1", UserID: "user123", Status: "completed", ZipPath: &zipPath}
 	mockDB.On("GetVideoByID", "v1").Return(video, nil)
-	mockMinio.On("GetFileStream", "z.zip").Return(nil, errors.New("stream error"))
+	mockMinio.On("StatProcessedObject", "z.zip").Return(minio.ObjectInfo{Size: 3, ETag: "abc"}, nil)
+	mockMinio.On("GetProcessedObjectRange", "z.zip", int64(0), int64(-1)).Return(nil, errors.New("stream error"))
 
 	req, _ := http.NewRequest("GET", "/videos/v1/download", nil)
 	w := httptest.NewRecorder()
@@ -600,6 +1379,30 @@ func TestDownloadZip_Error_GetStreamFails(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+func TestDownloadZip_AccessDenied(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	handler := NewVideoHandler(mockDB, mockMinio, nil, nil)
+
+	r := gin.New()
+	r.GET("/videos/:id/download", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.DownloadZip(c)
+	})
+
+	zipPath := "z.zip"
+	video := &domain.Video{ID: "v1", UserID: "other_user", Status: "completed", ZipPath: &zipPath}
+	mockDB.On("GetVideoByID", "v1").Return(video, nil)
+
+	req, _ := http.NewRequest("GET", "/videos/v1/download", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockMinio.AssertNotCalled(t, "StatProcessedObject", mock.Anything)
+}
+
 // ---------- helpers ----------
 
 func TestIsValidVideoFile(t *testing.T) {
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"time"
+
+	"video-service/domain"
+
+	"shared/netguard"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ImportRequest asks for a video to be fetched from a URL. Filename defaults
+// to the last segment of the URL path.
+type ImportRequest struct {
+	URL      string         `json:"url" binding:"required"`
+	Filename string         `json:"filename"`
+	BatchID  string         `json:"batch_id"`
+	Options  OptionsRequest `json:"options"`
+}
+
+// ImportVideo accepts a URL to import from. The video is created as
+// importing and fetched in the background by the importer, which enforces
+// the size, content and address rules; its progress and any failure show up
+// on the video like they do for processing.
+func (h *VideoHandler) ImportVideo(c *gin.Context) {
+	var req ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	sourceURL, err := netguard.ValidateURL(req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	originalName := req.Filename
+	if originalName == "" {
+		originalName = path.Base(sourceURL.Path)
+	}
+	if !isValidVideoFile(originalName) {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success:   false,
+			Message:   "Invalid video format. Supported: mp4, avi, mov, mkv, wmv, flv, webm (set filename if the URL does not end in one)",
+			ErrorCode: ErrCodeUnsupportedFormat,
+		})
+		return
+	}
+
+	options, err := req.Options.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The size is not known until the file is fetched, where the importer's
+	// own limit applies.
+	if rejection := h.checkQuota(c, 0); rejection != nil {
+		c.JSON(rejection.status, rejection.response)
+		return
+	}
+
+	batchID, ok := h.batchForUpload(c, req.BatchID)
+	if !ok {
+		return
+	}
+
+	videoID := uuid.New().String()
+	filename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), videoID, filepath.Ext(originalName))
+
+	video := h.newVideo(c, videoID, filename, originalName, "", 0)
+	video.Status = "importing"
+	video.BatchID = batchID
+
+	now := time.Now()
+	err = h.db.CreateVideoImport(video, &domain.VideoImport{
+		VideoID:       videoID,
+		SourceURL:     sourceURL.String(),
+		Options:       options,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, UploadResponse{
+			Success: false,
+			Message: "Failed to create video record: " + err.Error(),
+		})
+		return
+	}
+
+	h.auditVideo(c, "video.import", videoID)
+
+	c.JSON(http.StatusAccepted, UploadResponse{
+		Success: true,
+		Message: "Video import accepted",
+		VideoID: videoID,
+		Status:  "importing",
+	})
+}
@@ -31,6 +31,36 @@ func (h *InternalHandler) GetVideoByID(c *gin.Context) {
 	c.JSON(http.StatusOK, video)
 }
 
+// GetBatch reports a batch's aggregate status, for the batch email.
+func (h *InternalHandler) GetBatch(c *gin.Context) {
+	batchID := c.Param("id")
+
+	batch, err := h.db.GetBatch(batchID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Batch not found",
+		})
+		return
+	}
+
+	videos, err := h.db.GetBatchVideos(batchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get batch videos",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newBatchResponse(batch, videos))
+}
+
+// InternalVideoListResponse is a page of full video records, taking the same
+// query parameters as the public listing.
+type InternalVideoListResponse struct {
+	Videos     []*domain.Video `json:"videos"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
 func (h *InternalHandler) ListUserVideos(c *gin.Context) {
 	userID := c.Query("user_id")
 	if userID == "" {
@@ -40,7 +70,13 @@ func (h *InternalHandler) ListUserVideos(c *gin.Context) {
 		return
 	}
 
-	videos, err := h.db.GetVideosByUserID(userID, "")
+	query, err := parseVideoListQuery(c, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.db.ListVideos(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get videos",
@@ -48,7 +84,10 @@ func (h *InternalHandler) ListUserVideos(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, videos)
+	c.JSON(http.StatusOK, InternalVideoListResponse{
+		Videos:     page.Videos,
+		NextCursor: encodeCursor(page.Next),
+	})
 }
 
 type UpdateStatusRequest struct {
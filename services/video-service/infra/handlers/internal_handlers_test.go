@@ -61,13 +61,22 @@ func TestInternalListUserVideos_Success(t *testing.T) {
 	r := gin.New()
 	r.GET("/internal/videos", h.ListUserVideos)
 
-	mockDB.On("GetVideosByUserID", "u1", "").Return([]*domain.Video{{ID: "v1", UserID: "u1"}}, nil)
-
-	req, _ := http.NewRequest("GET", "/internal/videos?user_id=u1", nil)
+	mockDB.On("ListVideos", mock.MatchedBy(func(q domain.VideoListQuery) bool {
+		return q.UserID == "u1" && len(q.Statuses) == 1 && q.Statuses[0] == "completed" && q.Limit == 5
+	})).Return(&domain.VideoPage{
+		Videos: []*domain.Video{{ID: "v1", UserID: "u1"}},
+		Next:   &domain.VideoCursor{Sort: domain.VideoSortCreated, Descending: true, Value: "2024-01-01T00:00:00", ID: "v1"},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/internal/videos?user_id=u1&status=completed&limit=5", nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	var resp InternalVideoListResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp.Videos, 1)
+	assert.NotEmpty(t, resp.NextCursor)
 }
 
 func TestInternalListUserVideos_MissingUserID(t *testing.T) {
@@ -93,7 +102,7 @@ func TestInternalListUserVideos_DBError(t *testing.T) {
 	r := gin.New()
 	r.GET("/internal/videos", h.ListUserVideos)
 
-	mockDB.On("GetVideosByUserID", "u1", "").Return(nil, errors.New("db error"))
+	mockDB.On("ListVideos", mock.Anything).Return(nil, errors.New("db error"))
 
 	req, _ := http.NewRequest("GET", "/internal/videos?user_id=u1", nil)
 	w := httptest.NewRecorder()
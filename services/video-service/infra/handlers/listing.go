@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Page sizes of video listings.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var videoStatuses = map[string]bool{
+	"pending": true, "importing": true, "queued": true, "processing": true,
+	"completed": true, "failed": true, "cancelled": true, "expired": true,
+}
+
+type VideoListResponse struct {
+	Videos     []VideoResponse `json:"videos"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// parseVideoListQuery reads a listing's filters, order and page from the
+// query string:
+//
+//	status        one or more statuses, repeated or comma-separated
+//	created_from  RFC 3339 time or date, inclusive
+//	created_to    RFC 3339 time, exclusive, or date, inclusive
+//	q             text searched for in original_name
+//	sort          created_at (default), size or duration
+//	order         desc (default) or asc
+//	limit         page size, 1 to 100, default 20
+//	cursor        next_cursor of the previous page
+func parseVideoListQuery(c *gin.Context, userID string) (domain.VideoListQuery, error) {
+	query := domain.VideoListQuery{
+		UserID:     userID,
+		Search:     strings.TrimSpace(c.Query("q")),
+		Sort:       c.DefaultQuery("sort", domain.VideoSortCreated),
+		Descending: true,
+		Limit:      defaultPageSize,
+	}
+
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if !videoStatuses[status] {
+				return query, fmt.Errorf("unknown status %q", status)
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	var err error
+	if query.CreatedFrom, err = parseListTime(c.Query("created_from"), false); err != nil {
+		return query, fmt.Errorf("invalid created_from: %w", err)
+	}
+	if query.CreatedTo, err = parseListTime(c.Query("created_to"), true); err != nil {
+		return query, fmt.Errorf("invalid created_to: %w", err)
+	}
+
+	switch query.Sort {
+	case domain.VideoSortCreated, domain.VideoSortSize, domain.VideoSortDuration:
+	default:
+		return query, fmt.Errorf("sort must be created_at, size or duration")
+	}
+
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "desc":
+	case "asc":
+		query.Descending = false
+	default:
+		return query, fmt.Errorf("order must be asc or desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		query.Limit = limit
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil || cursor.Sort != query.Sort || cursor.Descending != query.Descending {
+			return query, fmt.Errorf("invalid cursor")
+		}
+		query.After = cursor
+	}
+
+	return query, nil
+}
+
+// parseListTime reads an RFC 3339 time or a date. As the end of a range, a
+// date includes the whole day.
+func parseListTime(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("expected RFC 3339 time or YYYY-MM-DD date")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// encodeCursor turns a page's next position into the opaque next_cursor.
+func encodeCursor(cursor *domain.VideoCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*domain.VideoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor domain.VideoCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return nil, err
+	}
+	if err := checkCursorValue(cursor.Sort, cursor.Value); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// checkCursorValue makes sure the value parses as the sort column's type
+// before it reaches the database.
+func checkCursorValue(sort, value string) error {
+	var err error
+	switch sort {
+	case domain.VideoSortCreated:
+		_, err = time.Parse(domain.CursorTimeFormat, value)
+	case domain.VideoSortSize:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case domain.VideoSortDuration:
+		_, err = strconv.ParseFloat(value, 64)
+	default:
+		err = fmt.Errorf("unknown sort %q", sort)
+	}
+	return err
+}
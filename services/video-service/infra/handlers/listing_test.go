@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// parseQuery runs parseVideoListQuery on a request with the given query
+// string.
+func parseQuery(t *testing.T, rawQuery string) (domain.VideoListQuery, error) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/videos?"+rawQuery, nil)
+	return parseVideoListQuery(c, "user123")
+}
+
+func listRouter(db *MockDatabase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(db, nil, nil, nil)
+	r := gin.New()
+	r.GET("/videos", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.List(c)
+	})
+	return r
+}
+
+const cursorVideoID = "6f1c2a7e-1b4f-4a59-9b9e-2f0f6c1d8e11"
+
+// ---------- parseVideoListQuery ----------
+
+func TestParseVideoListQuery_Defaults(t *testing.T) {
+	query, err := parseQuery(t, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.VideoListQuery{
+		UserID: "user123", Sort: domain.VideoSortCreated, Descending: true, Limit: defaultPageSize,
+	}, query)
+}
+
+func TestParseVideoListQuery_Filters(t *testing.T) {
+	query, err := parseQuery(t, "status=completed,failed&status=queued&created_from=2024-03-01"+
+		"&created_to=2024-03-31&q=+keynote+&sort=size&order=asc&limit=50")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"completed", "failed", "queued"}, query.Statuses)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *query.CreatedFrom)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), *query.CreatedTo)
+	assert.Equal(t, "keynote", query.Search)
+	assert.Equal(t, domain.VideoSortSize, query.Sort)
+	assert.False(t, query.Descending)
+	assert.Equal(t, 50, query.Limit)
+}
+
+func TestParseVideoListQuery_RFC3339Range(t *testing.T) {
+	query, err := parseQuery(t, "created_from=2024-03-01T10:00:00%2B02:00&created_to=2024-03-01T12:00:00Z")
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), *query.CreatedFrom)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), *query.CreatedTo)
+}
+
+func TestParseVideoListQuery_Rejected(t *testing.T) {
+	sizeCursor := encodeCursor(&domain.VideoCursor{Sort: domain.VideoSortSize, Descending: true, Value: "10", ID: cursorVideoID})
+
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{"unknown status", "status=done"},
+		{"bad created_from", "created_from=yesterday"},
+		{"bad created_to", "created_to=2024-13-01"},
+		{"unknown sort", "sort=name"},
+		{"unknown order", "order=up"},
+		{"limit too small", "limit=0"},
+		{"limit too large", "limit=101"},
+		{"limit not a number", "limit=ten"},
+		{"garbage cursor", "cursor=not-a-cursor"},
+		{"cursor for another sort", "cursor=" + sizeCursor},
+		{"cursor for another order", "sort=size&order=asc&cursor=" + sizeCursor},
+		{"cursor with bad value", "sort=size&cursor=" + encodeCursor(&domain.VideoCursor{
+			Sort: domain.VideoSortSize, Descending: true, Value: "big", ID: cursorVideoID})},
+		{"cursor with bad id", "cursor=" + encodeCursor(&domain.VideoCursor{
+			Sort: domain.VideoSortCreated, Descending: true, Value: "2024-01-01T00:00:00", ID: "1; DROP"})},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseQuery(t, tc.query)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := &domain.VideoCursor{
+		Sort: domain.VideoSortDuration, Descending: false, Value: "12.5", ID: cursorVideoID,
+	}
+
+	query, err := parseQuery(t, "sort=duration&order=asc&cursor="+encodeCursor(cursor))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, query.After)
+	assert.Empty(t, encodeCursor(nil))
+}
+
+// ---------- List ----------
+
+func TestList_NextPage(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := listRouter(mockDB)
+
+	next := &domain.VideoCursor{Sort: domain.VideoSortCreated, Descending: true, Value: "2024-03-01T10:00:00.123456", ID: cursorVideoID}
+	mockDB.On("ListVideos", mock.MatchedBy(func(q domain.VideoListQuery) bool {
+		return q.After == nil && q.Limit == 1
+	})).Return(&domain.VideoPage{Videos: []*domain.Video{{ID: cursorVideoID, UserID: "user123"}}, Next: next}, nil).Once()
+
+	w := serve(r, http.MethodGet, "/videos?limit=1")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var first VideoListResponse
+	json.Unmarshal(w.Body.Bytes(), &first)
+	assert.NotEmpty(t, first.NextCursor)
+
+	mockDB.On("ListVideos", mock.MatchedBy(func(q domain.VideoListQuery) bool {
+		return q.After != nil && *q.After == *next
+	})).Return(&domain.VideoPage{Videos: []*domain.Video{}}, nil).Once()
+
+	w = serve(r, http.MethodGet, "/videos?limit=1&cursor="+first.NextCursor)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var second VideoListResponse
+	json.Unmarshal(w.Body.Bytes(), &second)
+	assert.Empty(t, second.Videos)
+	assert.Empty(t, second.NextCursor)
+	mockDB.AssertExpectations(t)
+}
+
+func TestList_InvalidQuery(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := listRouter(mockDB)
+
+	w := serve(r, http.MethodGet, "/videos?sort=name")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockDB.AssertNotCalled(t, "ListVideos", mock.Anything)
+}
+
+func TestListTrash_OnlyTrashed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDB := new(MockDatabase)
+	handler := NewVideoHandler(mockDB, nil, nil, nil)
+	r := gin.New()
+	r.GET("/videos/trash", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		handler.ListTrash(c)
+	})
+
+	deletedAt := time.Now()
+	mockDB.On("ListVideos", mock.MatchedBy(func(q domain.VideoListQuery) bool {
+		return q.Trashed && q.UserID == "user123" && q.Search == "keynote"
+	})).Return(&domain.VideoPage{Videos: []*domain.Video{{ID: "v1", UserID: "user123", DeletedAt: &deletedAt}}}, nil)
+
+	w := serve(r, http.MethodGet, "/videos/trash?q=keynote")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response VideoListResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Videos, 1)
+	assert.NotNil(t, response.Videos[0].DeletedAt)
+	mockDB.AssertExpectations(t)
+}
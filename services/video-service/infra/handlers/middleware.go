@@ -1,8 +1,7 @@
 package handlers
 
 import (
-	"fmt"
-	"net/http"
+	"strings"
 	"github.com/gin-gonic/gin"
 )
 
@@ -10,44 +9,16 @@ func CorsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Share-Password, Tus-Resumable, Upload-Length, Upload-Metadata, Upload-Offset, Upload-Defer-Length")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, HEAD, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Expires, X-Video-Id, ETag, Last-Modified, Accept-Ranges, Content-Range, Content-Disposition")
 		if c.Request.Method == "OPTIONS" {
+			if strings.HasPrefix(c.Request.URL.Path, tusBasePath) {
+				setTusDiscoveryHeaders(c)
+			}
 			c.AbortWithStatus(204)
 			return
 		}
 		c.Next()
 	}
 }
-
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if gwUser := c.GetHeader("X-User-Id"); gwUser != "" {
-			c.Set("user_id", gwUser)
-			c.Next()
-			return
-		}
-
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
-			c.Abort()
-			return
-		}
-		userID, err := validateToken(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-		c.Set("user_id", userID)
-		c.Next()
-	}
-}
-
-func validateToken(token string) (string, error) {
-    if token == "" {
-        return "", fmt.Errorf("empty token")
-    }
-	return "00000000-0000-0000-0000-000000000000", nil
-}
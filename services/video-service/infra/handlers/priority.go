@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"video-service/infra/utils"
+)
+
+// Bounds of the priority column and of x-max-priority on video.upload.queue.
+const (
+	minPriority = 1
+	maxPriority = 10
+)
+
+// priorityTiers parses PRIORITY_TIERS ("admin:8,user:5") into a role → base
+// priority map. Malformed entries are ignored.
+func priorityTiers() map[string]int {
+	tiers := map[string]int{}
+	for _, entry := range strings.Split(utils.GetEnv("PRIORITY_TIERS", "admin:8,user:5"), ",") {
+		role, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		priority, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		tiers[strings.TrimSpace(role)] = clampPriority(priority)
+	}
+	return tiers
+}
+
+// uploadPriority starts from the tier of the user's role and drops one level
+// for every PRIORITY_BACKLOG_STEP videos the user already has in flight, so a
+// large batch from one account queues behind everyone else's first uploads.
+func uploadPriority(role string, activeVideos int) int {
+	tiers := priorityTiers()
+	priority, ok := tiers[role]
+	if !ok {
+		priority, ok = tiers["user"]
+	}
+	if !ok {
+		priority = 5
+	}
+
+	step, err := strconv.Atoi(utils.GetEnv("PRIORITY_BACKLOG_STEP", "10"))
+	if err != nil || step <= 0 {
+		step = 10
+	}
+
+	return clampPriority(priority - activeVideos/step)
+}
+
+func clampPriority(priority int) int {
+	if priority < minPriority {
+		return minPriority
+	}
+	if priority > maxPriority {
+		return maxPriority
+	}
+	return priority
+}
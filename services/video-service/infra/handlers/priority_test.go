@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadPriority_Defaults(t *testing.T) {
+	assert.Equal(t, 5, uploadPriority("user", 0))
+	assert.Equal(t, 8, uploadPriority("admin", 0))
+	assert.Equal(t, 5, uploadPriority("", 0))
+	assert.Equal(t, 5, uploadPriority("unknown", 9))
+}
+
+func TestUploadPriority_BacklogPenalty(t *testing.T) {
+	assert.Equal(t, 4, uploadPriority("user", 10))
+	assert.Equal(t, 3, uploadPriority("user", 29))
+	assert.Equal(t, 1, uploadPriority("user", 200))
+}
+
+func TestUploadPriority_ConfiguredTiers(t *testing.T) {
+	os.Setenv("PRIORITY_TIERS", "admin:10, premium:7 ,user:3,broken,bad:x")
+	os.Setenv("PRIORITY_BACKLOG_STEP", "5")
+	defer os.Unsetenv("PRIORITY_TIERS")
+	defer os.Unsetenv("PRIORITY_BACKLOG_STEP")
+
+	assert.Equal(t, 7, uploadPriority("premium", 0))
+	assert.Equal(t, 6, uploadPriority("premium", 5))
+	assert.Equal(t, 10, uploadPriority("admin", 4))
+	assert.Equal(t, 3, uploadPriority("bad", 0))
+}
+
+func TestClampPriority(t *testing.T) {
+	assert.Equal(t, 1, clampPriority(-3))
+	assert.Equal(t, 10, clampPriority(42))
+	assert.Equal(t, 7, clampPriority(7))
+}
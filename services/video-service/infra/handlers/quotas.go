@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"video-service/domain"
+	"video-service/infra/utils"
+
+	"shared/clienterr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned when an upload would go over the user's quota.
+const (
+	ErrCodeStorageQuotaExceeded = "storage_quota_exceeded"
+	ErrCodeDailyLimitExceeded   = "daily_upload_limit_exceeded"
+)
+
+// QuotaLimits are what a user may store and upload. Zero means no limit.
+type QuotaLimits struct {
+	MaxStorageBytes  int64 `json:"max_storage_bytes"`
+	MaxVideosPerDay  int64 `json:"max_videos_per_day"`
+	MaxFileSizeBytes int64 `json:"max_file_size_bytes"`
+}
+
+type QuotaResponse struct {
+	UserID   string            `json:"user_id"`
+	Plan     string            `json:"plan"`
+	Limits   QuotaLimits       `json:"limits"`
+	Override *domain.UserQuota `json:"override,omitempty"`
+	Usage    domain.QuotaUsage `json:"usage"`
+	ResetsAt time.Time         `json:"resets_at"`
+}
+
+// SetQuotaRequest replaces a user's override. Omitted fields fall back to the
+// plan; zero lifts the limit.
+type SetQuotaRequest struct {
+	MaxStorageBytes  *int64 `json:"max_storage_bytes"`
+	MaxVideosPerDay  *int64 `json:"max_videos_per_day"`
+	MaxFileSizeBytes *int64 `json:"max_file_size_bytes"`
+}
+
+func planFileSizes() map[string]int64 {
+	return utils.PlanValues("QUOTA_MAX_FILE_BYTES", "user:"+strconv.FormatInt(maxUploadSize, 10))
+}
+
+// planLimits returns the limits of a plan. Plans are user roles; an unknown
+// role gets the limits set for every plan, or else the user plan's.
+func planLimits(plan string) QuotaLimits {
+	return QuotaLimits{
+		MaxStorageBytes:  utils.PlanValue(utils.PlanValues("QUOTA_STORAGE_BYTES", "admin:0,user:10737418240"), plan, 0),
+		MaxVideosPerDay:  utils.PlanValue(utils.PlanValues("QUOTA_VIDEOS_PER_DAY", "admin:0,user:50"), plan, 0),
+		MaxFileSizeBytes: utils.PlanValue(planFileSizes(), plan, maxUploadSize),
+	}
+}
+
+// largestFileSize is the biggest file any plan accepts, or 0 if some plan
+// has no limit.
+func largestFileSize() int64 {
+	largest := maxUploadSize
+	for _, limit := range planFileSizes() {
+		if limit == 0 {
+			return 0
+		}
+		if limit > largest {
+			largest = limit
+		}
+	}
+	return largest
+}
+
+// apply lays a user's override over the plan limits.
+func (l QuotaLimits) apply(quota *domain.UserQuota) QuotaLimits {
+	if quota == nil {
+		return l
+	}
+	if quota.MaxStorageBytes != nil {
+		l.MaxStorageBytes = *quota.MaxStorageBytes
+	}
+	if quota.MaxVideosPerDay != nil {
+		l.MaxVideosPerDay = *quota.MaxVideosPerDay
+	}
+	if quota.MaxFileSizeBytes != nil {
+		l.MaxFileSizeBytes = *quota.MaxFileSizeBytes
+	}
+	return l
+}
+
+// quotaDay returns the start of the UTC day the daily limit counts from.
+func quotaDay(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour)
+}
+
+// loadQuota reads the user's override and usage and works out their limits.
+func (h *VideoHandler) loadQuota(userID, plan string, now time.Time) (*QuotaResponse, error) {
+	if plan == "" {
+		plan = "user"
+	}
+
+	override, err := h.db.GetUserQuota(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota: %w", err)
+	}
+
+	day := quotaDay(now)
+	usage, err := h.db.GetQuotaUsage(userID, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+
+	return &QuotaResponse{
+		UserID:   userID,
+		Plan:     plan,
+		Limits:   planLimits(plan).apply(override),
+		Override: override,
+		Usage:    *usage,
+		ResetsAt: day.Add(24 * time.Hour),
+	}, nil
+}
+
+// checkQuota rejects an upload of files with the given sizes if any is over
+// the user's file size limit, or if they would take the user past their
+// storage or daily video limit. A size of zero stands for a file whose size is
+// not known yet; it counts as a video but only fails the storage check once
+// the user is already at their limit.
+func (h *VideoHandler) checkQuota(c *gin.Context, sizes ...int64) *uploadRejection {
+	now := time.Now()
+	quota, err := h.loadQuota(c.GetString("user_id"), c.GetString("user_role"), now)
+	if err != nil {
+		return rejectUpload(http.StatusInternalServerError, "Failed to check quota: "+err.Error(), "")
+	}
+	return quotaRejection(c, quota, now, sizes...)
+}
+
+// checkFinishedUpload checks the quota again once an upload has all its
+// bytes, against the size it ended up with: the limits and the user's other
+// uploads may have changed while it was in progress. The session's own
+// reservation is taken out of the usage so it is not counted twice.
+func (h *VideoHandler) checkFinishedUpload(c *gin.Context, session *domain.UploadSession) *uploadRejection {
+	now := time.Now()
+	quota, err := h.loadQuota(session.UserID, c.GetString("user_role"), now)
+	if err != nil {
+		return rejectUpload(http.StatusInternalServerError, "Failed to check quota: "+err.Error(), "")
+	}
+	if !uploadExpired(session) && quota.Usage.ReservedVideos > 0 {
+		quota.Usage.ReservedBytes = max(quota.Usage.ReservedBytes-session.SizeBytes, 0)
+		quota.Usage.ReservedVideos--
+	}
+	return quotaRejection(c, quota, now, session.OffsetBytes)
+}
+
+func quotaRejection(c *gin.Context, quota *QuotaResponse, now time.Time, sizes ...int64) *uploadRejection {
+	limits, usage := quota.Limits, quota.Usage
+
+	var total int64
+	for _, size := range sizes {
+		if limits.MaxFileSizeBytes > 0 && size > limits.MaxFileSizeBytes {
+			return rejectUpload(http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("File too large. Max size: %.2fMB, got: %.2fMB",
+					float64(limits.MaxFileSizeBytes)/1024/1024, float64(size)/1024/1024), ErrCodeFileTooLarge)
+		}
+		total += size
+	}
+
+	if limits.MaxVideosPerDay > 0 && usage.VideosToday+usage.ReservedVideos+int64(len(sizes)) > limits.MaxVideosPerDay {
+		c.Header("Retry-After", strconv.Itoa(int(quota.ResetsAt.Sub(now).Seconds())+1))
+		return rejectUpload(http.StatusTooManyRequests,
+			fmt.Sprintf("Daily upload limit reached: %d videos per day, resets at %s",
+				limits.MaxVideosPerDay, quota.ResetsAt.Format(time.RFC3339)), ErrCodeDailyLimitExceeded)
+	}
+
+	used := usage.StoredBytes + usage.ReservedBytes
+	if limits.MaxStorageBytes > 0 && (used+total > limits.MaxStorageBytes || used >= limits.MaxStorageBytes) {
+		return rejectUpload(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Storage quota exceeded: %.2fMB used of %.2fMB, upload needs %.2fMB",
+				float64(used)/1024/1024, float64(limits.MaxStorageBytes)/1024/1024, float64(total)/1024/1024),
+			ErrCodeStorageQuotaExceeded)
+	}
+
+	return nil
+}
+
+// GetQuota shows the caller's limits and usage. Admins may look at anyone's
+// through /quota/:user_id.
+func (h *VideoHandler) GetQuota(c *gin.Context) {
+	userID, plan, ok := h.quotaSubject(c)
+	if !ok {
+		return
+	}
+
+	quota, err := h.loadQuota(userID, plan, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quota)
+}
+
+// SetQuota replaces a user's override of their plan limits. Admins only.
+func (h *VideoHandler) SetQuota(c *gin.Context) {
+	if c.GetString("user_role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	var req SetQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, limit := range []*int64{req.MaxStorageBytes, req.MaxVideosPerDay, req.MaxFileSizeBytes} {
+		if limit != nil && *limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quota limits cannot be negative"})
+			return
+		}
+	}
+
+	userID, plan, ok := h.quotaSubject(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.SetUserQuota(&domain.UserQuota{
+		UserID:           userID,
+		MaxStorageBytes:  req.MaxStorageBytes,
+		MaxVideosPerDay:  req.MaxVideosPerDay,
+		MaxFileSizeBytes: req.MaxFileSizeBytes,
+		UpdatedAt:        time.Now(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save quota"})
+		return
+	}
+
+	quota, err := h.loadQuota(userID, plan, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quota)
+}
+
+// quotaSubject resolves whose quota a request is about and their plan. Only
+// admins may name another user, whose plan is then looked up in the auth
+// service.
+func (h *VideoHandler) quotaSubject(c *gin.Context) (string, string, bool) {
+	userID := c.GetString("user_id")
+	target := c.Param("user_id")
+	if target == "" || target == userID {
+		return userID, c.GetString("user_role"), true
+	}
+
+	if c.GetString("user_role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return "", "", false
+	}
+
+	plan, err := h.authClient.GetUserRole(target)
+	if err != nil {
+		if errors.Is(err, clienterr.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		} else {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to look up user: " + err.Error()})
+		}
+		return "", "", false
+	}
+	return target, plan, true
+}
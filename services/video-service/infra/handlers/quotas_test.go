@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"shared/clienterr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func quotaRouter(db *MockDatabase, authClient *MockAuthClient, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(db, new(MockMinIO), new(MockRabbitMQ), authClient)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		c.Set("user_role", role)
+	})
+	r.POST("/upload", handler.Upload)
+	r.POST("/batches", handler.CreateBatch)
+	r.POST("/import", handler.ImportVideo)
+	r.GET("/quota", handler.GetQuota)
+	r.GET("/quota/:user_id", handler.GetQuota)
+	r.PUT("/quota/:user_id", handler.SetQuota)
+	return r
+}
+
+func uploadForm(content []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("video", "clip.mp4")
+	part.Write(content)
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func postUpload(r *gin.Engine, content []byte) *httptest.ResponseRecorder {
+	body, contentType := uploadForm(content)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// ---------- Plans ----------
+
+func TestPlanLimits_Defaults(t *testing.T) {
+	user := planLimits("user")
+	assert.Equal(t, int64(10*1024*1024*1024), user.MaxStorageBytes)
+	assert.Equal(t, int64(50), user.MaxVideosPerDay)
+	assert.Equal(t, maxUploadSize, user.MaxFileSizeBytes)
+
+	admin := planLimits("admin")
+	assert.Zero(t, admin.MaxStorageBytes)
+	assert.Zero(t, admin.MaxVideosPerDay)
+	assert.Equal(t, maxUploadSize, admin.MaxFileSizeBytes)
+
+	assert.Equal(t, user, planLimits("unknown"))
+}
+
+func TestPlanLimits_Configured(t *testing.T) {
+	os.Setenv("QUOTA_STORAGE_BYTES", "user:1000, premium:5000,broken,bad:-1")
+	os.Setenv("QUOTA_VIDEOS_PER_DAY", "premium:0")
+	os.Setenv("QUOTA_MAX_FILE_BYTES", "user:100,premium:900")
+	defer os.Unsetenv("QUOTA_STORAGE_BYTES")
+	defer os.Unsetenv("QUOTA_VIDEOS_PER_DAY")
+	defer os.Unsetenv("QUOTA_MAX_FILE_BYTES")
+
+	assert.Equal(t, QuotaLimits{MaxStorageBytes: 5000, MaxVideosPerDay: 0, MaxFileSizeBytes: 900}, planLimits("premium"))
+	assert.Equal(t, QuotaLimits{MaxStorageBytes: 1000, MaxVideosPerDay: 0, MaxFileSizeBytes: 100}, planLimits("bad"))
+	assert.Equal(t, maxUploadSize, largestFileSize())
+}
+
+func TestLargestFileSize_Unlimited(t *testing.T) {
+	os.Setenv("QUOTA_MAX_FILE_BYTES", "user:100,admin:0")
+	defer os.Unsetenv("QUOTA_MAX_FILE_BYTES")
+
+	assert.Zero(t, largestFileSize())
+}
+
+func TestQuotaLimits_Override(t *testing.T) {
+	plan := QuotaLimits{MaxStorageBytes: 1000, MaxVideosPerDay: 5, MaxFileSizeBytes: 100}
+
+	assert.Equal(t, plan, plan.apply(nil))
+	assert.Equal(t, QuotaLimits{MaxStorageBytes: 0, MaxVideosPerDay: 5, MaxFileSizeBytes: 300},
+		plan.apply(&domain.UserQuota{MaxStorageBytes: int64Ptr(0), MaxFileSizeBytes: int64Ptr(300)}))
+}
+
+// ---------- Enforcement ----------
+
+func TestUpload_FileOverUserLimit(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockDB.On("GetUserQuota", "user123").Return(&domain.UserQuota{UserID: "user123", MaxFileSizeBytes: int64Ptr(10)}, nil)
+	mockDB.On("GetQuotaUsage", "user123", mock.Anything).Return(&domain.QuotaUsage{}, nil)
+	r := quotaRouter(mockDB, new(MockAuthClient), "user")
+
+	w := postUpload(r, sampleMP4)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, ErrCodeFileTooLarge, resp.ErrorCode)
+}
+
+func TestUpload_DailyLimitReached(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockDB.On("GetUserQuota", "user123").Return(nil, nil)
+	mockDB.On("GetQuotaUsage", "user123", mock.MatchedBy(func(since time.Time) bool {
+		return since.Equal(quotaDay(time.Now()))
+	})).Return(&domain.QuotaUsage{VideosToday: 49, ReservedVideos: 1}, nil)
+	r := quotaRouter(mockDB, new(MockAuthClient), "user")
+
+	w := postUpload(r, sampleMP4)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	var resp UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, ErrCodeDailyLimitExceeded, resp.ErrorCode)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.True(t, retryAfter > 0 && retryAfter <= 24*60*60+1)
+}
+
+func TestUpload_StorageQuotaExceeded(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockDB.On("GetUserQuota", "user123").Return(&domain.UserQuota{UserID: "user123", MaxStorageBytes: int64Ptr(1000)}, nil)
+	mockDB.On("GetQuotaUsage", "user123", mock.Anything).Return(&domain.QuotaUsage{StoredBytes: 960, ReservedBytes: 20}, nil)
+	r := quotaRouter(mockDB, new(MockAuthClient), "user")
+
+	w := postUpload(r, sampleMP4)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, ErrCodeStorageQuotaExceeded, resp.ErrorCode)
+}
+
+func TestUpload_AdminPlanIsUnlimited(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	mockAuth := new(MockAuthClient)
+	handler := NewVideoHandler(mockDB, mockMinio, new(MockRabbitMQ), mockAuth)
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+		c.Set("user_role", "admin")
+		handler.Upload(c)
+	})
+
+	mockDB.On("GetUserQuota", "user123").Return(nil, nil)
+	mockDB.On("GetQuotaUsage", "user123", mock.Anything).
+		Return(&domain.QuotaUsage{StoredBytes: 1 << 40, VideosToday: 10000}, nil)
+	mockDB.On("CountActiveVideos", "user123").Return(0, nil)
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(nil)
+	mockMinio.On("UploadFile", mock.Anything, mock.Anything, mock.Anything).Return("2024/01/01/clip.mp4", nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil).Maybe()
+
+	w := postUpload(r, sampleMP4)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUploadBatch_CountsEveryFile(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockDB.On("GetUserQuota", "user123").Return(&domain.UserQuota{UserID: "user123", MaxVideosPerDay: int64Ptr(3)}, nil)
+	mockDB.On("GetQuotaUsage", "user123", mock.Anything).Return(&domain.QuotaUsage{VideosToday: 2}, nil)
+	r := quotaRouter(mockDB, new(MockAuthClient), "user")
+
+	body, contentType := multipartBatch(map[string][]byte{"a.mp4": sampleMP4, "b.mp4": sampleMP4}, nil)
+	req := httptest.NewRequest(http.MethodPost, "/batches", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	mockDB.AssertNotCalled(t, "CreateBatch", mock.Anything)
+}
+
+func TestImportVideo_StorageFull(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockDB.On("GetUserQuota", "user123").Return(&domain.UserQuota{UserID: "user123", MaxStorageBytes: int64Ptr(1000)}, nil)
+	mockDB.On("GetQuotaUsage", "user123", mock.Anything).Return(&domain.QuotaUsage{StoredBytes: 1000}, nil)
+	r := quotaRouter(mockDB, new(MockAuthClient), "user")
+
+	w := postJSON(r, "/import", gin.H{"url": "https://files.example.com/clip.mp4"})
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	mockDB.AssertNotCalled(t, "CreateVideoImport", mock.Anything, mock.Anything)
+}
+
+// ---------- GetQuota ----------
+
+func TestGetQuota_Own(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockDB.On("GetUserQuota", "user123").Return(nil, nil)
+	mockDB.On("GetQuotaUsage", "user123", mock.Anything).
+		Return(&domain.QuotaUsage{StoredBytes: 2048, ReservedBytes: 512, VideosToday: 3, ReservedVideos: 1}, nil)
+	r := quotaRouter(mockDB, new(MockAuthClient), "")
+
+	w := serve(r, http.MethodGet, "/quota")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp QuotaResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "user123", resp.UserID)
+	assert.Equal(t, "user", resp.Plan)
+	assert.Equal(t, planLimits("user"), resp.Limits)
+	assert.Equal(t, int64(2048), resp.Usage.StoredBytes)
+	assert.Equal(t, int64(1), resp.Usage.ReservedVideos)
+	assert.True(t, resp.ResetsAt.Equal(quotaDay(time.Now()).Add(24*time.Hour)))
+}
+
+func TestGetQuota_OtherUserRequiresAdmin(t *testing.T) {
+	r := quotaRouter(new(MockDatabase), new(MockAuthClient), "user")
+
+	w := serve(r, http.MethodGet, "/quota/someone-else")
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestGetQuota_AdminUsesTargetPlan(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	mockAuth.On("GetUserRole", "user456").Return("admin", nil)
+	mockDB.On("GetUserQuota", "user456").Return(nil, nil)
+	mockDB.On("GetQuotaUsage", "user456", mock.Anything).Return(&domain.QuotaUsage{}, nil)
+	r := quotaRouter(mockDB, mockAuth, "admin")
+
+	w := serve(r, http.MethodGet, "/quota/user456")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp QuotaResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "user456", resp.UserID)
+	assert.Equal(t, "admin", resp.Plan)
+}
+
+func TestGetQuota_UnknownUser(t *testing.T) {
+	mockAuth := new(MockAuthClient)
+	mockAuth.On("GetUserRole", "missing").Return("", &clienterr.StatusError{StatusCode: http.StatusNotFound})
+	r := quotaRouter(new(MockDatabase), mockAuth, "admin")
+
+	w := serve(r, http.MethodGet, "/quota/missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// ---------- SetQuota ----------
+
+func TestSetQuota_Success(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	mockAuth.On("GetUserRole", "user456").Return("user", nil)
+	mockDB.On("SetUserQuota", mock.MatchedBy(func(q *domain.UserQuota) bool {
+		return q.UserID == "user456" && *q.MaxStorageBytes == 5000 && q.MaxVideosPerDay == nil && *q.MaxFileSizeBytes == 0
+	})).Return(nil)
+	mockDB.On("GetUserQuota", "user456").
+		Return(&domain.UserQuota{UserID: "user456", MaxStorageBytes: int64Ptr(5000), MaxFileSizeBytes: int64Ptr(0)}, nil)
+	mockDB.On("GetQuotaUsage", "user456", mock.Anything).Return(&domain.QuotaUsage{}, nil)
+	r := quotaRouter(mockDB, mockAuth, "admin")
+
+	body, _ := json.Marshal(gin.H{"max_storage_bytes": 5000, "max_file_size_bytes": 0})
+	req := httptest.NewRequest(http.MethodPut, "/quota/user456", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp QuotaResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, QuotaLimits{MaxStorageBytes: 5000, MaxVideosPerDay: 50, MaxFileSizeBytes: 0}, resp.Limits)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSetQuota_Rejected(t *testing.T) {
+	cases := []struct {
+		name   string
+		role   string
+		body   string
+		status int
+	}{
+		{"not admin", "user", `{"max_storage_bytes": 1}`, http.StatusForbidden},
+		{"negative", "admin", `{"max_videos_per_day": -1}`, http.StatusBadRequest},
+		{"invalid json", "admin", `{`, http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			r := quotaRouter(mockDB, new(MockAuthClient), tc.role)
+
+			req := httptest.NewRequest(http.MethodPut, "/quota/user456", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			mockDB.AssertNotCalled(t, "SetUserQuota", mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RunResponse struct {
+	ID                  string                   `json:"id"`
+	Status              string                   `json:"status"`
+	Current             bool                     `json:"current"`
+	Options             domain.ProcessingOptions `json:"options"`
+	FrameCount          *int                     `json:"frame_count,omitempty"`
+	ZipSizeBytes        *int64                   `json:"zip_size_bytes,omitempty"`
+	ErrorMessage        *string                  `json:"error_message,omitempty"`
+	QueuedAt            time.Time                `json:"queued_at"`
+	ProcessingStarted   *time.Time               `json:"processing_started_at,omitempty"`
+	ProcessingCompleted *time.Time               `json:"processing_completed_at,omitempty"`
+}
+
+type RunListResponse struct {
+	VideoID      string        `json:"video_id"`
+	CurrentRunID *string       `json:"current_run_id,omitempty"`
+	Runs         []RunResponse `json:"runs"`
+}
+
+type SetCurrentRunRequest struct {
+	RunID string `json:"run_id" binding:"required"`
+}
+
+// Reprocess queues the video's stored file again with the options in the
+// body, as a new run. The video keeps showing its current results until the
+// new run completes, unless it has none to show.
+func (h *VideoHandler) Reprocess(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	var req OptionsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+	options, err := req.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if video.StoragePath == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Video has no stored file to reprocess"})
+		return
+	}
+
+	message := domain.VideoProcessingMessage{
+		VideoID:     video.ID,
+		UserID:      video.UserID,
+		StoragePath: video.StoragePath,
+		Filename:    video.Filename,
+		Priority:    video.Priority,
+		Options:     options,
+		RunID:       uuid.New().String(),
+		Reprocess:   true,
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue video"})
+		return
+	}
+
+	run, err := h.db.QueueVideoRun(video.ID, &domain.OutboxMessage{
+		ID:          uuid.New().String(),
+		AggregateID: video.ID,
+		Exchange:    "video.exchange",
+		RoutingKey:  "video.upload",
+		Payload:     payload,
+		Priority:    video.Priority,
+		CreatedAt:   time.Now(),
+	})
+	switch {
+	case errors.Is(err, domain.ErrVideoBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": "Video is still being processed"})
+		return
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue video"})
+		return
+	}
+
+	h.auditVideo(c, "video.reprocess", video.ID)
+
+	current := video.Status != "completed"
+	c.JSON(http.StatusAccepted, newRunResponse(run, current))
+}
+
+// ListRuns shows the video's runs, oldest first.
+func (h *VideoHandler) ListRuns(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.db.GetVideoRuns(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch runs"})
+		return
+	}
+
+	response := RunListResponse{
+		VideoID:      video.ID,
+		CurrentRunID: video.CurrentRunID,
+		Runs:         make([]RunResponse, 0, len(runs)),
+	}
+	for _, run := range runs {
+		current := video.CurrentRunID != nil && *video.CurrentRunID == run.ID
+		response.Runs = append(response.Runs, newRunResponse(run, current))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetCurrentRun picks which completed run the video shows and downloads.
+func (h *VideoHandler) SetCurrentRun(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	var req SetCurrentRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	runs, err := h.db.GetVideoRuns(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch runs"})
+		return
+	}
+	var run *domain.VideoRun
+	for _, r := range runs {
+		if r.ID == req.RunID {
+			run = r
+		}
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Run not found"})
+		return
+	}
+	if run.Status != "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only a completed run can be made current"})
+		return
+	}
+
+	updated, err := h.db.SetCurrentVideoRun(video.ID, run.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update video"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only a completed run can be made current"})
+		return
+	}
+
+	video, err = h.db.GetVideoByID(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
+		return
+	}
+
+	h.auditVideo(c, "video.run.select", video.ID)
+
+	c.JSON(http.StatusOK, newVideoResponse(video))
+}
+
+// ownedVideo loads the video named in the path and checks it belongs to the
+// caller. Videos in the trash are not found.
+func (h *VideoHandler) ownedVideo(c *gin.Context) (*domain.Video, bool) {
+	video, err := h.db.GetVideoByID(c.Param("id"))
+	if err != nil || video.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return nil, false
+	}
+	if video.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+	return video, true
+}
+
+func newRunResponse(run *domain.VideoRun, current bool) RunResponse {
+	return RunResponse{
+		ID:                  run.ID,
+		Status:              run.Status,
+		Current:             current,
+		Options:             run.Options,
+		FrameCount:          run.FrameCount,
+		ZipSizeBytes:        run.ZipSizeBytes,
+		ErrorMessage:        run.ErrorMessage,
+		QueuedAt:            run.QueuedAt,
+		ProcessingStarted:   run.ProcessingStartedAt,
+		ProcessingCompleted: run.ProcessingCompletedAt,
+	}
+}
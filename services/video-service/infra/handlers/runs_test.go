@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func runsRouter(db *MockDatabase, auth *MockAuthClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(db, nil, nil, auth)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "user123")
+	})
+	r.POST("/videos/:id/reprocess", handler.Reprocess)
+	r.GET("/videos/:id/runs", handler.ListRuns)
+	r.PUT("/videos/:id/runs/current", handler.SetCurrentRun)
+	return r
+}
+
+func sendJSON(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func storedVideo(status string, currentRunID string) *domain.Video {
+	return &domain.Video{
+		ID: "v1", UserID: "user123", Filename: "clip.mp4", StoragePath: "raw/clip.mp4",
+		Status: status, Priority: 5, CurrentRunID: &currentRunID,
+	}
+}
+
+// ---------- enqueueVideo ----------
+
+func TestEnqueueVideo_StartsFirstRun(t *testing.T) {
+	mockDB := new(MockDatabase)
+	handler := NewVideoHandler(mockDB, nil, nil, nil)
+
+	var message domain.VideoProcessingMessage
+	mockDB.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		json.Unmarshal(args.Get(1).(*domain.OutboxMessage).Payload, &message)
+	}).Return(nil)
+
+	video := &domain.Video{ID: "v1", UserID: "user123"}
+	err := handler.enqueueVideo(video, domain.ProcessingOptions{})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, message.RunID)
+	assert.False(t, message.Reprocess)
+	assert.Equal(t, message.RunID, *video.CurrentRunID)
+}
+
+// ---------- Reprocess ----------
+
+func TestReprocess_QueuesRun(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	r := runsRouter(mockDB, mockAuth)
+
+	mockDB.On("GetVideoByID", "v1").Return(storedVideo("completed", "r1"), nil)
+	mockDB.On("QueueVideoRun", "v1", withPayload(func(m domain.VideoProcessingMessage) bool {
+		return m.VideoID == "v1" && m.StoragePath == "raw/clip.mp4" && m.Filename == "clip.mp4" &&
+			m.RunID != "" && m.Reprocess && len(m.Options.Timestamps) == 2
+	})).Return(&domain.VideoRun{ID: "r2", VideoID: "v1", Status: "queued", QueuedAt: time.Now()}, nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/reprocess", `{"timestamps": ["1", "00:02"]}`)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var run RunResponse
+	json.Unmarshal(w.Body.Bytes(), &run)
+	assert.Equal(t, "r2", run.ID)
+	assert.Equal(t, "queued", run.Status)
+	assert.False(t, run.Current, "a completed video keeps its run until the new one completes")
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestReprocess_FailedVideoSwitchesToNewRun(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	r := runsRouter(mockDB, mockAuth)
+
+	mockDB.On("GetVideoByID", "v1").Return(storedVideo("failed", "r1"), nil)
+	mockDB.On("QueueVideoRun", "v1", mock.Anything).Return(&domain.VideoRun{ID: "r2", Status: "queued"}, nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/reprocess", "")
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var run RunResponse
+	json.Unmarshal(w.Body.Bytes(), &run)
+	assert.True(t, run.Current)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestReprocess_Busy(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := runsRouter(mockDB, nil)
+
+	mockDB.On("GetVideoByID", "v1").Return(storedVideo("completed", "r1"), nil)
+	mockDB.On("QueueVideoRun", "v1", mock.Anything).Return(nil, domain.ErrVideoBusy)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/reprocess", "{}")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestReprocess_InvalidOptions(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := runsRouter(mockDB, nil)
+
+	mockDB.On("GetVideoByID", "v1").Return(storedVideo("completed", "r1"), nil)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/reprocess", `{"start": "10", "end": "5"}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockDB.AssertNotCalled(t, "QueueVideoRun", mock.Anything, mock.Anything)
+}
+
+func TestReprocess_AccessDenied(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := runsRouter(mockDB, nil)
+
+	video := storedVideo("completed", "r1")
+	video.UserID = "other"
+	mockDB.On("GetVideoByID", "v1").Return(video, nil)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/reprocess", "{}")
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockDB.AssertNotCalled(t, "QueueVideoRun", mock.Anything, mock.Anything)
+}
+
+// ---------- ListRuns ----------
+
+func TestListRuns_MarksCurrent(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := runsRouter(mockDB, nil)
+
+	zipPath := "processed/frames_v1_r1.zip"
+	mockDB.On("GetVideoByID", "v1").Return(storedVideo("completed", "r1"), nil)
+	mockDB.On("GetVideoRuns", "v1").Return([]*domain.VideoRun{
+		{ID: "r1", Status: "completed", ZipPath: &zipPath},
+		{ID: "r2", Status: "processing", Options: domain.ProcessingOptions{SnapToChapters: true}},
+	}, nil)
+
+	w := serve(r, http.MethodGet, "/videos/v1/runs")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response RunListResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "r1", *response.CurrentRunID)
+	assert.Len(t, response.Runs, 2)
+	assert.True(t, response.Runs[0].Current)
+	assert.False(t, response.Runs[1].Current)
+	assert.True(t, response.Runs[1].Options.SnapToChapters)
+	assert.NotContains(t, w.Body.String(), zipPath)
+}
+
+// ---------- SetCurrentRun ----------
+
+func TestSetCurrentRun_Success(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	r := runsRouter(mockDB, mockAuth)
+
+	zipPath := "processed/frames_v1_r1.zip"
+	selected := storedVideo("completed", "r1")
+	selected.ZipPath = &zipPath
+	mockDB.On("GetVideoByID", "v1").Return(storedVideo("completed", "r2"), nil).Once()
+	mockDB.On("GetVideoRuns", "v1").Return([]*domain.VideoRun{
+		{ID: "r1", Status: "completed"}, {ID: "r2", Status: "completed"},
+	}, nil)
+	mockDB.On("SetCurrentVideoRun", "v1", "r1").Return(true, nil)
+	mockDB.On("GetVideoByID", "v1").Return(selected, nil).Once()
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	w := sendJSON(r, http.MethodPut, "/videos/v1/runs/current", `{"run_id": "r1"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response VideoResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "r1", *response.CurrentRunID)
+	assert.Equal(t, "/api/v1/videos/v1/download", *response.DownloadURL)
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestSetCurrentRun_Rejected(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"missing run_id", `{}`, http.StatusBadRequest},
+		{"unknown run", `{"run_id": "r9"}`, http.StatusNotFound},
+		{"run not completed", `{"run_id": "r2"}`, http.StatusConflict},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			r := runsRouter(mockDB, nil)
+
+			mockDB.On("GetVideoByID", "v1").Return(storedVideo("completed", "r1"), nil)
+			mockDB.On("GetVideoRuns", "v1").Return([]*domain.VideoRun{
+				{ID: "r1", Status: "completed"}, {ID: "r2", Status: "failed"},
+			}, nil)
+
+			w := sendJSON(r, http.MethodPut, "/videos/v1/runs/current", tc.body)
+
+			assert.Equal(t, tc.status, w.Code)
+			mockDB.AssertNotCalled(t, "SetCurrentVideoRun", mock.Anything, mock.Anything)
+		})
+	}
+}
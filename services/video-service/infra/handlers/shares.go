@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Lifetime of a share link when none is asked for, and the longest allowed.
+const (
+	defaultShareExpiry = 7 * 24 * time.Hour
+	maxShareExpiry     = 30 * 24 * time.Hour
+)
+
+// sharePasswordHeader carries the password of a protected share link; the
+// password query parameter is accepted too, for links opened in a browser.
+const sharePasswordHeader = "X-Share-Password"
+
+type CreateShareRequest struct {
+	ExpiresInHours int    `json:"expires_in_hours"`
+	Password       string `json:"password"`
+	MaxDownloads   *int   `json:"max_downloads"`
+}
+
+type ShareResponse struct {
+	ID                string     `json:"id"`
+	VideoID           string     `json:"video_id"`
+	Token             string     `json:"token,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	PasswordProtected bool       `json:"password_protected"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	MaxDownloads      *int       `json:"max_downloads,omitempty"`
+	DownloadCount     int        `json:"download_count"`
+	Active            bool       `json:"active"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type ShareListResponse struct {
+	VideoID string          `json:"video_id"`
+	Shares  []ShareResponse `json:"shares"`
+}
+
+// CreateShare creates a share link for one of the caller's completed videos.
+// The link's token is only in this response.
+func (h *VideoHandler) CreateShare(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	var req CreateShareRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	expiry := defaultShareExpiry
+	if req.ExpiresInHours != 0 {
+		expiry = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if expiry <= 0 || expiry > maxShareExpiry {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expires_in_hours must be between 1 and %d", int(maxShareExpiry.Hours()))})
+		return
+	}
+	if req.MaxDownloads != nil && *req.MaxDownloads < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_downloads must be at least 1"})
+		return
+	}
+	if len(req.Password) > 72 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password must be at most 72 bytes"})
+		return
+	}
+
+	if video.Status != "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only a completed video can be shared"})
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	now := time.Now()
+	link := &domain.ShareLink{
+		ID:           uuid.New().String(),
+		VideoID:      video.ID,
+		UserID:       video.UserID,
+		TokenHash:    hashShareToken(token),
+		ExpiresAt:    now.Add(expiry),
+		MaxDownloads: req.MaxDownloads,
+		CreatedAt:    now,
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+			return
+		}
+		passwordHash := string(hash)
+		link.PasswordHash = &passwordHash
+	}
+
+	if err := h.db.CreateShareLink(link); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	h.auditVideo(c, "video.share.create", video.ID)
+
+	response := newShareResponse(link, now)
+	response.Token = token
+	response.URL = fmt.Sprintf("/api/v1/shares/%s/download", token)
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListShares lists the share links of one of the caller's videos, newest
+// first.
+func (h *VideoHandler) ListShares(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	links, err := h.db.GetShareLinks(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch share links"})
+		return
+	}
+
+	now := time.Now()
+	response := ShareListResponse{VideoID: video.ID, Shares: make([]ShareResponse, 0, len(links))}
+	for _, link := range links {
+		response.Shares = append(response.Shares, newShareResponse(link, now))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeShare stops a share link from allowing any more downloads.
+func (h *VideoHandler) RevokeShare(c *gin.Context) {
+	video, ok := h.ownedVideo(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.db.RevokeShareLink(video.ID, c.Param("share_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+
+	h.auditVideo(c, "video.share.revoke", video.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
+
+// DownloadShared streams a video's frames to whoever holds a share link for
+// it. It needs no account, only the link's password if it has one.
+func (h *VideoHandler) DownloadShared(c *gin.Context) {
+	link, err := h.db.GetShareLinkByToken(hashShareToken(c.Param("token")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+	if !link.Usable(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "Share link has expired"})
+		return
+	}
+
+	if link.PasswordHash != nil {
+		password := c.GetHeader(sharePasswordHeader)
+		if password == "" {
+			password = c.Query("password")
+		}
+		if bcrypt.CompareHashAndPassword([]byte(*link.PasswordHash), []byte(password)) != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid share link password"})
+			return
+		}
+	}
+
+	video, err := h.db.GetVideoByID(link.VideoID)
+	if err != nil || video.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	if !zipReady(c, video) {
+		return
+	}
+
+	// Every response that sends content counts, a 206 included, once the zip
+	// is known to exist: only a 304 revalidation is free. Resuming with Range
+	// is a download of its own, so it cannot get the zip past the limit. It
+	// is counted before it is sent, so a link with one download left cannot
+	// be used twice at once.
+	h.streamZip(c, video, func() bool {
+		used, err := h.db.UseShareLink(link.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to use share link"})
+			return false
+		}
+		if !used {
+			c.JSON(http.StatusGone, gin.H{"error": "Share link has expired"})
+			return false
+		}
+		h.auditVideo(c, "video.share.download", video.ID)
+		return true
+	})
+}
+
+// newShareToken returns a random, URL-safe share link token.
+func newShareToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashShareToken is how a token is stored and looked up.
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newShareResponse(link *domain.ShareLink, now time.Time) ShareResponse {
+	return ShareResponse{
+		ID:                link.ID,
+		VideoID:           link.VideoID,
+		PasswordProtected: link.PasswordHash != nil,
+		ExpiresAt:         link.ExpiresAt,
+		MaxDownloads:      link.MaxDownloads,
+		DownloadCount:     link.DownloadCount,
+		Active:            link.Usable(now),
+		RevokedAt:         link.RevokedAt,
+		LastUsedAt:        link.LastUsedAt,
+		CreatedAt:         link.CreatedAt,
+	}
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func sharesRouter(db *MockDatabase, minio *MockMinIO, auth *MockAuthClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewVideoHandler(db, minio, nil, auth)
+	r := gin.New()
+	owner := r.Group("/videos", func(c *gin.Context) {
+		c.Set("user_id", "user123")
+	})
+	owner.POST("/:id/shares", handler.CreateShare)
+	owner.GET("/:id/shares", handler.ListShares)
+	owner.DELETE("/:id/shares/:share_id", handler.RevokeShare)
+	r.GET("/shares/:token/download", handler.DownloadShared)
+	return r
+}
+
+func completedVideo() *domain.Video {
+	zipPath := "processed/frames_v1.zip"
+	return &domain.Video{ID: "v1", UserID: "user123", Status: "completed", ZipPath: &zipPath}
+}
+
+// sharedLink is a link to v1 with the given token, valid for another day.
+func sharedLink(token string) *domain.ShareLink {
+	return &domain.ShareLink{
+		ID: "s1", VideoID: "v1", UserID: "user123", TokenHash: hashShareToken(token),
+		ExpiresAt: time.Now().Add(24 * time.Hour), CreatedAt: time.Now(),
+	}
+}
+
+// ---------- CreateShare ----------
+
+func TestCreateShare_Success(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	r := sharesRouter(mockDB, nil, mockAuth)
+
+	var link *domain.ShareLink
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	mockDB.On("CreateShareLink", mock.Anything).Run(func(args mock.Arguments) {
+		link = args.Get(0).(*domain.ShareLink)
+	}).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.MatchedBy(func(req domain.AuditLogRequest) bool {
+		return req.Action == "video.share.create"
+	})).Return(nil)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/shares", `{"expires_in_hours": 48, "password": "s3cret", "max_downloads": 3}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response ShareResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NotEmpty(t, response.Token)
+	assert.Equal(t, "/api/v1/shares/"+response.Token+"/download", response.URL)
+	assert.True(t, response.PasswordProtected)
+	assert.True(t, response.Active)
+	assert.Equal(t, 3, *response.MaxDownloads)
+
+	assert.Equal(t, hashShareToken(response.Token), link.TokenHash)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(*link.PasswordHash), []byte("s3cret")))
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), link.ExpiresAt, time.Minute)
+	assert.NotContains(t, w.Body.String(), link.TokenHash)
+	time.Sleep(20 * time.Millisecond)
+	mockAuth.AssertExpectations(t)
+}
+
+func TestCreateShare_DefaultsWithoutBody(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockAuth := new(MockAuthClient)
+	r := sharesRouter(mockDB, nil, mockAuth)
+
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	mockDB.On("CreateShareLink", mock.MatchedBy(func(l *domain.ShareLink) bool {
+		return l.PasswordHash == nil && l.MaxDownloads == nil &&
+			l.ExpiresAt.Sub(l.CreatedAt) == defaultShareExpiry
+	})).Return(nil)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/shares", "")
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockDB.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestCreateShare_Rejected(t *testing.T) {
+	processing := completedVideo()
+	processing.Status = "processing"
+	other := completedVideo()
+	other.UserID = "other"
+
+	cases := []struct {
+		name   string
+		video  *domain.Video
+		body   string
+		status int
+	}{
+		{"negative expiry", completedVideo(), `{"expires_in_hours": -1}`, http.StatusBadRequest},
+		{"expiry too long", completedVideo(), `{"expires_in_hours": 721}`, http.StatusBadRequest},
+		{"no downloads", completedVideo(), `{"max_downloads": 0}`, http.StatusBadRequest},
+		{"not completed", processing, `{}`, http.StatusConflict},
+		{"other user", other, `{}`, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			r := sharesRouter(mockDB, nil, nil)
+
+			mockDB.On("GetVideoByID", "v1").Return(tc.video, nil)
+
+			w := sendJSON(r, http.MethodPost, "/videos/v1/shares", tc.body)
+
+			assert.Equal(t, tc.status, w.Code)
+			mockDB.AssertNotCalled(t, "CreateShareLink", mock.Anything)
+		})
+	}
+}
+
+// ---------- ListShares ----------
+
+func TestListShares_MarksActive(t *testing.T) {
+	mockDB := new(MockDatabase)
+	r := sharesRouter(mockDB, nil, nil)
+
+	revokedAt := time.Now()
+	maxDownloads := 2
+	active := sharedLink("a")
+	revoked := sharedLink("b")
+	revoked.RevokedAt = &revokedAt
+	expired := sharedLink("c")
+	expired.ExpiresAt = time.Now().Add(-time.Hour)
+	used := sharedLink("d")
+	used.MaxDownloads, used.DownloadCount = &maxDownloads, 2
+
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	mockDB.On("GetShareLinks", "v1").Return([]*domain.ShareLink{active, revoked, expired, used}, nil)
+
+	w := serve(r, http.MethodGet, "/videos/v1/shares")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ShareListResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Shares, 4)
+	assert.True(t, response.Shares[0].Active)
+	assert.False(t, response.Shares[1].Active)
+	assert.False(t, response.Shares[2].Active)
+	assert.False(t, response.Shares[3].Active)
+	assert.Empty(t, response.Shares[0].Token)
+}
+
+// ---------- RevokeShare ----------
+
+func TestRevokeShare(t *testing.T) {
+	cases := []struct {
+		name    string
+		revoked bool
+		status  int
+	}{
+		{"revoked", true, http.StatusOK},
+		{"unknown link", false, http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			mockAuth := new(MockAuthClient)
+			r := sharesRouter(mockDB, nil, mockAuth)
+
+			mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+			mockDB.On("RevokeShareLink", "v1", "s1").Return(tc.revoked, nil)
+			mockAuth.On("CreateAuditLog", mock.Anything).Return(nil)
+
+			w := serve(r, http.MethodDelete, "/videos/v1/shares/s1")
+
+			assert.Equal(t, tc.status, w.Code)
+			time.Sleep(20 * time.Millisecond)
+		})
+	}
+}
+
+// ---------- DownloadShared ----------
+
+func TestDownloadShared_WithPassword(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		path    string
+		headers map[string]string
+	}{
+		{"header", "/shares/tok/download", map[string]string{sharePasswordHeader: "s3cret"}},
+		{"query", "/shares/tok/download?password=s3cret", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			mockMinio := new(MockMinIO)
+			mockAuth := new(MockAuthClient)
+			r := sharesRouter(mockDB, mockMinio, mockAuth)
+
+			hash, _ := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+			passwordHash := string(hash)
+			link := sharedLink("tok")
+			link.PasswordHash = &passwordHash
+			mockDB.On("GetShareLinkByToken", hashShareToken("tok")).Return(link, nil)
+			mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+			mockDB.On("UseShareLink", "s1").Return(true, nil)
+			mockAuth.On("CreateAuditLog", mock.MatchedBy(func(req domain.AuditLogRequest) bool {
+				return req.Action == "video.share.download" && req.UserID == nil
+			})).Return(nil)
+			mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{Size: 3, ETag: "abc"}, nil)
+			mockMinio.On("GetProcessedObjectRange", "processed/frames_v1.zip", int64(0), int64(-1)).
+				Return(io.NopCloser(strings.NewReader("zip")), nil)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "zip", w.Body.String())
+			mockDB.AssertExpectations(t)
+			mockMinio.AssertExpectations(t)
+			time.Sleep(20 * time.Millisecond)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDownloadShared_Rejected(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	passwordHash := string(hash)
+	deletedAt := time.Now()
+
+	protected := sharedLink("tok")
+	protected.PasswordHash = &passwordHash
+	expired := sharedLink("tok")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	trashed := completedVideo()
+	trashed.DeletedAt = &deletedAt
+	processing := completedVideo()
+	processing.Status = "processing"
+
+	cases := []struct {
+		name   string
+		link   *domain.ShareLink
+		video  *domain.Video
+		used   bool
+		status int
+	}{
+		{"unknown token", nil, nil, false, http.StatusNotFound},
+		{"expired", expired, completedVideo(), false, http.StatusGone},
+		{"wrong password", protected, completedVideo(), false, http.StatusUnauthorized},
+		{"video in trash", sharedLink("tok"), trashed, false, http.StatusNotFound},
+		{"video not ready", sharedLink("tok"), processing, false, http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			mockMinio := new(MockMinIO)
+			r := sharesRouter(mockDB, mockMinio, nil)
+
+			if tc.link == nil {
+				mockDB.On("GetShareLinkByToken", hashShareToken("tok")).Return(nil, errors.New("not found"))
+			} else {
+				mockDB.On("GetShareLinkByToken", hashShareToken("tok")).Return(tc.link, nil)
+			}
+			mockDB.On("GetVideoByID", "v1").Return(tc.video, nil)
+			mockDB.On("UseShareLink", "s1").Return(tc.used, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/shares/tok/download", nil)
+			req.Header.Set(sharePasswordHeader, "guess")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			mockMinio.AssertNotCalled(t, "StatProcessedObject", mock.Anything)
+		})
+	}
+}
+
+// sharedDownload requests the zip of an unprotected link to v1, which has
+// used downloads of its max downloads.
+func sharedDownload(mockDB *MockDatabase, mockMinio *MockMinIO, used, max int, headers map[string]string) *httptest.ResponseRecorder {
+	link := sharedLink("tok")
+	link.DownloadCount = used
+	link.MaxDownloads = &max
+	mockDB.On("GetShareLinkByToken", hashShareToken("tok")).Return(link, nil)
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/shares/tok/download", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	mockAuth := new(MockAuthClient)
+	mockAuth.On("CreateAuditLog", mock.Anything).Return(nil).Maybe()
+	w := httptest.NewRecorder()
+	sharesRouter(mockDB, mockMinio, mockAuth).ServeHTTP(w, req)
+	return w
+}
+
+func TestDownloadShared_LastDownloadTaken(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	mockDB.On("UseShareLink", "s1").Return(false, nil)
+	mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{Size: 3, ETag: "abc"}, nil)
+
+	w := sharedDownload(mockDB, mockMinio, 0, 1, nil)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	mockMinio.AssertNotCalled(t, "GetProcessedObjectRange", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDownloadShared_MissingZipNotCounted(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{}, errors.New("no such key"))
+
+	w := sharedDownload(mockDB, mockMinio, 0, 1, nil)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockDB.AssertNotCalled(t, "UseShareLink", mock.Anything)
+}
+
+func TestDownloadShared_RevalidationNotCounted(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{Size: 3, ETag: "abc"}, nil)
+
+	w := sharedDownload(mockDB, mockMinio, 0, 1, map[string]string{"If-None-Match": `"abc"`})
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	mockDB.AssertNotCalled(t, "UseShareLink", mock.Anything)
+}
+
+func TestDownloadShared_ResumeCounted(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	mockDB.On("UseShareLink", "s1").Return(true, nil)
+	mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{Size: 3, ETag: "abc"}, nil)
+	mockMinio.On("GetProcessedObjectRange", "processed/frames_v1.zip", int64(1), int64(2)).
+		Return(io.NopCloser(strings.NewReader("ip")), nil)
+
+	w := sharedDownload(mockDB, mockMinio, 1, 2, map[string]string{"Range": "bytes=1-"})
+
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "ip", w.Body.String())
+	mockDB.AssertExpectations(t)
+}
+
+func TestDownloadShared_ResumeRefusedOnceUsedUp(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+
+	w := sharedDownload(mockDB, mockMinio, 1, 1, map[string]string{"Range": "bytes=1-"})
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	mockDB.AssertNotCalled(t, "UseShareLink", mock.Anything)
+	mockMinio.AssertNotCalled(t, "GetProcessedObjectRange", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDownloadShared_RangeFromStartCounted(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	mockDB.On("UseShareLink", "s1").Return(true, nil)
+	mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{Size: 3, ETag: "abc"}, nil)
+	mockMinio.On("GetProcessedObjectRange", "processed/frames_v1.zip", int64(0), int64(0)).
+		Return(io.NopCloser(strings.NewReader("z")), nil)
+
+	w := sharedDownload(mockDB, mockMinio, 0, 1, map[string]string{"Range": "bytes=0-0"})
+
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	mockDB.AssertExpectations(t)
+}
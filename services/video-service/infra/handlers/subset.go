@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"video-service/domain"
+	"video-service/infra/archive"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSubsetSelectors caps how many ranges, frames and timestamps one subset
+// request can name in total.
+const maxSubsetSelectors = 1000
+
+// FrameRange selects frames From to To, both inclusive, by index.
+type FrameRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// SubsetDownloadRequest picks the frames of a subset download. Frames can be
+// picked by index range, by index, or by the timestamp they are nearest to;
+// the zip holds every frame picked by any of them, in index order.
+type SubsetDownloadRequest struct {
+	Ranges     []FrameRange `json:"ranges"`
+	Frames     []int        `json:"frames"`
+	Timestamps []float64    `json:"timestamps"`
+}
+
+// DownloadSubset streams a zip of some of the frames of one of the caller's
+// completed videos. Each frame is copied still compressed from the stored
+// zip, so the new one is never built in memory or on disk.
+func (h *VideoHandler) DownloadSubset(c *gin.Context) {
+	var req SubsetDownloadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	video, ok := h.ownedVideo(c)
+	if !ok || !zipReady(c, video) {
+		return
+	}
+
+	index, err := h.frameIndex(video)
+	if err != nil {
+		fmt.Printf("Failed to read frames of video %s: %v\n", video.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read frames"})
+		return
+	}
+
+	selected, status, err := index.selectFrames(req)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", subsetFilename(*video.ZipPath, selected)))
+	c.Status(http.StatusOK)
+
+	if err := index.writeSubset(c.Writer, selected); err != nil {
+		// The response has started, so all that can be done is to stop;
+		// without its directory the client is left with an unreadable zip
+		// rather than an incomplete one that looks whole.
+		fmt.Printf("Failed to stream frame subset of video %s: %v\n", video.ID, err)
+		c.Abort()
+	}
+}
+
+func (req SubsetDownloadRequest) validate() error {
+	if len(req.Ranges)+len(req.Frames)+len(req.Timestamps) == 0 {
+		return fmt.Errorf("ranges, frames or timestamps is required")
+	}
+	if len(req.Ranges)+len(req.Frames)+len(req.Timestamps) > maxSubsetSelectors {
+		return fmt.Errorf("at most %d ranges, frames and timestamps can be given", maxSubsetSelectors)
+	}
+	for _, r := range req.Ranges {
+		if r.From < 1 || r.To < r.From {
+			return fmt.Errorf("invalid range %d-%d", r.From, r.To)
+		}
+	}
+	for _, n := range req.Frames {
+		if n < 1 {
+			return fmt.Errorf("invalid frame index %d", n)
+		}
+	}
+	for _, ts := range req.Timestamps {
+		if ts < 0 || math.IsNaN(ts) {
+			return fmt.Errorf("invalid timestamp %v", ts)
+		}
+	}
+	return nil
+}
+
+// selectFrames returns the indexes of the frames req picks, in order, or
+// the status and error to respond with when it cannot be satisfied.
+func (index *frameIndex) selectFrames(req SubsetDownloadRequest) ([]int, int, error) {
+	picked := map[int]bool{}
+
+	for _, r := range req.Ranges {
+		for _, frame := range index.frames {
+			if frame.Index >= r.From && frame.Index <= r.To {
+				picked[frame.Index] = true
+			}
+		}
+	}
+
+	for _, n := range req.Frames {
+		if _, ok := index.files[n]; !ok {
+			return nil, http.StatusNotFound, fmt.Errorf("frame %d not found", n)
+		}
+		picked[n] = true
+	}
+
+	for _, ts := range req.Timestamps {
+		n, ok := index.nearestFrame(ts)
+		if !ok {
+			return nil, http.StatusConflict, fmt.Errorf("the frames of this video have no timestamps")
+		}
+		picked[n] = true
+	}
+
+	if len(picked) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("no frames in the requested ranges")
+	}
+
+	selected := make([]int, 0, len(picked))
+	for n := range picked {
+		selected = append(selected, n)
+	}
+	sort.Ints(selected)
+	return selected, 0, nil
+}
+
+// nearestFrame returns the frame taken closest to ts. It reports false when
+// the frames have no timestamps.
+func (index *frameIndex) nearestFrame(ts float64) (int, bool) {
+	best, bestDistance := 0, math.Inf(1)
+	for _, frame := range index.frames {
+		if frame.Timestamp == nil {
+			return 0, false
+		}
+		if d := math.Abs(*frame.Timestamp - ts); d < bestDistance {
+			best, bestDistance = frame.Index, d
+		}
+	}
+	return best, best != 0
+}
+
+// writeSubset writes a zip of the selected frames to w, with a frame
+// manifest of their own when their timestamps are known.
+func (index *frameIndex) writeSubset(w io.Writer, selected []int) error {
+	zw := zip.NewWriter(w)
+	manifest := []domain.FrameInfo{}
+
+	for _, n := range selected {
+		file := index.files[n]
+		if err := copyZipEntry(zw, index.archive, file); err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+		if frame := index.frame(n); frame != nil && frame.Timestamp != nil {
+			manifest = append(manifest, domain.FrameInfo{Index: n, Name: file.Name, Timestamp: *frame.Timestamp})
+		}
+	}
+
+	if len(manifest) > 0 {
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: frameManifestName, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(entry).Encode(manifest); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (index *frameIndex) frame(n int) *FrameResponse {
+	i := sort.Search(len(index.frames), func(i int) bool { return index.frames[i].Index >= n })
+	if i < len(index.frames) && index.frames[i].Index == n {
+		return &index.frames[i]
+	}
+	return nil
+}
+
+// copyZipEntry copies an entry of the stored zip into zw as it is stored,
+// without decompressing it.
+func copyZipEntry(zw *zip.Writer, reader *archive.Reader, file *zip.File) error {
+	raw, err := reader.OpenRaw(file)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+
+	header := file.FileHeader
+	entry, err := zw.CreateRaw(&header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, raw)
+	return err
+}
+
+// subsetFilename names a subset after the zip it came from and the frames
+// it holds, e.g. frames_v1_run1_frames_100-250.zip.
+func subsetFilename(zipPath string, selected []int) string {
+	base := strings.TrimSuffix(filepath.Base(zipPath), filepath.Ext(zipPath))
+	first, last := selected[0], selected[len(selected)-1]
+	if first == last {
+		return fmt.Sprintf("%s_frame_%d.zip", base, first)
+	}
+	return fmt.Sprintf("%s_frames_%d-%d.zip", base, first, last)
+}
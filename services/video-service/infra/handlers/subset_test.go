@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"video-service/domain"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// subsetFrames are five distinct frames taken every half second from 10s.
+func subsetFrames() ([][]byte, []domain.FrameInfo) {
+	frames := [][]byte{}
+	manifest := []domain.FrameInfo{}
+	for i := 1; i <= 5; i++ {
+		frames = append(frames, framePNG(i, i))
+		manifest = append(manifest, domain.FrameInfo{Index: i, Name: frameName(i), Timestamp: 10 + float64(i-1)/2})
+	}
+	return frames, manifest
+}
+
+// unzip returns the entries of a zip by name.
+func unzip(t *testing.T, data []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	entries := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		entries[f.Name], err = io.ReadAll(rc)
+		assert.NoError(t, err)
+		rc.Close()
+	}
+	return entries
+}
+
+// ---------- DownloadSubset ----------
+
+func TestDownloadSubset_RangesAndFrames(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	frames, manifest := subsetFrames()
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip(frames, manifest))
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/download/subset", `{"ranges": [{"from": 2, "to": 3}], "frames": [5, 3]}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="frames_v1_frames_2-5.zip"`, w.Header().Get("Content-Disposition"))
+
+	entries := unzip(t, w.Body.Bytes())
+	assert.Len(t, entries, 4)
+	assert.Equal(t, frames[1], entries["frame_0002.png"])
+	assert.Equal(t, frames[2], entries["frame_0003.png"])
+	assert.Equal(t, frames[4], entries["frame_0005.png"])
+
+	var subsetManifest []domain.FrameInfo
+	assert.NoError(t, json.Unmarshal(entries[frameManifestName], &subsetManifest))
+	assert.Equal(t, []domain.FrameInfo{manifest[1], manifest[2], manifest[4]}, subsetManifest)
+}
+
+func TestDownloadSubset_Timestamps(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	frames, manifest := subsetFrames()
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	serveZip(mockMinio, framesZip(frames, manifest))
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/download/subset", `{"timestamps": [11.1]}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="frames_v1_frame_3.zip"`, w.Header().Get("Content-Disposition"))
+	entries := unzip(t, w.Body.Bytes())
+	assert.Equal(t, frames[2], entries["frame_0003.png"])
+}
+
+func TestDownloadSubset_Rejected(t *testing.T) {
+	frames, manifest := subsetFrames()
+
+	cases := []struct {
+		name     string
+		body     string
+		manifest []domain.FrameInfo
+		status   int
+	}{
+		{"nothing picked", `{}`, manifest, http.StatusBadRequest},
+		{"not json", `frames`, manifest, http.StatusBadRequest},
+		{"reversed range", `{"ranges": [{"from": 4, "to": 2}]}`, manifest, http.StatusBadRequest},
+		{"zero index", `{"frames": [0]}`, manifest, http.StatusBadRequest},
+		{"negative timestamp", `{"timestamps": [-1]}`, manifest, http.StatusBadRequest},
+		{"unknown frame", `{"frames": [9]}`, manifest, http.StatusNotFound},
+		{"range past the end", `{"ranges": [{"from": 6, "to": 10}]}`, manifest, http.StatusNotFound},
+		{"no timestamps", `{"timestamps": [10]}`, nil, http.StatusConflict},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := new(MockDatabase)
+			mockMinio := new(MockMinIO)
+			r := framesRouter(mockDB, mockMinio)
+
+			mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+			serveZip(mockMinio, framesZip(frames, tc.manifest))
+
+			w := sendJSON(r, http.MethodPost, "/videos/v1/download/subset", tc.body)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Empty(t, w.Header().Get("Content-Disposition"))
+		})
+	}
+}
+
+func TestDownloadSubset_NotOwner(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	other := completedVideo()
+	other.UserID = "other"
+	mockDB.On("GetVideoByID", "v1").Return(other, nil)
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/download/subset", `{"frames": [1]}`)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockMinio.AssertNotCalled(t, "StatProcessedObject", mock.Anything)
+}
+
+func TestDownloadSubset_StorageFailsMidStream(t *testing.T) {
+	mockDB := new(MockDatabase)
+	mockMinio := new(MockMinIO)
+	r := framesRouter(mockDB, mockMinio)
+
+	frames, manifest := subsetFrames()
+	data := framesZip(frames, manifest)
+	reads := 0
+	mockDB.On("GetVideoByID", "v1").Return(completedVideo(), nil)
+	mockMinio.On("StatProcessedObject", "processed/frames_v1.zip").Return(minio.ObjectInfo{Size: int64(len(data)), ETag: "zipetag"}, nil)
+	mockMinio.On("GetProcessedObjectRange", "processed/frames_v1.zip", mock.Anything, mock.Anything).
+		Return(func(start, end int64) (io.ReadCloser, error) {
+			reads++
+			// The directory, then the local header and data of the first frame.
+			if reads > 3 {
+				return nil, errors.New("storage down")
+			}
+			return io.NopCloser(bytes.NewReader(data[start : end+1])), nil
+		})
+
+	w := sendJSON(r, http.MethodPost, "/videos/v1/download/subset", `{"frames": [1, 2]}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.Error(t, err)
+}
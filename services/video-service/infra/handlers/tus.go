@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"video-service/domain"
+	"video-service/infra/media"
+	"video-service/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Resumable uploads follow the tus 1.0.0 core protocol with the creation,
+// termination and expiration extensions. Each PATCH appends to a MinIO
+// multipart upload, and the upload session row records how far it got, so a
+// client that loses its connection asks for the offset with HEAD and carries
+// on from there.
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,termination,expiration"
+	tusBasePath    = "/api/v1/videos/uploads"
+	tusContentType = "application/offset+octet-stream"
+
+	// tusPartSize is the S3 minimum for every part but the last.
+	tusPartSize    = 5 * 1024 * 1024
+	tusUploadLease = 2 * time.Minute
+	tusUploadTTL   = 24 * time.Hour
+)
+
+var errUnsupportedContainer = errors.New("file content is not a supported video container")
+
+// setTusDiscoveryHeaders answers an OPTIONS request with what the server
+// supports.
+func setTusDiscoveryHeaders(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	if largest := largestFileSize(); largest > 0 {
+		c.Header("Tus-Max-Size", strconv.FormatInt(largest, 10))
+	}
+}
+
+func checkTusResumable(c *gin.Context) bool {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus version"})
+		return false
+	}
+	return true
+}
+
+// CreateUpload starts a resumable upload. The file name, processing options
+// and batch_id travel in Upload-Metadata, using the same fields as the form
+// upload (timestamps as a comma-separated list). A JSON request without the tus
+// header asks for a direct upload instead.
+func (h *VideoHandler) CreateUpload(c *gin.Context) {
+	if c.GetHeader("Tus-Resumable") == "" && c.ContentType() == "application/json" {
+		h.createDirectUpload(c)
+		return
+	}
+
+	if !checkTusResumable(c) {
+		return
+	}
+
+	if c.GetHeader("Upload-Defer-Length") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Defer-Length is not supported"})
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Length"})
+		return
+	}
+	if length == 0 {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success:   false,
+			Message:   "Uploaded file is empty",
+			ErrorCode: ErrCodeEmptyFile,
+		})
+		return
+	}
+
+	metadata, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	originalName := metadata["filename"]
+	if !isValidVideoFile(originalName) {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success:   false,
+			Message:   "Invalid video format. Supported: mp4, avi, mov, mkv, wmv, flv, webm",
+			ErrorCode: ErrCodeUnsupportedFormat,
+		})
+		return
+	}
+
+	timestamps := []string{}
+	if value := metadata["timestamps"]; value != "" {
+		timestamps = strings.Split(value, ",")
+	}
+	options, err := parseOptionFields(func(key string) string { return metadata[key] }, timestamps)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if rejection := h.checkQuota(c, length); rejection != nil {
+		c.JSON(rejection.status, rejection.response)
+		return
+	}
+
+	batchID, ok := h.batchForUpload(c, metadata["batch_id"])
+	if !ok {
+		return
+	}
+
+	videoID := uuid.New().String()
+	filename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), videoID, filepath.Ext(originalName))
+
+	storagePath, multipartID, err := h.minio.NewMultipartUpload(filename)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	session := &domain.UploadSession{
+		ID:                uuid.New().String(),
+		UserID:            c.GetString("user_id"),
+		VideoID:           videoID,
+		Filename:          filename,
+		OriginalName:      originalName,
+		StoragePath:       storagePath,
+		Method:            domain.UploadMethodTus,
+		MultipartUploadID: multipartID,
+		SizeBytes:         length,
+		Options:           options,
+		BatchID:           batchID,
+		Status:            domain.UploadStatusUploading,
+		ExpiresAt:         now.Add(tusUploadTTL),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := h.db.CreateUploadSession(session); err != nil {
+		h.minio.AbortMultipartUpload(storagePath, multipartID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload session"})
+		return
+	}
+
+	c.Header("Location", tusBasePath+"/"+session.ID)
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// GetUploadOffset tells a client where to resume.
+func (h *VideoHandler) GetUploadOffset(c *gin.Context) {
+	if !checkTusResumable(c) {
+		return
+	}
+
+	session, err := h.db.GetUploadSession(c.Param("upload_id"))
+	if err != nil || session.UserID != c.GetString("user_id") || session.Method != domain.UploadMethodTus {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if uploadExpired(session) {
+		c.Status(http.StatusGone)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(session.OffsetBytes, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.SizeBytes, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	if session.Status == domain.UploadStatusCompleted {
+		c.Header("X-Video-Id", session.VideoID)
+	}
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload appends the request body at Upload-Offset. The request that
+// brings the upload to its full length also creates the video and queues it,
+// exactly as a form upload would.
+func (h *VideoHandler) PatchUpload(c *gin.Context) {
+	if !checkTusResumable(c) {
+		return
+	}
+
+	if c.ContentType() != tusContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + tusContentType})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Offset"})
+		return
+	}
+
+	session, ok := h.lockUploadSession(c)
+	if !ok {
+		return
+	}
+	defer h.releaseUploadSession(session)
+
+	if session.Method != domain.UploadMethodTus {
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload is not a tus upload"})
+		return
+	}
+
+	if offset != session.OffsetBytes {
+		c.Header("Upload-Offset", strconv.FormatInt(session.OffsetBytes, 10))
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the current offset"})
+		return
+	}
+
+	if session.Status == domain.UploadStatusUploading {
+		remaining := session.SizeBytes - session.OffsetBytes
+		if c.Request.ContentLength > remaining {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body exceeds Upload-Length"})
+			return
+		}
+
+		if err := h.writeUploadChunk(session, io.LimitReader(c.Request.Body, remaining)); err != nil {
+			if errors.Is(err, errUnsupportedContainer) {
+				h.discardUpload(session)
+				c.JSON(http.StatusBadRequest, UploadResponse{
+					Success:   false,
+					Message:   "File content is not a supported video container",
+					ErrorCode: ErrCodeUnsupportedFormat,
+				})
+				return
+			}
+			c.Header("Upload-Offset", strconv.FormatInt(session.OffsetBytes, 10))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store upload chunk: " + err.Error()})
+			return
+		}
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(session.OffsetBytes, 10))
+
+	if session.OffsetBytes == session.SizeBytes {
+		if rejection := h.finishUpload(c, session); rejection != nil {
+			c.JSON(rejection.status, rejection.response)
+			return
+		}
+		c.Header("X-Video-Id", session.VideoID)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TerminateUpload abandons an upload and frees what it stored. Direct
+// uploads can be cancelled the same way, without the tus header.
+func (h *VideoHandler) TerminateUpload(c *gin.Context) {
+	session, ok := h.lockUploadSession(c)
+	if !ok {
+		return
+	}
+	defer h.releaseUploadSession(session)
+
+	if session.Method == domain.UploadMethodTus && !checkTusResumable(c) {
+		return
+	}
+
+	if session.Status == domain.UploadStatusCompleted {
+		if err := h.db.DeleteUploadSession(session.ID); err == nil {
+			session.Deleted = true
+		}
+	} else {
+		h.discardUpload(session)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// lockUploadSession loads the caller's session and takes its lease, answering
+// the request itself when that is not possible.
+func (h *VideoHandler) lockUploadSession(c *gin.Context) (*domain.UploadSession, bool) {
+	session, err := h.db.LockUploadSession(c.Param("upload_id"), tusUploadLease)
+	if errors.Is(err, domain.ErrUploadLocked) {
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload is in use by another request"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil, false
+	}
+
+	if session.UserID != c.GetString("user_id") {
+		h.releaseUploadSession(session)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil, false
+	}
+	if uploadExpired(session) {
+		h.releaseUploadSession(session)
+		c.JSON(http.StatusGone, gin.H{"error": "Upload has expired"})
+		return nil, false
+	}
+
+	return session, true
+}
+
+// releaseUploadSession saves the session and gives up its lease, unless the
+// request deleted it.
+func (h *VideoHandler) releaseUploadSession(session *domain.UploadSession) {
+	if session.Deleted {
+		return
+	}
+	session.LockedUntil = nil
+	if err := h.db.UpdateUploadSession(session); err != nil {
+		fmt.Printf("Failed to save upload session %s: %v\n", session.ID, err)
+	}
+}
+
+// writeUploadChunk appends body to the session's multipart upload in parts of
+// tusPartSize. Whatever is left short of a part (and is not the end of the
+// file) goes to the tail object and is prepended on the next request. The
+// session always describes what has been stored, even when the client drops
+// the connection halfway, so HEAD reports a safe offset to resume from.
+func (h *VideoHandler) writeUploadChunk(session *domain.UploadSession, body io.Reader) error {
+	buf := make([]byte, tusPartSize)
+	partsBytes := session.OffsetBytes - session.TailBytes
+	hadTail := session.TailBytes > 0
+	filled := 0
+
+	if hadTail {
+		tail, err := h.minio.GetRawObject(session.TailObjectName())
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadFull(tail, buf[:session.TailBytes])
+		tail.Close()
+		if err != nil {
+			return err
+		}
+		filled = int(session.TailBytes)
+	}
+
+	for {
+		n, readErr := io.ReadFull(body, buf[filled:])
+		filled += n
+		total := partsBytes + int64(filled)
+
+		if partsBytes == 0 && (filled >= media.SniffLen || total == session.SizeBytes) {
+			head := buf[:filled]
+			if len(head) > media.SniffLen {
+				head = head[:media.SniffLen]
+			}
+			if media.MatchContainer(head) == "" {
+				return errUnsupportedContainer
+			}
+		}
+
+		if filled == len(buf) || (filled > 0 && total == session.SizeBytes) {
+			err := h.minio.UploadPart(session.StoragePath, session.MultipartUploadID, session.PartsCount+1,
+				bytes.NewReader(buf[:filled]), int64(filled))
+			if err != nil {
+				return err
+			}
+			session.PartsCount++
+			partsBytes = total
+			session.TailBytes = 0
+			session.OffsetBytes = partsBytes
+			filled = 0
+		}
+
+		if readErr != nil || total == session.SizeBytes {
+			break
+		}
+	}
+
+	if int64(filled) != session.TailBytes {
+		if err := h.minio.PutRawObject(session.TailObjectName(), bytes.NewReader(buf[:filled]), int64(filled)); err != nil {
+			return err
+		}
+		session.TailBytes = int64(filled)
+		session.OffsetBytes = partsBytes + session.TailBytes
+	} else if hadTail && filled == 0 {
+		h.minio.DeleteFile(session.TailObjectName())
+	}
+
+	return nil
+}
+
+// finishUpload checks the quota again, assembles the parts and creates the
+// video. An upload the quota no longer has room for is discarded. Both steps
+// are recorded on the session, so a retry after a failure picks up where the
+// last attempt stopped instead of creating the video twice.
+func (h *VideoHandler) finishUpload(c *gin.Context, session *domain.UploadSession) *uploadRejection {
+	if session.Status == domain.UploadStatusUploading {
+		if rejection := h.checkFinishedUpload(c, session); rejection != nil {
+			if rejection.status != http.StatusInternalServerError {
+				h.discardUpload(session)
+			}
+			return rejection
+		}
+		if err := h.minio.CompleteMultipartUpload(session.StoragePath, session.MultipartUploadID); err != nil {
+			return rejectUpload(http.StatusInternalServerError, "Failed to assemble upload: "+err.Error(), "")
+		}
+		session.Status = domain.UploadStatusAssembled
+		if err := h.db.UpdateUploadSession(session); err != nil {
+			return rejectUpload(http.StatusInternalServerError, "Failed to save upload session: "+err.Error(), "")
+		}
+	}
+
+	if session.Status == domain.UploadStatusAssembled {
+		video := h.newVideo(c, session.VideoID, session.Filename, session.OriginalName, session.StoragePath, session.SizeBytes)
+		video.BatchID = session.BatchID
+		if err := h.enqueueVideo(video, session.Options); err != nil {
+			if _, getErr := h.db.GetVideoByID(session.VideoID); getErr != nil {
+				return rejectUpload(http.StatusInternalServerError, "Failed to create video record: "+err.Error(), "")
+			}
+		} else {
+			h.auditVideo(c, "video.upload", session.VideoID)
+		}
+		session.Status = domain.UploadStatusCompleted
+	}
+
+	return nil
+}
+
+// discardUpload drops everything an unfinished upload stored.
+func (h *VideoHandler) discardUpload(session *domain.UploadSession) {
+	service.DiscardUpload(h.db, h.minio, session)
+}
+
+func uploadExpired(session *domain.UploadSession) bool {
+	return session.Status != domain.UploadStatusCompleted && time.Now().After(session.ExpiresAt)
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header: comma-separated
+// pairs of a key and an optional base64 value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("Invalid Upload-Metadata")
+		}
+		value := ""
+		if len(fields) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid Upload-Metadata value for %s", fields[0])
+			}
+			value = string(decoded)
+		}
+		metadata[fields[0]] = value
+	}
+
+	return metadata, nil
+}
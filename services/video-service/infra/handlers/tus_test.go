@@ -0,0 +1,616 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ---------- In-memory fakes ----------
+
+// memoryStorage keeps multipart uploads and raw objects in memory so tests can
+// compare the assembled file byte for byte.
+type memoryStorage struct {
+	*MockMinIO
+	mu        sync.Mutex
+	parts     map[int][]byte
+	objects   map[string][]byte
+	types     map[string]string
+	assembled []byte
+	completes int
+	aborted   bool
+
+	rangeReads int64
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{MockMinIO: new(MockMinIO), parts: map[int][]byte{}, objects: map[string][]byte{},
+		types: map[string]string{}}
+}
+
+func (m *memoryStorage) NewMultipartUpload(filename string) (string, string, error) {
+	return "2026/01/01/" + filename, "multipart-1", nil
+}
+
+func (m *memoryStorage) UploadPart(objectName, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("part %d: got %d bytes, declared %d", partNumber, len(data), size)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parts[partNumber] = data
+	return nil
+}
+
+func (m *memoryStorage) CompleteMultipartUpload(objectName, uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completes++
+	m.assembled = nil
+	for i := 1; i <= len(m.parts); i++ {
+		part, ok := m.parts[i]
+		if !ok {
+			return fmt.Errorf("missing part %d", i)
+		}
+		if i < len(m.parts) && len(part) < tusPartSize {
+			return fmt.Errorf("part %d is smaller than the minimum part size", i)
+		}
+		m.assembled = append(m.assembled, part...)
+	}
+	return nil
+}
+
+func (m *memoryStorage) AbortMultipartUpload(objectName, uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aborted = true
+	return nil
+}
+
+func (m *memoryStorage) PutRawObject(objectName string, reader io.Reader, size int64) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[objectName] = data
+	return nil
+}
+
+func (m *memoryStorage) GetRawObject(objectName string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[objectName]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryStorage) DeleteFile(objectName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, objectName)
+	return nil
+}
+
+// memorySessions stores upload sessions the way the database does, leases
+// included. It counts updates to sessions that were already deleted.
+type memorySessions struct {
+	*MockDatabase
+	mu           sync.Mutex
+	sessions     map[string]domain.UploadSession
+	staleUpdates int
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{MockDatabase: new(MockDatabase), sessions: map[string]domain.UploadSession{}}
+}
+
+func (m *memorySessions) CreateUploadSession(session *domain.UploadSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID] = *session
+	return nil
+}
+
+func (m *memorySessions) GetUploadSession(id string) (*domain.UploadSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, errors.New("sql: no rows in result set")
+	}
+	return &session, nil
+}
+
+func (m *memorySessions) LockUploadSession(id string, lease time.Duration) (*domain.UploadSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, errors.New("sql: no rows in result set")
+	}
+	if session.LockedUntil != nil && session.LockedUntil.After(time.Now()) {
+		return nil, domain.ErrUploadLocked
+	}
+	session.LockedUntil = TimePtr(time.Now().Add(lease))
+	m.sessions[id] = session
+	return &session, nil
+}
+
+func (m *memorySessions) UpdateUploadSession(session *domain.UploadSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[session.ID]; ok {
+		m.sessions[session.ID] = *session
+	} else {
+		m.staleUpdates++
+	}
+	return nil
+}
+
+func (m *memorySessions) DeleteUploadSession(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+// GetUserQuota leaves every user on their plan.
+func (m *memorySessions) GetUserQuota(userID string) (*domain.UserQuota, error) {
+	return nil, nil
+}
+
+// GetQuotaUsage counts the user's live sessions; there are no videos.
+func (m *memorySessions) GetQuotaUsage(userID string, since time.Time) (*domain.QuotaUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	usage := &domain.QuotaUsage{}
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.Status != domain.UploadStatusCompleted {
+			usage.ReservedBytes += session.SizeBytes
+			usage.ReservedVideos++
+		}
+	}
+	return usage, nil
+}
+
+// ---------- Helpers ----------
+
+func tusRouter(h *VideoHandler, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	r.POST(tusBasePath, h.CreateUpload)
+	r.HEAD(tusBasePath+"/:upload_id", h.GetUploadOffset)
+	r.PATCH(tusBasePath+"/:upload_id", h.PatchUpload)
+	r.DELETE(tusBasePath+"/:upload_id", h.TerminateUpload)
+	r.POST(tusBasePath+"/:upload_id/complete", h.CompleteUpload)
+	return r
+}
+
+func tusMetadata(pairs ...string) string {
+	encoded := []string{}
+	for i := 0; i < len(pairs); i += 2 {
+		encoded = append(encoded, pairs[i]+" "+base64.StdEncoding.EncodeToString([]byte(pairs[i+1])))
+	}
+	return strings.Join(encoded, ",")
+}
+
+func createUpload(t *testing.T, r *gin.Engine, length int, metadata string) string {
+	req := httptest.NewRequest(http.MethodPost, tusBasePath, nil)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Upload-Length", strconv.Itoa(length))
+	req.Header.Set("Upload-Metadata", metadata)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	return w.Header().Get("Location")
+}
+
+func patchUpload(r *gin.Engine, location string, offset int, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, location, body)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Content-Type", tusContentType)
+	req.Header.Set("Upload-Offset", strconv.Itoa(offset))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func headUpload(r *gin.Engine, location string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodHead, location, nil)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// brokenReader returns its data and then fails, like a client whose
+// connection drops in the middle of a request.
+type brokenReader struct {
+	data []byte
+}
+
+func (b *brokenReader) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		return 0, errors.New("connection reset by peer")
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+// videoBytes returns an MP4-looking file of the given size.
+func videoBytes(size int) []byte {
+	data := make([]byte, size)
+	copy(data, sampleMP4)
+	for i := len(sampleMP4); i < size; i++ {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func sessionID(location string) string {
+	return strings.TrimPrefix(location, tusBasePath+"/")
+}
+
+// ---------- CreateUpload ----------
+
+func TestCreateUpload_Success(t *testing.T) {
+	db := newMemorySessions()
+	r := tusRouter(NewVideoHandler(db, newMemoryStorage(), nil, nil), "user-1")
+
+	location := createUpload(t, r, 1024, tusMetadata("filename", "clip.mp4", "snap_to_chapters", "true"))
+
+	session, err := db.GetUploadSession(sessionID(location))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", session.UserID)
+	assert.Equal(t, "clip.mp4", session.OriginalName)
+	assert.Equal(t, int64(1024), session.SizeBytes)
+	assert.Equal(t, "multipart-1", session.MultipartUploadID)
+	assert.True(t, session.Options.SnapToChapters)
+	assert.Equal(t, domain.UploadStatusUploading, session.Status)
+}
+
+func TestCreateUpload_Rejected(t *testing.T) {
+	cases := []struct {
+		name      string
+		headers   map[string]string
+		status    int
+		errorCode string
+	}{
+		{"missing tus version", map[string]string{"Upload-Length": "10"}, http.StatusPreconditionFailed, ""},
+		{"deferred length", map[string]string{"Tus-Resumable": tusVersion, "Upload-Defer-Length": "1"}, http.StatusBadRequest, ""},
+		{"too large", map[string]string{"Tus-Resumable": tusVersion, "Upload-Length": strconv.FormatInt(maxUploadSize+1, 10),
+			"Upload-Metadata": tusMetadata("filename", "clip.mp4")}, http.StatusRequestEntityTooLarge, ErrCodeFileTooLarge},
+		{"empty", map[string]string{"Tus-Resumable": tusVersion, "Upload-Length": "0",
+			"Upload-Metadata": tusMetadata("filename", "clip.mp4")}, http.StatusBadRequest, ErrCodeEmptyFile},
+		{"bad extension", map[string]string{"Tus-Resumable": tusVersion, "Upload-Length": "10",
+			"Upload-Metadata": tusMetadata("filename", "notes.txt")}, http.StatusBadRequest, ErrCodeUnsupportedFormat},
+		{"bad options", map[string]string{"Tus-Resumable": tusVersion, "Upload-Length": "10",
+			"Upload-Metadata": tusMetadata("filename", "clip.mp4", "start", "20", "end", "10")}, http.StatusBadRequest, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tusRouter(NewVideoHandler(newMemorySessions(), newMemoryStorage(), nil, nil), "user-1")
+			req := httptest.NewRequest(http.MethodPost, tusBasePath, nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tusVersion, w.Header().Get("Tus-Resumable"))
+			if tc.errorCode != "" {
+				var resp UploadResponse
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				assert.Equal(t, tc.errorCode, resp.ErrorCode)
+			}
+		})
+	}
+}
+
+// ---------- PatchUpload ----------
+
+func TestPatchUpload_ResumesAfterDisconnect(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	authClient := new(MockAuthClient)
+	authClient.On("CreateAuditLog", mock.Anything).Return(nil).Maybe()
+	r := tusRouter(NewVideoHandler(db, storage, nil, authClient), "user-1")
+
+	file := videoBytes(tusPartSize + 3000)
+	location := createUpload(t, r, len(file), tusMetadata("filename", "clip.mp4", "timestamps", "1,2.5"))
+	id := sessionID(location)
+
+	// A small first chunk is held back until a full part is available.
+	w := patchUpload(r, location, 0, bytes.NewReader(file[:1000]))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "1000", w.Header().Get("Upload-Offset"))
+	assert.Len(t, storage.parts, 0)
+
+	// The connection drops after a full part and some more bytes arrived.
+	patchUpload(r, location, 1000, &brokenReader{data: file[1000 : tusPartSize+500]})
+
+	w = headUpload(r, location)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strconv.Itoa(tusPartSize+500), w.Header().Get("Upload-Offset"))
+	assert.Equal(t, strconv.Itoa(len(file)), w.Header().Get("Upload-Length"))
+
+	db.On("CountActiveVideos", "user-1").Return(0, nil)
+	db.On("CreateVideoWithOutbox", mock.MatchedBy(func(v *domain.Video) bool {
+		session, _ := db.GetUploadSession(id)
+		return v.ID == session.VideoID && v.StoragePath == session.StoragePath &&
+			v.OriginalName == "clip.mp4" && v.SizeBytes == int64(len(file)) && v.Status == "queued"
+	}), withPayload(func(m domain.VideoProcessingMessage) bool {
+		return len(m.Options.Timestamps) == 2 && m.Options.Timestamps[1] == 2.5
+	})).Return(nil)
+
+	w = patchUpload(r, location, tusPartSize+500, bytes.NewReader(file[tusPartSize+500:]))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, strconv.Itoa(len(file)), w.Header().Get("Upload-Offset"))
+
+	session, _ := db.GetUploadSession(id)
+	assert.Equal(t, session.VideoID, w.Header().Get("X-Video-Id"))
+	assert.Equal(t, domain.UploadStatusCompleted, session.Status)
+	assert.Nil(t, session.LockedUntil)
+	assert.Equal(t, file, storage.assembled)
+	assert.Empty(t, storage.objects, "tail object should be cleaned up")
+	db.AssertExpectations(t)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestPatchUpload_OffsetMismatch(t *testing.T) {
+	db := newMemorySessions()
+	r := tusRouter(NewVideoHandler(db, newMemoryStorage(), nil, nil), "user-1")
+	location := createUpload(t, r, 100, tusMetadata("filename", "clip.mp4"))
+
+	w := patchUpload(r, location, 40, bytes.NewReader(sampleMP4))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "0", w.Header().Get("Upload-Offset"))
+	session, _ := db.GetUploadSession(sessionID(location))
+	assert.Nil(t, session.LockedUntil)
+}
+
+func TestPatchUpload_WrongContentType(t *testing.T) {
+	r := tusRouter(NewVideoHandler(newMemorySessions(), newMemoryStorage(), nil, nil), "user-1")
+	location := createUpload(t, r, 100, tusMetadata("filename", "clip.mp4"))
+
+	req := httptest.NewRequest(http.MethodPatch, location, bytes.NewReader(sampleMP4))
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Upload-Offset", "0")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestPatchUpload_ExceedsLength(t *testing.T) {
+	r := tusRouter(NewVideoHandler(newMemorySessions(), newMemoryStorage(), nil, nil), "user-1")
+	location := createUpload(t, r, 10, tusMetadata("filename", "clip.mp4"))
+
+	w := patchUpload(r, location, 0, bytes.NewReader(sampleMP4))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestPatchUpload_RejectsNonVideo(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	r := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1")
+	location := createUpload(t, r, 4096, tusMetadata("filename", "clip.mp4"))
+
+	w := patchUpload(r, location, 0, strings.NewReader("#!/bin/sh\necho this is not a video\n"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, ErrCodeUnsupportedFormat, resp.ErrorCode)
+	assert.True(t, storage.aborted)
+	_, err := db.GetUploadSession(sessionID(location))
+	assert.Error(t, err)
+	assert.Zero(t, db.staleUpdates)
+}
+
+func TestPatchUpload_Locked(t *testing.T) {
+	db := newMemorySessions()
+	r := tusRouter(NewVideoHandler(db, newMemoryStorage(), nil, nil), "user-1")
+	location := createUpload(t, r, 100, tusMetadata("filename", "clip.mp4"))
+	db.LockUploadSession(sessionID(location), time.Minute)
+
+	w := patchUpload(r, location, 0, bytes.NewReader(sampleMP4))
+
+	assert.Equal(t, http.StatusLocked, w.Code)
+}
+
+func TestPatchUpload_OtherUser(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	location := createUpload(t, tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1"), 100, tusMetadata("filename", "clip.mp4"))
+
+	other := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-2")
+	assert.Equal(t, http.StatusNotFound, patchUpload(other, location, 0, bytes.NewReader(sampleMP4)).Code)
+	assert.Equal(t, http.StatusNotFound, headUpload(other, location).Code)
+
+	session, _ := db.GetUploadSession(sessionID(location))
+	assert.Nil(t, session.LockedUntil)
+}
+
+func TestPatchUpload_Expired(t *testing.T) {
+	db := newMemorySessions()
+	r := tusRouter(NewVideoHandler(db, newMemoryStorage(), nil, nil), "user-1")
+	location := createUpload(t, r, 100, tusMetadata("filename", "clip.mp4"))
+	session, _ := db.GetUploadSession(sessionID(location))
+	session.ExpiresAt = time.Now().Add(-time.Minute)
+	db.UpdateUploadSession(session)
+
+	assert.Equal(t, http.StatusGone, patchUpload(r, location, 0, bytes.NewReader(sampleMP4)).Code)
+	assert.Equal(t, http.StatusGone, headUpload(r, location).Code)
+}
+
+func TestPatchUpload_RetryAfterVideoRecordFailure(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	authClient := new(MockAuthClient)
+	authClient.On("CreateAuditLog", mock.Anything).Return(nil).Maybe()
+	r := tusRouter(NewVideoHandler(db, storage, nil, authClient), "user-1")
+
+	file := videoBytes(2048)
+	location := createUpload(t, r, len(file), tusMetadata("filename", "clip.mp4"))
+	id := sessionID(location)
+
+	db.On("CountActiveVideos", "user-1").Return(0, nil)
+	db.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+	db.On("GetVideoByID", mock.Anything).Return(nil, errors.New("not found")).Once()
+
+	w := patchUpload(r, location, 0, bytes.NewReader(file))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	session, _ := db.GetUploadSession(id)
+	assert.Equal(t, domain.UploadStatusAssembled, session.Status)
+
+	// The retry only has to create the video; the file is already assembled.
+	db.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(nil).Once()
+	w = patchUpload(r, location, len(file), bytes.NewReader(nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, session.VideoID, w.Header().Get("X-Video-Id"))
+	assert.Equal(t, 1, storage.completes)
+	session, _ = db.GetUploadSession(id)
+	assert.Equal(t, domain.UploadStatusCompleted, session.Status)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestPatchUpload_QuotaDoesNotCountItsOwnReservation(t *testing.T) {
+	os.Setenv("QUOTA_STORAGE_BYTES", "user:3000")
+	defer os.Unsetenv("QUOTA_STORAGE_BYTES")
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	authClient := new(MockAuthClient)
+	authClient.On("CreateAuditLog", mock.Anything).Return(nil).Maybe()
+	r := tusRouter(NewVideoHandler(db, storage, nil, authClient), "user-1")
+
+	file := videoBytes(2048)
+	location := createUpload(t, r, len(file), tusMetadata("filename", "clip.mp4"))
+	db.On("CountActiveVideos", "user-1").Return(0, nil)
+	db.On("CreateVideoWithOutbox", mock.Anything, mock.Anything).Return(nil).Once()
+
+	w := patchUpload(r, location, 0, bytes.NewReader(file))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 1, storage.completes)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestPatchUpload_QuotaCheckedAgainAtFinish(t *testing.T) {
+	os.Setenv("QUOTA_STORAGE_BYTES", "user:3000")
+	defer os.Unsetenv("QUOTA_STORAGE_BYTES")
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	r := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1")
+
+	file := videoBytes(2048)
+	location := createUpload(t, r, len(file), tusMetadata("filename", "clip.mp4"))
+	// Another upload takes the rest of the quota while this one is running.
+	db.CreateUploadSession(&domain.UploadSession{
+		ID: "other", UserID: "user-1", SizeBytes: 1500, Status: domain.UploadStatusUploading,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	w := patchUpload(r, location, 0, bytes.NewReader(file))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, ErrCodeStorageQuotaExceeded, resp.ErrorCode)
+	assert.Zero(t, storage.completes)
+	assert.True(t, storage.aborted)
+	_, err := db.GetUploadSession(sessionID(location))
+	assert.Error(t, err)
+	assert.Zero(t, db.staleUpdates)
+}
+
+// ---------- TerminateUpload ----------
+
+func TestTerminateUpload(t *testing.T) {
+	db := newMemorySessions()
+	storage := newMemoryStorage()
+	r := tusRouter(NewVideoHandler(db, storage, nil, nil), "user-1")
+	location := createUpload(t, r, 4096, tusMetadata("filename", "clip.mp4"))
+	patchUpload(r, location, 0, bytes.NewReader(videoBytes(100)))
+	assert.Len(t, storage.objects, 1)
+
+	req := httptest.NewRequest(http.MethodDelete, location, nil)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, storage.aborted)
+	assert.Empty(t, storage.objects)
+	assert.Equal(t, http.StatusNotFound, headUpload(r, location).Code)
+	assert.Zero(t, db.staleUpdates)
+}
+
+// ---------- Helpers ----------
+
+func TestParseUploadMetadata(t *testing.T) {
+	metadata, err := parseUploadMetadata("filename " + base64.StdEncoding.EncodeToString([]byte("my clip.mp4")) + ",is_draft")
+	assert.NoError(t, err)
+	assert.Equal(t, "my clip.mp4", metadata["filename"])
+	value, ok := metadata["is_draft"]
+	assert.True(t, ok)
+	assert.Empty(t, value)
+
+	_, err = parseUploadMetadata("filename not-base64!")
+	assert.Error(t, err)
+
+	metadata, err = parseUploadMetadata("")
+	assert.NoError(t, err)
+	assert.Empty(t, metadata)
+}
+
+func TestCorsMiddleware_TusDiscovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CorsMiddleware())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, tusBasePath, nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, tusVersion, w.Header().Get("Tus-Version"))
+	assert.Contains(t, w.Header().Get("Tus-Extension"), "creation")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+}
@@ -0,0 +1,93 @@
+// Package media recognises video files by their content, for every path a
+// video can enter through.
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// SniffLen covers the longest signature we check (the 16-byte ASF GUID).
+const SniffLen = 16
+
+var (
+	ebmlMagic = []byte{0x1A, 0x45, 0xDF, 0xA3}
+	asfMagic  = []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11, 0xA6, 0xD9, 0x00, 0xAA, 0x00, 0x62, 0xCE, 0x6C}
+)
+
+// quickTimeAtoms are the atoms other than ftyp a QuickTime file may start
+// with; older files have no ftyp at all.
+var quickTimeAtoms = map[string]bool{
+	"moov": true,
+	"mdat": true,
+	"wide": true,
+	"free": true,
+	"skip": true,
+}
+
+// DetectContainer reads the first bytes of r and returns the container
+// they belong to, or "" when they match none of the supported formats. The
+// reader is rewound so the whole file can still be uploaded.
+func DetectContainer(r io.ReadSeeker) (string, error) {
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return MatchContainer(head[:n]), nil
+}
+
+// MatchContainer returns the container the leading bytes of a file belong
+// to, or "" when they match none of the supported formats.
+func MatchContainer(head []byte) string {
+	switch {
+	case len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")):
+		return "mp4"
+	case len(head) >= 8 && quickTimeAtoms[string(head[4:8])] && validAtomSize(head):
+		return "mov"
+	case len(head) >= 12 && bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("AVI ")):
+		return "avi"
+	case bytes.HasPrefix(head, ebmlMagic):
+		return "matroska"
+	case bytes.HasPrefix(head, asfMagic):
+		return "asf"
+	case bytes.HasPrefix(head, []byte("FLV")):
+		return "flv"
+	}
+	return ""
+}
+
+// validAtomSize reports whether the size leading an atom can be one: 0 (to
+// the end of the file), 1 (a 64-bit size follows) or at least the 8-byte
+// header.
+func validAtomSize(head []byte) bool {
+	size := binary.BigEndian.Uint32(head[0:4])
+	return size == 0 || size == 1 || size >= 8
+}
+
+// videoContentTypes are the content types accepted for a video, whether a
+// client declares them or a remote server sends them.
+var videoContentTypes = map[string]bool{
+	"application/octet-stream": true,
+	"video/mp4":                true,
+	"video/quicktime":          true,
+	"video/x-msvideo":          true,
+	"video/x-matroska":         true,
+	"video/x-ms-wmv":           true,
+	"video/x-flv":              true,
+	"video/webm":               true,
+}
+
+// IsVideoContentType reports whether contentType, without parameters, is one
+// a video may be stored with.
+func IsVideoContentType(contentType string) bool {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return videoContentTypes[strings.ToLower(strings.TrimSpace(contentType))]
+}
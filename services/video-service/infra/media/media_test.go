@@ -0,0 +1,65 @@
+package media
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchContainer(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     []byte
+		expected string
+	}{
+		{"mp4", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), "mp4"},
+		{"mov", []byte("\x00\x00\x00\x14ftypqt  "), "mp4"},
+		{"mov starting with wide", []byte("\x00\x00\x00\x08wide\x00\x01\x00\x00mdat"), "mov"},
+		{"mov starting with moov", []byte("\x00\x00\x04\x00moov\x00\x00\x00\x6cmvhd"), "mov"},
+		{"mov starting with mdat", []byte("\x00\x00\x00\x00mdat"), "mov"},
+		{"mov starting with free", []byte("\x00\x00\x00\x10free"), "mov"},
+		{"mov starting with skip", []byte("\x00\x00\x00\x01skip"), "mov"},
+		{"atom too small", []byte("\x00\x00\x00\x04free"), ""},
+		{"avi", []byte("RIFF\x10\x00\x00\x00AVI LIST"), "avi"},
+		{"wav is not avi", []byte("RIFF\x10\x00\x00\x00WAVEfmt "), ""},
+		{"mkv/webm", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x86}, "matroska"},
+		{"wmv", asfMagic, "asf"},
+		{"flv", []byte("FLV\x01\x05"), "flv"},
+		{"text", []byte("hello, world"), ""},
+		{"too short", []byte("\x00\x00"), ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchContainer(tt.head))
+		})
+	}
+}
+
+func TestDetectContainer_Rewinds(t *testing.T) {
+	r := bytes.NewReader(append([]byte("\x00\x00\x00\x18ftypmp42"), []byte("payload")...))
+
+	container, err := DetectContainer(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "mp4", container)
+	data, _ := io.ReadAll(r)
+	assert.Equal(t, 19, len(data))
+}
+
+func TestDetectContainer_ShortFile(t *testing.T) {
+	container, err := DetectContainer(bytes.NewReader([]byte("FLV")))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "flv", container)
+}
+
+func TestIsVideoContentType(t *testing.T) {
+	assert.True(t, IsVideoContentType("video/mp4"))
+	assert.True(t, IsVideoContentType("Video/WebM; codecs=vp9"))
+	assert.True(t, IsVideoContentType("application/octet-stream"))
+	assert.False(t, IsVideoContentType("text/html; charset=utf-8"))
+	assert.False(t, IsVideoContentType(""))
+}
@@ -0,0 +1,59 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFrame encodes a width x height PNG, red on the left half and blue on
+// the right.
+func testFrame(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestThumbnail_ScalesDownKeepingAspect(t *testing.T) {
+	data, err := Thumbnail(bytes.NewReader(testFrame(640, 360)), 160)
+
+	assert.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 160, 90), img.Bounds())
+
+	r, _, b, _ := img.At(10, 45).RGBA()
+	assert.Greater(t, r, b)
+	r, _, b, _ = img.At(150, 45).RGBA()
+	assert.Greater(t, b, r)
+}
+
+func TestThumbnail_NeverScalesUp(t *testing.T) {
+	data, err := Thumbnail(bytes.NewReader(testFrame(64, 48)), 320)
+
+	assert.NoError(t, err)
+	img, _ := jpeg.Decode(bytes.NewReader(data))
+	assert.Equal(t, image.Rect(0, 0, 64, 48), img.Bounds())
+}
+
+func TestThumbnail_NotAnImage(t *testing.T) {
+	_, err := Thumbnail(strings.NewReader("not an image"), 100)
+
+	assert.Error(t, err)
+}
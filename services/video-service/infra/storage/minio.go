@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 	"video-service/infra/utils"
@@ -14,6 +16,8 @@ import (
 
 type MinIOClient struct {
 	client          *minio.Client
+	core            *minio.Core
+	presigner       *minio.Client
 	bucketRaw       string
 	bucketProcessed string
 }
@@ -25,6 +29,9 @@ func InitMinIO() *MinIOClient {
 	useSSL := utils.GetEnv("MINIO_USE_SSL", "false") == "true"
 	bucketRaw := utils.GetEnv("MINIO_BUCKET_RAW", "videos-raw")
 	bucketProcessed := utils.GetEnv("MINIO_BUCKET_PROCESSED", "videos-processed")
+	publicEndpoint := utils.GetEnv("MINIO_PUBLIC_ENDPOINT", endpoint)
+	publicSSL := utils.GetEnv("MINIO_PUBLIC_USE_SSL", strconv.FormatBool(useSSL)) == "true"
+	region := utils.GetEnv("MINIO_REGION", "us-east-1")
 
 	client, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
@@ -34,6 +41,18 @@ func InitMinIO() *MinIOClient {
 		log.Fatalf("Failed to create MinIO client: %v", err)
 	}
 
+	// Presigned URLs are used by clients outside the cluster, so they are
+	// signed for the public endpoint. The region is fixed because that
+	// endpoint may not be reachable from here to look it up.
+	presigner, err := minio.New(publicEndpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: publicSSL,
+		Region: region,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create MinIO presign client: %v", err)
+	}
+
 	ctx := context.Background()
 
 	buckets := []string{bucketRaw, bucketProcessed}
@@ -55,6 +74,8 @@ func InitMinIO() *MinIOClient {
 
 	return &MinIOClient{
 		client:          client,
+		core:            &minio.Core{Client: client},
+		presigner:       presigner,
 		bucketRaw:       bucketRaw,
 		bucketProcessed: bucketProcessed,
 	}
@@ -81,6 +102,173 @@ func (m *MinIOClient) UploadFile(reader io.Reader, filename string, size int64)
 	return objectName, nil
 }
 
+// NewMultipartUpload starts a multipart upload for a raw video, named the same
+// way UploadFile names single-shot uploads.
+func (m *MinIOClient) NewMultipartUpload(filename string) (string, string, error) {
+	ctx := context.Background()
+
+	objectName := fmt.Sprintf("%s/%s", time.Now().Format("2006/01/02"), filename)
+
+	uploadID, err := m.core.NewMultipartUpload(ctx, m.bucketRaw, objectName, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return objectName, uploadID, nil
+}
+
+func (m *MinIOClient) UploadPart(objectName, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	ctx := context.Background()
+
+	_, err := m.core.PutObjectPart(ctx, m.bucketRaw, objectName, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{})
+	return err
+}
+
+// CompleteMultipartUpload assembles every part uploaded so far, in order.
+func (m *MinIOClient) CompleteMultipartUpload(objectName, uploadID string) error {
+	ctx := context.Background()
+
+	parts := []minio.CompletePart{}
+	marker := 0
+	for {
+		result, err := m.core.ListObjectParts(ctx, m.bucketRaw, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return err
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	_, err := m.core.CompleteMultipartUpload(ctx, m.bucketRaw, objectName, uploadID, parts, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	return err
+}
+
+func (m *MinIOClient) AbortMultipartUpload(objectName, uploadID string) error {
+	ctx := context.Background()
+
+	return m.core.AbortMultipartUpload(ctx, m.bucketRaw, objectName, uploadID)
+}
+
+// PutRawObject stores an object in the raw bucket under the exact name given.
+func (m *MinIOClient) PutRawObject(objectName string, reader io.Reader, size int64) error {
+	ctx := context.Background()
+
+	_, err := m.client.PutObject(ctx, m.bucketRaw, objectName, reader, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	return err
+}
+
+func (m *MinIOClient) GetRawObject(objectName string) (io.ReadCloser, error) {
+	ctx := context.Background()
+
+	obj, err := m.client.GetObject(ctx, m.bucketRaw, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// GetProcessedObject opens a processed ZIP for reading.
+func (m *MinIOClient) GetProcessedObject(objectName string) (io.ReadCloser, error) {
+	obj, err := m.GetFileStream(objectName)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (m *MinIOClient) StatProcessedObject(objectName string) (minio.ObjectInfo, error) {
+	ctx := context.Background()
+
+	return m.client.StatObject(ctx, m.bucketProcessed, objectName, minio.StatObjectOptions{})
+}
+
+// GetProcessedObjectRange opens bytes start to end, inclusive, of a processed
+// ZIP. A negative end reads the whole object.
+func (m *MinIOClient) GetProcessedObjectRange(objectName string, start, end int64) (io.ReadCloser, error) {
+	ctx := context.Background()
+
+	opts := minio.GetObjectOptions{}
+	if end >= 0 {
+		if err := opts.SetRange(start, end); err != nil {
+			return nil, err
+		}
+	}
+	obj, err := m.client.GetObject(ctx, m.bucketProcessed, objectName, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// GetRawObjectRange opens bytes start to end, inclusive, of a raw object.
+func (m *MinIOClient) GetRawObjectRange(objectName string, start, end int64) (io.ReadCloser, error) {
+	ctx := context.Background()
+
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(start, end); err != nil {
+		return nil, err
+	}
+	obj, err := m.client.GetObject(ctx, m.bucketRaw, objectName, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// StatRawObject returns the object's metadata, including the SHA-256 MinIO
+// checked it against if it was uploaded with one.
+func (m *MinIOClient) StatRawObject(objectName string) (minio.ObjectInfo, error) {
+	ctx := context.Background()
+
+	return m.client.StatObject(ctx, m.bucketRaw, objectName, minio.StatObjectOptions{Checksum: true})
+}
+
+// PresignPutObject returns a URL the client can PUT the raw video to without
+// credentials. Content-Type, Content-Length and x-amz-checksum-sha256 (the
+// base64 digest) are part of the signature, so MinIO rejects a request that
+// sends anything else, and refuses a body that does not match the digest.
+func (m *MinIOClient) PresignPutObject(objectName, contentType string, size int64, checksumSHA256 string, expires time.Duration) (string, error) {
+	ctx := context.Background()
+
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Length", strconv.FormatInt(size, 10))
+	headers.Set("x-amz-checksum-sha256", checksumSHA256)
+
+	u, err := m.presigner.PresignHeader(ctx, http.MethodPut, m.bucketRaw, objectName, expires, nil, headers)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
 func (m *MinIOClient) UploadProcessedFile(reader io.Reader, filename string, size int64) (string, error) {
 	ctx := context.Background()
 
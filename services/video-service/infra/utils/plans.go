@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PlanValues parses a per-plan setting such as QUOTA_STORAGE_BYTES
+// ("admin:0,user:10737418240") into a plan → value map. An entry without a
+// plan, like the 30 in "30,admin:0", is stored under "" and applies to every
+// plan not listed. Malformed and negative entries are ignored.
+func PlanValues(key, fallback string) map[string]int64 {
+	values := map[string]int64{}
+	for _, entry := range strings.Split(GetEnv(key, fallback), ",") {
+		plan, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			plan, value = "", plan
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || limit < 0 {
+			continue
+		}
+		values[strings.TrimSpace(plan)] = limit
+	}
+	return values
+}
+
+// PlanValue picks the plan's value, falling back to the value for every
+// plan, then to the user plan and then to fallback.
+func PlanValue(values map[string]int64, plan string, fallback int64) int64 {
+	for _, key := range []string{plan, "", "user"} {
+		if value, ok := values[key]; ok {
+			return value
+		}
+	}
+	return fallback
+}
@@ -17,3 +17,21 @@ func TestGetEnv_Default(t *testing.T) {
 	os.Unsetenv("MISSING_VIDEO_KEY")
 	assert.Equal(t, "fallback", GetEnv("MISSING_VIDEO_KEY", "fallback"))
 }
+
+func TestPlanValues(t *testing.T) {
+	os.Setenv("TEST_PLAN_VALUES", "30, admin:0,user:7,broken,bad:-1,premium:x")
+	defer os.Unsetenv("TEST_PLAN_VALUES")
+
+	values := PlanValues("TEST_PLAN_VALUES", "")
+
+	assert.Equal(t, map[string]int64{"": 30, "admin": 0, "user": 7}, values)
+	assert.Equal(t, int64(0), PlanValue(values, "admin", 99))
+	assert.Equal(t, int64(7), PlanValue(values, "user", 99))
+	assert.Equal(t, int64(30), PlanValue(values, "premium", 99))
+}
+
+func TestPlanValue_Fallbacks(t *testing.T) {
+	assert.Equal(t, int64(5), PlanValue(map[string]int64{"user": 5}, "premium", 99))
+	assert.Equal(t, int64(99), PlanValue(map[string]int64{}, "user", 99))
+	assert.Equal(t, map[string]int64{"user": 1}, PlanValues("MISSING_PLAN_VALUES", "user:1"))
+}
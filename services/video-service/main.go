@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -16,6 +17,9 @@ import (
 	"video-service/infra/metrics"
 	"video-service/infra/storage"
 	"video-service/infra/utils"
+	"video-service/service"
+	"shared/jwtauth"
+	"shared/netguard"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -33,7 +37,47 @@ func main() {
 	authServiceURL := utils.GetEnv("AUTH_SERVICE_URL", "http://auth-service:8081")
 	authClient := clients.NewAuthServiceClient(authServiceURL)
 
-	router := setupRouter(db, minio, rabbitmq, authClient)
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	relay := service.NewOutboxRelay(db, rabbitmq,
+		time.Duration(getEnvInt("OUTBOX_POLL_INTERVAL_MS", 1000))*time.Millisecond,
+		getEnvInt("OUTBOX_BATCH_SIZE", 50),
+		time.Duration(getEnvInt("OUTBOX_RETENTION_HOURS", 24))*time.Hour)
+	go relay.Run(workersCtx)
+	go service.NewVideoEventConsumer(db, rabbitmq).Run(workersCtx)
+
+	importAllowed, err := netguard.ParseCIDRs(utils.GetEnv("IMPORT_ALLOWED_CIDRS", ""))
+	if err != nil {
+		log.Fatalf("Invalid IMPORT_ALLOWED_CIDRS: %v", err)
+	}
+	importer := service.NewVideoImporter(db, minio, netguard.NewClient(importAllowed, 0),
+		time.Duration(getEnvInt("IMPORT_POLL_INTERVAL_MS", 2000))*time.Millisecond,
+		int64(getEnvInt("MAX_UPLOAD_SIZE", 500*1024*1024)))
+	go importer.Run(workersCtx)
+
+	janitor := service.NewRetentionJanitor(db, minio, authClient, service.LoadRetentionConfig(),
+		time.Duration(getEnvInt("RETENTION_INTERVAL_MS", 60*60*1000))*time.Millisecond)
+	go janitor.Run(workersCtx)
+
+	purger := service.NewTrashPurger(db, minio,
+		time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30))*24*time.Hour,
+		time.Duration(getEnvInt("TRASH_PURGE_INTERVAL_MS", 60*60*1000))*time.Millisecond)
+	go purger.Run(workersCtx)
+
+	uploadJanitor := service.NewUploadJanitor(db, minio,
+		time.Duration(getEnvInt("UPLOAD_SWEEP_INTERVAL_MS", 15*60*1000))*time.Millisecond)
+	go uploadJanitor.Run(workersCtx)
+
+	auth, err := jwtauth.New(jwtauth.Config{
+		Secret:         utils.GetEnv("JWT_SECRET", "6f9e5ef5-ab0c-4bb4-a4f5-caefc64bc5d1"),
+		Issuer:         utils.GetEnv("JWT_ISSUER", "g57-auth-service"),
+		TrustedCallers: utils.GetEnv("TRUSTED_CALLER_CIDRS", ""),
+	})
+	if err != nil {
+		log.Fatalf("Invalid authentication config: %v", err)
+	}
+
+	router := setupRouter(db, minio, rabbitmq, authClient, auth)
 
 	port := utils.GetEnv("PORT", "8082")
 	srv := &http.Server{
@@ -56,6 +100,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopWorkers()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -66,7 +111,7 @@ func main() {
 	log.Println("Server exited")
 }
 
-func setupRouter(db *database.Database, minio *storage.MinIOClient, rabbitmq *broker.RabbitMQClient, authClient domain.AuthServiceClient) *gin.Engine {
+func setupRouter(db *database.Database, minio *storage.MinIOClient, rabbitmq *broker.RabbitMQClient, authClient domain.AuthServiceClient, auth *jwtauth.Authenticator) *gin.Engine {
 	if utils.GetEnv("GIN_MODE", "debug") == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -85,19 +130,47 @@ func setupRouter(db *database.Database, minio *storage.MinIOClient, rabbitmq *br
 	api := router.Group("/api/v1")
 	{
 		videos := api.Group("/videos")
-		videos.Use(handlers.AuthMiddleware())
+		videos.Use(auth.Middleware())
 		{
 			videoHandler := handlers.NewVideoHandler(db, minio, rabbitmq, authClient)
 			videos.POST("/upload", videoHandler.Upload)
+			videos.POST("/import", videoHandler.ImportVideo)
 			videos.GET("/", videoHandler.List)
+			videos.GET("/trash", videoHandler.ListTrash)
 			videos.GET("/:id", videoHandler.GetVideo)
 			videos.DELETE("/:id", videoHandler.DeleteVideo)
+			videos.POST("/:id/restore", videoHandler.RestoreVideo)
+			videos.POST("/:id/reprocess", videoHandler.Reprocess)
+			videos.GET("/:id/runs", videoHandler.ListRuns)
+			videos.PUT("/:id/runs/current", videoHandler.SetCurrentRun)
+			videos.GET("/:id/download", videoHandler.DownloadZip)
+			videos.POST("/:id/download/subset", videoHandler.DownloadSubset)
+			videos.GET("/:id/frames", videoHandler.ListFrames)
+			videos.GET("/:id/frames/:index", videoHandler.GetFrame)
+			videos.POST("/:id/shares", videoHandler.CreateShare)
+			videos.GET("/:id/shares", videoHandler.ListShares)
+			videos.DELETE("/:id/shares/:share_id", videoHandler.RevokeShare)
+
+			videos.GET("/quota", videoHandler.GetQuota)
+			videos.GET("/quota/:user_id", videoHandler.GetQuota)
+			videos.PUT("/quota/:user_id", videoHandler.SetQuota)
+
+			videos.POST("/uploads", videoHandler.CreateUpload)
+			videos.HEAD("/uploads/:upload_id", videoHandler.GetUploadOffset)
+			videos.PATCH("/uploads/:upload_id", videoHandler.PatchUpload)
+			videos.DELETE("/uploads/:upload_id", videoHandler.TerminateUpload)
+			videos.POST("/uploads/:upload_id/complete", videoHandler.CompleteUpload)
+
+			videos.POST("/batches", videoHandler.CreateBatch)
+			videos.GET("/batches/:batch_id", videoHandler.GetBatch)
+			videos.POST("/batches/:batch_id/close", videoHandler.CloseBatch)
+			videos.GET("/batches/:batch_id/download", videoHandler.DownloadBatch)
 		}
 
-		videosPublic := api.Group("/videos")
+		shares := api.Group("/shares")
 		{
 			videoHandler := handlers.NewVideoHandler(db, minio, rabbitmq, authClient)
-			videosPublic.GET("/:id/download", videoHandler.DownloadZip)
+			shares.GET("/:token/download", videoHandler.DownloadShared)
 		}
 	}
 
@@ -106,6 +179,7 @@ func setupRouter(db *database.Database, minio *storage.MinIOClient, rabbitmq *br
 		internalHandler := handlers.NewInternalHandler(db)
 		internal.GET("/videos/:id", internalHandler.GetVideoByID)
 		internal.GET("/videos", internalHandler.ListUserVideos)
+		internal.GET("/batches/:id", internalHandler.GetBatch)
 		internal.PATCH("/videos/:id/status", internalHandler.UpdateVideoStatus)
 		internal.POST("/videos/:id/complete", internalHandler.CompleteVideo)
 		internal.POST("/videos/:id/fail", internalHandler.FailVideo)
@@ -146,3 +220,12 @@ func readinessProbe(db *database.Database, minio *storage.MinIOClient, rabbitmq
 		c.JSON(http.StatusOK, gin.H{"status": "ready"})
 	}
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		var intValue int
+		fmt.Sscanf(value, "%d", &intValue)
+		return intValue
+	}
+	return defaultValue
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// VideoEventConsumer applies the lifecycle events processing-service
+// publishes. Events wait in a durable queue while video-service is down, and
+// each is applied at most once, so the video catches up with its job once the
+// service is back.
+type VideoEventConsumer struct {
+	db         domain.DatabaseInterface
+	rabbitmq   domain.RabbitMQInterface
+	retryDelay time.Duration
+}
+
+func NewVideoEventConsumer(db domain.DatabaseInterface, rabbitmq domain.RabbitMQInterface) *VideoEventConsumer {
+	return &VideoEventConsumer{
+		db:         db,
+		rabbitmq:   rabbitmq,
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// Run consumes events until ctx is cancelled, subscribing again whenever the
+// broker drops the consumer.
+func (c *VideoEventConsumer) Run(ctx context.Context) {
+	log.Println("Video event consumer started")
+
+	consumerTag := fmt.Sprintf("video-events-%s", uuid.New().String())
+	for {
+		msgs, err := c.rabbitmq.SubscribeVideoEvents(consumerTag)
+		if err != nil {
+			log.Printf("Video event consumer: failed to subscribe: %v", err)
+		} else {
+			c.consume(ctx, msgs)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Video event consumer stopped")
+			return
+		case <-time.After(c.retryDelay):
+		}
+	}
+}
+
+func (c *VideoEventConsumer) consume(ctx context.Context, msgs <-chan amqp.Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				log.Println("Video event consumer: channel closed")
+				return
+			}
+			c.handleDelivery(msg)
+		}
+	}
+}
+
+func (c *VideoEventConsumer) handleDelivery(msg amqp.Delivery) {
+	var event domain.VideoEvent
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		log.Printf("Video event consumer: invalid event: %v", err)
+		msg.Nack(false, false)
+		return
+	}
+	if _, err := uuid.Parse(event.EventID); err != nil {
+		log.Printf("Video event consumer: discarding %s event without a valid event_id", event.Type)
+		msg.Nack(false, false)
+		return
+	}
+	if _, err := uuid.Parse(event.VideoID); err != nil {
+		log.Printf("Video event consumer: discarding event %s without a valid video_id", event.EventID)
+		msg.Nack(false, false)
+		return
+	}
+
+	switch event.Type {
+	case domain.EventVideoProcessingStarted, domain.EventVideoProcessingCompleted, domain.EventVideoProcessingFailed:
+	default:
+		log.Printf("Video event consumer: discarding event %s of unknown type %q", event.EventID, event.Type)
+		msg.Nack(false, false)
+		return
+	}
+
+	applied, err := c.db.ApplyVideoEvent(&event)
+	if err != nil {
+		log.Printf("Video event consumer: failed to apply %s for video %s: %v", event.Type, event.VideoID, err)
+		msg.Nack(false, true)
+		return
+	}
+
+	if applied {
+		log.Printf("Video %s: applied %s", event.VideoID, event.Type)
+	} else {
+		log.Printf("Video %s: skipped %s (duplicate or out of order)", event.VideoID, event.Type)
+	}
+	msg.Ack(false)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ─── Mocks ────────────────────────────────────────────────────────────────────
+
+type MockEventsDB struct {
+	domain.DatabaseInterface
+	mock.Mock
+}
+
+func (m *MockEventsDB) ApplyVideoEvent(event *domain.VideoEvent) (bool, error) {
+	args := m.Called(event)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockAcknowledger struct{ mock.Mock }
+
+func (m *MockAcknowledger) Ack(tag uint64, multiple bool) error {
+	return m.Called(tag, multiple).Error(0)
+}
+func (m *MockAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	return m.Called(tag, multiple, requeue).Error(0)
+}
+func (m *MockAcknowledger) Reject(tag uint64, requeue bool) error {
+	return m.Called(tag, requeue).Error(0)
+}
+
+const (
+	testEventID = "9b2f6a52-2a43-4d3e-9a57-0d0c1c6f6a01"
+	testVideoID = "5d8e1c3a-6f0b-4a7e-8d7c-3b1f2e4a5c60"
+)
+
+func eventDelivery(t *testing.T, ack amqp.Acknowledger, event interface{}) amqp.Delivery {
+	body, err := json.Marshal(event)
+	assert.NoError(t, err)
+	return amqp.Delivery{Body: body, Acknowledger: ack, DeliveryTag: 1}
+}
+
+func completedEvent() domain.VideoEvent {
+	return domain.VideoEvent{
+		EventID:      testEventID,
+		Type:         domain.EventVideoProcessingCompleted,
+		OccurredAt:   time.Now().UTC(),
+		VideoID:      testVideoID,
+		UserID:       "u1",
+		ZipPath:      "processed/frames.zip",
+		ZipSizeBytes: 2048,
+		FrameCount:   12,
+	}
+}
+
+// ─── handleDelivery ───────────────────────────────────────────────────────────
+
+func TestHandleDelivery_AppliesAndAcks(t *testing.T) {
+	db := new(MockEventsDB)
+	ack := new(MockAcknowledger)
+	db.On("ApplyVideoEvent", mock.MatchedBy(func(e *domain.VideoEvent) bool {
+		return e.EventID == testEventID && e.ZipPath == "processed/frames.zip" && e.FrameCount == 12
+	})).Return(true, nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	NewVideoEventConsumer(db, nil).handleDelivery(eventDelivery(t, ack, completedEvent()))
+
+	db.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
+
+func TestHandleDelivery_DuplicateAcked(t *testing.T) {
+	db := new(MockEventsDB)
+	ack := new(MockAcknowledger)
+	db.On("ApplyVideoEvent", mock.Anything).Return(false, nil)
+	ack.On("Ack", uint64(1), false).Return(nil)
+
+	NewVideoEventConsumer(db, nil).handleDelivery(eventDelivery(t, ack, completedEvent()))
+
+	ack.AssertExpectations(t)
+	ack.AssertNotCalled(t, "Nack", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleDelivery_DatabaseErrorRequeued(t *testing.T) {
+	db := new(MockEventsDB)
+	ack := new(MockAcknowledger)
+	db.On("ApplyVideoEvent", mock.Anything).Return(false, errors.New("connection refused"))
+	ack.On("Nack", uint64(1), false, true).Return(nil)
+
+	NewVideoEventConsumer(db, nil).handleDelivery(eventDelivery(t, ack, completedEvent()))
+
+	ack.AssertExpectations(t)
+}
+
+func TestHandleDelivery_InvalidEventsDiscarded(t *testing.T) {
+	missingID := completedEvent()
+	missingID.EventID = ""
+	badVideoID := completedEvent()
+	badVideoID.VideoID = "not-a-uuid"
+	unknownType := completedEvent()
+	unknownType.Type = "video.processing.paused"
+
+	cases := map[string]amqp.Delivery{}
+	for name, event := range map[string]domain.VideoEvent{
+		"missing event id": missingID,
+		"bad video id":     badVideoID,
+		"unknown type":     unknownType,
+	} {
+		cases[name] = eventDelivery(t, nil, event)
+	}
+	cases["malformed body"] = amqp.Delivery{Body: []byte("{"), DeliveryTag: 1}
+
+	for name, delivery := range cases {
+		t.Run(name, func(t *testing.T) {
+			db := new(MockEventsDB)
+			ack := new(MockAcknowledger)
+			ack.On("Nack", uint64(1), false, false).Return(nil)
+			delivery.Acknowledger = ack
+
+			NewVideoEventConsumer(db, nil).handleDelivery(delivery)
+
+			ack.AssertExpectations(t)
+			db.AssertNotCalled(t, "ApplyVideoEvent", mock.Anything)
+		})
+	}
+}
+
+// ─── Run ──────────────────────────────────────────────────────────────────────
+
+func TestRun_ResubscribesAfterFailure(t *testing.T) {
+	db := new(MockEventsDB)
+	mq := new(MockRabbitMQ)
+	ack := new(MockAcknowledger)
+
+	msgs := make(chan amqp.Delivery, 1)
+	msgs <- eventDelivery(t, ack, completedEvent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mq.On("SubscribeVideoEvents", mock.Anything).Return(nil, errors.New("connection refused")).Once()
+	mq.On("SubscribeVideoEvents", mock.Anything).Return((<-chan amqp.Delivery)(msgs), nil).Once()
+	db.On("ApplyVideoEvent", mock.Anything).Return(true, nil)
+	ack.On("Ack", uint64(1), false).Run(func(mock.Arguments) { cancel() }).Return(nil)
+
+	consumer := NewVideoEventConsumer(db, mq)
+	consumer.retryDelay = time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		consumer.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("consumer did not stop")
+	}
+
+	mq.AssertExpectations(t)
+	ack.AssertExpectations(t)
+}
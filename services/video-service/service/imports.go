@@ -0,0 +1,288 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"video-service/domain"
+	"video-service/infra/media"
+
+	"shared/netguard"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// importPartSize is how much of a remote file is buffered before it is
+	// written to storage as one multipart part.
+	importPartSize = 8 * 1024 * 1024
+
+	// importTimeout bounds a single fetch; the lease outlives it so another
+	// replica never picks up an import that is still running.
+	importTimeout = 30 * time.Minute
+	importLease   = importTimeout + 5*time.Minute
+)
+
+// permanentError marks an import failure that retrying will not fix.
+type permanentError struct{ error }
+
+func permanent(format string, args ...interface{}) error {
+	return &permanentError{fmt.Errorf(format, args...)}
+}
+
+// VideoImporter fetches videos submitted by URL into the raw bucket and queues
+// them for processing. Imports are claimed from the database with a lease, so
+// every replica may run one, and an import interrupted by a restart is
+// resumed from scratch once its lease runs out. While a video imports its
+// size_bytes tracks the bytes fetched so far, and error_message the reason
+// for the last failed attempt.
+type VideoImporter struct {
+	db          domain.DatabaseInterface
+	minio       domain.MinIOInterface
+	client      *http.Client
+	interval    time.Duration
+	maxSize     int64
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+func NewVideoImporter(db domain.DatabaseInterface, minio domain.MinIOInterface, client *http.Client, interval time.Duration, maxSize int64) *VideoImporter {
+	return &VideoImporter{
+		db:          db,
+		minio:       minio,
+		client:      client,
+		interval:    interval,
+		maxSize:     maxSize,
+		maxAttempts: 3,
+		retryDelay:  30 * time.Second,
+	}
+}
+
+// Run imports due videos every interval until ctx is cancelled.
+func (i *VideoImporter) Run(ctx context.Context) {
+	log.Printf("Video importer started (interval %s)", i.interval)
+
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			claimed, err := i.ImportOnce(ctx)
+			if err != nil {
+				log.Printf("Video importer: %v", err)
+			}
+			if !claimed || err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Video importer stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ImportOnce claims one due import and runs it. It reports whether there was
+// one to claim.
+func (i *VideoImporter) ImportOnce(ctx context.Context) (bool, error) {
+	videoImport, err := i.db.ClaimVideoImport(importLease)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim import: %w", err)
+	}
+	if videoImport == nil {
+		return false, nil
+	}
+
+	log.Printf("Video %s: importing %s (attempt %d)", videoImport.VideoID, videoImport.SourceURL, videoImport.Attempts)
+
+	fetchCtx, cancel := context.WithTimeout(ctx, importTimeout)
+	defer cancel()
+
+	storagePath, size, err := i.fetch(fetchCtx, videoImport)
+	if err != nil {
+		return true, i.importFailed(ctx, videoImport, err)
+	}
+
+	message, err := processingMessage(videoImport, storagePath)
+	if err != nil {
+		i.minio.DeleteFile(storagePath)
+		return true, i.importFailed(ctx, videoImport, err)
+	}
+
+	queued, err := i.db.CompleteVideoImport(videoImport.VideoID, storagePath, size, message)
+	if err != nil {
+		i.minio.DeleteFile(storagePath)
+		return true, i.importFailed(ctx, videoImport, fmt.Errorf("failed to queue video: %w", err))
+	}
+	if !queued {
+		log.Printf("Video %s: no longer importing, discarding fetched file", videoImport.VideoID)
+		i.minio.DeleteFile(storagePath)
+		return true, nil
+	}
+
+	log.Printf("Video %s: imported %d bytes and queued", videoImport.VideoID, size)
+	return true, nil
+}
+
+// importFailed schedules another attempt, or fails the video when the error
+// is permanent or the attempts are used up. An import cut short by shutdown
+// is retried straight away by whichever replica comes next.
+func (i *VideoImporter) importFailed(ctx context.Context, videoImport *domain.VideoImport, err error) error {
+	var permanentErr *permanentError
+	switch {
+	case ctx.Err() != nil:
+		return i.db.RetryVideoImport(videoImport.VideoID, 0, "Import interrupted, will resume")
+	case errors.As(err, &permanentErr) || videoImport.Attempts >= i.maxAttempts:
+		log.Printf("Video %s: import failed: %v", videoImport.VideoID, err)
+		return i.db.FailVideoImport(videoImport.VideoID, "Import failed: "+err.Error())
+	default:
+		delay := i.retryDelay * time.Duration(1<<(videoImport.Attempts-1))
+		log.Printf("Video %s: import attempt %d failed, retrying in %s: %v", videoImport.VideoID, videoImport.Attempts, delay, err)
+		return i.db.RetryVideoImport(videoImport.VideoID, delay,
+			fmt.Sprintf("Import attempt %d failed: %v", videoImport.Attempts, err))
+	}
+}
+
+// fetch streams the remote file into a multipart upload and returns where it
+// was stored and its size.
+func (i *VideoImporter) fetch(ctx context.Context, videoImport *domain.VideoImport) (string, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, videoImport.SourceURL, nil)
+	if err != nil {
+		return "", 0, permanent("invalid URL: %v", err)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		if errors.Is(err, netguard.ErrForbiddenAddress) {
+			return "", 0, permanent("URL points to an address that is not allowed")
+		}
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500:
+		return "", 0, fmt.Errorf("remote server answered %s", resp.Status)
+	default:
+		return "", 0, permanent("remote server answered %s", resp.Status)
+	}
+
+	// A missing Content-Type may be taken as application/octet-stream.
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !media.IsVideoContentType(contentType) {
+		return "", 0, permanent("remote file has content type %s, not a video", contentType)
+	}
+	if resp.ContentLength > i.maxSize {
+		return "", 0, permanent("remote file is too large: %d bytes, max %d", resp.ContentLength, i.maxSize)
+	}
+	if resp.ContentLength == 0 {
+		return "", 0, permanent("remote file is empty")
+	}
+
+	storagePath, uploadID, err := i.minio.NewMultipartUpload(videoImport.Filename)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to start upload: %w", err)
+	}
+
+	size, err := i.copyParts(videoImport, io.LimitReader(resp.Body, i.maxSize+1), storagePath, uploadID)
+	if err == nil && resp.ContentLength > 0 && size != resp.ContentLength {
+		err = fmt.Errorf("download truncated at %d of %d bytes", size, resp.ContentLength)
+	}
+	if err == nil {
+		err = i.minio.CompleteMultipartUpload(storagePath, uploadID)
+	}
+	if err != nil {
+		if abortErr := i.minio.AbortMultipartUpload(storagePath, uploadID); abortErr != nil {
+			log.Printf("Video %s: failed to abort upload: %v", videoImport.VideoID, abortErr)
+		}
+		return "", 0, err
+	}
+
+	return storagePath, size, nil
+}
+
+// copyParts writes body to the multipart upload, checking the container
+// signature on the first part and the size limit as it goes.
+func (i *VideoImporter) copyParts(videoImport *domain.VideoImport, body io.Reader, storagePath, uploadID string) (int64, error) {
+	buf := make([]byte, importPartSize)
+	var size int64
+	part := 0
+
+	for {
+		n, readErr := io.ReadFull(body, buf)
+		if n > 0 {
+			if part == 0 {
+				head := buf[:n]
+				if len(head) > media.SniffLen {
+					head = head[:media.SniffLen]
+				}
+				if media.MatchContainer(head) == "" {
+					return size, permanent("remote file is not a supported video container")
+				}
+			}
+
+			size += int64(n)
+			if size > i.maxSize {
+				return size, permanent("remote file is larger than %d bytes", i.maxSize)
+			}
+
+			part++
+			if err := i.minio.UploadPart(storagePath, uploadID, part, bytes.NewReader(buf[:n]), int64(n)); err != nil {
+				return size, fmt.Errorf("failed to store part %d: %w", part, err)
+			}
+			if err := i.db.UpdateVideoImportProgress(videoImport.VideoID, size); err != nil {
+				log.Printf("Video %s: failed to record import progress: %v", videoImport.VideoID, err)
+			}
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return size, fmt.Errorf("download failed after %d bytes: %w", size, readErr)
+		}
+	}
+
+	if size == 0 {
+		return 0, permanent("remote file is empty")
+	}
+	return size, nil
+}
+
+// processingMessage builds the outbox message that queues an imported video,
+// the same message an upload produces.
+func processingMessage(videoImport *domain.VideoImport, storagePath string) (*domain.OutboxMessage, error) {
+	payload, err := json.Marshal(domain.VideoProcessingMessage{
+		VideoID:     videoImport.VideoID,
+		UserID:      videoImport.UserID,
+		StoragePath: storagePath,
+		Filename:    videoImport.Filename,
+		Priority:    videoImport.Priority,
+		Options:     videoImport.Options,
+		RunID:       uuid.New().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.OutboxMessage{
+		ID:          uuid.New().String(),
+		AggregateID: videoImport.VideoID,
+		Exchange:    "video.exchange",
+		RoutingKey:  "video.upload",
+		Payload:     payload,
+		Priority:    videoImport.Priority,
+		CreatedAt:   time.Now(),
+	}, nil
+}
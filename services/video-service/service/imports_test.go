@@ -0,0 +1,329 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"shared/netguard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ─── Mocks ────────────────────────────────────────────────────────────────────
+
+type MockImportsDB struct {
+	domain.DatabaseInterface
+	mock.Mock
+}
+
+func (m *MockImportsDB) ClaimVideoImport(lease time.Duration) (*domain.VideoImport, error) {
+	args := m.Called(lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VideoImport), args.Error(1)
+}
+
+func (m *MockImportsDB) UpdateVideoImportProgress(videoID string, bytesFetched int64) error {
+	return m.Called(videoID, bytesFetched).Error(0)
+}
+
+func (m *MockImportsDB) CompleteVideoImport(videoID, storagePath string, size int64, message *domain.OutboxMessage) (bool, error) {
+	args := m.Called(videoID, storagePath, size, message)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockImportsDB) RetryVideoImport(videoID string, delay time.Duration, reason string) error {
+	return m.Called(videoID, delay, reason).Error(0)
+}
+
+func (m *MockImportsDB) FailVideoImport(videoID, reason string) error {
+	return m.Called(videoID, reason).Error(0)
+}
+
+// memoryStorage assembles multipart uploads in memory.
+type memoryStorage struct {
+	domain.MinIOInterface
+	mu      sync.Mutex
+	parts   map[int][]byte
+	objects map[string][]byte
+	aborted bool
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{parts: map[int][]byte{}, objects: map[string][]byte{}}
+}
+
+func (m *memoryStorage) NewMultipartUpload(filename string) (string, string, error) {
+	return "2026/01/01/" + filename, "multipart-1", nil
+}
+
+func (m *memoryStorage) UploadPart(objectName, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parts[partNumber] = data
+	return nil
+}
+
+func (m *memoryStorage) CompleteMultipartUpload(objectName, uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	object := []byte{}
+	for i := 1; i <= len(m.parts); i++ {
+		object = append(object, m.parts[i]...)
+	}
+	m.objects[objectName] = object
+	return nil
+}
+
+func (m *memoryStorage) AbortMultipartUpload(objectName, uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aborted = true
+	return nil
+}
+
+func (m *memoryStorage) DeleteFile(objectName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, objectName)
+	return nil
+}
+
+var mp4Header = []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2avc1mp41")
+
+func mp4Bytes(size int) []byte {
+	data := make([]byte, size)
+	copy(data, mp4Header)
+	for i := len(mp4Header); i < size; i++ {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func videoImport(sourceURL string, attempts int) *domain.VideoImport {
+	return &domain.VideoImport{
+		VideoID:   testVideoID,
+		UserID:    "u1",
+		Filename:  "20260101_000000_clip.mp4",
+		Priority:  5,
+		SourceURL: sourceURL,
+		Options:   domain.ProcessingOptions{SnapToChapters: true},
+		Attempts:  attempts,
+	}
+}
+
+// serveFile answers every request with body and contentType.
+func serveFile(contentType string, body []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	}))
+}
+
+func newTestImporter(db domain.DatabaseInterface, storage domain.MinIOInterface, maxSize int64) *VideoImporter {
+	allowed, _ := netguard.ParseCIDRs("127.0.0.1")
+	return NewVideoImporter(db, storage, netguard.NewClient(allowed, 5*time.Second), time.Millisecond, maxSize)
+}
+
+// ─── ImportOnce ───────────────────────────────────────────────────────────────
+
+func TestImportOnce_NothingToClaim(t *testing.T) {
+	db := new(MockImportsDB)
+	db.On("ClaimVideoImport", importLease).Return(nil, nil)
+
+	claimed, err := newTestImporter(db, newMemoryStorage(), 1024).ImportOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func TestImportOnce_StreamsFileAndQueuesVideo(t *testing.T) {
+	file := mp4Bytes(importPartSize + 1000)
+	server := serveFile("video/mp4", file)
+	defer server.Close()
+
+	db := new(MockImportsDB)
+	storage := newMemoryStorage()
+	storagePath := "2026/01/01/20260101_000000_clip.mp4"
+	db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL+"/clip.mp4", 1), nil)
+	db.On("UpdateVideoImportProgress", testVideoID, int64(importPartSize)).Return(nil).Once()
+	db.On("UpdateVideoImportProgress", testVideoID, int64(len(file))).Return(nil).Once()
+	db.On("CompleteVideoImport", testVideoID, storagePath, int64(len(file)), mock.MatchedBy(func(m *domain.OutboxMessage) bool {
+		var message domain.VideoProcessingMessage
+		json.Unmarshal(m.Payload, &message)
+		return m.RoutingKey == "video.upload" && m.Priority == 5 && message.StoragePath == storagePath &&
+			message.Options.SnapToChapters && message.RunID != ""
+	})).Return(true, nil)
+
+	claimed, err := newTestImporter(db, storage, int64(len(file))).ImportOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, file, storage.objects[storagePath])
+	db.AssertExpectations(t)
+}
+
+func TestImportOnce_QuickTimeWithoutFtyp(t *testing.T) {
+	file := append([]byte("\x00\x00\x00\x08wide\x00\x00\x00\x1amdat"), make([]byte, 1000)...)
+	server := serveFile("video/quicktime", file)
+	defer server.Close()
+
+	db := new(MockImportsDB)
+	storage := newMemoryStorage()
+	storagePath := "2026/01/01/20260101_000000_clip.mp4"
+	db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL+"/clip.mov", 1), nil)
+	db.On("UpdateVideoImportProgress", testVideoID, int64(len(file))).Return(nil)
+	db.On("CompleteVideoImport", testVideoID, storagePath, int64(len(file)), mock.Anything).Return(true, nil)
+
+	claimed, err := newTestImporter(db, storage, int64(len(file))).ImportOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, file, storage.objects[storagePath])
+	db.AssertExpectations(t)
+}
+
+func TestImportOnce_PermanentFailures(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        []byte
+		maxSize     int64
+	}{
+		{"html page", "text/html; charset=utf-8", []byte("<html></html>"), 1024},
+		{"not a video container", "application/octet-stream", bytes.Repeat([]byte("x"), 100), 1024},
+		{"too large", "video/mp4", mp4Bytes(2048), 1024},
+		{"empty", "video/mp4", []byte{}, 1024},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := serveFile(tc.contentType, tc.body)
+			defer server.Close()
+
+			db := new(MockImportsDB)
+			db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL, 1), nil)
+			db.On("UpdateVideoImportProgress", mock.Anything, mock.Anything).Return(nil).Maybe()
+			db.On("FailVideoImport", testVideoID, mock.AnythingOfType("string")).Return(nil)
+
+			claimed, err := newTestImporter(db, newMemoryStorage(), tc.maxSize).ImportOnce(context.Background())
+
+			assert.NoError(t, err)
+			assert.True(t, claimed)
+			db.AssertExpectations(t)
+			db.AssertNotCalled(t, "RetryVideoImport", mock.Anything, mock.Anything, mock.Anything)
+			db.AssertNotCalled(t, "CompleteVideoImport", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestImportOnce_StreamedOversizeAborts(t *testing.T) {
+	// Without a Content-Length the limit is enforced while streaming.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.(http.Flusher).Flush()
+		w.Write(mp4Bytes(4096))
+	}))
+	defer server.Close()
+
+	db := new(MockImportsDB)
+	storage := newMemoryStorage()
+	db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL, 1), nil)
+	db.On("FailVideoImport", testVideoID, mock.MatchedBy(func(reason string) bool {
+		return reason == "Import failed: remote file is larger than 1024 bytes"
+	})).Return(nil)
+
+	newTestImporter(db, storage, 1024).ImportOnce(context.Background())
+
+	assert.True(t, storage.aborted)
+	db.AssertExpectations(t)
+}
+
+func TestImportOnce_ForbiddenAddress(t *testing.T) {
+	server := serveFile("video/mp4", mp4Bytes(100))
+	defer server.Close()
+
+	db := new(MockImportsDB)
+	db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL, 1), nil)
+	db.On("FailVideoImport", testVideoID, "Import failed: URL points to an address that is not allowed").Return(nil)
+
+	importer := NewVideoImporter(db, newMemoryStorage(), netguard.NewClient(nil, time.Second), time.Millisecond, 1024)
+	importer.ImportOnce(context.Background())
+
+	db.AssertExpectations(t)
+}
+
+func TestImportOnce_ServerErrorRetriedWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	db := new(MockImportsDB)
+	db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL, 2), nil)
+	db.On("RetryVideoImport", testVideoID, 60*time.Second, "Import attempt 2 failed: remote server answered 503 Service Unavailable").Return(nil)
+
+	newTestImporter(db, newMemoryStorage(), 1024).ImportOnce(context.Background())
+
+	db.AssertExpectations(t)
+}
+
+func TestImportOnce_LastAttemptFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	db := new(MockImportsDB)
+	db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL, 3), nil)
+	db.On("FailVideoImport", testVideoID, "Import failed: remote server answered 502 Bad Gateway").Return(nil)
+
+	newTestImporter(db, newMemoryStorage(), 1024).ImportOnce(context.Background())
+
+	db.AssertExpectations(t)
+}
+
+func TestImportOnce_VideoDeletedWhileImporting(t *testing.T) {
+	server := serveFile("video/mp4", mp4Bytes(100))
+	defer server.Close()
+
+	db := new(MockImportsDB)
+	storage := newMemoryStorage()
+	db.On("ClaimVideoImport", importLease).Return(videoImport(server.URL, 1), nil)
+	db.On("UpdateVideoImportProgress", testVideoID, int64(100)).Return(nil)
+	db.On("CompleteVideoImport", testVideoID, mock.Anything, int64(100), mock.Anything).Return(false, nil)
+
+	claimed, err := newTestImporter(db, storage, 1024).ImportOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Empty(t, storage.objects)
+}
+
+func TestImportOnce_ClaimError(t *testing.T) {
+	db := new(MockImportsDB)
+	db.On("ClaimVideoImport", importLease).Return(nil, fmt.Errorf("connection refused"))
+
+	claimed, err := newTestImporter(db, newMemoryStorage(), 1024).ImportOnce(context.Background())
+
+	assert.Error(t, err)
+	assert.False(t, claimed)
+}
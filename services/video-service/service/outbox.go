@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"video-service/domain"
+)
+
+// OutboxRelay publishes messages written to the outbox table. Every replica
+// may run one: rows are locked with SKIP LOCKED, so each is claimed by a
+// single relay, and a row is only marked sent after the broker confirms it.
+// A crash between the confirm and the commit publishes the row again, which
+// makes delivery at-least-once. Sent rows are kept for retention, then
+// deleted; zero keeps them forever.
+type OutboxRelay struct {
+	db             domain.DatabaseInterface
+	rabbitmq       domain.RabbitMQInterface
+	interval       time.Duration
+	batchSize      int
+	publishTimeout time.Duration
+	retention      time.Duration
+	pruneInterval  time.Duration
+	lastPrune      time.Time
+	now            func() time.Time
+}
+
+func NewOutboxRelay(db domain.DatabaseInterface, rabbitmq domain.RabbitMQInterface, interval time.Duration, batchSize int, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		db:             db,
+		rabbitmq:       rabbitmq,
+		interval:       interval,
+		batchSize:      batchSize,
+		publishTimeout: 5 * time.Second,
+		retention:      retention,
+		pruneInterval:  10 * time.Minute,
+		now:            time.Now,
+	}
+}
+
+// Run relays pending messages every interval until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	log.Printf("Outbox relay started (interval %s, batch %d, retention %s)", r.interval, r.batchSize, r.retention)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		if r.retention > 0 && r.now().Sub(r.lastPrune) >= r.pruneInterval {
+			r.lastPrune = r.now()
+			if pruned, err := r.Prune(ctx); err != nil {
+				log.Printf("Outbox relay: failed to prune sent messages: %v", err)
+			} else if pruned > 0 {
+				log.Printf("Outbox relay: %d sent messages pruned", pruned)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain keeps relaying full batches so a backlog clears without waiting a
+// tick per batch.
+func (r *OutboxRelay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := r.RelayOnce(ctx)
+		if err != nil {
+			log.Printf("Outbox relay: %v", err)
+			return
+		}
+		if sent < r.batchSize {
+			return
+		}
+	}
+}
+
+// RelayOnce publishes a single batch and returns how many messages were sent.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	return r.db.RelayOutbox(r.batchSize, func(message *domain.OutboxMessage) error {
+		publishCtx, cancel := context.WithTimeout(ctx, r.publishTimeout)
+		defer cancel()
+		return r.rabbitmq.PublishConfirmed(publishCtx, message.Exchange, message.RoutingKey, message.Payload, message.Priority)
+	})
+}
+
+// Prune deletes the messages sent more than retention ago, a batch at a time,
+// and returns how many it deleted.
+func (r *OutboxRelay) Prune(ctx context.Context) (int, error) {
+	before := r.now().Add(-r.retention)
+	pruned := 0
+	for ctx.Err() == nil {
+		n, err := r.db.PruneOutbox(before, r.batchSize)
+		pruned += n
+		if err != nil {
+			return pruned, err
+		}
+		if n < r.batchSize {
+			break
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ─── Mocks ────────────────────────────────────────────────────────────────────
+
+// MockOutboxDB replays the given messages through RelayOutbox the same way
+// the real implementation does: a failed message stays pending and the batch
+// carries on. The real backoff is not modelled, so it is retried next call.
+type MockOutboxDB struct {
+	domain.DatabaseInterface
+	mock.Mock
+	mu      sync.Mutex
+	pending []*domain.OutboxMessage
+}
+
+func (m *MockOutboxDB) remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+func (m *MockOutboxDB) RelayOutbox(limit int, publish func(*domain.OutboxMessage) error) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Called(limit)
+	sent := 0
+	var publishErr error
+	pending := []*domain.OutboxMessage{}
+	for i, message := range m.pending {
+		if i == limit {
+			pending = append(pending, m.pending[i:]...)
+			break
+		}
+		if err := publish(message); err != nil {
+			if publishErr == nil {
+				publishErr = err
+			}
+			pending = append(pending, message)
+			continue
+		}
+		sent++
+	}
+	m.pending = pending
+	return sent, publishErr
+}
+
+func (m *MockOutboxDB) PruneOutbox(sentBefore time.Time, limit int) (int, error) {
+	args := m.Called(sentBefore, limit)
+	return args.Int(0), args.Error(1)
+}
+
+type MockRabbitMQ struct{ mock.Mock }
+
+func (m *MockRabbitMQ) PublishConfirmed(ctx context.Context, exchange, routingKey string, body []byte, priority int) error {
+	return m.Called(exchange, routingKey, body, priority).Error(0)
+}
+func (m *MockRabbitMQ) SubscribeVideoEvents(consumerTag string) (<-chan amqp.Delivery, error) {
+	args := m.Called(consumerTag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan amqp.Delivery), args.Error(1)
+}
+func (m *MockRabbitMQ) Ping() error  { return m.Called().Error(0) }
+func (m *MockRabbitMQ) Close() error { return m.Called().Error(0) }
+
+func outboxMessage(id string, priority int) *domain.OutboxMessage {
+	return &domain.OutboxMessage{
+		ID:          id,
+		AggregateID: "video-" + id,
+		Exchange:    "video.exchange",
+		RoutingKey:  "video.upload",
+		Payload:     []byte(`{"video_id":"video-` + id + `"}`),
+		Priority:    priority,
+	}
+}
+
+// ─── RelayOnce ────────────────────────────────────────────────────────────────
+
+func TestRelayOnce_PublishesWithConfirmFields(t *testing.T) {
+	db := &MockOutboxDB{pending: []*domain.OutboxMessage{outboxMessage("1", 7)}}
+	db.On("RelayOutbox", 10).Return()
+	mq := new(MockRabbitMQ)
+	mq.On("PublishConfirmed", "video.exchange", "video.upload", []byte(`{"video_id":"video-1"}`), 7).Return(nil)
+
+	sent, err := NewOutboxRelay(db, mq, time.Second, 10, 0).RelayOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	mq.AssertExpectations(t)
+}
+
+func TestRelayOnce_SkipsFailedMessage(t *testing.T) {
+	db := &MockOutboxDB{pending: []*domain.OutboxMessage{outboxMessage("1", 5), outboxMessage("2", 5)}}
+	db.On("RelayOutbox", 10).Return()
+	mq := new(MockRabbitMQ)
+	mq.On("PublishConfirmed", mock.Anything, mock.Anything, []byte(`{"video_id":"video-1"}`), mock.Anything).Return(errors.New("unroutable"))
+	mq.On("PublishConfirmed", mock.Anything, mock.Anything, []byte(`{"video_id":"video-2"}`), mock.Anything).Return(nil)
+
+	sent, err := NewOutboxRelay(db, mq, time.Second, 10, 0).RelayOnce(context.Background())
+
+	// The failing row does not hold back the one behind it.
+	assert.EqualError(t, err, "unroutable")
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 1, db.remaining())
+	mq.AssertNumberOfCalls(t, "PublishConfirmed", 2)
+}
+
+// ─── Prune ────────────────────────────────────────────────────────────────────
+
+func TestPrune_DeletesInBatches(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-24 * time.Hour)
+	db := &MockOutboxDB{}
+	db.On("PruneOutbox", cutoff, 2).Return(2, nil).Once()
+	db.On("PruneOutbox", cutoff, 2).Return(1, nil).Once()
+	relay := NewOutboxRelay(db, new(MockRabbitMQ), time.Second, 2, 24*time.Hour)
+	relay.now = func() time.Time { return now }
+
+	pruned, err := relay.Prune(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pruned)
+	db.AssertExpectations(t)
+}
+
+func TestPrune_StopsOnError(t *testing.T) {
+	db := &MockOutboxDB{}
+	db.On("PruneOutbox", mock.Anything, 2).Return(0, errors.New("db down")).Once()
+
+	pruned, err := NewOutboxRelay(db, new(MockRabbitMQ), time.Second, 2, time.Hour).Prune(context.Background())
+
+	assert.EqualError(t, err, "db down")
+	assert.Zero(t, pruned)
+	db.AssertNumberOfCalls(t, "PruneOutbox", 1)
+}
+
+// ─── Run ──────────────────────────────────────────────────────────────────────
+
+func TestRun_DrainsFullBatchesThenStops(t *testing.T) {
+	db := &MockOutboxDB{pending: []*domain.OutboxMessage{
+		outboxMessage("1", 5), outboxMessage("2", 5), outboxMessage("3", 5),
+	}}
+	db.On("RelayOutbox", 2).Return()
+	mq := new(MockRabbitMQ)
+	mq.On("PublishConfirmed", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewOutboxRelay(db, mq, time.Hour, 2, 0).Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return db.remaining() == 0 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	// Two full batches are drained back to back, the third call finds one row.
+	db.AssertNumberOfCalls(t, "RelayOutbox", 2)
+}
+
+func TestRun_RetriesOnNextTick(t *testing.T) {
+	db := &MockOutboxDB{pending: []*domain.OutboxMessage{outboxMessage("1", 5)}}
+	db.On("RelayOutbox", 10).Return()
+	mq := new(MockRabbitMQ)
+	mq.On("PublishConfirmed", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("broker down")).Once()
+	mq.On("PublishConfirmed", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewOutboxRelay(db, mq, 10*time.Millisecond, 10, 0).Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return db.remaining() == 0 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestRun_PrunesOnlyWithRetention(t *testing.T) {
+	db := &MockOutboxDB{}
+	db.On("RelayOutbox", 10).Return()
+	db.On("PruneOutbox", mock.Anything, 10).Return(0, nil)
+	mq := new(MockRabbitMQ)
+
+	for _, retention := range []time.Duration{0, time.Hour} {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			NewOutboxRelay(db, mq, 5*time.Millisecond, 10, retention).Run(ctx)
+			close(done)
+		}()
+		time.Sleep(30 * time.Millisecond)
+		cancel()
+		<-done
+	}
+
+	// Pruning runs once per prune interval, not on every tick.
+	db.AssertNumberOfCalls(t, "PruneOutbox", 1)
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"video-service/domain"
+	"video-service/infra/utils"
+
+	"shared/clienterr"
+
+	"github.com/google/uuid"
+)
+
+const day = 24 * time.Hour
+
+// RetentionPolicy is how many days a plan keeps each kind of file. Zero keeps
+// it forever.
+type RetentionPolicy struct {
+	// RawDays keeps a processed video's upload for this long after it
+	// completes.
+	RawDays int64
+	// ZipDays keeps a completed video's zips for this long, after which the
+	// video expires.
+	ZipDays int64
+	// FailedDays keeps a failed video's files for this long, after which the
+	// video expires.
+	FailedDays int64
+}
+
+// RetentionConfig holds the retention days of every plan, as read from
+// RETENTION_RAW_DAYS, RETENTION_ZIP_DAYS and RETENTION_FAILED_DAYS in the
+// "days,plan:days" form of utils.PlanValues. Owners are warned WarnDays
+// before their files are removed; zero sends no warnings.
+type RetentionConfig struct {
+	Raw      map[string]int64
+	Zip      map[string]int64
+	Failed   map[string]int64
+	WarnDays int64
+}
+
+// LoadRetentionConfig reads the retention settings. Unset, nothing is ever
+// removed.
+func LoadRetentionConfig() RetentionConfig {
+	warnDays, err := strconv.ParseInt(utils.GetEnv("RETENTION_WARN_DAYS", "3"), 10, 64)
+	if err != nil || warnDays < 0 {
+		warnDays = 0
+	}
+	return RetentionConfig{
+		Raw:      utils.PlanValues("RETENTION_RAW_DAYS", "0"),
+		Zip:      utils.PlanValues("RETENTION_ZIP_DAYS", "0"),
+		Failed:   utils.PlanValues("RETENTION_FAILED_DAYS", "0"),
+		WarnDays: warnDays,
+	}
+}
+
+// Policy returns the retention of a plan.
+func (c RetentionConfig) Policy(plan string) RetentionPolicy {
+	return RetentionPolicy{
+		RawDays:    utils.PlanValue(c.Raw, plan, 0),
+		ZipDays:    utils.PlanValue(c.Zip, plan, 0),
+		FailedDays: utils.PlanValue(c.Failed, plan, 0),
+	}
+}
+
+// horizon is how long ago a video must have finished for any plan to remove
+// or warn about its files, or zero if no plan removes anything.
+func (c RetentionConfig) horizon() time.Duration {
+	var shortest int64
+	for _, values := range []map[string]int64{c.Raw, c.Zip, c.Failed} {
+		for _, days := range values {
+			if days > 0 && (shortest == 0 || days < shortest) {
+				shortest = days
+			}
+		}
+	}
+	if shortest == 0 {
+		return 0
+	}
+	return time.Duration(max(shortest-c.WarnDays, 0)) * day
+}
+
+// retentionStep is a removal the policy schedules for a video.
+type retentionStep struct {
+	at        time.Time
+	deleteRaw bool
+	expire    bool
+}
+
+// steps lists when the policy removes the files the video still has.
+func (p RetentionPolicy) steps(video *domain.Video) []retentionStep {
+	finished := video.CreatedAt
+	if video.ProcessingCompletedAt != nil {
+		finished = *video.ProcessingCompletedAt
+	}
+	after := func(days int64) time.Time { return finished.Add(time.Duration(days) * day) }
+
+	steps := []retentionStep{}
+	switch video.Status {
+	case "failed":
+		if p.FailedDays > 0 {
+			steps = append(steps, retentionStep{at: after(p.FailedDays), deleteRaw: video.StoragePath != "", expire: true})
+		}
+	case "completed", domain.VideoStatusExpired:
+		if p.RawDays > 0 && video.StoragePath != "" {
+			steps = append(steps, retentionStep{at: after(p.RawDays), deleteRaw: true})
+		}
+		if p.ZipDays > 0 && video.Status == "completed" {
+			steps = append(steps, retentionStep{at: after(p.ZipDays), expire: true})
+		}
+	}
+	return steps
+}
+
+// RetentionJanitor applies the retention policy of each video owner's plan:
+// it deletes uploads and zips that are past their days, leaves the videos
+// expired and warns owners beforehand. Every replica may run one; each
+// change is made under a row lock and only once.
+type RetentionJanitor struct {
+	db         domain.DatabaseInterface
+	minio      domain.MinIOInterface
+	authClient domain.AuthServiceClient
+	config     RetentionConfig
+	interval   time.Duration
+	batchSize  int
+	now        func() time.Time
+}
+
+func NewRetentionJanitor(db domain.DatabaseInterface, minio domain.MinIOInterface, authClient domain.AuthServiceClient, config RetentionConfig, interval time.Duration) *RetentionJanitor {
+	return &RetentionJanitor{
+		db:         db,
+		minio:      minio,
+		authClient: authClient,
+		config:     config,
+		interval:   interval,
+		batchSize:  100,
+		now:        time.Now,
+	}
+}
+
+// Run sweeps once at start and then every interval until ctx is cancelled.
+func (j *RetentionJanitor) Run(ctx context.Context) {
+	log.Printf("Retention janitor started (interval %s)", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if changed, err := j.Sweep(ctx); err != nil {
+			log.Printf("Retention janitor: %v", err)
+		} else if changed > 0 {
+			log.Printf("Retention janitor: %d videos changed", changed)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Retention janitor stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep goes through every video old enough for the policy to touch, and
+// returns how many it removed files from or warned about.
+func (j *RetentionJanitor) Sweep(ctx context.Context) (int, error) {
+	horizon := j.config.horizon()
+	if horizon == 0 {
+		return 0, nil
+	}
+
+	now := j.now()
+	plans := map[string]string{}
+	changed := 0
+	afterID := ""
+	for ctx.Err() == nil {
+		videos, err := j.db.ListRetentionCandidates(now.Add(-horizon), afterID, j.batchSize)
+		if err != nil {
+			return changed, fmt.Errorf("failed to list videos: %w", err)
+		}
+
+		for _, video := range videos {
+			plan, err := j.plan(plans, video.UserID)
+			if err != nil {
+				log.Printf("Retention janitor: skipping video %s: %v", video.ID, err)
+				continue
+			}
+			ok, err := j.review(video, j.config.Policy(plan), now)
+			if err != nil {
+				log.Printf("Retention janitor: video %s: %v", video.ID, err)
+				continue
+			}
+			if ok {
+				changed++
+			}
+		}
+
+		if len(videos) < j.batchSize {
+			break
+		}
+		afterID = videos[len(videos)-1].ID
+	}
+	return changed, nil
+}
+
+// plan looks up the owner's plan once per sweep. The files of a user the
+// auth service no longer knows follow the user plan.
+func (j *RetentionJanitor) plan(plans map[string]string, userID string) (string, error) {
+	if plan, ok := plans[userID]; ok {
+		return plan, nil
+	}
+	plan, err := j.authClient.GetUserRole(userID)
+	if errors.Is(err, clienterr.ErrNotFound) {
+		plan, err = "user", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up plan of %s: %w", userID, err)
+	}
+	if plan == "" {
+		plan = "user"
+	}
+	plans[userID] = plan
+	return plan, nil
+}
+
+// review removes whatever the policy says is due, or else warns about the
+// next removal once it is close enough. It reports whether it did either.
+func (j *RetentionJanitor) review(video *domain.Video, policy RetentionPolicy, now time.Time) (bool, error) {
+	var due retentionStep
+	var next *retentionStep
+	for _, step := range policy.steps(video) {
+		if !step.at.After(now) {
+			due.deleteRaw = due.deleteRaw || step.deleteRaw
+			due.expire = due.expire || step.expire
+			continue
+		}
+		if next == nil || step.at.Before(next.at) {
+			step := step
+			next = &step
+		}
+	}
+
+	if due.deleteRaw || due.expire {
+		removed, err := j.db.ApplyRetention(video.ID, due.deleteRaw, due.expire)
+		if err != nil {
+			return false, fmt.Errorf("failed to apply retention: %w", err)
+		}
+		// The rows no longer point at these objects, so a failed delete only
+		// leaves an orphan behind.
+		for _, object := range removed {
+			if err := j.minio.DeleteFile(object); err != nil {
+				log.Printf("Retention janitor: failed to delete %s of video %s: %v", object, video.ID, err)
+			}
+		}
+		if len(removed) > 0 {
+			log.Printf("Video %s: retention removed %d files", video.ID, len(removed))
+		}
+		return len(removed) > 0, nil
+	}
+
+	if next == nil || j.config.WarnDays == 0 || video.RetentionWarnedAt != nil ||
+		next.at.Sub(now) > time.Duration(j.config.WarnDays)*day {
+		return false, nil
+	}
+	message, err := expiryWarning(video, *next)
+	if err != nil {
+		return false, err
+	}
+	return j.db.MarkRetentionWarned(video.ID, message)
+}
+
+// expiryWarning builds the notification announcing a removal.
+func expiryWarning(video *domain.Video, step retentionStep) (*domain.OutboxMessage, error) {
+	date := step.at.UTC().Format("2006-01-02")
+	subject, text := "Video Files Expiring",
+		fmt.Sprintf("The frames of your video %q will be deleted on %s. Download them before then.", video.OriginalName, date)
+	switch {
+	case video.Status == "failed":
+		subject, text = "Failed Video Expiring",
+			fmt.Sprintf("Your video %q failed to process and will be removed on %s.", video.OriginalName, date)
+	case !step.expire:
+		subject, text = "Original Upload Expiring",
+			fmt.Sprintf("The original upload of your video %q will be deleted on %s. It cannot be reprocessed after that.",
+				video.OriginalName, date)
+	}
+
+	payload, err := json.Marshal(domain.NotificationMessage{
+		UserID:  video.UserID,
+		VideoID: video.ID,
+		Type:    domain.NotificationVideoExpiring,
+		Subject: subject,
+		Message: text,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.OutboxMessage{
+		ID:          uuid.New().String(),
+		AggregateID: video.ID,
+		Exchange:    "notification.exchange",
+		RoutingKey:  "notification.email",
+		Payload:     payload,
+		CreatedAt:   time.Now(),
+	}, nil
+}
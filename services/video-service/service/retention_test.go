@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"shared/clienterr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ─── Mocks ────────────────────────────────────────────────────────────────────
+
+type MockRetentionDB struct {
+	domain.DatabaseInterface
+	mock.Mock
+}
+
+func (m *MockRetentionDB) ListRetentionCandidates(before time.Time, afterID string, limit int) ([]*domain.Video, error) {
+	args := m.Called(before, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Video), args.Error(1)
+}
+
+func (m *MockRetentionDB) ApplyRetention(videoID string, deleteRaw, expire bool) ([]string, error) {
+	args := m.Called(videoID, deleteRaw, expire)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRetentionDB) MarkRetentionWarned(videoID string, message *domain.OutboxMessage) (bool, error) {
+	args := m.Called(videoID, message)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockRetentionStorage struct {
+	domain.MinIOInterface
+	mock.Mock
+}
+
+func (m *MockRetentionStorage) DeleteFile(objectName string) error {
+	return m.Called(objectName).Error(0)
+}
+
+type MockRoles struct {
+	domain.AuthServiceClient
+	mock.Mock
+}
+
+func (m *MockRoles) GetUserRole(userID string) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+var retentionNow = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func retentionConfig() RetentionConfig {
+	return RetentionConfig{
+		Raw:      map[string]int64{"admin": 0, "user": 30},
+		Zip:      map[string]int64{"admin": 0, "user": 90},
+		Failed:   map[string]int64{"": 7},
+		WarnDays: 3,
+	}
+}
+
+func newTestJanitor(db *MockRetentionDB, storage *MockRetentionStorage, roles *MockRoles) *RetentionJanitor {
+	j := NewRetentionJanitor(db, storage, roles, retentionConfig(), time.Hour)
+	j.now = func() time.Time { return retentionNow }
+	return j
+}
+
+// finishedVideo is a video of u1 that finished the given number of days ago.
+func finishedVideo(id, status string, daysAgo int, storagePath string) *domain.Video {
+	finished := retentionNow.Add(-time.Duration(daysAgo) * day)
+	return &domain.Video{
+		ID: id, UserID: "u1", OriginalName: "talk.mp4", Status: status, StoragePath: storagePath,
+		CreatedAt: finished.Add(-time.Hour), ProcessingCompletedAt: &finished,
+	}
+}
+
+func expectCandidates(db *MockRetentionDB, videos ...*domain.Video) {
+	// The shortest retention is 7 days, less 3 days of warning.
+	db.On("ListRetentionCandidates", retentionNow.Add(-4*day), "", 100).Return(videos, nil).Once()
+}
+
+// ─── Config ───────────────────────────────────────────────────────────────────
+
+func TestRetentionConfig_Policy(t *testing.T) {
+	config := retentionConfig()
+
+	assert.Equal(t, RetentionPolicy{RawDays: 30, ZipDays: 90, FailedDays: 7}, config.Policy("user"))
+	assert.Equal(t, RetentionPolicy{FailedDays: 7}, config.Policy("admin"))
+	assert.Equal(t, config.Policy("user"), config.Policy("premium"))
+	assert.Equal(t, 4*day, config.horizon())
+}
+
+func TestLoadRetentionConfig_DefaultsKeepEverything(t *testing.T) {
+	config := LoadRetentionConfig()
+
+	assert.Equal(t, RetentionPolicy{}, config.Policy("user"))
+	assert.Zero(t, config.horizon())
+	assert.Equal(t, int64(3), config.WarnDays)
+}
+
+// ─── Sweep ────────────────────────────────────────────────────────────────────
+
+func TestSweep_Disabled(t *testing.T) {
+	db := new(MockRetentionDB)
+	j := NewRetentionJanitor(db, nil, nil, RetentionConfig{WarnDays: 3}, time.Hour)
+
+	changed, err := j.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Zero(t, changed)
+	db.AssertNotCalled(t, "ListRetentionCandidates", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSweep_DeletesDueUpload(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	roles.On("GetUserRole", "u1").Return("user", nil).Once()
+	expectCandidates(db, finishedVideo("v1", "completed", 40, "raw/v1.mp4"))
+	db.On("ApplyRetention", "v1", true, false).Return([]string{"raw/v1.mp4"}, nil)
+	storage.On("DeleteFile", "raw/v1.mp4").Return(nil)
+
+	changed, err := newTestJanitor(db, storage, roles).Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	db.AssertExpectations(t)
+	storage.AssertExpectations(t)
+}
+
+func TestSweep_ExpiresZipsAndUpload(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	roles.On("GetUserRole", "u1").Return("user", nil).Once()
+	expectCandidates(db, finishedVideo("v1", "completed", 95, "raw/v1.mp4"))
+	db.On("ApplyRetention", "v1", true, true).Return([]string{"raw/v1.mp4", "zips/v1_r1.zip", "zips/v1_r2.zip"}, nil)
+	storage.On("DeleteFile", "raw/v1.mp4").Return(nil)
+	storage.On("DeleteFile", "zips/v1_r1.zip").Return(errors.New("gone"))
+	storage.On("DeleteFile", "zips/v1_r2.zip").Return(nil)
+
+	changed, err := newTestJanitor(db, storage, roles).Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	storage.AssertExpectations(t)
+}
+
+func TestSweep_PurgesFailedVideo(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	roles.On("GetUserRole", "u1").Return("admin", nil).Once()
+	expectCandidates(db, finishedVideo("v1", "failed", 8, "raw/v1.mp4"))
+	db.On("ApplyRetention", "v1", true, true).Return([]string{"raw/v1.mp4"}, nil)
+	storage.On("DeleteFile", "raw/v1.mp4").Return(nil)
+
+	changed, err := newTestJanitor(db, storage, roles).Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	db.AssertExpectations(t)
+}
+
+func TestSweep_AdminKeepsProcessedVideos(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	roles.On("GetUserRole", "u1").Return("admin", nil).Once()
+	expectCandidates(db, finishedVideo("v1", "completed", 400, "raw/v1.mp4"), finishedVideo("v2", "completed", 200, "raw/v2.mp4"))
+
+	changed, err := newTestJanitor(db, storage, roles).Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Zero(t, changed)
+	db.AssertNotCalled(t, "ApplyRetention", mock.Anything, mock.Anything, mock.Anything)
+	db.AssertNotCalled(t, "MarkRetentionWarned", mock.Anything, mock.Anything)
+	roles.AssertExpectations(t)
+}
+
+func TestSweep_WarnsBeforeExpiry(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	roles.On("GetUserRole", "u1").Return("user", nil)
+	warned := time.Now()
+	alreadyWarned := finishedVideo("v2", "completed", 88, "")
+	alreadyWarned.RetentionWarnedAt = &warned
+	expectCandidates(db, finishedVideo("v1", "completed", 88, ""), alreadyWarned, finishedVideo("v3", "completed", 80, ""))
+
+	var notification domain.NotificationMessage
+	db.On("MarkRetentionWarned", "v1", mock.MatchedBy(func(m *domain.OutboxMessage) bool {
+		return m.RoutingKey == "notification.email" && json.Unmarshal(m.Payload, &notification) == nil
+	})).Return(true, nil).Once()
+
+	changed, err := newTestJanitor(db, storage, roles).Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	assert.Equal(t, domain.NotificationVideoExpiring, notification.Type)
+	assert.Equal(t, "v1", notification.VideoID)
+	assert.Contains(t, notification.Message, "frames")
+	assert.Contains(t, notification.Message, "2026-06-03")
+	db.AssertExpectations(t)
+}
+
+func TestSweep_WarnsAboutUploadFirst(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	roles.On("GetUserRole", "u1").Return("user", nil)
+	expectCandidates(db, finishedVideo("v1", "completed", 28, "raw/v1.mp4"))
+
+	var notification domain.NotificationMessage
+	db.On("MarkRetentionWarned", "v1", mock.MatchedBy(func(m *domain.OutboxMessage) bool {
+		return json.Unmarshal(m.Payload, &notification) == nil
+	})).Return(true, nil).Once()
+
+	_, err := newTestJanitor(db, storage, roles).Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Contains(t, notification.Message, "original upload")
+	assert.Contains(t, notification.Message, "2026-06-03")
+}
+
+func TestSweep_Pages(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	roles.On("GetUserRole", "u1").Return("user", nil).Once()
+	j := newTestJanitor(db, storage, roles)
+	j.batchSize = 2
+
+	before := retentionNow.Add(-4 * day)
+	db.On("ListRetentionCandidates", before, "", 2).Return([]*domain.Video{
+		finishedVideo("v1", "completed", 10, ""), finishedVideo("v2", "completed", 10, ""),
+	}, nil).Once()
+	db.On("ListRetentionCandidates", before, "v2", 2).Return([]*domain.Video{
+		finishedVideo("v3", "failed", 10, ""),
+	}, nil).Once()
+	db.On("ApplyRetention", "v3", false, true).Return([]string{}, nil)
+
+	changed, err := j.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Zero(t, changed)
+	db.AssertExpectations(t)
+	roles.AssertExpectations(t)
+}
+
+func TestSweep_PlanLookup(t *testing.T) {
+	db, storage, roles := new(MockRetentionDB), new(MockRetentionStorage), new(MockRoles)
+	gone := finishedVideo("v1", "completed", 40, "raw/v1.mp4")
+	gone.UserID = "deleted"
+	unreachable := finishedVideo("v2", "completed", 40, "raw/v2.mp4")
+	unreachable.UserID = "u2"
+	roles.On("GetUserRole", "deleted").Return("", clienterr.ErrNotFound)
+	roles.On("GetUserRole", "u2").Return("", errors.New("auth down"))
+	expectCandidates(db, gone, unreachable)
+	db.On("ApplyRetention", "v1", true, false).Return([]string{"raw/v1.mp4"}, nil)
+	storage.On("DeleteFile", "raw/v1.mp4").Return(nil)
+
+	changed, err := newTestJanitor(db, storage, roles).Sweep(context.Background())
+
+	// A deleted user's files follow the user plan; the video of a user whose
+	// plan cannot be read waits for the next sweep.
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	db.AssertNotCalled(t, "ApplyRetention", "v2", mock.Anything, mock.Anything)
+}
+
+func TestSweep_ListError(t *testing.T) {
+	db := new(MockRetentionDB)
+	db.On("ListRetentionCandidates", mock.Anything, "", 100).Return(nil, errors.New("db down"))
+
+	_, err := newTestJanitor(db, nil, nil).Sweep(context.Background())
+
+	assert.ErrorContains(t, err, "db down")
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"video-service/domain"
+)
+
+// TrashPurger deletes videos that have been in the trash for longer than the
+// grace period, together with their files. Every replica may run one; a video
+// restored while it is being purged is left alone.
+type TrashPurger struct {
+	db        domain.DatabaseInterface
+	minio     domain.MinIOInterface
+	grace     time.Duration
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+func NewTrashPurger(db domain.DatabaseInterface, minio domain.MinIOInterface, grace, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		db:        db,
+		minio:     minio,
+		grace:     grace,
+		interval:  interval,
+		batchSize: 100,
+		now:       time.Now,
+	}
+}
+
+// Run purges once at start and then every interval until ctx is cancelled.
+func (p *TrashPurger) Run(ctx context.Context) {
+	log.Printf("Trash purger started (grace %s, interval %s)", p.grace, p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := p.Purge(ctx); err != nil {
+			log.Printf("Trash purger: %v", err)
+		} else if purged > 0 {
+			log.Printf("Trash purger: %d videos purged", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Trash purger stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes every video whose grace period is over and returns how many
+// it deleted.
+func (p *TrashPurger) Purge(ctx context.Context) (int, error) {
+	before := p.now().Add(-p.grace)
+	purged := 0
+	afterID := ""
+	for ctx.Err() == nil {
+		videos, err := p.db.ListTrashedVideos(before, afterID, p.batchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list trashed videos: %w", err)
+		}
+
+		for _, video := range videos {
+			removed, err := p.db.PurgeVideo(video.ID, before)
+			if err != nil {
+				log.Printf("Trash purger: video %s: %v", video.ID, err)
+				continue
+			}
+			if removed == nil {
+				continue
+			}
+			// The row is gone, so a failed delete only leaves an orphan behind.
+			for _, object := range removed {
+				if err := p.minio.DeleteFile(object); err != nil {
+					log.Printf("Trash purger: failed to delete %s of video %s: %v", object, video.ID, err)
+				}
+			}
+			purged++
+		}
+
+		if len(videos) < p.batchSize {
+			break
+		}
+		afterID = videos[len(videos)-1].ID
+	}
+	return purged, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ─── Mocks ────────────────────────────────────────────────────────────────────
+
+type MockTrashDB struct {
+	domain.DatabaseInterface
+	mock.Mock
+}
+
+func (m *MockTrashDB) ListTrashedVideos(before time.Time, afterID string, limit int) ([]*domain.Video, error) {
+	args := m.Called(before, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Video), args.Error(1)
+}
+
+func (m *MockTrashDB) PurgeVideo(id string, trashedBefore time.Time) ([]string, error) {
+	args := m.Called(id, trashedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func newTestPurger(db *MockTrashDB, storage *MockRetentionStorage) (*TrashPurger, time.Time) {
+	p := NewTrashPurger(db, storage, 30*day, time.Hour)
+	p.now = func() time.Time { return retentionNow }
+	return p, retentionNow.Add(-30 * day)
+}
+
+// ─── Purge ────────────────────────────────────────────────────────────────────
+
+func TestPurge_DeletesVideosAndFiles(t *testing.T) {
+	db, storage := new(MockTrashDB), new(MockRetentionStorage)
+	p, before := newTestPurger(db, storage)
+
+	db.On("ListTrashedVideos", before, "", 100).Return([]*domain.Video{{ID: "v1"}, {ID: "v2"}}, nil).Once()
+	db.On("PurgeVideo", "v1", before).Return([]string{"zips/v1.zip", "raw/v1.mp4"}, nil)
+	db.On("PurgeVideo", "v2", before).Return([]string{}, nil)
+	storage.On("DeleteFile", "zips/v1.zip").Return(errors.New("gone"))
+	storage.On("DeleteFile", "raw/v1.mp4").Return(nil)
+
+	purged, err := p.Purge(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	db.AssertExpectations(t)
+	storage.AssertExpectations(t)
+}
+
+func TestPurge_SkipsRestoredAndFailingVideos(t *testing.T) {
+	db, storage := new(MockTrashDB), new(MockRetentionStorage)
+	p, before := newTestPurger(db, storage)
+	p.batchSize = 2
+
+	db.On("ListTrashedVideos", before, "", 2).Return([]*domain.Video{{ID: "v1"}, {ID: "v2"}}, nil).Once()
+	db.On("ListTrashedVideos", before, "v2", 2).Return([]*domain.Video{}, nil).Once()
+	db.On("PurgeVideo", "v1", before).Return(nil, nil)
+	db.On("PurgeVideo", "v2", before).Return(nil, errors.New("db down"))
+
+	purged, err := p.Purge(context.Background())
+
+	assert.NoError(t, err)
+	assert.Zero(t, purged)
+	db.AssertExpectations(t)
+	storage.AssertNotCalled(t, "DeleteFile", mock.Anything)
+}
+
+func TestPurge_ListError(t *testing.T) {
+	db := new(MockTrashDB)
+	p, before := newTestPurger(db, nil)
+	db.On("ListTrashedVideos", before, "", 100).Return(nil, errors.New("db down"))
+
+	_, err := p.Purge(context.Background())
+
+	assert.ErrorContains(t, err, "db down")
+}
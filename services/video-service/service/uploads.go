@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"video-service/domain"
+)
+
+// uploadJanitorLease is how long the janitor holds a session it is
+// discarding, long enough for the storage calls to finish.
+const uploadJanitorLease = 5 * time.Minute
+
+// UploadJanitor discards upload sessions that expired before being
+// completed, together with what they stored: the multipart upload and tail
+// object of a tus upload, or the object of a direct upload. Every replica may
+// run one; a session in use by a request is left for the next sweep.
+type UploadJanitor struct {
+	db        domain.DatabaseInterface
+	minio     domain.MinIOInterface
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+func NewUploadJanitor(db domain.DatabaseInterface, minio domain.MinIOInterface, interval time.Duration) *UploadJanitor {
+	return &UploadJanitor{
+		db:        db,
+		minio:     minio,
+		interval:  interval,
+		batchSize: 100,
+		now:       time.Now,
+	}
+}
+
+// Run sweeps once at start and then every interval until ctx is cancelled.
+func (j *UploadJanitor) Run(ctx context.Context) {
+	log.Printf("Upload janitor started (interval %s)", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if discarded, err := j.Sweep(ctx); err != nil {
+			log.Printf("Upload janitor: %v", err)
+		} else if discarded > 0 {
+			log.Printf("Upload janitor: %d expired uploads discarded", discarded)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Upload janitor stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep discards every expired, unfinished upload session and returns how
+// many it discarded.
+func (j *UploadJanitor) Sweep(ctx context.Context) (int, error) {
+	before := j.now()
+	discarded := 0
+	afterID := ""
+	for ctx.Err() == nil {
+		sessions, err := j.db.ListExpiredUploadSessions(before, afterID, j.batchSize)
+		if err != nil {
+			return discarded, fmt.Errorf("failed to list expired uploads: %w", err)
+		}
+
+		for _, expired := range sessions {
+			session, err := j.db.LockUploadSession(expired.ID, uploadJanitorLease)
+			if errors.Is(err, domain.ErrUploadLocked) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Upload janitor: upload %s: %v", expired.ID, err)
+				continue
+			}
+			// Completed while it was being listed.
+			if session.Status == domain.UploadStatusCompleted {
+				session.LockedUntil = nil
+				j.db.UpdateUploadSession(session)
+				continue
+			}
+			DiscardUpload(j.db, j.minio, session)
+			discarded++
+		}
+
+		if len(sessions) < j.batchSize {
+			break
+		}
+		afterID = sessions[len(sessions)-1].ID
+	}
+	return discarded, nil
+}
+
+// DiscardUpload drops everything an unfinished upload stored, then the
+// session itself.
+func DiscardUpload(db domain.DatabaseInterface, minio domain.MinIOInterface, session *domain.UploadSession) {
+	if session.Method == domain.UploadMethodPresigned {
+		minio.DeleteFile(session.StoragePath)
+	} else {
+		if err := minio.AbortMultipartUpload(session.StoragePath, session.MultipartUploadID); err != nil {
+			fmt.Printf("Failed to abort multipart upload for session %s: %v\n", session.ID, err)
+		}
+		if session.TailBytes > 0 {
+			minio.DeleteFile(session.TailObjectName())
+		}
+	}
+	if err := db.DeleteUploadSession(session.ID); err != nil {
+		fmt.Printf("Failed to delete upload session %s: %v\n", session.ID, err)
+		return
+	}
+	session.Deleted = true
+}
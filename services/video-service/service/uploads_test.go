@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"video-service/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ─── Mocks ────────────────────────────────────────────────────────────────────
+
+type MockUploadDB struct {
+	domain.DatabaseInterface
+	mock.Mock
+}
+
+func (m *MockUploadDB) ListExpiredUploadSessions(before time.Time, afterID string, limit int) ([]*domain.UploadSession, error) {
+	args := m.Called(before, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UploadSession), args.Error(1)
+}
+
+func (m *MockUploadDB) LockUploadSession(id string, lease time.Duration) (*domain.UploadSession, error) {
+	args := m.Called(id, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UploadSession), args.Error(1)
+}
+
+func (m *MockUploadDB) UpdateUploadSession(session *domain.UploadSession) error {
+	return m.Called(session).Error(0)
+}
+
+func (m *MockUploadDB) DeleteUploadSession(id string) error {
+	return m.Called(id).Error(0)
+}
+
+type MockUploadStorage struct {
+	domain.MinIOInterface
+	mock.Mock
+}
+
+func (m *MockUploadStorage) AbortMultipartUpload(objectName, uploadID string) error {
+	return m.Called(objectName, uploadID).Error(0)
+}
+
+func (m *MockUploadStorage) DeleteFile(objectName string) error {
+	return m.Called(objectName).Error(0)
+}
+
+func newTestUploadJanitor(db *MockUploadDB, storage *MockUploadStorage) *UploadJanitor {
+	j := NewUploadJanitor(db, storage, time.Hour)
+	j.now = func() time.Time { return retentionNow }
+	return j
+}
+
+// ─── Sweep ────────────────────────────────────────────────────────────────────
+
+func TestUploadSweep_DiscardsExpiredUploads(t *testing.T) {
+	db, storage := new(MockUploadDB), new(MockUploadStorage)
+	j := newTestUploadJanitor(db, storage)
+
+	tus := &domain.UploadSession{ID: "s1", Method: domain.UploadMethodTus, StoragePath: "raw/v1.mp4",
+		MultipartUploadID: "mp-1", TailBytes: 10, Status: domain.UploadStatusUploading}
+	direct := &domain.UploadSession{ID: "s2", Method: domain.UploadMethodPresigned, StoragePath: "raw/v2.mp4",
+		Status: domain.UploadStatusUploading}
+
+	db.On("ListExpiredUploadSessions", retentionNow, "", 100).
+		Return([]*domain.UploadSession{{ID: "s1"}, {ID: "s2"}}, nil).Once()
+	db.On("LockUploadSession", "s1", uploadJanitorLease).Return(tus, nil)
+	db.On("LockUploadSession", "s2", uploadJanitorLease).Return(direct, nil)
+	db.On("DeleteUploadSession", "s1").Return(nil)
+	db.On("DeleteUploadSession", "s2").Return(nil)
+	storage.On("AbortMultipartUpload", "raw/v1.mp4", "mp-1").Return(errors.New("gone"))
+	storage.On("DeleteFile", "uploads/s1.tail").Return(nil)
+	storage.On("DeleteFile", "raw/v2.mp4").Return(nil)
+
+	discarded, err := j.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, discarded)
+	db.AssertExpectations(t)
+	storage.AssertExpectations(t)
+}
+
+func TestUploadSweep_SkipsLockedAndCompletedUploads(t *testing.T) {
+	db, storage := new(MockUploadDB), new(MockUploadStorage)
+	j := newTestUploadJanitor(db, storage)
+	j.batchSize = 3
+
+	locked := time.Now().Add(time.Minute)
+	completed := &domain.UploadSession{ID: "s2", Status: domain.UploadStatusCompleted, LockedUntil: &locked}
+
+	db.On("ListExpiredUploadSessions", retentionNow, "", 3).
+		Return([]*domain.UploadSession{{ID: "s1"}, {ID: "s2"}, {ID: "s3"}}, nil).Once()
+	db.On("ListExpiredUploadSessions", retentionNow, "s3", 3).Return([]*domain.UploadSession{}, nil).Once()
+	db.On("LockUploadSession", "s1", uploadJanitorLease).Return(nil, domain.ErrUploadLocked)
+	db.On("LockUploadSession", "s2", uploadJanitorLease).Return(completed, nil)
+	db.On("LockUploadSession", "s3", uploadJanitorLease).Return(nil, errors.New("db down"))
+	db.On("UpdateUploadSession", mock.MatchedBy(func(s *domain.UploadSession) bool {
+		return s.ID == "s2" && s.LockedUntil == nil
+	})).Return(nil)
+
+	discarded, err := j.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Zero(t, discarded)
+	db.AssertExpectations(t)
+	db.AssertNotCalled(t, "DeleteUploadSession", mock.Anything)
+	storage.AssertNotCalled(t, "DeleteFile", mock.Anything)
+}
+
+func TestUploadSweep_ListError(t *testing.T) {
+	db := new(MockUploadDB)
+	j := newTestUploadJanitor(db, nil)
+	db.On("ListExpiredUploadSessions", retentionNow, "", 100).Return(nil, errors.New("db down"))
+
+	_, err := j.Sweep(context.Background())
+
+	assert.ErrorContains(t, err, "db down")
+}